@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/yourusername/vectorchat/internal/db"
+)
+
+const (
+	// schedulerLockKey identifies the Postgres advisory lock held by the leading scheduler
+	schedulerLockKey int64 = 0x76635f637261776c // "vc_crawl"
+	// leaderCheckInterval is how often standbys try to take the lock and the leader checks it still holds it
+	leaderCheckInterval = 10 * time.Second
+)
+
+// campaign keeps the manager's leader flag in step with the scheduler advisory lock until ctx is done.
+// The lock lives on a dedicated connection, so a leader that crashes or loses the database
+// releases it and a standby takes over within leaderCheckInterval.
+func campaign(ctx context.Context, dbConn *db.Database, m *scheduleManager, logger *slog.Logger) {
+	ticker := time.NewTicker(leaderCheckInterval)
+	defer ticker.Stop()
+
+	var lock *db.AdvisoryLock
+	for {
+		if lock == nil {
+			l, err := dbConn.TryAdvisoryLock(ctx, schedulerLockKey)
+			if err != nil {
+				logger.Warn("failed to take scheduler lock", "error", err)
+			} else if l != nil {
+				lock = l
+				m.leader.Store(true)
+				logger.Info("became scheduler leader")
+			}
+		} else if !lock.Held(ctx) {
+			m.leader.Store(false)
+			_ = lock.Release(context.Background())
+			lock = nil
+			logger.Warn("lost scheduler leadership")
+		}
+
+		select {
+		case <-ctx.Done():
+			if lock != nil {
+				m.leader.Store(false)
+				releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				_ = lock.Release(releaseCtx)
+				cancel()
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
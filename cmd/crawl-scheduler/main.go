@@ -3,10 +3,13 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"log/slog"
 	"os"
 	"os/signal"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -14,7 +17,9 @@ import (
 	"github.com/google/uuid"
 	"github.com/nats-io/nats.go"
 	"github.com/yourusername/vectorchat/internal/db"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
 	"github.com/yourusername/vectorchat/internal/queue"
+	"github.com/yourusername/vectorchat/internal/services"
 	"github.com/yourusername/vectorchat/pkg/config"
 	"github.com/yourusername/vectorchat/pkg/jobs"
 )
@@ -54,103 +59,221 @@ func main() {
 	}
 
 	repo := db.NewCrawlScheduleRepository(dbConn)
-	manager := newScheduleManager(repo, js, logger)
-	if err := manager.loadAndSchedule(ctx); err != nil {
+	crawls, err := queue.NewCrawlQueue(js)
+	if err != nil {
+		logger.Error("failed to open crawl queue", "error", err)
+		os.Exit(1)
+	}
+	manager := newScheduleManager(repo, db.NewCrawlCredentialRepository(dbConn), crawls, logger)
+	defer manager.stopAll()
+
+	// Every replica keeps its schedules current so a standby can take over at once; only the leader enqueues
+	go campaign(ctx, dbConn, manager, logger)
+	go pruneRuns(ctx, db.NewCrawlRunRepository(dbConn), manager, cfg.CrawlRunRetention, logger)
+
+	// Changes made through the API arrive as events; the periodic resync catches anything missed
+	events, err := js.Subscribe(jobs.CrawlScheduleEventSubject, func(msg *nats.Msg) {
+		var ev jobs.CrawlScheduleEvent
+		if err := json.Unmarshal(msg.Data, &ev); err != nil {
+			logger.Warn("invalid schedule change event", "error", err)
+			return
+		}
+		manager.handleEvent(ctx, ev)
+	}, nats.OrderedConsumer(), nats.DeliverNew())
+	if err != nil {
+		logger.Warn("failed to subscribe to schedule changes; relying on periodic resync", "error", err)
+	} else {
+		defer events.Unsubscribe()
+	}
+
+	if err := manager.sync(ctx); err != nil {
 		logger.Error("failed to register schedules", "error", err)
 	}
 
-	ticker := time.NewTicker(2 * time.Minute)
+	ticker := time.NewTicker(resyncInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
-			manager.stopAll()
 			logger.Info("scheduler shutting down")
 			return
 		case <-ticker.C:
-			if err := manager.loadAndSchedule(ctx); err != nil {
-				logger.Warn("periodic reload failed", "error", err)
+			if err := manager.sync(ctx); err != nil {
+				logger.Warn("periodic resync failed", "error", err)
 			}
 		}
 	}
 }
 
+// resyncInterval is how often schedules are reconciled with the database as a safety net for missed events
+const resyncInterval = 5 * time.Minute
+
+// scheduledJob is the gocron job registered for one crawl schedule.
+type scheduledJob struct {
+	runner    string // timezone of the scheduler running the job
+	id        uuid.UUID
+	updatedAt time.Time
+}
+
 type scheduleManager struct {
-	repo   *db.CrawlScheduleRepository
-	js     nats.JetStreamContext
-	logger *slog.Logger
+	repo        *db.CrawlScheduleRepository
+	credentials *db.CrawlCredentialRepository
+	crawls      *queue.CrawlQueue
+	logger      *slog.Logger
+	// leader is set while this replica holds the scheduler lock
+	leader atomic.Bool
+
+	mu sync.Mutex
 	// keyed by timezone
 	runners map[string]gocron.Scheduler
+	// keyed by crawl schedule ID
+	jobs map[uuid.UUID]scheduledJob
 }
 
-func newScheduleManager(repo *db.CrawlScheduleRepository, js nats.JetStreamContext, logger *slog.Logger) *scheduleManager {
+func newScheduleManager(repo *db.CrawlScheduleRepository, credentials *db.CrawlCredentialRepository, crawls *queue.CrawlQueue, logger *slog.Logger) *scheduleManager {
 	return &scheduleManager{
-		repo:    repo,
-		js:      js,
-		logger:  logger,
-		runners: make(map[string]gocron.Scheduler),
+		repo:        repo,
+		credentials: credentials,
+		crawls:      crawls,
+		logger:      logger,
+		runners:     make(map[string]gocron.Scheduler),
+		jobs:        make(map[uuid.UUID]scheduledJob),
 	}
 }
 
 func (m *scheduleManager) stopAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	for _, sched := range m.runners {
 		_ = sched.Shutdown()
 	}
 	m.runners = make(map[string]gocron.Scheduler)
+	m.jobs = make(map[uuid.UUID]scheduledJob)
 }
 
-func (m *scheduleManager) loadAndSchedule(ctx context.Context) error {
+// sync reconciles the registered jobs with the enabled schedules in the database,
+// touching only schedules that were added, changed or removed.
+func (m *scheduleManager) sync(ctx context.Context) error {
 	schedules, err := m.repo.ListActive(ctx)
 	if err != nil {
 		return err
 	}
 
-	// reset schedulers
-	m.stopAll()
-
-	// group by timezone and register
+	active := make(map[uuid.UUID]bool, len(schedules))
 	for _, s := range schedules {
-		loc := time.UTC
-		if s.Timezone != "" {
-			if l, err := time.LoadLocation(s.Timezone); err == nil {
-				loc = l
-			}
+		active[s.ID] = true
+		m.apply(s)
+	}
+
+	m.mu.Lock()
+	for id := range m.jobs {
+		if !active[id] {
+			m.removeLocked(id)
 		}
-		tzKey := loc.String()
-		sched, ok := m.runners[tzKey]
-		if !ok {
-			sched, err = gocron.NewScheduler(gocron.WithLocation(loc))
-			if err != nil {
-				return err
-			}
-			m.runners[tzKey] = sched
+	}
+	m.mu.Unlock()
+
+	m.logger.Info("schedules synced", "count", len(schedules))
+	return nil
+}
+
+// handleEvent applies a schedule change published by the API.
+func (m *scheduleManager) handleEvent(ctx context.Context, ev jobs.CrawlScheduleEvent) {
+	if ev.Action == jobs.CrawlScheduleDeleted {
+		m.remove(ev.ScheduleID)
+		return
+	}
+	s, err := m.repo.FindByID(ctx, ev.ScheduleID)
+	if err != nil {
+		if apperrors.Is(err, apperrors.ErrNotFound) {
+			m.remove(ev.ScheduleID)
+			return
 		}
+		m.logger.Warn("failed to load changed schedule", "schedule_id", ev.ScheduleID, "error", err)
+		return
+	}
+	m.apply(s)
+}
 
-		// capture copy
-		scheduleCopy := *s
-		_, err := sched.NewJob(
-			gocron.CronJob(scheduleCopy.CronExpr, false),
-			gocron.NewTask(func() {
-				m.enqueue(scheduleCopy)
-			}),
-		)
-		if err != nil {
-			m.logger.Warn("failed to add job", "schedule_id", scheduleCopy.ID, "error", err)
-			continue
+// apply registers s, replacing its job when the schedule changed since it was registered.
+// Disabled schedules are unregistered.
+func (m *scheduleManager) apply(s *db.CrawlSchedule) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.jobs[s.ID]; ok {
+		if s.Enabled && existing.updatedAt.Equal(s.UpdatedAt) {
+			return
 		}
+		m.removeLocked(s.ID)
+	}
+	if !s.Enabled {
+		return
 	}
 
-	for _, sched := range m.runners {
+	loc := time.UTC
+	if s.Timezone != "" {
+		if l, err := time.LoadLocation(s.Timezone); err == nil {
+			loc = l
+		}
+	}
+	tzKey := loc.String()
+	sched, ok := m.runners[tzKey]
+	if !ok {
+		var err error
+		sched, err = gocron.NewScheduler(gocron.WithLocation(loc))
+		if err != nil {
+			m.logger.Warn("failed to create scheduler", "timezone", tzKey, "error", err)
+			return
+		}
 		sched.Start()
+		m.runners[tzKey] = sched
 	}
 
-	m.logger.Info("schedules loaded", "count", len(schedules))
-	return nil
+	// capture copy
+	scheduleCopy := *s
+	job, err := sched.NewJob(
+		gocron.CronJob(scheduleCopy.CronExpr, false),
+		gocron.NewTask(func() {
+			m.fire(scheduleCopy)
+		}),
+	)
+	if err != nil {
+		m.logger.Warn("failed to add job", "schedule_id", scheduleCopy.ID, "error", err)
+		return
+	}
+	m.jobs[s.ID] = scheduledJob{runner: tzKey, id: job.ID(), updatedAt: s.UpdatedAt}
+}
+
+func (m *scheduleManager) remove(id uuid.UUID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeLocked(id)
+}
+
+func (m *scheduleManager) removeLocked(id uuid.UUID) {
+	job, ok := m.jobs[id]
+	if !ok {
+		return
+	}
+	if sched, ok := m.runners[job.runner]; ok {
+		_ = sched.RemoveJob(job.id)
+	}
+	delete(m.jobs, id)
+}
+
+// fire enqueues a crawl for s when this replica is the leader.
+func (m *scheduleManager) fire(s db.CrawlSchedule) {
+	if !m.leader.Load() {
+		return
+	}
+	m.enqueue(s)
 }
 
 func (m *scheduleManager) enqueue(schedule db.CrawlSchedule) {
 	now := time.Now().UTC()
+	opts := services.CrawlOptionsForSchedule(&schedule)
 	payload := jobs.CrawlJobPayload{
 		JobID:                 uuid.New(),
 		ScheduleID:            schedule.ID,
@@ -158,23 +281,35 @@ func (m *scheduleManager) enqueue(schedule db.CrawlSchedule) {
 		RequestedAt:           now,
 		ChatbotID:             schedule.ChatbotID,
 		SharedKnowledgeBaseID: schedule.SharedKnowledgeBaseID,
+		Options:               &opts,
+		Trigger:               jobs.CrawlTriggerCron,
 	}
-
-	body, err := json.Marshal(payload)
-	if err != nil {
-		m.logger.Error("failed to marshal payload", "schedule_id", schedule.ID, "error", err)
-		return
+	// Credentials travel by reference; the worker decrypts them
+	if cred, err := m.credentials.FindByScope(context.Background(), schedule.ChatbotID, schedule.SharedKnowledgeBaseID, schedule.RootURL); err == nil {
+		payload.CredentialsID = &cred.ID
+	} else if !apperrors.Is(err, apperrors.ErrNotFound) {
+		m.logger.Warn("failed to look up crawl credentials", "schedule_id", schedule.ID, "error", err)
 	}
 
-	if _, err := m.js.Publish(jobs.CrawlSubject, body); err != nil {
+	// A crawl still running from the previous tick or a manual trigger covers this one
+	var inFlight *queue.CrawlInFlightError
+	if err := m.crawls.Enqueue(payload); errors.As(err, &inFlight) {
+		msg := "previous crawl still queued or running"
+		next := services.NextCrawlRun(schedule.CronExpr, schedule.Timezone, now)
+		_ = m.repo.UpdateRunInfo(context.Background(), schedule.ID, &now, next, strPtr("skipped"), &msg, nil)
+		m.logger.Info("skipped crawl job; website already being crawled", "schedule_id", schedule.ID, "job_id", inFlight.JobID)
+		return
+	} else if err != nil {
 		msg := err.Error()
-		m.repo.UpdateRunInfo(context.Background(), schedule.ID, &now, nil, strPtr("publish_error"), &msg)
+		next := services.NextCrawlRun(schedule.CronExpr, schedule.Timezone, now)
+		m.repo.UpdateRunInfo(context.Background(), schedule.ID, &now, next, strPtr("publish_error"), &msg, nil)
 		m.logger.Error("failed to publish job", "schedule_id", schedule.ID, "error", err)
 		return
 	}
 
 	status := "enqueued"
-	_ = m.repo.UpdateRunInfo(context.Background(), schedule.ID, &now, nil, &status, nil)
+	next := services.NextCrawlRun(schedule.CronExpr, schedule.Timezone, now)
+	_ = m.repo.UpdateRunInfo(context.Background(), schedule.ID, &now, next, &status, nil, nil)
 	m.logger.Info("enqueued crawl job", "schedule_id", schedule.ID, "url", schedule.RootURL, "target", targetLabel(schedule))
 }
 
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/yourusername/vectorchat/internal/db"
+	"github.com/yourusername/vectorchat/pkg/jobs"
+)
+
+// runRetentionInterval is how often the leader prunes the crawl run history
+const runRetentionInterval = time.Hour
+
+// pruneRuns closes runs whose worker died mid-crawl and deletes runs older than retention until
+// ctx is done. Only the leader prunes, so replicas do not race on the same rows.
+func pruneRuns(ctx context.Context, runs *db.CrawlRunRepository, m *scheduleManager, retention time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(runRetentionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !m.leader.Load() {
+			continue
+		}
+
+		now := time.Now().UTC()
+		// Workers renew the heartbeat of their runs while crawling; a run gone quiet has no worker left
+		if n, err := runs.AbandonStale(ctx, now.Add(-jobs.CrawlRunStaleAfter)); err != nil {
+			logger.Warn("failed to close abandoned crawl runs", "error", err)
+		} else if n > 0 {
+			logger.Info("closed abandoned crawl runs", "count", n)
+		}
+		if retention <= 0 {
+			continue
+		}
+		if n, err := runs.DeleteBefore(ctx, now.Add(-retention)); err != nil {
+			logger.Warn("failed to prune crawl run history", "error", err)
+		} else if n > 0 {
+			logger.Info("pruned crawl run history", "count", n, "retention", retention)
+		}
+	}
+}
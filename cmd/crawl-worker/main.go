@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/vectorchat/internal/bootstrap"
+	"github.com/yourusername/vectorchat/internal/db"
+	"github.com/yourusername/vectorchat/internal/queue"
+	"github.com/yourusername/vectorchat/internal/services"
+	"github.com/yourusername/vectorchat/internal/worker"
+	"github.com/yourusername/vectorchat/pkg/config"
+	"github.com/yourusername/vectorchat/pkg/constants"
+	"github.com/yourusername/vectorchat/pkg/jobs"
+	stripe_sub "github.com/yourusername/vectorchat/pkg/stripe_sub"
+)
+
+// The crawl worker consumes scheduled and manual crawl jobs outside the API process, so crawlers
+// can be scaled on their own. Set CRAWL_WORKER_ENABLED=false on the API when running it.
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var cfg config.AppConfig
+	if err := config.Load(&cfg); err != nil {
+		log.Fatalf("crawl worker: failed to load config: %v", err)
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	if err := run(ctx, &cfg, logger); err != nil {
+		logger.Error("crawl worker failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, cfg *config.AppConfig, logger *slog.Logger) error {
+	dbConn, err := db.NewDatabase(cfg.PGConnection)
+	if err != nil {
+		return fmt.Errorf("failed to connect database: %w", err)
+	}
+	defer dbConn.Close()
+	repos := db.NewRepositories(dbConn)
+
+	nc, err := queue.Connect(cfg.NATSURL, cfg.NATSUsername, cfg.NATSPassword)
+	if err != nil {
+		return fmt.Errorf("failed to connect nats: %w", err)
+	}
+	defer nc.Drain()
+
+	js, err := nc.JetStream()
+	if err != nil {
+		return fmt.Errorf("failed to init jetstream: %w", err)
+	}
+	if err := queue.EnsureStreams(js); err != nil {
+		logger.Warn("failed to ensure streams; will continue and expect existing stream", "error", err, "stream", jobs.CrawlStream)
+	}
+
+	// Plans are read to hold crawls to the knowledge base owner's crawl limits
+	subs, err := stripe_sub.New(ctx, stripe_sub.Config{
+		DB:             dbConn.DB,
+		StripeAPIKey:   os.Getenv("STRIPE_API_KEY"),
+		DefaultPlanKey: constants.PlanFree,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to init subscriptions: %w", err)
+	}
+	crawlLimits := services.NewCrawlLimitService(subs, repos.Chat, repos.SharedKB, repos.Org, repos.User)
+
+	vectorizers, err := bootstrap.NewVectorizers(cfg)
+	if err != nil {
+		return err
+	}
+	kbService, err := bootstrap.NewKnowledgeBaseService(ctx, cfg, repos, dbConn, vectorizers, crawlLimits, logger)
+	if err != nil {
+		return err
+	}
+	credentials := services.NewCrawlCredentialService(repos.CrawlAuth, cfg.CrawlerCredentialsKey)
+
+	crawlWorker, err := worker.NewCrawlWorker(nc, js, kbService, credentials, repos.Schedule, repos.CrawlRuns, logger, worker.CrawlWorkerOptions{
+		Concurrency:  cfg.CrawlWorkerConcurrency,
+		DrainTimeout: cfg.CrawlWorkerDrainTimeout,
+	})
+	if err != nil {
+		return err
+	}
+
+	health := fiber.New(fiber.Config{DisableStartupMessage: true})
+	health.Get("/healthz", func(c *fiber.Ctx) error {
+		h := crawlWorker.Health()
+		if h.Status != worker.HealthOK {
+			c.Status(fiber.StatusServiceUnavailable)
+		}
+		return c.JSON(h)
+	})
+	go func() {
+		if err := health.Listen(cfg.CrawlWorkerHealthAddr); err != nil {
+			logger.Warn("health endpoint stopped", "error", err)
+		}
+	}()
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = health.ShutdownWithContext(shutdownCtx)
+	}()
+
+	// Run returns once ctx is cancelled by SIGTERM and running crawls have drained
+	return crawlWorker.Run(ctx)
+}
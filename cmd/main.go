@@ -8,12 +8,13 @@ import (
 	"log"
 	"log/slog"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/swagger"
-	"github.com/google/uuid"
 	"github.com/nats-io/nats.go"
 	"github.com/pressly/goose/v3"
 	"github.com/urfave/cli/v3"
@@ -24,16 +25,17 @@ import (
 	_ "github.com/yourusername/vectorchat/docs" // Import generated docs
 	swaggerDocs "github.com/yourusername/vectorchat/docs"
 	"github.com/yourusername/vectorchat/internal/api"
-	"github.com/yourusername/vectorchat/internal/crawler"
+	"github.com/yourusername/vectorchat/internal/bootstrap"
 	"github.com/yourusername/vectorchat/internal/db"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
 	"github.com/yourusername/vectorchat/internal/llm"
 	"github.com/yourusername/vectorchat/internal/middleware"
 	"github.com/yourusername/vectorchat/internal/queue"
+	"github.com/yourusername/vectorchat/internal/rerank"
 	"github.com/yourusername/vectorchat/internal/services"
-	"github.com/yourusername/vectorchat/internal/vectorize"
+	"github.com/yourusername/vectorchat/internal/worker"
 	"github.com/yourusername/vectorchat/pkg/config"
 	"github.com/yourusername/vectorchat/pkg/constants"
-	"github.com/yourusername/vectorchat/pkg/docprocessor"
 	"github.com/yourusername/vectorchat/pkg/jobs"
 	stripe_sub "github.com/yourusername/vectorchat/pkg/stripe_sub"
 )
@@ -69,6 +71,7 @@ func main() {
 					return runApplication(&appCfg)
 				},
 			},
+			reembedCommand(),
 		},
 	}
 
@@ -88,9 +91,6 @@ func runApplication(appCfg *config.AppConfig) error {
 	}
 
 	openaiKey := appCfg.OpenAIKey
-	if openaiKey == "" {
-		return fmt.Errorf("OPENAI_API_KEY environment variable is required")
-	}
 
 	llmAPIKey := appCfg.LLMAPIKey
 	if llmAPIKey == "" {
@@ -134,15 +134,23 @@ func runApplication(appCfg *config.AppConfig) error {
 		}
 	}
 
-	// Initialize vectorizer
-	vectorizer := vectorize.NewOpenAIVectorizer(openaiKey)
+	// Initialize vectorizers for the configured model and any still used by knowledge bases
+	vectorizers, err := bootstrap.NewVectorizers(appCfg)
+	if err != nil {
+		return err
+	}
+	logger.Info("embedding configuration", "backend", appCfg.EmbeddingBackend, "base_url", appCfg.EmbeddingBaseURL, "model", appCfg.EmbeddingModel, "dimensions", appCfg.EmbeddingDims, "served_models", vectorizers.Models())
 
 	llmBaseURL := appCfg.LLMBaseURL
 	if llmBaseURL == "" {
 		llmBaseURL = "https://api.openai.com/v1"
 	}
 	defaultChatModel := appCfg.LLMModelChat
-	llmClient := llm.NewOpenAIClient(llmAPIKey, llmBaseURL, defaultChatModel, nil)
+	priceTable, err := llm.ParsePriceTable(appCfg.LLMPriceTable)
+	if err != nil {
+		return fmt.Errorf("invalid LLM_PRICE_TABLE: %w", err)
+	}
+	llmClient := llm.NewOpenAIClient(llmAPIKey, llmBaseURL, defaultChatModel, nil, priceTable)
 	fallbackModelIDs := []string{defaultChatModel}
 	if appCfg.LLMModelPromptGen != "" {
 		fallbackModelIDs = append(fallbackModelIDs, appCfg.LLMModelPromptGen)
@@ -152,17 +160,20 @@ func runApplication(appCfg *config.AppConfig) error {
 		return fmt.Errorf("failed to reach LLM backend: %w", err)
 	}
 
-	// Initialize crawl4ai client (optional)
-	webCrawler, err := crawler.NewAPIClient(appCfg.CrawlerAPIURL, nil)
-	if err != nil {
-		logger.Warn("failed to initialize crawl4ai client; falling back to built-in crawler", "error", err)
-	}
-
-	markitdownClient, err := docprocessor.NewMarkitdownClient(appCfg.MarkitdownURL)
-	if err != nil {
-		return fmt.Errorf("failed to configure markitdown client: %w", err)
+	// Initialize reranker (optional, enabled per chatbot)
+	var reranker rerank.Reranker
+	switch appCfg.RerankerBackend {
+	case constants.RerankerBackendLLM:
+		reranker = rerank.NewLLMReranker(llmClient, appCfg.RerankerModel)
+	case constants.RerankerBackendHTTP:
+		if appCfg.RerankerURL == "" {
+			return fmt.Errorf("RERANKER_URL is required for the http reranker backend")
+		}
+		reranker = rerank.NewHTTPReranker(appCfg.RerankerURL)
+	case "":
+	default:
+		logger.Warn("unknown reranker backend; reranking disabled", "backend", appCfg.RerankerBackend)
 	}
-	processor := docprocessor.NewProcessor(markitdownClient)
 
 	// Initialize repositories
 	repos := db.NewRepositories(pool)
@@ -182,28 +193,73 @@ func runApplication(appCfg *config.AppConfig) error {
 	}
 
 	// Initialize services
-	kbService := services.NewKnowledgeBaseService(repos.File, repos.Document, vectorizer, processor, webCrawler, pool)
-	sharedKBService := services.NewSharedKnowledgeBaseService(repos.SharedKB, repos.File, repos.Document, kbService)
+	crawlLimits := services.NewCrawlLimitService(svc, repos.Chat, repos.SharedKB, repos.Org, repos.User)
+	kbService, err := bootstrap.NewKnowledgeBaseService(context.Background(), appCfg, repos, pool, vectorizers, crawlLimits, logger)
+	if err != nil {
+		return err
+	}
+	crawlCredentials := services.NewCrawlCredentialService(repos.CrawlAuth, appCfg.CrawlerCredentialsKey)
+	ingestionService := services.NewIngestionService(repos.Ingestion, kbService, crawlCredentials, js)
+	sharedKBService := services.NewSharedKnowledgeBaseService(repos.SharedKB, repos.File, repos.Document, kbService, ingestionService)
 	hydraService := services.NewHydraService(appCfg.HydraAdminURL, appCfg.HydraPublicURL)
 	logger.Info("hydra configuration", "admin_url", appCfg.HydraAdminURL, "public_url", appCfg.HydraPublicURL)
 	authService := services.NewAuthService(repos.User)
-	chatService := services.NewChatService(repos.Chat, repos.SharedKB, repos.Document, repos.File, repos.Message, repos.Revision, repos.LLMUsage, repos.Org, repos.OrgMembers, vectorizer, kbService, llmClient, pool, defaultChatModel)
+	actionService := services.NewActionService(repos.Actions, appCfg.ActionSecretsKey)
+	chatService := services.NewChatService(repos.Chat, repos.SharedKB, repos.Document, repos.File, repos.Message, repos.Revision, repos.LLMUsage, repos.Credits, repos.Org, repos.OrgMembers, repos.Embeddings, vectorizers, kbService, llmClient, reranker, actionService, pool, defaultChatModel)
 	orgService := services.NewOrganizationService(repos.Org, repos.OrgMembers, repos.OrgInvites, repos.User)
 	apiKeyService := services.NewAPIKeyService(hydraService)
 	commonService := services.NewCommonService()
-	scheduleService := services.NewCrawlScheduleService(repos.Schedule, kbService, js)
+	var crawlQueue *queue.CrawlQueue
+	if js != nil {
+		if crawlQueue, err = queue.NewCrawlQueue(js); err != nil {
+			logger.Warn("failed to open crawl queue; crawls cannot be triggered", "error", err)
+		}
+	}
+	scheduleService := services.NewCrawlScheduleService(repos.Schedule, repos.CrawlRuns, kbService, crawlCredentials, js, crawlQueue)
 	promptService := services.NewPromptService(llmClient, appCfg.LLMModelPromptGen)
+	widgetService := services.NewWidgetService(repos.Chat, appCfg.WidgetSecret)
+	creditService := services.NewMessageCreditService(svc, repos.Credits, repos.Org, repos.User)
 
 	// Validate that cron library supports our expressions (minute granularity) once at startup
 	if _, err := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow).Parse("*/5 * * * *"); err != nil {
 		logger.Warn("cron parser init failed", "error", err)
 	}
 
-	// Start embedded crawl worker if enabled and JetStream available
+	// SIGINT and SIGTERM stop the server and the embedded workers; running crawls drain first
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Start embedded crawl worker if enabled and JetStream available; cmd/crawl-worker runs it standalone
+	crawlWorkerDone := make(chan struct{})
 	if appCfg.CrawlWorkerEnabled && js != nil {
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-		go startCrawlWorker(ctx, js, repos.Schedule, kbService, logger)
+		crawlWorker, err := worker.NewCrawlWorker(nc, js, kbService, crawlCredentials, repos.Schedule, repos.CrawlRuns, logger, worker.CrawlWorkerOptions{
+			Concurrency:  appCfg.CrawlWorkerConcurrency,
+			DrainTimeout: appCfg.CrawlWorkerDrainTimeout,
+		})
+		if err != nil {
+			logger.Warn("crawl worker unavailable", "error", err)
+			close(crawlWorkerDone)
+		} else {
+			go func() {
+				defer close(crawlWorkerDone)
+				if err := crawlWorker.Run(ctx); err != nil {
+					logger.Warn("crawl worker: failed to subscribe", "error", err)
+				}
+			}()
+		}
+	} else {
+		close(crawlWorkerDone)
+	}
+
+	// Uploads are always processed in the background; without JetStream they run in-process
+	if js != nil {
+		go startIngestionWorker(ctx, js, ingestionService, logger)
+	}
+	// Jobs interrupted by a restart stay queued or in progress until they are handed out again
+	if recovered, err := ingestionService.RecoverStale(context.Background()); err != nil {
+		logger.Warn("failed to recover interrupted ingestion jobs", "error", err)
+	} else if recovered > 0 {
+		logger.Info("requeued interrupted ingestion jobs", "jobs", recovered)
 	}
 
 	// Initialize auth middleware
@@ -216,18 +272,26 @@ func runApplication(appCfg *config.AppConfig) error {
 	orgMiddleware := middleware.NewOrganizationMiddleware(orgService)
 
 	// Initialize subscription limits middleware
-	subscriptionLimits := middleware.NewSubscriptionLimitsMiddleware(svc, chatService)
+	subscriptionLimits := middleware.NewSubscriptionLimitsMiddleware(svc, chatService, creditService)
 
-	// Set up Fiber app
-	app := fiber.New(fiber.Config{
-		BodyLimit: 10 * 1024 * 1024, // 10MB limit for file uploads
-	})
+	// Set up Fiber app. c.IP() keys the public per-IP rate limit, so the proxy header is only
+	// honoured on connections from a trusted proxy.
+	fiberCfg := fiber.Config{
+		BodyLimit:               10 * 1024 * 1024, // 10MB limit for file uploads
+		EnableTrustedProxyCheck: true,
+		EnableIPValidation:      true,
+	}
+	if len(appCfg.TrustedProxies) > 0 {
+		fiberCfg.TrustedProxies = appCfg.TrustedProxies
+		fiberCfg.ProxyHeader = appCfg.ProxyHeader
+	}
+	app := fiber.New(fiberCfg)
 
 	app.Use(fiberLogger.New())
 
 	// Initialize API handlers
-	chatbotHandler := api.NewChatHandler(authMiddleware, chatService, ownershipMiddleware, orgMiddleware, commonService, subscriptionLimits, scheduleService, promptService)
-	sharedKnowledgeBaseHandler := api.NewSharedKnowledgeBaseHandler(authMiddleware, orgMiddleware, sharedKBService, scheduleService)
+	chatbotHandler := api.NewChatHandler(authMiddleware, chatService, ownershipMiddleware, orgMiddleware, commonService, subscriptionLimits, scheduleService, promptService, creditService, actionService, ingestionService)
+	sharedKnowledgeBaseHandler := api.NewSharedKnowledgeBaseHandler(authMiddleware, orgMiddleware, subscriptionLimits, sharedKBService, scheduleService)
 	organizationHandler := api.NewOrganizationHandler(authMiddleware, orgService, orgMiddleware)
 	authHandler := api.NewAuthHandler(authService, authMiddleware, api.AuthConfig{
 		KratosPublicURL: appCfg.KratosPublicURL,
@@ -238,7 +302,8 @@ func runApplication(appCfg *config.AppConfig) error {
 	subsHandler := api.NewStripeSubHandler(authMiddleware, svc)
 	conversationHandler := api.NewConversationHandler(authMiddleware, chatService, orgMiddleware)
 	widgetHandler := api.NewWidgetHandler(authMiddleware)
-	queueHandler := api.NewQueueHandler(authMiddleware, services.NewQueueMetricsService(js))
+	publicChatHandler := api.NewPublicChatHandler(chatService, widgetService, subscriptionLimits, appCfg.WidgetIPLimit, appCfg.WidgetSessionLimit)
+	queueHandler := api.NewQueueHandler(authMiddleware, services.NewQueueMetricsService(js), appCfg.QueueAdminEmails)
 	llmHandler := api.NewLLMHandler(authMiddleware, llmService, svc)
 
 	// Register routes
@@ -249,6 +314,7 @@ func runApplication(appCfg *config.AppConfig) error {
 	subsHandler.RegisterRoutes(app)
 	conversationHandler.RegisterRoutes(app)
 	widgetHandler.RegisterRoutes(app)
+	publicChatHandler.RegisterRoutes(app)
 	queueHandler.RegisterRoutes(app)
 	llmHandler.RegisterRoutes(app)
 	organizationHandler.RegisterRoutes(app)
@@ -262,27 +328,39 @@ func runApplication(appCfg *config.AppConfig) error {
 		port = "8080"
 	}
 
+	go func() {
+		<-ctx.Done()
+		logger.Info("shutting down")
+		if err := app.ShutdownWithTimeout(10 * time.Second); err != nil {
+			logger.Warn("server shutdown failed", "error", err)
+		}
+	}()
+
 	log.Printf("Server starting on port %s", port)
-	return app.Listen(":" + port)
+	err = app.Listen(":" + port)
+	// Listen also returns when the server fails to start; stop the workers either way
+	stop()
+	<-crawlWorkerDone
+	return err
 }
 
-func startCrawlWorker(ctx context.Context, js nats.JetStreamContext, scheduleRepo *db.CrawlScheduleRepository, kbService *services.KnowledgeBaseService, logger *slog.Logger) {
+func startIngestionWorker(ctx context.Context, js nats.JetStreamContext, ingestionService *services.IngestionService, logger *slog.Logger) {
 	sub, err := js.PullSubscribe(
-		jobs.CrawlSubject,
-		"crawler-workers",
-		nats.BindStream(jobs.CrawlStream),
+		jobs.IngestionSubject,
+		"ingestion-workers",
+		nats.BindStream(jobs.IngestionStream),
 		nats.ManualAck(),
 	)
 	if err != nil {
-		logger.Warn("crawl worker: failed to subscribe", "error", err)
+		logger.Warn("ingestion worker: failed to subscribe", "error", err)
 		return
 	}
-	logger.Info("crawl worker started (embedded)")
+	logger.Info("ingestion worker started (embedded)")
 
 	for {
 		select {
 		case <-ctx.Done():
-			logger.Info("crawl worker stopping")
+			logger.Info("ingestion worker stopping")
 			return
 		default:
 		}
@@ -292,47 +370,44 @@ func startCrawlWorker(ctx context.Context, js nats.JetStreamContext, scheduleRep
 			if err == nats.ErrTimeout {
 				continue
 			}
-			logger.Warn("crawl worker: fetch error", "error", err)
+			logger.Warn("ingestion worker: fetch error", "error", err)
 			continue
 		}
 
 		for _, msg := range msgs {
-			handleCrawlMessage(ctx, msg, kbService, scheduleRepo, logger)
+			handleIngestionMessage(ctx, msg, ingestionService, logger)
 		}
 	}
 }
 
-func handleCrawlMessage(ctx context.Context, msg *nats.Msg, kb *services.KnowledgeBaseService, repo *db.CrawlScheduleRepository, logger *slog.Logger) {
-	var payload jobs.CrawlJobPayload
+func handleIngestionMessage(ctx context.Context, msg *nats.Msg, ingestionService *services.IngestionService, logger *slog.Logger) {
+	var payload jobs.IngestionJobPayload
 	if err := json.Unmarshal(msg.Data, &payload); err != nil {
-		logger.Warn("crawl worker: invalid payload", "error", err)
+		logger.Warn("ingestion worker: invalid payload", "error", err)
 		_ = msg.Term()
 		return
 	}
 
-	target := services.KnowledgeBaseTarget{
-		ChatbotID:             payload.ChatbotID,
-		SharedKnowledgeBaseID: payload.SharedKnowledgeBaseID,
-	}
+	// Large files can take longer than the ack wait; keep the message claimed while we work
+	stop := queue.KeepInProgress(msg, constants.IngestionHeartbeat, nil)
+	defer stop()
 
-	start := time.Now().UTC()
-	if _, err := kb.IngestWebsite(ctx, target, payload.RootURL); err != nil {
-		if payload.ScheduleID != uuid.Nil {
-			errMsg := err.Error()
-			status := "failed"
-			_ = repo.UpdateRunInfo(context.Background(), payload.ScheduleID, &start, nil, &status, &errMsg)
+	if err := ingestionService.Process(ctx, payload.JobID); err != nil {
+		if apperrors.Is(err, apperrors.ErrNotFound) {
+			// the knowledge base was deleted together with its jobs
+			_ = msg.Term()
+			return
+		}
+		if apperrors.Is(err, apperrors.ErrIngestionJobBusy) {
+			// another worker is running the job; check again once it would count as stale
+			_ = msg.NakWithDelay(constants.IngestionStaleAfter)
+			return
 		}
-		logger.Error("crawl worker: crawl failed", "schedule_id", payload.ScheduleID, "error", err)
+		logger.Error("ingestion worker: job could not be processed", "job_id", payload.JobID, "error", err)
 		_ = msg.Nak()
 		return
 	}
-
-	if payload.ScheduleID != uuid.Nil {
-		status := "completed"
-		_ = repo.UpdateRunInfo(context.Background(), payload.ScheduleID, &start, nil, &status, nil)
-	}
 	_ = msg.Ack()
-	logger.Info("crawl worker: crawl completed", "schedule_id", payload.ScheduleID, "url", payload.RootURL)
 }
 
 // defaultPlans returns the requested initial plans seeded on startup.
@@ -344,6 +419,8 @@ func defaultPlans() []stripe_sub.PlanParams {
 		constants.LimitDataSources:    "5 data sources (websites, files, texts)",
 		constants.LimitEmbedWebsites:  true,
 		constants.LimitAPIAccess:      true,
+		constants.LimitCrawlPages:     constants.DefaultCrawlPages,
+		constants.LimitCrawlDepth:     constants.DefaultCrawlDepth,
 	}
 	hobbyFeatures := map[string]any{
 		"includes":                    "Everything in Free",
@@ -352,8 +429,11 @@ func defaultPlans() []stripe_sub.PlanParams {
 		constants.LimitTrainingData:   "4 MB",
 		constants.LimitChatbots:       3,
 		constants.LimitDataSources:    "20 data sources (websites, files, texts)",
+		constants.LimitEmbedWebsites:  true,
 		constants.LimitAPIAccess:      true,
 		constants.LimitAnalytics:      true,
+		constants.LimitCrawlPages:     100,
+		constants.LimitCrawlDepth:     3,
 	}
 	standardFeatures := map[string]any{
 		"includes":                    "Everything in Hobby",
@@ -361,8 +441,11 @@ func defaultPlans() []stripe_sub.PlanParams {
 		constants.LimitTrainingData:   "33 MB",
 		constants.LimitChatbots:       5,
 		constants.LimitDataSources:    "50 data sources",
+		constants.LimitEmbedWebsites:  true,
 		constants.LimitSeats:          3,
 		constants.LimitCustomBranding: true,
+		constants.LimitCrawlPages:     500,
+		constants.LimitCrawlDepth:     5,
 		"team_collaboration_tools":    true,
 		"priority_email_support":      true,
 	}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/urfave/cli/v3"
+
+	"github.com/yourusername/vectorchat/internal/bootstrap"
+	"github.com/yourusername/vectorchat/internal/db"
+	"github.com/yourusername/vectorchat/internal/services"
+	"github.com/yourusername/vectorchat/pkg/config"
+)
+
+// reembedCommand re-embeds a chatbot or shared knowledge base with the configured EMBEDDING_* model
+// and reports past runs. Retrieval keeps working on the old vectors until the cutover commits.
+func reembedCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "reembed",
+		Usage: "Re-embed a knowledge base with the configured embedding model",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "chatbot-id", Usage: "chatbot whose documents are re-embedded"},
+			&cli.StringFlag{Name: "shared-kb-id", Usage: "shared knowledge base whose documents are re-embedded"},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			target, err := reembedTarget(cmd.String("chatbot-id"), cmd.String("shared-kb-id"))
+			if err != nil {
+				return err
+			}
+			return withEmbeddingMigrations(ctx, func(svc *services.EmbeddingMigrationService) error {
+				start := time.Now()
+				result, err := svc.Run(ctx, target, func(m *db.EmbeddingMigration) {
+					fmt.Printf("embedded %d/%d documents\n", m.EmbeddedDocuments, m.TotalDocuments)
+				})
+				if err != nil {
+					return fmt.Errorf("re-embed failed: %w", err)
+				}
+				m := result.Migration
+				fmt.Printf("migration %s done in %s: model=%s dimensions=%d documents=%d applied=%d stale=%d\n",
+					m.ID, time.Since(start).Round(time.Second), m.TargetModel, m.TargetDimensions, m.TotalDocuments, result.Applied, result.Stale)
+				fmt.Printf("the knowledge base now uses %s; the API and crawl workers must serve it via EMBEDDING_MODEL or EMBEDDING_ADDITIONAL_MODELS\n", m.TargetModel)
+				if result.Stale > 0 {
+					fmt.Println("some documents changed during the run and still use another model; run reembed again to migrate them")
+				}
+				return nil
+			})
+		},
+		Commands: []*cli.Command{
+			{
+				Name:  "status",
+				Usage: "Show recent re-embed runs, or one run by --id",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "id", Usage: "migration ID"},
+					&cli.IntFlag{Name: "limit", Value: 20, Usage: "number of runs to list"},
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return withEmbeddingMigrations(ctx, func(svc *services.EmbeddingMigrationService) error {
+						var migrations []*db.EmbeddingMigration
+						if raw := cmd.String("id"); raw != "" {
+							id, err := uuid.Parse(raw)
+							if err != nil {
+								return fmt.Errorf("invalid --id: %w", err)
+							}
+							m, err := svc.Get(ctx, id)
+							if err != nil {
+								return err
+							}
+							migrations = append(migrations, m)
+						} else {
+							var err error
+							if migrations, err = svc.ListRecent(ctx, cmd.Int("limit")); err != nil {
+								return err
+							}
+						}
+						printEmbeddingMigrations(migrations)
+						return nil
+					})
+				},
+			},
+		},
+	}
+}
+
+func reembedTarget(chatbotID, sharedKBID string) (services.KnowledgeBaseTarget, error) {
+	var target services.KnowledgeBaseTarget
+	if (chatbotID == "") == (sharedKBID == "") {
+		return target, fmt.Errorf("exactly one of --chatbot-id or --shared-kb-id is required")
+	}
+	if chatbotID != "" {
+		id, err := uuid.Parse(chatbotID)
+		if err != nil {
+			return target, fmt.Errorf("invalid --chatbot-id: %w", err)
+		}
+		target.ChatbotID = &id
+		return target, nil
+	}
+	id, err := uuid.Parse(sharedKBID)
+	if err != nil {
+		return target, fmt.Errorf("invalid --shared-kb-id: %w", err)
+	}
+	target.SharedKnowledgeBaseID = &id
+	return target, nil
+}
+
+// withEmbeddingMigrations connects to the database and embedding backend from the environment
+func withEmbeddingMigrations(ctx context.Context, fn func(*services.EmbeddingMigrationService) error) error {
+	var appCfg config.AppConfig
+	if err := config.Load(&appCfg); err != nil {
+		return err
+	}
+
+	if err := runMigrations(appCfg.PGConnection, appCfg.MigrationsPath); err != nil {
+		return fmt.Errorf("failed to run migrations: %v", err)
+	}
+	pool, err := db.NewDatabase(appCfg.PGConnection)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %v", err)
+	}
+	defer pool.Close()
+
+	vectorizer, err := bootstrap.NewVectorizer(&appCfg)
+	if err != nil {
+		return err
+	}
+
+	repos := db.NewRepositories(pool)
+	return fn(services.NewEmbeddingMigrationService(repos.Embeddings, repos.Document, repos.Revision, vectorizer, pool))
+}
+
+func printEmbeddingMigrations(migrations []*db.EmbeddingMigration) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSCOPE\tMODEL\tDIMS\tSTATUS\tPROGRESS\tSTARTED\tERROR")
+	for _, m := range migrations {
+		scope := ""
+		if m.ChatbotID != nil {
+			scope = "chatbot " + m.ChatbotID.String()
+		} else if m.SharedKnowledgeBaseID != nil {
+			scope = "shared-kb " + m.SharedKnowledgeBaseID.String()
+		}
+		errMsg := ""
+		if m.Error != nil {
+			errMsg = *m.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%d/%d\t%s\t%s\n",
+			m.ID, scope, m.TargetModel, m.TargetDimensions, m.Status, m.EmbeddedDocuments, m.TotalDocuments, m.CreatedAt.Format(time.RFC3339), errMsg)
+	}
+	w.Flush()
+}
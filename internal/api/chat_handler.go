@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/yourusername/vectorchat/internal/db"
@@ -28,6 +29,9 @@ type ChatHandler struct {
 	SubscriptionLimits *middleware.SubscriptionLimitsMiddleware
 	ScheduleService    *services.CrawlScheduleService
 	PromptService      *services.PromptService
+	CreditService      *services.MessageCreditService
+	ActionService      *services.ActionService
+	IngestionService   *services.IngestionService
 }
 
 func NewChatHandler(
@@ -39,6 +43,9 @@ func NewChatHandler(
 	subscriptionLimits *middleware.SubscriptionLimitsMiddleware,
 	scheduleService *services.CrawlScheduleService,
 	promptService *services.PromptService,
+	creditService *services.MessageCreditService,
+	actionService *services.ActionService,
+	ingestionService *services.IngestionService,
 ) *ChatHandler {
 	return &ChatHandler{
 		ChatService:        chatService,
@@ -49,6 +56,9 @@ func NewChatHandler(
 		SubscriptionLimits: subscriptionLimits,
 		ScheduleService:    scheduleService,
 		PromptService:      promptService,
+		CreditService:      creditService,
+		ActionService:      actionService,
+		IngestionService:   ingestionService,
 	}
 }
 
@@ -61,23 +71,34 @@ func (h *ChatHandler) RegisterRoutes(app *fiber.App) {
 	// File upload and management
 	chat.Post("/chatbot", h.SubscriptionLimits.CheckLimit(constants.LimitChatbots), h.POST_CreateChatbot)
 	chat.Get("/chatbots", h.GET_ListChatbots)
+	chat.Get("/usage", h.GET_MessageCreditUsage)
 	chat.Get("/chatbot/:chatID", h.OwershipMiddleware.IsChatbotOwner, h.GET_ChatbotByID)
 	chat.Put("/chatbot/:chatID", h.OwershipMiddleware.IsChatbotOwner, h.PUT_UpdateChatbot)
 	chat.Patch("/chatbot/:chatID/toggle", h.OwershipMiddleware.IsChatbotOwner, h.PATCH_ToggleChatbot)
 	chat.Post("/chatbot/:chatID/transfer", h.OwershipMiddleware.IsChatbotOwner, h.POST_TransferChatbot)
+	chat.Put("/chatbot/:chatID/allowed-origins", h.OwershipMiddleware.IsChatbotOwner, h.SubscriptionLimits.CheckLimit(constants.LimitEmbedWebsites), h.PUT_AllowedOrigins)
 	chat.Delete("/chatbot/:chatID", h.OwershipMiddleware.IsChatbotOwner, h.DELETE_Chatbot)
 	chat.Post("/system-prompt/generate", h.POST_GenerateSystemPrompt)
 	chat.Post("/:chatID/upload", h.OwershipMiddleware.IsChatbotOwner, h.SubscriptionLimits.CheckLimit(constants.LimitDataSources), h.SubscriptionLimits.CheckLimit(constants.LimitTrainingData), h.POST_UploadFile)
 	chat.Post("/:chatID/text", h.OwershipMiddleware.IsChatbotOwner, h.SubscriptionLimits.CheckLimit(constants.LimitDataSources), h.SubscriptionLimits.CheckLimit(constants.LimitTrainingData), h.POST_UploadText)
-	chat.Post("/:chatID/website", h.OwershipMiddleware.IsChatbotOwner, h.SubscriptionLimits.CheckLimit(constants.LimitDataSources), h.POST_UploadWebsite)
+	chat.Post("/:chatID/website", h.OwershipMiddleware.IsChatbotOwner, h.SubscriptionLimits.CheckLimit(constants.LimitDataSources), h.SubscriptionLimits.CheckLimit(constants.LimitCrawlPages), h.POST_UploadWebsite)
+	chat.Get("/:chatID/ingestion-jobs/:jobID", h.OwershipMiddleware.IsChatbotOwner, h.GET_IngestionJob)
+	chat.Get("/:chatID/ingestion-jobs/:jobID/events", h.OwershipMiddleware.IsChatbotOwner, h.GET_IngestionJobEvents)
 	chat.Get("/:chatID/text", h.OwershipMiddleware.IsChatbotOwner, h.GET_TextSources)
 	chat.Delete("/:chatID/text/:id", h.OwershipMiddleware.IsChatbotOwner, h.DELETE_TextSource)
+	chat.Get("/:chatID/websites/:sourceID/pages", h.OwershipMiddleware.IsChatbotOwner, h.GET_WebsitePages)
+	chat.Delete("/:chatID/websites/:sourceID/pages/:pageID", h.OwershipMiddleware.IsChatbotOwner, h.DELETE_WebsitePage)
 	chat.Delete("/:chatID/files/:filename", h.OwershipMiddleware.IsChatbotOwner, h.DELETE_ChatFile)
 	chat.Get("/:chatID/files", h.OwershipMiddleware.IsChatbotOwner, h.GET_ChatFiles)
 	chat.Get("/:chatID/crawl-schedules", h.OwershipMiddleware.IsChatbotOwner, h.GET_CrawlSchedules)
-	chat.Put("/:chatID/crawl-schedules", h.OwershipMiddleware.IsChatbotOwner, h.PUT_CrawlSchedule)
+	chat.Put("/:chatID/crawl-schedules", h.OwershipMiddleware.IsChatbotOwner, h.SubscriptionLimits.CheckLimit(constants.LimitCrawlPages), h.PUT_CrawlSchedule)
 	chat.Delete("/:chatID/crawl-schedules/:scheduleID", h.OwershipMiddleware.IsChatbotOwner, h.DELETE_CrawlSchedule)
-	chat.Post("/:chatID/crawl-now", h.OwershipMiddleware.IsChatbotOwner, h.POST_CrawlNow)
+	chat.Get("/:chatID/crawl-schedules/:scheduleID/runs", h.OwershipMiddleware.IsChatbotOwner, h.GET_CrawlRuns)
+	chat.Post("/:chatID/crawl-now", h.OwershipMiddleware.IsChatbotOwner, h.SubscriptionLimits.CheckLimit(constants.LimitCrawlPages), h.POST_CrawlNow)
+	chat.Get("/:chatID/actions", h.OwershipMiddleware.IsChatbotOwner, h.GET_Actions)
+	chat.Post("/:chatID/actions", h.OwershipMiddleware.IsChatbotOwner, h.POST_Action)
+	chat.Put("/:chatID/actions/:actionID", h.OwershipMiddleware.IsChatbotOwner, h.PUT_Action)
+	chat.Delete("/:chatID/actions/:actionID", h.OwershipMiddleware.IsChatbotOwner, h.DELETE_Action)
 
 	// Chat
 	chat.Post("/:chatID/message", h.OwershipMiddleware.IsChatbotOwner, h.SubscriptionLimits.CheckMessageCredits(), h.POST_ChatMessage)
@@ -130,13 +151,13 @@ func (h *ChatHandler) POST_GenerateSystemPrompt(c *fiber.Ctx) error {
 }
 
 // @Summary Upload file
-// @Description Upload a file to be used for chat context
+// @Description Upload a file to be used for chat context. The file is processed in the background; poll the returned ingestion job for progress.
 // @Tags chat
 // @Accept multipart/form-data
 // @Produce json
 // @Param chatID path string true "Chat session ID"
 // @Param file formData file true "File to upload"
-// @Success 200 {object} models.FileUploadResponse
+// @Success 202 {object} models.IngestionJobResponse
 // @Failure 400 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
 // @Security BearerAuth
@@ -152,7 +173,7 @@ func (h *ChatHandler) POST_UploadFile(c *fiber.Ctx) error {
 		return ErrorResponse(c, "No file uploaded", err, http.StatusBadRequest)
 	}
 
-	response, err := h.ChatService.ProcessFileUpload(c.Context(), chatID, file)
+	response, err := h.IngestionService.EnqueueFile(c.Context(), services.KnowledgeBaseTarget{ChatbotID: &chatID}, file)
 	if err != nil {
 		status := http.StatusInternalServerError
 		if apperrors.Is(err, apperrors.ErrInvalidChatbotParameters) {
@@ -161,17 +182,17 @@ func (h *ChatHandler) POST_UploadFile(c *fiber.Ctx) error {
 		return ErrorResponse(c, "Failed to upload file", err, status)
 	}
 
-	return c.JSON(response)
+	return c.Status(http.StatusAccepted).JSON(response)
 }
 
 // @Summary Upload plain text
-// @Description Upload plain text to be indexed for chat context
+// @Description Upload plain text to be indexed for chat context. Indexing runs in the background.
 // @Tags chat
 // @Accept json
 // @Produce json
 // @Param chatID path string true "Chat session ID"
 // @Param body body models.TextUploadRequest true "Text payload"
-// @Success 200 {object} models.MessageResponse
+// @Success 202 {object} models.IngestionJobResponse
 // @Failure 400 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
 // @Security BearerAuth
@@ -190,21 +211,22 @@ func (h *ChatHandler) POST_UploadText(c *fiber.Ctx) error {
 		return ErrorResponse(c, "Text is required", nil, http.StatusBadRequest)
 	}
 
-	if err := h.ChatService.ProcessTextUpload(c.Context(), chatID, req.Text); err != nil {
+	response, err := h.IngestionService.EnqueueText(c.Context(), services.KnowledgeBaseTarget{ChatbotID: &chatID}, req.Text)
+	if err != nil {
 		return ErrorResponse(c, "Failed to upload text", err)
 	}
 
-	return c.JSON(models.MessageResponse{Message: "Text processed successfully"})
+	return c.Status(http.StatusAccepted).JSON(response)
 }
 
 // @Summary Add website
-// @Description Crawl a website from a root URL and index its text content
+// @Description Crawl a website from a root URL and index its text content. The crawl runs in the background.
 // @Tags chat
 // @Accept json
 // @Produce json
 // @Param chatID path string true "Chat session ID"
 // @Param body body models.WebsiteUploadRequest true "Website URL"
-// @Success 200 {object} models.MessageResponse
+// @Success 202 {object} models.IngestionJobResponse
 // @Failure 400 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
 // @Security BearerAuth
@@ -219,11 +241,84 @@ func (h *ChatHandler) POST_UploadWebsite(c *fiber.Ctx) error {
 		return ErrorResponse(c, "Invalid request body", err, http.StatusBadRequest)
 	}
 
-	if err := h.ChatService.AddWebsite(c.Context(), chatID, req.URL); err != nil {
-		return ErrorResponse(c, "Failed to index website", err)
+	response, err := h.IngestionService.EnqueueWebsite(c.Context(), services.KnowledgeBaseTarget{ChatbotID: &chatID}, req.URL, req.Options, req.Auth)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if apperrors.Is(err, apperrors.ErrInvalidChatbotParameters) {
+			status = http.StatusBadRequest
+		}
+		return ErrorResponse(c, "Failed to index website", err, status)
+	}
+
+	return c.Status(http.StatusAccepted).JSON(response)
+}
+
+// @Summary Get ingestion job
+// @Description Poll the progress of a file, text or website upload
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param chatID path string true "Chat session ID"
+// @Param jobID path string true "Ingestion job ID"
+// @Success 200 {object} models.IngestionJobResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /chat/{chatID}/ingestion-jobs/{jobID} [get]
+func (h *ChatHandler) GET_IngestionJob(c *fiber.Ctx) error {
+	chatID, err := h.ChatService.ParseChatID(c.Params("chatID"))
+	if err != nil {
+		return ErrorResponse(c, "Invalid chat ID", err, http.StatusBadRequest)
+	}
+	jobID, err := parseUUIDParam(c, "jobID")
+	if err != nil {
+		return ErrorResponse(c, "Invalid ingestion job ID", err, http.StatusBadRequest)
 	}
 
-	return c.JSON(models.MessageResponse{Message: "Website indexed successfully"})
+	resp, err := h.IngestionService.Get(c.Context(), services.KnowledgeBaseTarget{ChatbotID: &chatID}, jobID)
+	if err != nil {
+		if apperrors.Is(err, apperrors.ErrNotFound) {
+			return ErrorResponse(c, "Ingestion job not found", err, http.StatusNotFound)
+		}
+		return ErrorResponse(c, "Failed to load ingestion job", err)
+	}
+	return c.JSON(resp)
+}
+
+// @Summary Stream ingestion job progress
+// @Description Server-sent events with the job state whenever it changes; the stream ends once the job is done or failed
+// @Tags chat
+// @Produce text/event-stream
+// @Param chatID path string true "Chat session ID"
+// @Param jobID path string true "Ingestion job ID"
+// @Success 200 {string} string "Progress events"
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /chat/{chatID}/ingestion-jobs/{jobID}/events [get]
+func (h *ChatHandler) GET_IngestionJobEvents(c *fiber.Ctx) error {
+	chatID, err := h.ChatService.ParseChatID(c.Params("chatID"))
+	if err != nil {
+		return ErrorResponse(c, "Invalid chat ID", err, http.StatusBadRequest)
+	}
+	jobID, err := parseUUIDParam(c, "jobID")
+	if err != nil {
+		return ErrorResponse(c, "Invalid ingestion job ID", err, http.StatusBadRequest)
+	}
+
+	target := services.KnowledgeBaseTarget{ChatbotID: &chatID}
+	load := func(ctx context.Context) (*models.IngestionJobResponse, error) {
+		return h.IngestionService.Get(ctx, target, jobID)
+	}
+	if _, err := load(c.Context()); err != nil {
+		if apperrors.Is(err, apperrors.ErrNotFound) {
+			return ErrorResponse(c, "Ingestion job not found", err, http.StatusNotFound)
+		}
+		return ErrorResponse(c, "Failed to load ingestion job", err)
+	}
+	return streamIngestionJob(c, load)
 }
 
 // @Summary List text sources
@@ -290,6 +385,70 @@ func (h *ChatHandler) DELETE_TextSource(c *fiber.Ctx) error {
 	return c.JSON(models.MessageResponse{Message: "Text source deleted successfully"})
 }
 
+// @Summary List website pages
+// @Description List the crawled pages of a website source
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param chatID path string true "Chat session ID"
+// @Param sourceID path string true "Website source ID"
+// @Success 200 {object} models.WebsitePagesResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /chat/{chatID}/websites/{sourceID}/pages [get]
+func (h *ChatHandler) GET_WebsitePages(c *fiber.Ctx) error {
+	chatID, err := h.ChatService.ParseChatID(c.Params("chatID"))
+	if err != nil {
+		return ErrorResponse(c, "Invalid chat ID", err, http.StatusBadRequest)
+	}
+	sourceID, err := parseUUIDParam(c, "sourceID")
+	if err != nil {
+		return ErrorResponse(c, "Invalid website source ID", err, http.StatusBadRequest)
+	}
+
+	resp, err := h.ChatService.ListWebsitePages(c.Context(), chatID, sourceID)
+	if err != nil {
+		return ErrorResponse(c, "Failed to retrieve website pages", err, websitePageErrorStatus(err))
+	}
+	return c.JSON(resp)
+}
+
+// @Summary Delete website page
+// @Description Delete one crawled page and its chunks from a website source
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param chatID path string true "Chat session ID"
+// @Param sourceID path string true "Website source ID"
+// @Param pageID path string true "Page ID"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /chat/{chatID}/websites/{sourceID}/pages/{pageID} [delete]
+func (h *ChatHandler) DELETE_WebsitePage(c *fiber.Ctx) error {
+	chatID, err := h.ChatService.ParseChatID(c.Params("chatID"))
+	if err != nil {
+		return ErrorResponse(c, "Invalid chat ID", err, http.StatusBadRequest)
+	}
+	sourceID, err := parseUUIDParam(c, "sourceID")
+	if err != nil {
+		return ErrorResponse(c, "Invalid website source ID", err, http.StatusBadRequest)
+	}
+	pageID, err := parseUUIDParam(c, "pageID")
+	if err != nil {
+		return ErrorResponse(c, "Invalid page ID", err, http.StatusBadRequest)
+	}
+
+	if err := h.ChatService.DeleteWebsitePage(c.Context(), chatID, sourceID, pageID); err != nil {
+		return ErrorResponse(c, "Failed to delete website page", err, websitePageErrorStatus(err))
+	}
+	return c.JSON(models.MessageResponse{Message: "Website page deleted successfully"})
+}
+
 // @Summary Delete chat file
 // @Description Delete a file from a chat session
 // @Tags chat
@@ -444,6 +603,42 @@ func (h *ChatHandler) DELETE_CrawlSchedule(c *fiber.Ctx) error {
 	return c.SendStatus(http.StatusNoContent)
 }
 
+// @Summary List crawl runs
+// @Description List past and running crawls of a schedule's URL, newest first, including manual crawls of the URL.
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param chatID path string true "Chat session ID"
+// @Param scheduleID path string true "Schedule ID (UUID)"
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param before query string false "next_before of the previous page (RFC 3339)"
+// @Success 200 {object} models.CrawlRunListResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /chat/{chatID}/crawl-schedules/{scheduleID}/runs [get]
+func (h *ChatHandler) GET_CrawlRuns(c *fiber.Ctx) error {
+	chatID, err := h.ChatService.ParseChatID(c.Params("chatID"))
+	if err != nil {
+		return ErrorResponse(c, "Invalid chat ID", err, http.StatusBadRequest)
+	}
+	scheduleID, err := parseUUIDParam(c, "scheduleID")
+	if err != nil {
+		return ErrorResponse(c, "Invalid schedule id", err, http.StatusBadRequest)
+	}
+	before, err := parseBeforeQuery(c)
+	if err != nil {
+		return ErrorResponse(c, "Invalid before timestamp", err, http.StatusBadRequest)
+	}
+
+	resp, err := h.ScheduleService.ListRuns(c.Context(), services.KnowledgeBaseTarget{ChatbotID: &chatID}, scheduleID, before, c.QueryInt("limit", 20))
+	if err != nil {
+		return ErrorResponse(c, "Failed to list crawl runs", err, crawlRunsErrorStatus(err))
+	}
+	return c.JSON(resp)
+}
+
 // @Summary Crawl once now
 // @Description Enqueue a single crawl job for this chatbot knowledge base using the provided URL.
 // @Tags chat
@@ -451,8 +646,9 @@ func (h *ChatHandler) DELETE_CrawlSchedule(c *fiber.Ctx) error {
 // @Produce json
 // @Param chatID path string true "Chat session ID"
 // @Param body body models.WebsiteUploadRequest true "Website URL"
-// @Success 202 {object} models.MessageResponse
+// @Success 202 {object} models.CrawlNowResponse
 // @Failure 400 {object} models.APIResponse
+// @Failure 409 {object} models.CrawlNowResponse "A crawl of the URL is already queued or running"
 // @Failure 500 {object} models.APIResponse
 // @Security BearerAuth
 // @Router /chat/{chatID}/crawl-now [post]
@@ -467,14 +663,151 @@ func (h *ChatHandler) POST_CrawlNow(c *fiber.Ctx) error {
 		return ErrorResponse(c, "Invalid request body", err, http.StatusBadRequest)
 	}
 
-	if err := h.ScheduleService.EnqueueOnce(c.Context(), services.KnowledgeBaseTarget{ChatbotID: &chatID}, req.URL); err != nil {
+	resp, err := h.ScheduleService.EnqueueOnce(c.Context(), services.KnowledgeBaseTarget{ChatbotID: &chatID}, req.URL, req.Options, req.Auth)
+	if err != nil {
 		status := http.StatusInternalServerError
 		if apperrors.Is(err, apperrors.ErrInvalidChatbotParameters) {
 			status = http.StatusBadRequest
 		}
 		return ErrorResponse(c, "Failed to enqueue crawl", err, status)
 	}
-	return c.Status(http.StatusAccepted).JSON(models.MessageResponse{Message: "Crawl enqueued"})
+	if resp.Status == models.CrawlAlreadyRunning {
+		return c.Status(http.StatusConflict).JSON(resp)
+	}
+	return c.Status(http.StatusAccepted).JSON(resp)
+}
+
+// @Summary List chatbot actions
+// @Description List the HTTP actions the chatbot can call while answering. Header values are never returned.
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param chatID path string true "Chat session ID"
+// @Success 200 {object} models.ChatbotActionListResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /chat/{chatID}/actions [get]
+func (h *ChatHandler) GET_Actions(c *fiber.Ctx) error {
+	chatID, err := h.ChatService.ParseChatID(c.Params("chatID"))
+	if err != nil {
+		return ErrorResponse(c, "Invalid chat ID", err, http.StatusBadRequest)
+	}
+
+	resp, err := h.ActionService.List(c.Context(), chatID)
+	if err != nil {
+		return ErrorResponse(c, "Failed to list actions", err)
+	}
+	return c.JSON(resp)
+}
+
+// @Summary Create chatbot action
+// @Description Register an HTTP action the model can call as a tool. Parameters is a JSON schema object; {name} placeholders in the URL are filled from the arguments.
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param chatID path string true "Chat session ID"
+// @Param body body models.ChatbotActionRequest true "Action payload"
+// @Success 201 {object} models.ChatbotActionResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /chat/{chatID}/actions [post]
+func (h *ChatHandler) POST_Action(c *fiber.Ctx) error {
+	chatID, err := h.ChatService.ParseChatID(c.Params("chatID"))
+	if err != nil {
+		return ErrorResponse(c, "Invalid chat ID", err, http.StatusBadRequest)
+	}
+
+	var req models.ChatbotActionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return ErrorResponse(c, "Invalid request body", err, http.StatusBadRequest)
+	}
+
+	resp, err := h.ActionService.Create(c.Context(), chatID, &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if apperrors.Is(err, apperrors.ErrInvalidChatbotParameters) {
+			status = http.StatusBadRequest
+		}
+		return ErrorResponse(c, "Failed to create action", err, status)
+	}
+	return c.Status(http.StatusCreated).JSON(resp)
+}
+
+// @Summary Update chatbot action
+// @Description Replace an action definition. Omit headers to keep the stored ones.
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param chatID path string true "Chat session ID"
+// @Param actionID path string true "Action ID (UUID)"
+// @Param body body models.ChatbotActionRequest true "Action payload"
+// @Success 200 {object} models.ChatbotActionResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /chat/{chatID}/actions/{actionID} [put]
+func (h *ChatHandler) PUT_Action(c *fiber.Ctx) error {
+	chatID, err := h.ChatService.ParseChatID(c.Params("chatID"))
+	if err != nil {
+		return ErrorResponse(c, "Invalid chat ID", err, http.StatusBadRequest)
+	}
+	actionID, err := parseUUIDParam(c, "actionID")
+	if err != nil {
+		return ErrorResponse(c, "Invalid action id", err, http.StatusBadRequest)
+	}
+
+	var req models.ChatbotActionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return ErrorResponse(c, "Invalid request body", err, http.StatusBadRequest)
+	}
+
+	resp, err := h.ActionService.Update(c.Context(), chatID, actionID, &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if apperrors.Is(err, apperrors.ErrNotFound) {
+			status = http.StatusNotFound
+		} else if apperrors.Is(err, apperrors.ErrInvalidChatbotParameters) {
+			status = http.StatusBadRequest
+		}
+		return ErrorResponse(c, "Failed to update action", err, status)
+	}
+	return c.JSON(resp)
+}
+
+// @Summary Delete chatbot action
+// @Description Remove an action from a chatbot
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param chatID path string true "Chat session ID"
+// @Param actionID path string true "Action ID (UUID)"
+// @Success 204 {string} string ""
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /chat/{chatID}/actions/{actionID} [delete]
+func (h *ChatHandler) DELETE_Action(c *fiber.Ctx) error {
+	chatID, err := h.ChatService.ParseChatID(c.Params("chatID"))
+	if err != nil {
+		return ErrorResponse(c, "Invalid chat ID", err, http.StatusBadRequest)
+	}
+	actionID, err := parseUUIDParam(c, "actionID")
+	if err != nil {
+		return ErrorResponse(c, "Invalid action id", err, http.StatusBadRequest)
+	}
+
+	if err := h.ActionService.Delete(c.Context(), chatID, actionID); err != nil {
+		status := http.StatusInternalServerError
+		if apperrors.Is(err, apperrors.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		return ErrorResponse(c, "Failed to delete action", err, status)
+	}
+	return c.SendStatus(http.StatusNoContent)
 }
 
 // @Summary Get list of chatbots
@@ -501,6 +834,30 @@ func (h *ChatHandler) GET_ListChatbots(c *fiber.Ctx) error {
 	return c.JSON(response)
 }
 
+// @Summary Get message credit usage
+// @Description Get message credits used and remaining in the current billing period for the personal or organization pool
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.MessageCreditUsageResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /chat/usage [get]
+func (h *ChatHandler) GET_MessageCreditUsage(c *fiber.Ctx) error {
+	user, err := GetUser(c)
+	if err != nil {
+		return err
+	}
+
+	response, err := h.CreditService.Status(c.Context(), user.ID, GetOrgContext(c).ID)
+	if err != nil {
+		return ErrorResponse(c, "Failed to retrieve message credit usage", err)
+	}
+
+	return c.JSON(response)
+}
+
 // @Summary Send chat message
 // @Description Send a message and get a response with context from uploaded files
 // @Tags chat
@@ -508,7 +865,7 @@ func (h *ChatHandler) GET_ListChatbots(c *fiber.Ctx) error {
 // @Produce json
 // @Param message body models.ChatMessageRequest true "Chat message"
 // @Param chatID path string true "Chat session ID"
-// @Success 200 {object} models.ChatResponse
+// @Success 200 {object} models.ChatMessageResponse
 // @Failure 400 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
 // @Security BearerAuth
@@ -522,22 +879,7 @@ func (h *ChatHandler) POST_ChatMessage(c *fiber.Ctx) error {
 		return ErrorResponse(c, msg, err, status)
 	}
 
-	response, sessionID, err := h.ChatService.ChatWithChatbot(c.Context(), ctxData.chatbot, ctxData.query, ctxData.sessionID)
-	if err != nil {
-		if apperrors.Is(err, apperrors.ErrNoDocumentsFound) {
-			return ErrorResponse(c, "No documents found for this chat. Please upload some files first.", err, http.StatusNotFound)
-		} else if apperrors.Is(err, apperrors.ErrUnauthorizedChatbotAccess) {
-			return ErrorResponse(c, "You don't have permission to access this chatbot", err, http.StatusForbidden)
-		} else if apperrors.Is(err, apperrors.ErrChatbotNotFound) {
-			return ErrorResponse(c, "Chatbot not found", err, http.StatusNotFound)
-		}
-		return ErrorResponse(c, "Chat error", err)
-	}
-
-	return c.JSON(fiber.Map{
-		"response":   response,
-		"session_id": sessionID,
-	})
+	return sendChatReply(c, h.ChatService, ctxData)
 }
 
 // @Summary Stream chat message
@@ -561,17 +903,106 @@ func (h *ChatHandler) POST_StreamChatMessage(c *fiber.Ctx) error {
 		return ErrorResponse(c, msg, err, status)
 	}
 
+	return streamChatReply(c, h.ChatService, ctxData)
+}
+
+type chatMessageContext struct {
+	chatbot   *db.Chatbot
+	query     string
+	sessionID *string
+}
+
+func (h *ChatHandler) prepareChatMessageContext(c *fiber.Ctx) (*chatMessageContext, int, string, error) {
+	chatID := c.Params("chatID")
+	if chatID == "" {
+		return nil, http.StatusBadRequest, "Chat ID is required", nil
+	}
+
+	req, err := parseChatMessageRequest(c, h.ChatService)
+	if err != nil {
+		return nil, http.StatusBadRequest, "Invalid query parameter", err
+	}
+
+	user, err := GetUser(c)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	chatbot, err := h.ChatService.GetChatbotForUser(c.Context(), chatID, user.ID, GetOrgContext(c))
+	if err != nil {
+		if apperrors.Is(err, apperrors.ErrChatbotNotFound) {
+			return nil, http.StatusNotFound, "Chatbot not found", err
+		}
+		return nil, http.StatusInternalServerError, "Failed to load chatbot", err
+	}
+
+	return &chatMessageContext{
+		chatbot:   chatbot,
+		query:     req.Query,
+		sessionID: req.SessionID,
+	}, 0, "", nil
+}
+
+// parseChatMessageRequest reads the chat message from the JSON body or form and validates the query
+func parseChatMessageRequest(c *fiber.Ctx, chatService *services.ChatService) (*models.ChatMessageRequest, error) {
+	var req models.ChatMessageRequest
+	if err := c.BodyParser(&req); err != nil {
+		// Fallback for form value if body parsing fails
+		req.Query = c.FormValue("query")
+	}
+
+	query, err := chatService.ValidateAndParseQuery(&req, c.FormValue("query"))
+	if err != nil {
+		return nil, err
+	}
+	req.Query = query
+	return &req, nil
+}
+
+// sendChatReply answers a chat message as a single JSON response
+func sendChatReply(c *fiber.Ctx, chatService *services.ChatService, ctxData *chatMessageContext) error {
+	result, err := chatService.ChatWithChatbot(c.Context(), ctxData.chatbot, ctxData.query, ctxData.sessionID)
+	if err != nil {
+		if apperrors.Is(err, apperrors.ErrNoDocumentsFound) {
+			return ErrorResponse(c, "No documents found for this chat. Please upload some files first.", err, http.StatusNotFound)
+		} else if apperrors.Is(err, apperrors.ErrUnauthorizedChatbotAccess) {
+			return ErrorResponse(c, "You don't have permission to access this chatbot", err, http.StatusForbidden)
+		} else if apperrors.Is(err, apperrors.ErrChatbotNotFound) {
+			return ErrorResponse(c, "Chatbot not found", err, http.StatusNotFound)
+		}
+		return ErrorResponse(c, "Chat error", err)
+	}
+
+	sources := result.Sources
+	if sources == nil {
+		sources = []models.SourceCitation{}
+	}
+
+	return c.JSON(models.ChatMessageResponse{
+		Response:  result.Content,
+		SessionID: result.SessionID,
+		Sources:   sources,
+	})
+}
+
+// streamChatReply answers a chat message as server-sent events: chunk and tool events while
+// generating, then sources and done
+func streamChatReply(c *fiber.Ctx, chatService *services.ChatService, ctxData *chatMessageContext) error {
 	c.Set("Content-Type", "text/event-stream")
 	c.Set("Cache-Control", "no-cache")
 	c.Set("Connection", "keep-alive")
 
 	ctx := c.Context()
+	// the reply is written after the credit middleware returned, which only sees the 200
+	releaseCredit := middleware.MessageCreditRelease(c)
 
 	type streamEvent struct {
-		Type      string `json:"type"`
-		Content   string `json:"content,omitempty"`
-		SessionID string `json:"session_id,omitempty"`
-		Error     string `json:"error,omitempty"`
+		Type      string                  `json:"type"`
+		Content   string                  `json:"content,omitempty"`
+		SessionID string                  `json:"session_id,omitempty"`
+		Sources   []models.SourceCitation `json:"sources,omitempty"`
+		Tool      *models.ToolCallStatus  `json:"tool,omitempty"`
+		Error     string                  `json:"error,omitempty"`
 	}
 
 	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
@@ -587,7 +1018,7 @@ func (h *ChatHandler) POST_StreamChatMessage(c *fiber.Ctx) error {
 		}
 
 		clientClosed := false
-		completion, sessionID, err := h.ChatService.ChatWithChatbotStream(ctx, ctxData.chatbot, ctxData.query, ctxData.sessionID, func(callCtx context.Context, chunk string) error {
+		result, err := chatService.ChatWithChatbotStream(ctx, ctxData.chatbot, ctxData.query, ctxData.sessionID, func(callCtx context.Context, chunk string) error {
 			if chunk == "" {
 				return nil
 			}
@@ -596,61 +1027,136 @@ func (h *ChatHandler) POST_StreamChatMessage(c *fiber.Ctx) error {
 				return err
 			}
 			return nil
+		}, func(callCtx context.Context, status models.ToolCallStatus) error {
+			if err := send(streamEvent{Type: "tool", Tool: &status}); err != nil {
+				clientClosed = true
+				return err
+			}
+			return nil
 		})
 		if err != nil {
+			releaseCredit()
 			if clientClosed || errors.Is(err, context.Canceled) {
 				return
 			}
 			_ = send(streamEvent{Type: "error", Error: err.Error()})
 			return
 		}
-		_ = send(streamEvent{Type: "done", Content: completion, SessionID: sessionID})
+		if len(result.Sources) > 0 {
+			if err := send(streamEvent{Type: "sources", Sources: result.Sources, SessionID: result.SessionID}); err != nil {
+				return
+			}
+		}
+		_ = send(streamEvent{Type: "done", Content: result.Content, SessionID: result.SessionID})
 	})
 
 	return nil
 }
 
-type chatMessageContext struct {
-	chatbot   *db.Chatbot
-	query     string
-	sessionID *string
-}
+// ingestionJobLoader reads the current state of an ingestion job for the progress stream
+type ingestionJobLoader func(ctx context.Context) (*models.IngestionJobResponse, error)
 
-func (h *ChatHandler) prepareChatMessageContext(c *fiber.Ctx) (*chatMessageContext, int, string, error) {
-	var req models.ChatMessageRequest
-	chatID := c.Params("chatID")
-	if chatID == "" {
-		return nil, http.StatusBadRequest, "Chat ID is required", nil
-	}
+// streamIngestionJob sends a progress event whenever the job changes and a final done event
+// once it has finished or failed.
+func streamIngestionJob(c *fiber.Ctx, load ingestionJobLoader) error {
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
 
-	if err := c.BodyParser(&req); err != nil {
-		// Fallback for form value if body parsing fails
-		req.Query = c.FormValue("query")
+	ctx := c.Context()
+
+	type progressEvent struct {
+		Type  string                       `json:"type"`
+		Job   *models.IngestionJobResponse `json:"job,omitempty"`
+		Error string                       `json:"error,omitempty"`
 	}
 
-	query, err := h.ChatService.ValidateAndParseQuery(&req, c.FormValue("query"))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		send := func(event progressEvent) error {
+			payload, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			if _, err = fmt.Fprintf(w, "data: %s\n\n", payload); err != nil {
+				return err
+			}
+			return w.Flush()
+		}
+
+		ticker := time.NewTicker(constants.IngestionPollInterval)
+		defer ticker.Stop()
+
+		var last *models.IngestionJobResponse
+		for {
+			job, err := load(ctx)
+			if err != nil {
+				_ = send(progressEvent{Type: "error", Error: err.Error()})
+				return
+			}
+			if job.Status == constants.IngestionDone || job.Status == constants.IngestionFailed {
+				_ = send(progressEvent{Type: "done", Job: job})
+				return
+			}
+			if last == nil || job.Status != last.Status || job.ProcessedChunks != last.ProcessedChunks || job.TotalChunks != last.TotalChunks {
+				if err := send(progressEvent{Type: "progress", Job: job}); err != nil {
+					return
+				}
+				last = job
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+
+	return nil
+}
+
+// @Summary Set widget allowed origins
+// @Description Replace the websites allowed to call the chatbot's public widget chat API. An empty list disables public access.
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param chatID path string true "Chatbot ID"
+// @Param body body models.AllowedOriginsRequest true "Allowed origins"
+// @Success 200 {object} models.ChatbotResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /chat/chatbot/{chatID}/allowed-origins [put]
+func (h *ChatHandler) PUT_AllowedOrigins(c *fiber.Ctx) error {
+	user, err := GetUser(c)
 	if err != nil {
-		return nil, http.StatusBadRequest, "Invalid query parameter", err
+		return err
 	}
 
-	user, err := GetUser(c)
+	chatID, err := h.ChatService.ParseChatID(c.Params("chatID"))
 	if err != nil {
-		return nil, 0, "", err
+		return ErrorResponse(c, "Invalid chat ID", err, http.StatusBadRequest)
 	}
 
-	chatbot, err := h.ChatService.GetChatbotForUser(c.Context(), chatID, user.ID, GetOrgContext(c))
+	var req models.AllowedOriginsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return ErrorResponse(c, "Invalid request body", err, http.StatusBadRequest)
+	}
+
+	response, err := h.ChatService.UpdateAllowedOrigins(c.Context(), chatID, user.ID, GetOrgContext(c), req.AllowedOrigins)
 	if err != nil {
-		if apperrors.Is(err, apperrors.ErrChatbotNotFound) {
-			return nil, http.StatusNotFound, "Chatbot not found", err
+		status := http.StatusInternalServerError
+		if apperrors.Is(err, apperrors.ErrInvalidChatbotParameters) {
+			status = http.StatusBadRequest
+		} else if apperrors.Is(err, apperrors.ErrChatbotNotFound) {
+			status = http.StatusNotFound
 		}
-		return nil, http.StatusInternalServerError, "Failed to load chatbot", err
+		return ErrorResponse(c, "Failed to update allowed origins", err, status)
 	}
 
-	return &chatMessageContext{
-		chatbot:   chatbot,
-		query:     query,
-		sessionID: req.SessionID,
-	}, 0, "", nil
+	return c.JSON(response)
 }
 
 // @Summary Create a new chatbot
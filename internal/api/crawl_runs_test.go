@@ -0,0 +1,75 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+)
+
+func TestCrawlRunsErrorStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"invalid parameters", apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "limit must be between 1 and 100"), http.StatusBadRequest},
+		{"unknown schedule", apperrors.ErrNotFound, http.StatusNotFound},
+		{"other knowledge base", apperrors.ErrUnauthorizedKnowledgeBaseAccess, http.StatusForbidden},
+		{"database failure", errors.New("connection reset"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := crawlRunsErrorStatus(tt.err); got != tt.want {
+				t.Fatalf("crawlRunsErrorStatus(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBeforeQuery(t *testing.T) {
+	app := fiber.New()
+	app.Get("/runs", func(c *fiber.Ctx) error {
+		before, err := parseBeforeQuery(c)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).SendString(err.Error())
+		}
+		if before == nil {
+			return c.SendString("none")
+		}
+		return c.SendString(before.UTC().Format(time.RFC3339Nano))
+	})
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantBody   string
+	}{
+		{"no cursor", "", http.StatusOK, "none"},
+		{"next_before of a previous page", "?before=2025-03-02T04:00:00.123456Z", http.StatusOK, "2025-03-02T04:00:00.123456Z"},
+		{"offset timestamp", "?before=2025-03-02T06:00:00%2B02:00", http.StatusOK, "2025-03-02T04:00:00Z"},
+		{"not a timestamp", "?before=yesterday", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/runs"+tt.query, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if tt.wantBody != "" && string(body) != tt.wantBody {
+				t.Fatalf("before = %s, want %s", body, tt.wantBody)
+			}
+		})
+	}
+}
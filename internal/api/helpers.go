@@ -2,9 +2,11 @@ package api
 
 import (
 	"log/slog"
+	"net/http"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/yourusername/vectorchat/internal/db"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
 	"github.com/yourusername/vectorchat/internal/services"
 	"github.com/yourusername/vectorchat/pkg/models"
 )
@@ -34,3 +36,16 @@ func ErrorResponse(c *fiber.Ctx, msg string, err error, statusCode ...int) error
 	}
 	return c.Status(fiber.StatusInternalServerError).JSON(res)
 }
+
+// websitePageErrorStatus maps website page lookup errors to HTTP status codes
+func websitePageErrorStatus(err error) int {
+	switch {
+	case apperrors.Is(err, apperrors.ErrInvalidChatbotParameters):
+		return http.StatusBadRequest
+	case apperrors.Is(err, apperrors.ErrSharedKnowledgeBaseNotFound), apperrors.Is(err, apperrors.ErrFileNotFound), apperrors.Is(err, apperrors.ErrNotFound):
+		return http.StatusNotFound
+	case apperrors.Is(err, apperrors.ErrUnauthorizedKnowledgeBaseAccess), apperrors.Is(err, apperrors.ErrUnauthorizedChatbotAccess):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
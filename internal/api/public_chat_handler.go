@@ -0,0 +1,190 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"github.com/google/uuid"
+	"github.com/yourusername/vectorchat/internal/db"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+	"github.com/yourusername/vectorchat/internal/middleware"
+	"github.com/yourusername/vectorchat/internal/services"
+	"github.com/yourusername/vectorchat/pkg/constants"
+)
+
+// PublicChatHandler serves the unauthenticated chat API used by embedded widgets.
+// Access is limited to the chatbot's allowed origins, anonymous session tokens and rate limits;
+// replies count against the chatbot owner's message credits.
+type PublicChatHandler struct {
+	ChatService        *services.ChatService
+	WidgetService      *services.WidgetService
+	SubscriptionLimits *middleware.SubscriptionLimitsMiddleware
+	ipRateLimit        int
+	sessionRateLimit   int
+}
+
+func NewPublicChatHandler(
+	chatService *services.ChatService,
+	widgetService *services.WidgetService,
+	subscriptionLimits *middleware.SubscriptionLimitsMiddleware,
+	ipRateLimit int,
+	sessionRateLimit int,
+) *PublicChatHandler {
+	return &PublicChatHandler{
+		ChatService:        chatService,
+		WidgetService:      widgetService,
+		SubscriptionLimits: subscriptionLimits,
+		ipRateLimit:        ipRateLimit,
+		sessionRateLimit:   sessionRateLimit,
+	}
+}
+
+func (h *PublicChatHandler) RegisterRoutes(app *fiber.App) {
+	public := app.Group("/public/chat")
+
+	perIP := h.rateLimiter(h.ipRateLimit, func(c *fiber.Ctx) string {
+		return "ip:" + c.Params("chatID") + ":" + c.IP()
+	})
+	perSession := h.rateLimiter(h.sessionRateLimit, func(c *fiber.Ctx) string {
+		return "session:" + c.Locals("widget_session").(uuid.UUID).String()
+	})
+
+	public.Options("/:chatID/*", h.allowOrigin, h.preflight)
+	public.Post("/:chatID/session", h.allowOrigin, perIP, h.POST_Session)
+	public.Post("/:chatID/message", h.allowOrigin, perIP, h.requireSession, perSession, h.SubscriptionLimits.CheckMessageCredits(), h.POST_Message)
+	public.Post("/:chatID/stream-message", h.allowOrigin, perIP, h.requireSession, perSession, h.SubscriptionLimits.CheckMessageCredits(), h.POST_StreamMessage)
+}
+
+// @Summary Start a widget chat session
+// @Description Issue an anonymous session token for the public widget chat API. The calling page's origin must be in the chatbot's allowed origins.
+// @Tags public
+// @Produce json
+// @Param chatID path string true "Chatbot ID"
+// @Success 200 {object} models.WidgetSessionResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 429 {object} models.APIResponse
+// @Router /public/chat/{chatID}/session [post]
+func (h *PublicChatHandler) POST_Session(c *fiber.Ctx) error {
+	chatbot := c.Locals("widget_chatbot").(*db.Chatbot)
+	return c.JSON(h.WidgetService.IssueSession(chatbot.ID))
+}
+
+// @Summary Send widget chat message
+// @Description Send a message from an embedded widget. Requires the X-Widget-Session token from the session endpoint.
+// @Tags public
+// @Accept json
+// @Produce json
+// @Param chatID path string true "Chatbot ID"
+// @Param X-Widget-Session header string true "Widget session token"
+// @Param message body models.ChatMessageRequest true "Chat message"
+// @Success 200 {object} models.ChatMessageResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 402 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 429 {object} models.APIResponse
+// @Router /public/chat/{chatID}/message [post]
+func (h *PublicChatHandler) POST_Message(c *fiber.Ctx) error {
+	ctxData, err := h.prepareChatMessageContext(c)
+	if err != nil {
+		return ErrorResponse(c, "Invalid query parameter", err, http.StatusBadRequest)
+	}
+	return sendChatReply(c, h.ChatService, ctxData)
+}
+
+// @Summary Stream widget chat message
+// @Description Send a message from an embedded widget and receive a streamed response. Requires the X-Widget-Session token.
+// @Tags public
+// @Accept json
+// @Produce text/event-stream
+// @Param chatID path string true "Chatbot ID"
+// @Param X-Widget-Session header string true "Widget session token"
+// @Param message body models.ChatMessageRequest true "Chat message"
+// @Success 200 {string} string "Streamed response"
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 402 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 429 {object} models.APIResponse
+// @Router /public/chat/{chatID}/stream-message [post]
+func (h *PublicChatHandler) POST_StreamMessage(c *fiber.Ctx) error {
+	ctxData, err := h.prepareChatMessageContext(c)
+	if err != nil {
+		return ErrorResponse(c, "Invalid query parameter", err, http.StatusBadRequest)
+	}
+	return streamChatReply(c, h.ChatService, ctxData)
+}
+
+// prepareChatMessageContext binds the message to the session from the token, ignoring any session_id in the body
+func (h *PublicChatHandler) prepareChatMessageContext(c *fiber.Ctx) (*chatMessageContext, error) {
+	req, err := parseChatMessageRequest(c, h.ChatService)
+	if err != nil {
+		return nil, err
+	}
+	sessionID := c.Locals("widget_session").(uuid.UUID).String()
+	return &chatMessageContext{
+		chatbot:   c.Locals("widget_chatbot").(*db.Chatbot),
+		query:     req.Query,
+		sessionID: &sessionID,
+	}, nil
+}
+
+// allowOrigin loads the chatbot and checks the caller's Origin (or Referer) against its allow-list,
+// answering with CORS headers for that origin only
+func (h *PublicChatHandler) allowOrigin(c *fiber.Ctx) error {
+	chatbot, err := h.WidgetService.GetPublicChatbot(c.Context(), c.Params("chatID"))
+	if err != nil {
+		if apperrors.Is(err, apperrors.ErrChatbotNotFound) {
+			return ErrorResponse(c, "Chatbot not found", err, http.StatusNotFound)
+		}
+		return ErrorResponse(c, "Failed to load chatbot", err)
+	}
+
+	origin := services.RequestOrigin(c.Get(fiber.HeaderOrigin), c.Get(fiber.HeaderReferer))
+	if origin == "" || !h.WidgetService.OriginAllowed(chatbot, origin) {
+		return ErrorResponse(c, "Origin not allowed for this chatbot", nil, http.StatusForbidden)
+	}
+
+	c.Set(fiber.HeaderVary, fiber.HeaderOrigin)
+	if requestOrigin := c.Get(fiber.HeaderOrigin); requestOrigin != "" {
+		c.Set(fiber.HeaderAccessControlAllowOrigin, requestOrigin)
+	}
+	c.Locals("widget_chatbot", chatbot)
+	return c.Next()
+}
+
+func (h *PublicChatHandler) preflight(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderAccessControlAllowMethods, strings.Join([]string{fiber.MethodPost, fiber.MethodOptions}, ", "))
+	c.Set(fiber.HeaderAccessControlAllowHeaders, strings.Join([]string{fiber.HeaderContentType, constants.WidgetSessionHeader}, ", "))
+	c.Set(fiber.HeaderAccessControlMaxAge, "600")
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// requireSession validates the anonymous session token issued for this chatbot
+func (h *PublicChatHandler) requireSession(c *fiber.Ctx) error {
+	chatbot := c.Locals("widget_chatbot").(*db.Chatbot)
+	sessionID, err := h.WidgetService.VerifySession(chatbot.ID, c.Get(constants.WidgetSessionHeader))
+	if err != nil {
+		return ErrorResponse(c, "Invalid or expired widget session", err, http.StatusUnauthorized)
+	}
+	c.Locals("widget_session", sessionID)
+	return c.Next()
+}
+
+func (h *PublicChatHandler) rateLimiter(max int, key func(*fiber.Ctx) string) fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:          max,
+		Expiration:   time.Minute,
+		KeyGenerator: key,
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(http.StatusTooManyRequests).JSON(fiber.Map{
+				"error": "Too many requests. Please slow down.",
+				"code":  "RATE_LIMITED",
+			})
+		},
+	})
+}
@@ -2,8 +2,11 @@ package api
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
 	"github.com/yourusername/vectorchat/internal/middleware"
 	"github.com/yourusername/vectorchat/internal/services"
 	"github.com/yourusername/vectorchat/pkg/models"
@@ -12,15 +15,36 @@ import (
 type QueueHandler struct {
 	AuthMiddleware *middleware.AuthMiddleware
 	Service        *services.QueueMetricsService
+	// AdminEmails may manage the crawl DLQ, which holds jobs of every tenant
+	AdminEmails []string
 }
 
-func NewQueueHandler(auth *middleware.AuthMiddleware, svc *services.QueueMetricsService) *QueueHandler {
-	return &QueueHandler{AuthMiddleware: auth, Service: svc}
+func NewQueueHandler(auth *middleware.AuthMiddleware, svc *services.QueueMetricsService, adminEmails []string) *QueueHandler {
+	return &QueueHandler{AuthMiddleware: auth, Service: svc, AdminEmails: adminEmails}
 }
 
 func (h *QueueHandler) RegisterRoutes(app *fiber.App) {
 	group := app.Group("/queue", h.AuthMiddleware.RequireAuth)
 	group.Get("/crawl/metrics", h.GET_CrawlMetrics)
+
+	dlq := group.Group("/crawl/dlq", h.requireQueueAdmin)
+	dlq.Get("/", h.GET_CrawlDeadLetters)
+	dlq.Post("/:sequence/replay", h.POST_ReplayCrawlDeadLetter)
+	dlq.Delete("/", h.DELETE_CrawlDeadLetters)
+}
+
+// requireQueueAdmin limits a route to the users listed in QUEUE_ADMIN_EMAILS.
+func (h *QueueHandler) requireQueueAdmin(c *fiber.Ctx) error {
+	user, err := GetUser(c)
+	if err != nil {
+		return ErrorResponse(c, "User not authenticated", err, http.StatusUnauthorized)
+	}
+	for _, email := range h.AdminEmails {
+		if email != "" && strings.EqualFold(email, user.Email) {
+			return c.Next()
+		}
+	}
+	return ErrorResponse(c, "Queue administration is restricted", nil, http.StatusForbidden)
 }
 
 // GET_CrawlMetrics provides basic JetStream crawl queue stats.
@@ -34,3 +58,59 @@ func (h *QueueHandler) GET_CrawlMetrics(c *fiber.Ctx) error {
 	}
 	return c.JSON(models.APIResponse{Message: "ok", Data: metrics})
 }
+
+// GET_CrawlDeadLetters lists dead-lettered crawl jobs, oldest first. Pass next_after from the
+// response as ?after= to read the next page.
+func (h *QueueHandler) GET_CrawlDeadLetters(c *fiber.Ctx) error {
+	if h.Service == nil {
+		return ErrorResponse(c, "Queue unavailable", nil, http.StatusServiceUnavailable)
+	}
+	limit := c.QueryInt("limit", 50)
+	if limit <= 0 || limit > 500 {
+		return ErrorResponse(c, "limit must be between 1 and 500", nil, http.StatusBadRequest)
+	}
+	var after uint64
+	if raw := c.Query("after"); raw != "" {
+		v, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return ErrorResponse(c, "Invalid after sequence", err, http.StatusBadRequest)
+		}
+		after = v
+	}
+	list, err := h.Service.ListCrawlDeadLetters(c.Context(), after, limit)
+	if err != nil {
+		return ErrorResponse(c, "Failed to list dead-lettered crawl jobs", err)
+	}
+	return c.JSON(models.APIResponse{Message: "ok", Data: list})
+}
+
+// POST_ReplayCrawlDeadLetter re-enqueues a dead-lettered crawl job and removes it from the DLQ.
+func (h *QueueHandler) POST_ReplayCrawlDeadLetter(c *fiber.Ctx) error {
+	if h.Service == nil {
+		return ErrorResponse(c, "Queue unavailable", nil, http.StatusServiceUnavailable)
+	}
+	seq, err := strconv.ParseUint(c.Params("sequence"), 10, 64)
+	if err != nil || seq == 0 {
+		return ErrorResponse(c, "Invalid dead letter sequence", err, http.StatusBadRequest)
+	}
+	letter, err := h.Service.ReplayCrawlDeadLetter(c.Context(), seq)
+	if err != nil {
+		if apperrors.Is(err, apperrors.ErrNotFound) {
+			return ErrorResponse(c, "Dead letter not found", err, http.StatusNotFound)
+		}
+		return ErrorResponse(c, "Failed to replay crawl job", err)
+	}
+	return c.Status(http.StatusAccepted).JSON(models.APIResponse{Message: "crawl job re-enqueued", Data: letter.Job})
+}
+
+// DELETE_CrawlDeadLetters drops every dead-lettered crawl job.
+func (h *QueueHandler) DELETE_CrawlDeadLetters(c *fiber.Ctx) error {
+	if h.Service == nil {
+		return ErrorResponse(c, "Queue unavailable", nil, http.StatusServiceUnavailable)
+	}
+	purged, err := h.Service.PurgeCrawlDeadLetters(c.Context())
+	if err != nil {
+		return ErrorResponse(c, "Failed to purge dead-lettered crawl jobs", err)
+	}
+	return c.JSON(models.APIResponse{Message: "ok", Data: fiber.Map{"purged": purged}})
+}
@@ -1,30 +1,35 @@
 package api
 
 import (
+	"context"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	apperrors "github.com/yourusername/vectorchat/internal/errors"
 	"github.com/yourusername/vectorchat/internal/middleware"
 	"github.com/yourusername/vectorchat/internal/services"
+	"github.com/yourusername/vectorchat/pkg/constants"
 	"github.com/yourusername/vectorchat/pkg/models"
 )
 
 type SharedKnowledgeBaseHandler struct {
-	AuthMiddleware *middleware.AuthMiddleware
-	OrgMiddleware  *middleware.OrganizationMiddleware
-	Service        *services.SharedKnowledgeBaseService
-	Schedule       *services.CrawlScheduleService
+	AuthMiddleware     *middleware.AuthMiddleware
+	OrgMiddleware      *middleware.OrganizationMiddleware
+	SubscriptionLimits *middleware.SubscriptionLimitsMiddleware
+	Service            *services.SharedKnowledgeBaseService
+	Schedule           *services.CrawlScheduleService
 }
 
-func NewSharedKnowledgeBaseHandler(auth *middleware.AuthMiddleware, org *middleware.OrganizationMiddleware, service *services.SharedKnowledgeBaseService, schedule *services.CrawlScheduleService) *SharedKnowledgeBaseHandler {
+func NewSharedKnowledgeBaseHandler(auth *middleware.AuthMiddleware, org *middleware.OrganizationMiddleware, limits *middleware.SubscriptionLimitsMiddleware, service *services.SharedKnowledgeBaseService, schedule *services.CrawlScheduleService) *SharedKnowledgeBaseHandler {
 	return &SharedKnowledgeBaseHandler{
-		AuthMiddleware: auth,
-		OrgMiddleware:  org,
-		Service:        service,
-		Schedule:       schedule,
+		AuthMiddleware:     auth,
+		OrgMiddleware:      org,
+		SubscriptionLimits: limits,
+		Service:            service,
+		Schedule:           schedule,
 	}
 }
 
@@ -39,15 +44,20 @@ func (h *SharedKnowledgeBaseHandler) RegisterRoutes(app *fiber.App) {
 
 	group.Post("/:id/upload", h.POST_UploadFile)
 	group.Post("/:id/text", h.POST_UploadText)
-	group.Post("/:id/website", h.POST_UploadWebsite)
+	group.Post("/:id/website", h.SubscriptionLimits.CheckLimit(constants.LimitCrawlPages), h.POST_UploadWebsite)
+	group.Get("/:id/ingestion-jobs/:jobID", h.GET_IngestionJob)
+	group.Get("/:id/ingestion-jobs/:jobID/events", h.GET_IngestionJobEvents)
 	group.Get("/:id/files", h.GET_Files)
 	group.Delete("/:id/files/:filename", h.DELETE_File)
 	group.Get("/:id/text", h.GET_TextSources)
 	group.Delete("/:id/text/:sourceId", h.DELETE_TextSource)
+	group.Get("/:id/websites/:sourceId/pages", h.GET_WebsitePages)
+	group.Delete("/:id/websites/:sourceId/pages/:pageId", h.DELETE_WebsitePage)
 	group.Get("/:id/crawl-schedules", h.GET_CrawlSchedules)
-	group.Put("/:id/crawl-schedules", h.PUT_CrawlSchedule)
+	group.Put("/:id/crawl-schedules", h.SubscriptionLimits.CheckLimit(constants.LimitCrawlPages), h.PUT_CrawlSchedule)
 	group.Delete("/:id/crawl-schedules/:scheduleID", h.DELETE_CrawlSchedule)
-	group.Post("/:id/crawl-now", h.POST_CrawlNow)
+	group.Get("/:id/crawl-schedules/:scheduleID/runs", h.GET_CrawlRuns)
+	group.Post("/:id/crawl-now", h.SubscriptionLimits.CheckLimit(constants.LimitCrawlPages), h.POST_CrawlNow)
 }
 
 // @Summary List shared knowledge bases
@@ -237,7 +247,7 @@ func (h *SharedKnowledgeBaseHandler) DELETE_KnowledgeBase(c *fiber.Ctx) error {
 // @Produce json
 // @Param id path string true "Knowledge base ID (UUID)"
 // @Param file formData file true "File to upload"
-// @Success 200 {object} models.SharedKnowledgeBaseFileUploadResponse
+// @Success 202 {object} models.IngestionJobResponse
 // @Failure 400 {object} models.APIResponse
 // @Failure 401 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
@@ -268,7 +278,7 @@ func (h *SharedKnowledgeBaseHandler) POST_UploadFile(c *fiber.Ctx) error {
 		return ErrorResponse(c, "Failed to upload file", err, status)
 	}
 
-	return c.JSON(resp)
+	return c.Status(http.StatusAccepted).JSON(resp)
 }
 
 // @Summary Upload text to shared knowledge base
@@ -278,7 +288,7 @@ func (h *SharedKnowledgeBaseHandler) POST_UploadFile(c *fiber.Ctx) error {
 // @Produce json
 // @Param id path string true "Knowledge base ID (UUID)"
 // @Param body body models.TextUploadRequest true "Text payload"
-// @Success 200 {object} models.MessageResponse
+// @Success 202 {object} models.IngestionJobResponse
 // @Failure 400 {object} models.APIResponse
 // @Failure 401 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
@@ -300,7 +310,8 @@ func (h *SharedKnowledgeBaseHandler) POST_UploadText(c *fiber.Ctx) error {
 		return ErrorResponse(c, "Invalid request body", err, http.StatusBadRequest)
 	}
 
-	if err := h.Service.ProcessTextUpload(c.Context(), user.ID, GetOrgContext(c), kbID, req.Text); err != nil {
+	resp, err := h.Service.ProcessTextUpload(c.Context(), user.ID, GetOrgContext(c), kbID, req.Text)
+	if err != nil {
 		status := http.StatusInternalServerError
 		if apperrors.Is(err, apperrors.ErrInvalidChatbotParameters) {
 			status = http.StatusBadRequest
@@ -308,17 +319,17 @@ func (h *SharedKnowledgeBaseHandler) POST_UploadText(c *fiber.Ctx) error {
 		return ErrorResponse(c, "Failed to add text", err, status)
 	}
 
-	return c.JSON(models.MessageResponse{Message: "Text processed successfully"})
+	return c.Status(http.StatusAccepted).JSON(resp)
 }
 
 // @Summary Upload website to shared knowledge base
-// @Description Crawl and index a website into the shared knowledge base starting from the provided URL
+// @Description Queue a crawl of the website starting from the provided URL and index it into the shared knowledge base
 // @Tags sharedKnowledgeBase
 // @Accept json
 // @Produce json
 // @Param id path string true "Knowledge base ID (UUID)"
 // @Param body body models.WebsiteUploadRequest true "Website payload"
-// @Success 200 {object} models.MessageResponse
+// @Success 202 {object} models.IngestionJobResponse
 // @Failure 400 {object} models.APIResponse
 // @Failure 401 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
@@ -340,7 +351,8 @@ func (h *SharedKnowledgeBaseHandler) POST_UploadWebsite(c *fiber.Ctx) error {
 		return ErrorResponse(c, "Invalid request body", err, http.StatusBadRequest)
 	}
 
-	if err := h.Service.ProcessWebsiteUpload(c.Context(), user.ID, GetOrgContext(c), kbID, req.URL); err != nil {
+	resp, err := h.Service.ProcessWebsiteUpload(c.Context(), user.ID, GetOrgContext(c), kbID, req.URL, req.Options, req.Auth)
+	if err != nil {
 		status := http.StatusInternalServerError
 		if apperrors.Is(err, apperrors.ErrInvalidChatbotParameters) {
 			status = http.StatusBadRequest
@@ -348,7 +360,91 @@ func (h *SharedKnowledgeBaseHandler) POST_UploadWebsite(c *fiber.Ctx) error {
 		return ErrorResponse(c, "Failed to index website", err, status)
 	}
 
-	return c.JSON(models.MessageResponse{Message: "Website indexed successfully"})
+	return c.Status(http.StatusAccepted).JSON(resp)
+}
+
+// @Summary Get shared knowledge base ingestion job
+// @Description Poll the progress of a file, text or website upload into the shared knowledge base
+// @Tags sharedKnowledgeBase
+// @Accept json
+// @Produce json
+// @Param id path string true "Knowledge base ID (UUID)"
+// @Param jobID path string true "Ingestion job ID (UUID)"
+// @Success 200 {object} models.IngestionJobResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /knowledge-bases/{id}/ingestion-jobs/{jobID} [get]
+func (h *SharedKnowledgeBaseHandler) GET_IngestionJob(c *fiber.Ctx) error {
+	user, err := GetUser(c)
+	if err != nil {
+		return err
+	}
+	kbID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		return ErrorResponse(c, "Invalid knowledge base id", err, http.StatusBadRequest)
+	}
+	jobID, err := parseUUIDParam(c, "jobID")
+	if err != nil {
+		return ErrorResponse(c, "Invalid ingestion job id", err, http.StatusBadRequest)
+	}
+
+	resp, err := h.Service.GetIngestionJob(c.Context(), user.ID, GetOrgContext(c), kbID, jobID)
+	if err != nil {
+		return sharedIngestionJobError(c, err)
+	}
+	return c.JSON(resp)
+}
+
+// @Summary Stream shared knowledge base ingestion job progress
+// @Description Server-sent events with the job state whenever it changes; the stream ends once the job is done or failed
+// @Tags sharedKnowledgeBase
+// @Produce text/event-stream
+// @Param id path string true "Knowledge base ID (UUID)"
+// @Param jobID path string true "Ingestion job ID (UUID)"
+// @Success 200 {string} string "Progress events"
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /knowledge-bases/{id}/ingestion-jobs/{jobID}/events [get]
+func (h *SharedKnowledgeBaseHandler) GET_IngestionJobEvents(c *fiber.Ctx) error {
+	user, err := GetUser(c)
+	if err != nil {
+		return err
+	}
+	kbID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		return ErrorResponse(c, "Invalid knowledge base id", err, http.StatusBadRequest)
+	}
+	jobID, err := parseUUIDParam(c, "jobID")
+	if err != nil {
+		return ErrorResponse(c, "Invalid ingestion job id", err, http.StatusBadRequest)
+	}
+
+	orgCtx := GetOrgContext(c)
+	load := func(ctx context.Context) (*models.IngestionJobResponse, error) {
+		return h.Service.GetIngestionJob(ctx, user.ID, orgCtx, kbID, jobID)
+	}
+	if _, err := load(c.Context()); err != nil {
+		return sharedIngestionJobError(c, err)
+	}
+	return streamIngestionJob(c, load)
+}
+
+func sharedIngestionJobError(c *fiber.Ctx, err error) error {
+	status := http.StatusInternalServerError
+	if apperrors.Is(err, apperrors.ErrSharedKnowledgeBaseNotFound) || apperrors.Is(err, apperrors.ErrNotFound) {
+		status = http.StatusNotFound
+	} else if apperrors.Is(err, apperrors.ErrUnauthorizedKnowledgeBaseAccess) {
+		status = http.StatusForbidden
+	}
+	return ErrorResponse(c, "Failed to load ingestion job", err, status)
 }
 
 // @Summary List files in shared knowledge base
@@ -509,6 +605,82 @@ func (h *SharedKnowledgeBaseHandler) DELETE_TextSource(c *fiber.Ctx) error {
 	return c.JSON(models.MessageResponse{Message: "Text source deleted successfully"})
 }
 
+// @Summary List website pages in shared knowledge base
+// @Description List the crawled pages of a website source in the shared knowledge base
+// @Tags sharedKnowledgeBase
+// @Accept json
+// @Produce json
+// @Param id path string true "Knowledge base ID (UUID)"
+// @Param sourceId path string true "Website source ID (UUID)"
+// @Success 200 {object} models.WebsitePagesResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /knowledge-bases/{id}/websites/{sourceId}/pages [get]
+func (h *SharedKnowledgeBaseHandler) GET_WebsitePages(c *fiber.Ctx) error {
+	user, err := GetUser(c)
+	if err != nil {
+		return err
+	}
+	kbID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		return ErrorResponse(c, "Invalid knowledge base id", err, http.StatusBadRequest)
+	}
+	sourceID, err := parseUUIDParam(c, "sourceId")
+	if err != nil {
+		return ErrorResponse(c, "Invalid website source id", err, http.StatusBadRequest)
+	}
+
+	resp, err := h.Service.ListWebsitePages(c.Context(), user.ID, GetOrgContext(c), kbID, sourceID)
+	if err != nil {
+		return ErrorResponse(c, "Failed to list website pages", err, websitePageErrorStatus(err))
+	}
+	return c.JSON(resp)
+}
+
+// @Summary Delete website page from shared knowledge base
+// @Description Delete one crawled page and its chunks from a website source in the shared knowledge base
+// @Tags sharedKnowledgeBase
+// @Accept json
+// @Produce json
+// @Param id path string true "Knowledge base ID (UUID)"
+// @Param sourceId path string true "Website source ID (UUID)"
+// @Param pageId path string true "Page ID (UUID)"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /knowledge-bases/{id}/websites/{sourceId}/pages/{pageId} [delete]
+func (h *SharedKnowledgeBaseHandler) DELETE_WebsitePage(c *fiber.Ctx) error {
+	user, err := GetUser(c)
+	if err != nil {
+		return err
+	}
+	kbID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		return ErrorResponse(c, "Invalid knowledge base id", err, http.StatusBadRequest)
+	}
+	sourceID, err := parseUUIDParam(c, "sourceId")
+	if err != nil {
+		return ErrorResponse(c, "Invalid website source id", err, http.StatusBadRequest)
+	}
+	pageID, err := parseUUIDParam(c, "pageId")
+	if err != nil {
+		return ErrorResponse(c, "Invalid page id", err, http.StatusBadRequest)
+	}
+
+	if err := h.Service.DeleteWebsitePage(c.Context(), user.ID, GetOrgContext(c), kbID, sourceID, pageID); err != nil {
+		return ErrorResponse(c, "Failed to delete website page", err, websitePageErrorStatus(err))
+	}
+	return c.JSON(models.MessageResponse{Message: "Website page deleted successfully"})
+}
+
 // @Summary List crawl schedules
 // @Description List crawl schedules for a shared knowledge base
 // @Tags sharedKnowledgeBase
@@ -653,6 +825,59 @@ func (h *SharedKnowledgeBaseHandler) DELETE_CrawlSchedule(c *fiber.Ctx) error {
 	return c.SendStatus(http.StatusNoContent)
 }
 
+// @Summary List crawl runs
+// @Description List past and running crawls of a schedule's URL, newest first, including manual crawls of the URL.
+// @Tags sharedKnowledgeBase
+// @Accept json
+// @Produce json
+// @Param id path string true "Knowledge base ID (UUID)"
+// @Param scheduleID path string true "Schedule ID (UUID)"
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param before query string false "next_before of the previous page (RFC 3339)"
+// @Success 200 {object} models.CrawlRunListResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /knowledge-bases/{id}/crawl-schedules/{scheduleID}/runs [get]
+func (h *SharedKnowledgeBaseHandler) GET_CrawlRuns(c *fiber.Ctx) error {
+	user, err := GetUser(c)
+	if err != nil {
+		return err
+	}
+	kbID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		return ErrorResponse(c, "Invalid knowledge base id", err, http.StatusBadRequest)
+	}
+	scheduleID, err := parseUUIDParam(c, "scheduleID")
+	if err != nil {
+		return ErrorResponse(c, "Invalid schedule id", err, http.StatusBadRequest)
+	}
+	before, err := parseBeforeQuery(c)
+	if err != nil {
+		return ErrorResponse(c, "Invalid before timestamp", err, http.StatusBadRequest)
+	}
+
+	// ensure ownership
+	orgCtx := GetOrgContext(c)
+	if _, err := h.Service.Get(c.Context(), user.ID, orgCtx, kbID); err != nil {
+		status := http.StatusInternalServerError
+		if apperrors.Is(err, apperrors.ErrSharedKnowledgeBaseNotFound) {
+			status = http.StatusNotFound
+		} else if apperrors.Is(err, apperrors.ErrUnauthorizedKnowledgeBaseAccess) {
+			status = http.StatusForbidden
+		}
+		return ErrorResponse(c, "Failed to fetch knowledge base", err, status)
+	}
+
+	resp, err := h.Schedule.ListRuns(c.Context(), services.KnowledgeBaseTarget{SharedKnowledgeBaseID: &kbID}, scheduleID, before, c.QueryInt("limit", 20))
+	if err != nil {
+		return ErrorResponse(c, "Failed to list crawl runs", err, crawlRunsErrorStatus(err))
+	}
+	return c.JSON(resp)
+}
+
 // @Summary Crawl once now
 // @Description Enqueue a single crawl job for this shared knowledge base.
 // @Tags sharedKnowledgeBase
@@ -660,9 +885,10 @@ func (h *SharedKnowledgeBaseHandler) DELETE_CrawlSchedule(c *fiber.Ctx) error {
 // @Produce json
 // @Param id path string true "Knowledge base ID (UUID)"
 // @Param body body models.WebsiteUploadRequest true "Website URL"
-// @Success 202 {object} models.MessageResponse
+// @Success 202 {object} models.CrawlNowResponse
 // @Failure 400 {object} models.APIResponse
 // @Failure 401 {object} models.APIResponse
+// @Failure 409 {object} models.CrawlNowResponse "A crawl of the URL is already queued or running"
 // @Failure 500 {object} models.APIResponse
 // @Security BearerAuth
 // @Router /knowledge-bases/{id}/crawl-now [post]
@@ -691,14 +917,45 @@ func (h *SharedKnowledgeBaseHandler) POST_CrawlNow(c *fiber.Ctx) error {
 		return ErrorResponse(c, "Invalid request body", err, http.StatusBadRequest)
 	}
 
-	if err := h.Schedule.EnqueueOnce(c.Context(), services.KnowledgeBaseTarget{SharedKnowledgeBaseID: &kbID}, req.URL); err != nil {
+	resp, err := h.Schedule.EnqueueOnce(c.Context(), services.KnowledgeBaseTarget{SharedKnowledgeBaseID: &kbID}, req.URL, req.Options, req.Auth)
+	if err != nil {
 		status := http.StatusInternalServerError
 		if apperrors.Is(err, apperrors.ErrInvalidChatbotParameters) {
 			status = http.StatusBadRequest
 		}
 		return ErrorResponse(c, "Failed to enqueue crawl", err, status)
 	}
-	return c.Status(http.StatusAccepted).JSON(models.MessageResponse{Message: "Crawl enqueued"})
+	if resp.Status == models.CrawlAlreadyRunning {
+		return c.Status(http.StatusConflict).JSON(resp)
+	}
+	return c.Status(http.StatusAccepted).JSON(resp)
+}
+
+// parseBeforeQuery reads the optional ?before= cursor of a listing newest first.
+func parseBeforeQuery(c *fiber.Ctx) (*time.Time, error) {
+	raw := c.Query("before")
+	if raw == "" {
+		return nil, nil
+	}
+	before, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return nil, err
+	}
+	return &before, nil
+}
+
+// crawlRunsErrorStatus maps errors of CrawlScheduleService.ListRuns to an HTTP status.
+func crawlRunsErrorStatus(err error) int {
+	switch {
+	case apperrors.Is(err, apperrors.ErrInvalidChatbotParameters):
+		return http.StatusBadRequest
+	case apperrors.Is(err, apperrors.ErrNotFound):
+		return http.StatusNotFound
+	case apperrors.Is(err, apperrors.ErrUnauthorizedKnowledgeBaseAccess):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 func parseUUIDParam(c *fiber.Ctx, key string) (uuid.UUID, error) {
@@ -0,0 +1,104 @@
+// Package bootstrap builds the services the API and the standalone workers share from the app config,
+// so every process crawls and embeds the same way.
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/yourusername/vectorchat/internal/crawler"
+	"github.com/yourusername/vectorchat/internal/db"
+	"github.com/yourusername/vectorchat/internal/services"
+	"github.com/yourusername/vectorchat/internal/vectorize"
+	"github.com/yourusername/vectorchat/pkg/config"
+	"github.com/yourusername/vectorchat/pkg/constants"
+	"github.com/yourusername/vectorchat/pkg/docprocessor"
+)
+
+// EmbeddingConfig reads the embedding backend from the EMBEDDING_* settings
+func EmbeddingConfig(cfg *config.AppConfig) (vectorize.Config, error) {
+	embeddingAPIKey := cfg.EmbeddingAPIKey
+	if embeddingAPIKey == "" {
+		embeddingAPIKey = cfg.OpenAIKey
+	}
+	if embeddingAPIKey == "" && cfg.EmbeddingBackend == constants.EmbeddingBackendOpenAI && strings.Contains(cfg.EmbeddingBaseURL, "api.openai.com") {
+		return vectorize.Config{}, fmt.Errorf("OPENAI_API_KEY or EMBEDDING_API_KEY environment variable is required for OpenAI embeddings")
+	}
+	return vectorize.Config{
+		Backend:    cfg.EmbeddingBackend,
+		BaseURL:    cfg.EmbeddingBaseURL,
+		APIKey:     embeddingAPIKey,
+		Model:      cfg.EmbeddingModel,
+		Dimensions: cfg.EmbeddingDims,
+	}, nil
+}
+
+// NewVectorizer builds the embedding client of EMBEDDING_MODEL
+func NewVectorizer(cfg *config.AppConfig) (*vectorize.EmbeddingVectorizer, error) {
+	embedding, err := EmbeddingConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	vectorizer, err := vectorize.NewVectorizer(embedding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure embeddings: %w", err)
+	}
+	return vectorizer, nil
+}
+
+// NewVectorizers serves EMBEDDING_MODEL and EMBEDDING_ADDITIONAL_MODELS
+func NewVectorizers(cfg *config.AppConfig) (*vectorize.Registry, error) {
+	embedding, err := EmbeddingConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	vectorizers, err := vectorize.NewRegistry(embedding, cfg.EmbeddingAdditionalModels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure embeddings: %w", err)
+	}
+	return vectorizers, nil
+}
+
+// NewKnowledgeBaseService builds the crawl and embedding pipeline. It fails when EMBEDDING_DIMENSIONS
+// disagrees with the vector columns new embeddings are written to.
+func NewKnowledgeBaseService(ctx context.Context, cfg *config.AppConfig, repos *db.Repositories, database *db.Database, vectorizers *vectorize.Registry, crawlLimits *services.CrawlLimitService, logger *slog.Logger) (*services.KnowledgeBaseService, error) {
+	// Stored vectors and new embeddings must agree on the dimension
+	if columns, err := repos.EmbeddingColumns(ctx); err != nil {
+		logger.Warn("failed to read embedding column dimensions", "error", err)
+	} else {
+		for _, col := range columns {
+			if col.Dimensions > 0 && col.Dimensions != cfg.EmbeddingDims {
+				return nil, fmt.Errorf("EMBEDDING_DIMENSIONS is %d but %s is vector(%d)", cfg.EmbeddingDims, col.Name, col.Dimensions)
+			}
+		}
+	}
+
+	// crawl4ai is optional; the built-in crawlers take over without it
+	webCrawler, err := crawler.NewAPIClient(cfg.CrawlerAPIURL, nil)
+	if err != nil {
+		logger.Warn("failed to initialize crawl4ai client; falling back to built-in crawler", "error", err)
+	}
+	crawlSettings := services.CrawlSettings{
+		UserAgent:     cfg.CrawlerUserAgent,
+		Delay:         cfg.CrawlerDelay,
+		ProbeInterval: cfg.CrawlerProbeInterval,
+	}
+	if cfg.CrawlerBrowserPath != "" {
+		browser, err := crawler.NewChromeCrawler(cfg.CrawlerBrowserPath)
+		if err != nil {
+			logger.Warn("headless browser unavailable; fallback crawler will not render JavaScript", "error", err)
+		} else {
+			crawlSettings.Browser = browser
+		}
+	}
+
+	markitdownClient, err := docprocessor.NewMarkitdownClient(cfg.MarkitdownURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure markitdown client: %w", err)
+	}
+	processor := docprocessor.NewProcessor(markitdownClient)
+
+	return services.NewKnowledgeBaseService(repos.File, repos.Document, repos.Pages, repos.Embeddings, vectorizers, processor, webCrawler, crawlSettings, crawlLimits, database), nil
+}
@@ -0,0 +1,101 @@
+package crawler
+
+import (
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Auth carries credentials for sites behind a login. Crawlers only send them to the host of
+// the crawl's root URL, never to other hosts a page links or redirects to.
+type Auth struct {
+	Headers  map[string]string
+	Cookies  map[string]string
+	Username string
+	Password string
+}
+
+func (a *Auth) empty() bool {
+	return a == nil || (len(a.Headers) == 0 && len(a.Cookies) == 0 && a.Username == "" && a.Password == "")
+}
+
+// headers returns the custom headers plus an Authorization header for basic auth.
+// Cookies are left out because each backend installs them its own way.
+func (a *Auth) headers() map[string]string {
+	if a.empty() {
+		return nil
+	}
+	out := make(map[string]string, len(a.Headers)+1)
+	for name, value := range a.Headers {
+		out[http.CanonicalHeaderKey(name)] = value
+	}
+	if a.Username != "" || a.Password != "" {
+		out["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(a.Username+":"+a.Password))
+	}
+	return out
+}
+
+// cookieHeader renders the cookies as a Cookie request header value.
+func (a *Auth) cookieHeader() string {
+	if a == nil || len(a.Cookies) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(a.Cookies))
+	for name := range a.Cookies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, (&http.Cookie{Name: name, Value: a.Cookies[name]}).String())
+	}
+	return strings.Join(parts, "; ")
+}
+
+// apply adds the credentials to req.
+func (a *Auth) apply(req *http.Request) {
+	for name, value := range a.headers() {
+		req.Header.Set(name, value)
+	}
+	if cookie := a.cookieHeader(); cookie != "" {
+		req.Header.Set("Cookie", cookie)
+	}
+}
+
+// Redact replaces every credential value found in s, e.g. in an error echoed by a remote service.
+func (a *Auth) Redact(s string) string {
+	if a.empty() {
+		return s
+	}
+	var secrets []string
+	for _, v := range a.headers() {
+		secrets = append(secrets, v)
+	}
+	for _, v := range a.Cookies {
+		secrets = append(secrets, v)
+	}
+	secrets = append(secrets, a.Password)
+	// Longest first so a value containing another is replaced whole
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+	for _, v := range secrets {
+		if len(v) >= 4 {
+			s = strings.ReplaceAll(s, v, "[REDACTED]")
+		}
+	}
+	return s
+}
+
+// String keeps credentials out of formatted output.
+func (a *Auth) String() string {
+	if a.empty() {
+		return "none"
+	}
+	return "[REDACTED]"
+}
+
+// LogValue keeps credentials out of structured logs.
+func (a *Auth) LogValue() slog.Value {
+	return slog.StringValue(a.String())
+}
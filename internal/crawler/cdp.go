@@ -0,0 +1,150 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// maxCDPMessageBytes bounds a single DevTools message; rendered page markup is returned inline
+const maxCDPMessageBytes = 64 << 20
+
+// cdpOrigin is the Origin of our DevTools connections; Chrome only accepts websockets from it
+const cdpOrigin = "http://localhost"
+
+// cdpConn is a minimal Chrome DevTools protocol client over the browser websocket.
+// Page commands carry the flattened session ID returned by Target.attachToTarget.
+// Events go to onEvent, each in its own goroutine so handlers may issue commands.
+type cdpConn struct {
+	ws      *websocket.Conn
+	onEvent func(*cdpConn, cdpMessage)
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan cdpMessage
+	err     error
+	done    chan struct{}
+}
+
+type cdpRequest struct {
+	ID        int64  `json:"id"`
+	SessionID string `json:"sessionId,omitempty"`
+	Method    string `json:"method"`
+	Params    any    `json:"params,omitempty"`
+}
+
+// cdpMessage is a command response, or an event when ID is zero.
+type cdpMessage struct {
+	ID        int64           `json:"id"`
+	Result    json.RawMessage `json:"result"`
+	Error     *cdpError       `json:"error"`
+	SessionID string          `json:"sessionId"`
+	Method    string          `json:"method"`
+	Params    json.RawMessage `json:"params"`
+}
+
+type cdpError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *cdpError) Error() string {
+	return fmt.Sprintf("devtools error %d: %s", e.Code, e.Message)
+}
+
+func dialCDP(ctx context.Context, wsURL string, onEvent func(*cdpConn, cdpMessage)) (*cdpConn, error) {
+	cfg, err := websocket.NewConfig(wsURL, cdpOrigin)
+	if err != nil {
+		return nil, fmt.Errorf("crawler: invalid devtools URL %q: %w", wsURL, err)
+	}
+	ws, err := cfg.DialContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("crawler: failed to connect to devtools: %w", err)
+	}
+	ws.MaxPayloadBytes = maxCDPMessageBytes
+
+	c := &cdpConn{
+		ws:      ws,
+		onEvent: onEvent,
+		pending: make(map[int64]chan cdpMessage),
+		done:    make(chan struct{}),
+	}
+	go c.readLoop()
+	return c, nil
+}
+
+func (c *cdpConn) readLoop() {
+	defer close(c.done)
+	for {
+		var resp cdpMessage
+		if err := websocket.JSON.Receive(c.ws, &resp); err != nil {
+			c.mu.Lock()
+			c.err = err
+			c.mu.Unlock()
+			return
+		}
+		if resp.ID == 0 {
+			if resp.Method != "" && c.onEvent != nil {
+				go c.onEvent(c, resp)
+			}
+			continue
+		}
+		c.mu.Lock()
+		ch, ok := c.pending[resp.ID]
+		delete(c.pending, resp.ID)
+		c.mu.Unlock()
+		if ok {
+			ch <- resp
+		}
+	}
+}
+
+// call sends a command and decodes its result into result, which may be nil.
+func (c *cdpConn) call(ctx context.Context, sessionID, method string, params, result any) error {
+	c.mu.Lock()
+	if c.err != nil {
+		err := c.err
+		c.mu.Unlock()
+		return fmt.Errorf("crawler: devtools connection closed: %w", err)
+	}
+	c.nextID++
+	id := c.nextID
+	ch := make(chan cdpMessage, 1)
+	c.pending[id] = ch
+	// Sending under the lock keeps frames from concurrent callers apart
+	err := websocket.JSON.Send(c.ws, cdpRequest{ID: id, SessionID: sessionID, Method: method, Params: params})
+	if err != nil {
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return fmt.Errorf("crawler: failed to send %s: %w", method, err)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return ctx.Err()
+	case <-c.done:
+		return errors.New("crawler: devtools connection closed")
+	case resp := <-ch:
+		if resp.Error != nil {
+			return fmt.Errorf("crawler: %s: %w", method, resp.Error)
+		}
+		if result != nil && len(resp.Result) > 0 {
+			if err := json.Unmarshal(resp.Result, result); err != nil {
+				return fmt.Errorf("crawler: failed to decode %s result: %w", method, err)
+			}
+		}
+		return nil
+	}
+}
+
+func (c *cdpConn) close() error {
+	return c.ws.Close()
+}
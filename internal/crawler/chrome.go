@@ -0,0 +1,363 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	// chromeStartTimeout bounds how long the browser may take to open its DevTools endpoint
+	chromeStartTimeout = 20 * time.Second
+	// defaultRenderSettle is how long scripts get to render after the load event
+	defaultRenderSettle = 500 * time.Millisecond
+)
+
+// ChromeCrawler renders pages in a local headless Chrome driven over the DevTools protocol,
+// so sites that build their content with JavaScript are indexed as a visitor sees them.
+// Link discovery, sitemaps, robots.txt and politeness delays work as in CrawlWebsite.
+type ChromeCrawler struct {
+	binary string
+	settle time.Duration
+}
+
+// NewChromeCrawler resolves the Chrome or Chromium binary, given as a path or a name on PATH.
+func NewChromeCrawler(binary string) (*ChromeCrawler, error) {
+	path, err := exec.LookPath(strings.TrimSpace(binary))
+	if err != nil {
+		return nil, fmt.Errorf("crawler: browser binary %q not found: %w", binary, err)
+	}
+	return &ChromeCrawler{binary: path, settle: defaultRenderSettle}, nil
+}
+
+// Crawl starts a browser for the duration of the crawl and loads every page in one tab.
+// Rendered pages are always loaded in full, so opts.Known is not used.
+func (c *ChromeCrawler) Crawl(ctx context.Context, root string, opts Options) (*Result, error) {
+	if c == nil {
+		return nil, errors.New("crawler: chrome crawler is nil")
+	}
+	opts = opts.withDefaults()
+	rootURL, err := url.Parse(root)
+	if err != nil || rootURL.Scheme == "" || rootURL.Host == "" {
+		return nil, ErrInvalidRootURL
+	}
+	f := newFetcher(&http.Client{Timeout: opts.Timeout}, opts.UserAgent, opts.Delay)
+
+	b, err := c.launch(ctx, f.userAgent, rootURL, opts.Auth)
+	if err != nil {
+		return nil, err
+	}
+	defer b.close()
+
+	return crawlSite(ctx, root, opts, f, func(ctx context.Context, u *url.URL, _ *Validators) (*html.Node, http.Header, error) {
+		if err := f.wait(ctx, u.Host); err != nil {
+			return nil, nil, err
+		}
+		loadCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+		return b.render(loadCtx, u, c.settle)
+	})
+}
+
+// chromeBrowser is a running headless browser with one attached page session.
+type chromeBrowser struct {
+	cmd     *exec.Cmd
+	dataDir string
+	conn    *cdpConn
+	session string
+}
+
+// launch starts the browser and attaches to a blank page. Credentials in auth are installed
+// for rootURL's host only: cookies through the cookie store, headers by intercepting requests.
+func (c *ChromeCrawler) launch(ctx context.Context, userAgent string, rootURL *url.URL, auth *Auth) (*chromeBrowser, error) {
+	dataDir, err := os.MkdirTemp("", "vectorchat-chrome-")
+	if err != nil {
+		return nil, fmt.Errorf("crawler: failed to create browser profile: %w", err)
+	}
+
+	args := []string{
+		"--headless=new",
+		"--disable-gpu",
+		"--disable-extensions",
+		"--no-first-run",
+		"--no-default-browser-check",
+		"--mute-audio",
+		"--remote-debugging-port=0",
+		"--remote-allow-origins=" + cdpOrigin,
+		"--user-data-dir=" + dataDir,
+		"--user-agent=" + userAgent,
+	}
+	// Chrome refuses to start its sandbox as root, which is the norm in containers
+	if os.Geteuid() == 0 {
+		args = append(args, "--no-sandbox")
+	}
+	args = append(args, "about:blank")
+
+	b := &chromeBrowser{cmd: exec.Command(c.binary, args...), dataDir: dataDir}
+	stderr, err := b.cmd.StderrPipe()
+	if err != nil {
+		b.close()
+		return nil, fmt.Errorf("crawler: failed to start browser: %w", err)
+	}
+	if err := b.cmd.Start(); err != nil {
+		b.close()
+		return nil, fmt.Errorf("crawler: failed to start browser: %w", err)
+	}
+
+	startCtx, cancel := context.WithTimeout(ctx, chromeStartTimeout)
+	defer cancel()
+
+	wsURL, err := waitForDevToolsURL(startCtx, stderr)
+	if err != nil {
+		b.close()
+		return nil, err
+	}
+	var onEvent func(*cdpConn, cdpMessage)
+	if headers := auth.headers(); len(headers) > 0 {
+		onEvent = continueWithHeaders(headers)
+	}
+	if b.conn, err = dialCDP(startCtx, wsURL, onEvent); err != nil {
+		b.close()
+		return nil, err
+	}
+
+	var target struct {
+		TargetID string `json:"targetId"`
+	}
+	if err := b.conn.call(startCtx, "", "Target.createTarget", map[string]any{"url": "about:blank"}, &target); err != nil {
+		b.close()
+		return nil, err
+	}
+	var attached struct {
+		SessionID string `json:"sessionId"`
+	}
+	if err := b.conn.call(startCtx, "", "Target.attachToTarget", map[string]any{"targetId": target.TargetID, "flatten": true}, &attached); err != nil {
+		b.close()
+		return nil, err
+	}
+	b.session = attached.SessionID
+
+	if err := b.authorize(startCtx, rootURL, auth); err != nil {
+		b.close()
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *chromeBrowser) authorize(ctx context.Context, rootURL *url.URL, auth *Auth) error {
+	if auth.empty() {
+		return nil
+	}
+	if len(auth.Cookies) > 0 {
+		origin := rootURL.Scheme + "://" + rootURL.Host + "/"
+		cookies := make([]map[string]any, 0, len(auth.Cookies))
+		for name, value := range auth.Cookies {
+			cookies = append(cookies, map[string]any{"name": name, "value": value, "url": origin})
+		}
+		if err := b.conn.call(ctx, b.session, "Network.setCookies", map[string]any{"cookies": cookies}, nil); err != nil {
+			return err
+		}
+	}
+	if len(auth.headers()) > 0 {
+		// Spelled out per scheme: a leading wildcard would also match URLs that merely contain the host
+		patterns := []map[string]any{
+			{"urlPattern": "http://" + rootURL.Host + "/*", "requestStage": "Request"},
+			{"urlPattern": "https://" + rootURL.Host + "/*", "requestStage": "Request"},
+		}
+		if err := b.conn.call(ctx, b.session, "Fetch.enable", map[string]any{"patterns": patterns}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// continueWithHeaders resumes requests paused by Fetch.enable with headers added.
+func continueWithHeaders(headers map[string]string) func(*cdpConn, cdpMessage) {
+	return func(c *cdpConn, ev cdpMessage) {
+		if ev.Method != "Fetch.requestPaused" {
+			return
+		}
+		var paused struct {
+			RequestID string `json:"requestId"`
+			Request   struct {
+				Headers map[string]string `json:"headers"`
+			} `json:"request"`
+		}
+		if err := json.Unmarshal(ev.Params, &paused); err != nil {
+			return
+		}
+		merged := make(map[string]string, len(paused.Request.Headers)+len(headers))
+		for name, value := range paused.Request.Headers {
+			merged[http.CanonicalHeaderKey(name)] = value
+		}
+		for name, value := range headers {
+			merged[name] = value
+		}
+		entries := make([]map[string]string, 0, len(merged))
+		for name, value := range merged {
+			entries = append(entries, map[string]string{"name": name, "value": value})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = c.call(ctx, ev.SessionID, "Fetch.continueRequest", map[string]any{"requestId": paused.RequestID, "headers": entries}, nil)
+	}
+}
+
+// waitForDevToolsURL reads the browser websocket endpoint Chrome prints on stderr.
+// The rest of stderr is drained in the background so the browser never blocks on it.
+func waitForDevToolsURL(ctx context.Context, stderr io.Reader) (string, error) {
+	found := make(chan string, 1)
+	go func() {
+		defer close(found)
+		scanner := bufio.NewScanner(stderr)
+		sent := false
+		for scanner.Scan() {
+			if sent {
+				continue
+			}
+			if wsURL, ok := parseDevToolsLine(scanner.Text()); ok {
+				found <- wsURL
+				sent = true
+			}
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return "", fmt.Errorf("crawler: browser did not open devtools: %w", ctx.Err())
+	case wsURL, ok := <-found:
+		if !ok {
+			return "", errors.New("crawler: browser exited before opening devtools")
+		}
+		return wsURL, nil
+	}
+}
+
+func parseDevToolsLine(line string) (string, bool) {
+	const prefix = "DevTools listening on "
+	i := strings.Index(line, prefix)
+	if i < 0 {
+		return "", false
+	}
+	wsURL := strings.TrimSpace(line[i+len(prefix):])
+	return wsURL, strings.HasPrefix(wsURL, "ws://")
+}
+
+// render navigates the page to u and returns the DOM once the page has loaded and settled.
+// Response headers are not available through the page, so validators are left empty.
+func (b *chromeBrowser) render(ctx context.Context, u *url.URL, settle time.Duration) (*html.Node, http.Header, error) {
+	var nav struct {
+		ErrorText string `json:"errorText"`
+	}
+	if err := b.conn.call(ctx, b.session, "Page.navigate", map[string]any{"url": u.String()}, &nav); err != nil {
+		return nil, nil, err
+	}
+	if nav.ErrorText != "" {
+		return nil, nil, fmt.Errorf("%s: %s", u, nav.ErrorText)
+	}
+
+	for {
+		state, err := b.evaluate(ctx, "document.readyState")
+		if err == nil && state == "complete" {
+			break
+		}
+		if err := sleepContext(ctx, 100*time.Millisecond); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := sleepContext(ctx, settle); err != nil {
+		return nil, nil, err
+	}
+
+	raw, err := b.evaluate(ctx, `JSON.stringify({
+		status: (performance.getEntriesByType("navigation")[0] || {}).responseStatus || 0,
+		contentType: document.contentType
+	})`)
+	if err != nil {
+		return nil, nil, err
+	}
+	var info struct {
+		Status      int    `json:"status"`
+		ContentType string `json:"contentType"`
+	}
+	if err := json.Unmarshal([]byte(raw), &info); err != nil {
+		return nil, nil, fmt.Errorf("crawler: failed to read page info: %w", err)
+	}
+	if info.Status >= http.StatusBadRequest {
+		return nil, nil, fmt.Errorf("%s returned status %d", u, info.Status)
+	}
+	if !strings.Contains(strings.ToLower(info.ContentType), "html") {
+		return nil, nil, nil
+	}
+
+	markup, err := b.evaluate(ctx, "document.documentElement.outerHTML")
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, err := html.Parse(strings.NewReader(markup))
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, http.Header{}, nil
+}
+
+// evaluate runs a script expression in the page and returns its string value.
+func (b *chromeBrowser) evaluate(ctx context.Context, expression string) (string, error) {
+	var res struct {
+		Result struct {
+			Value json.RawMessage `json:"value"`
+		} `json:"result"`
+		ExceptionDetails *struct {
+			Text string `json:"text"`
+		} `json:"exceptionDetails"`
+	}
+	params := map[string]any{"expression": expression, "returnByValue": true}
+	if err := b.conn.call(ctx, b.session, "Runtime.evaluate", params, &res); err != nil {
+		return "", err
+	}
+	if res.ExceptionDetails != nil {
+		return "", fmt.Errorf("crawler: script failed: %s", res.ExceptionDetails.Text)
+	}
+	var value string
+	if err := json.Unmarshal(res.Result.Value, &value); err != nil {
+		return "", fmt.Errorf("crawler: script returned a non-string value: %w", err)
+	}
+	return value, nil
+}
+
+func (b *chromeBrowser) close() {
+	if b.conn != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		_ = b.conn.call(ctx, "", "Browser.close", nil, nil)
+		cancel()
+		_ = b.conn.close()
+	}
+	if b.cmd.Process != nil {
+		_ = b.cmd.Process.Kill()
+		_ = b.cmd.Wait()
+	}
+	_ = os.RemoveAll(b.dataDir)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
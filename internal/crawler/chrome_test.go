@@ -0,0 +1,83 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestParseDevToolsLine(t *testing.T) {
+	line := "\nDevTools listening on ws://127.0.0.1:40123/devtools/browser/5f1c"
+	got, ok := parseDevToolsLine(line)
+	if !ok || got != "ws://127.0.0.1:40123/devtools/browser/5f1c" {
+		t.Fatalf("unexpected devtools URL %q (ok=%v)", got, ok)
+	}
+	if _, ok := parseDevToolsLine("[0101/000000.000:WARNING] something else"); ok {
+		t.Fatal("expected unrelated stderr line to be ignored")
+	}
+}
+
+// fakeDevTools answers the commands render issues and interleaves an event to check it is skipped.
+func fakeDevTools(t *testing.T, markup string) *httptest.Server {
+	return httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		for {
+			var req cdpRequest
+			if err := websocket.JSON.Receive(ws, &req); err != nil {
+				return
+			}
+			var result any
+			switch req.Method {
+			case "Page.navigate":
+				websocket.JSON.Send(ws, map[string]any{"method": "Page.frameStartedLoading", "sessionId": req.SessionID})
+				result = map[string]any{"frameId": "F1"}
+			case "Runtime.evaluate":
+				params, _ := json.Marshal(req.Params)
+				var value string
+				switch expr := string(params); {
+				case strings.Contains(expr, "readyState"):
+					value = "complete"
+				case strings.Contains(expr, "contentType"):
+					value = `{"status":200,"contentType":"text/html"}`
+				default:
+					value = markup
+				}
+				result = map[string]any{"result": map[string]any{"type": "string", "value": value}}
+			default:
+				t.Errorf("unexpected devtools method %s", req.Method)
+			}
+			websocket.JSON.Send(ws, map[string]any{"id": req.ID, "sessionId": req.SessionID, "result": result})
+		}
+	}))
+}
+
+func TestChromeRenderReturnsScriptBuiltDOM(t *testing.T) {
+	srv := fakeDevTools(t, `<html><head><title>App</title></head><body><div id="root"><h1>Rendered</h1></div></body></html>`)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := dialCDP(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.close()
+
+	b := &chromeBrowser{conn: conn, session: "S1"}
+	u, _ := url.Parse("https://example.com/app")
+	doc, _, err := b.render(ctx, u, 0)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if got := extractTitle(doc); got != "App" {
+		t.Fatalf("expected title App, got %q", got)
+	}
+	if got := extractMarkdown(doc); got != "App\n\n# Rendered" {
+		t.Fatalf("unexpected markdown %q", got)
+	}
+}
@@ -17,7 +17,12 @@ const defaultUserAgent = "VectorChatBot/1.0 (+https://vectorchat.local)"
 
 // WebCrawler defines the minimal behavior expected from a website crawler implementation.
 type WebCrawler interface {
-	Crawl(ctx context.Context, root string, opts Options) ([]Page, error)
+	Crawl(ctx context.Context, root string, opts Options) (*Result, error)
+}
+
+// HealthChecker is implemented by crawlers backed by a remote service that can be probed.
+type HealthChecker interface {
+	Health(ctx context.Context) error
 }
 
 // APIClient wraps the crawl4ai HTTP API.
@@ -52,8 +57,30 @@ func NewAPIClient(baseURL string, httpClient *http.Client) (*APIClient, error) {
 	}, nil
 }
 
+// Health reports whether the crawl4ai API is reachable and healthy.
+func (c *APIClient) Health(ctx context.Context) error {
+	if c == nil {
+		return errors.New("crawler: API client is nil")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/health", nil)
+	if err != nil {
+		return fmt.Errorf("crawler: failed to create health request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("crawler: crawl4ai health check failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("crawler: crawl4ai health check returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
 // Crawl requests markdown content for the provided root URL via the crawl4ai API.
-func (c *APIClient) Crawl(ctx context.Context, root string, opts Options) ([]Page, error) {
+// crawl4ai does its own fetching, so opts.Known is not used.
+func (c *APIClient) Crawl(ctx context.Context, root string, opts Options) (*Result, error) {
 	if c == nil {
 		return nil, errors.New("crawler: API client is nil")
 	}
@@ -62,21 +89,58 @@ func (c *APIClient) Crawl(ctx context.Context, root string, opts Options) ([]Pag
 	if target == "" {
 		return nil, errors.New("crawler: root URL is required")
 	}
+	opts = opts.withScope()
+
+	urls := []string{target}
+	// complete turns false when a limit or a failed page leaves part of the site unseen
+	complete := true
+	if opts.SitemapOnly {
+		listed, listedAll, err := SitemapURLs(ctx, c.httpClient, target, opts.UserAgent, opts.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("crawler: %w", err)
+		}
+		complete = listedAll
+		urls = urls[:0]
+		for i, raw := range listed {
+			if u, err := url.Parse(raw); err == nil && opts.Allows(u) {
+				urls = append(urls, raw)
+			}
+			if opts.MaxPages > 0 && len(urls) >= opts.MaxPages {
+				complete = complete && i == len(listed)-1
+				break
+			}
+		}
+		if len(urls) == 0 {
+			return nil, errors.New("crawler: sitemap lists no crawlable URLs")
+		}
+	}
 
 	payload := map[string]any{
 		"url":    target,
-		"urls":   []string{target},
+		"urls":   urls,
 		"output": "markdown",
 	}
 
+	browserParams := map[string]any{
+		"headless":   true,
+		"text_mode":  true,
+		"light_mode": true,
+		"verbose":    false,
+	}
+	// crawl4ai applies extra headers to the whole browser context; cookies are scoped to the root URL
+	if headers := opts.Auth.headers(); len(headers) > 0 {
+		browserParams["headers"] = headers
+	}
+	if opts.Auth != nil && len(opts.Auth.Cookies) > 0 {
+		cookies := make([]map[string]any, 0, len(opts.Auth.Cookies))
+		for name, value := range opts.Auth.Cookies {
+			cookies = append(cookies, map[string]any{"name": name, "value": value, "url": target})
+		}
+		browserParams["cookies"] = cookies
+	}
 	payload["browser_config"] = map[string]any{
-		"type": "BrowserConfig",
-		"params": map[string]any{
-			"headless":   true,
-			"text_mode":  true,
-			"light_mode": true,
-			"verbose":    false,
-		},
+		"type":   "BrowserConfig",
+		"params": browserParams,
 	}
 
 	payload["crawler_config"] = map[string]any{
@@ -89,10 +153,11 @@ func (c *APIClient) Crawl(ctx context.Context, root string, opts Options) ([]Pag
 			"capture_mhtml":          false,
 			"excluded_tags":          []string{"script", "style", "noscript"},
 			"exclude_external_links": true,
+			"check_robots_txt":       true,
 		},
 	}
 
-	if opts.MaxDepth > 0 {
+	if opts.MaxDepth > 0 && !opts.SitemapOnly {
 		payload["max_depth"] = opts.MaxDepth
 	}
 	if opts.MaxPages > 0 {
@@ -137,18 +202,31 @@ func (c *APIClient) Crawl(ctx context.Context, root string, opts Options) ([]Pag
 	}
 
 	if resp.StatusCode >= http.StatusBadRequest {
-		snippet := string(respBody)
+		// Validation errors echo the request, credentials included
+		snippet := opts.Auth.Redact(string(respBody))
 		if len(snippet) > 256 {
 			snippet = snippet[:256]
 		}
 		return nil, fmt.Errorf("crawler: crawl4ai returned status %d: %s", resp.StatusCode, strings.TrimSpace(snippet))
 	}
 
-	pages, err := parseCrawl4AIResponse(target, respBody)
+	pages, allLoaded, err := parseCrawl4AIResponse(target, respBody)
 	if err != nil {
 		return nil, err
 	}
-	return pages, nil
+	// crawl4ai stops following links at max_pages without saying whether more were left
+	if !allLoaded || (opts.MaxPages > 0 && len(pages) >= opts.MaxPages && !opts.SitemapOnly) {
+		complete = false
+	}
+
+	// crawl4ai follows links on its own; drop pages outside the requested scope
+	scoped := pages[:0]
+	for _, page := range pages {
+		if u, err := url.Parse(page.URL); err == nil && opts.Allows(u) {
+			scoped = append(scoped, page)
+		}
+	}
+	return &Result{Pages: scoped, Complete: complete}, nil
 }
 
 // crawl4AIResponse mirrors multiple possible shapes returned by the crawl4ai API.
@@ -166,6 +244,9 @@ type crawl4AIResponse struct {
 }
 
 type crawl4AIItem struct {
+	// Success and StatusCode report whether crawl4ai could load the page
+	Success         *bool           `json:"success"`
+	StatusCode      int             `json:"status_code"`
 	URL             json.RawMessage `json:"url"`
 	Title           json.RawMessage `json:"title"`
 	Markdown        json.RawMessage `json:"markdown"`
@@ -197,27 +278,29 @@ func (i crawl4AIItem) primaryText() string {
 	return ""
 }
 
-func parseCrawl4AIResponse(root string, data []byte) ([]Page, error) {
+// parseCrawl4AIResponse extracts the pages of a crawl4ai response. allLoaded is false when
+// crawl4ai reports a page it failed to load; removed pages (404 or 410) do not count.
+func parseCrawl4AIResponse(root string, data []byte) (pages []Page, allLoaded bool, err error) {
 	var resp crawl4AIResponse
 	if err := json.Unmarshal(data, &resp); err != nil {
 		// Try to parse as an array of items directly
 		var direct []crawl4AIItem
 		if err2 := json.Unmarshal(data, &direct); err2 == nil {
-			return itemsToPages(root, direct, ""), nil
+			return itemsToPages(root, direct, ""), allItemsLoaded(direct), nil
 		}
 		// Try simple markdown string
 		var markdown string
 		if err3 := json.Unmarshal(data, &markdown); err3 == nil && strings.TrimSpace(markdown) != "" {
-			return []Page{{URL: root, Title: "", Text: markdown}}, nil
+			return []Page{{URL: root, Title: "", Text: markdown}}, true, nil
 		}
-		return nil, fmt.Errorf("crawler: invalid crawl4ai response: %w", err)
+		return nil, false, fmt.Errorf("crawler: invalid crawl4ai response: %w", err)
 	}
 
 	if resp.Error != "" {
-		return nil, fmt.Errorf("crawler: crawl4ai error: %s", strings.TrimSpace(resp.Error))
+		return nil, false, fmt.Errorf("crawler: crawl4ai error: %s", strings.TrimSpace(resp.Error))
 	}
 	if strings.EqualFold(resp.Status, "error") && strings.TrimSpace(resp.Message) != "" {
-		return nil, fmt.Errorf("crawler: crawl4ai error: %s", strings.TrimSpace(resp.Message))
+		return nil, false, fmt.Errorf("crawler: crawl4ai error: %s", strings.TrimSpace(resp.Message))
 	}
 
 	combined := make([]crawl4AIItem, 0, 8)
@@ -229,16 +312,33 @@ func parseCrawl4AIResponse(root string, data []byte) ([]Page, error) {
 	combined = append(combined, resp.Pages...)
 	combined = append(combined, resp.Items...)
 
-	pages := itemsToPages(root, combined, resp.Title)
+	pages = itemsToPages(root, combined, resp.Title)
 	if len(pages) > 0 {
-		return pages, nil
+		return pages, allItemsLoaded(combined), nil
 	}
 
 	if strings.TrimSpace(resp.Markdown) != "" {
-		return []Page{{URL: root, Title: strings.TrimSpace(resp.Title), Text: resp.Markdown}}, nil
+		return []Page{{URL: root, Title: strings.TrimSpace(resp.Title), Text: resp.Markdown}}, true, nil
+	}
+
+	return nil, false, errors.New("crawler: crawl4ai response did not include markdown content")
+}
+
+// allItemsLoaded reports whether crawl4ai loaded every item, ignoring pages that no longer exist.
+func allItemsLoaded(items []crawl4AIItem) bool {
+	for _, item := range items {
+		if item.gone() {
+			continue
+		}
+		if (item.Success != nil && !*item.Success) || item.StatusCode >= http.StatusBadRequest {
+			return false
+		}
 	}
+	return true
+}
 
-	return nil, errors.New("crawler: crawl4ai response did not include markdown content")
+func (i crawl4AIItem) gone() bool {
+	return i.StatusCode == http.StatusNotFound || i.StatusCode == http.StatusGone
 }
 
 func itemsToPages(root string, items []crawl4AIItem, fallbackTitle string) []Page {
@@ -250,7 +350,7 @@ func itemsToPages(root string, items []crawl4AIItem, fallbackTitle string) []Pag
 	seen := make(map[string]struct{})
 	for _, item := range items {
 		text := strings.TrimSpace(item.primaryText())
-		if text == "" {
+		if text == "" || item.gone() {
 			continue
 		}
 		url := strings.TrimSpace(rawToString(item.URL))
@@ -0,0 +1,130 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fetcher issues GET requests with our user agent and keeps a politeness delay between
+// requests to the same host. The delay of a host is raised to its robots.txt crawl-delay.
+type fetcher struct {
+	client    *http.Client
+	userAgent string
+	delay     time.Duration
+	// auth is only sent to authHost
+	auth     *Auth
+	authHost string
+
+	mu     sync.Mutex
+	last   map[string]time.Time
+	delays map[string]time.Duration
+}
+
+func newFetcher(client *http.Client, userAgent string, delay time.Duration) *fetcher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if strings.TrimSpace(userAgent) == "" {
+		userAgent = defaultUserAgent
+	}
+	return &fetcher{
+		client:    client,
+		userAgent: userAgent,
+		delay:     delay,
+		last:      make(map[string]time.Time),
+		delays:    make(map[string]time.Duration),
+	}
+}
+
+// authorize sends auth with every request to host. Redirects away from host drop the credentials.
+func (f *fetcher) authorize(host string, auth *Auth) {
+	if auth.empty() {
+		return
+	}
+	f.auth = auth
+	f.authHost = strings.ToLower(host)
+
+	client := *f.client
+	next := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !strings.EqualFold(req.URL.Host, f.authHost) {
+			for name := range auth.headers() {
+				req.Header.Del(name)
+			}
+			req.Header.Del("Cookie")
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	f.client = &client
+}
+
+// setHostDelay raises the delay for host, e.g. to honour a robots.txt crawl-delay.
+func (f *fetcher) setHostDelay(host string, delay time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if delay > f.delays[strings.ToLower(host)] {
+		f.delays[strings.ToLower(host)] = delay
+	}
+}
+
+func (f *fetcher) get(ctx context.Context, u *url.URL) (*http.Response, error) {
+	return f.getWithHeader(ctx, u, nil)
+}
+
+// getWithHeader is get with extra request headers, e.g. conditional request validators.
+func (f *fetcher) getWithHeader(ctx context.Context, u *url.URL, header http.Header) (*http.Response, error) {
+	if err := f.wait(ctx, u.Host); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+	if f.auth != nil && strings.EqualFold(u.Host, f.authHost) {
+		f.auth.apply(req)
+	}
+	return f.client.Do(req)
+}
+
+// wait blocks until the politeness delay for host has passed since the previous request.
+func (f *fetcher) wait(ctx context.Context, host string) error {
+	host = strings.ToLower(host)
+	f.mu.Lock()
+	delay := f.delay
+	if d := f.delays[host]; d > delay {
+		delay = d
+	}
+	next := f.last[host].Add(delay)
+	now := time.Now()
+	if next.Before(now) {
+		next = now
+	}
+	f.last[host] = next
+	f.mu.Unlock()
+
+	if wait := time.Until(next); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRobotsBytes bounds how much of robots.txt is parsed, as allowed by RFC 9309
+const maxRobotsBytes = 500 << 10
+
+// robotsRules are the robots.txt rules that apply to our user agent on one host.
+type robotsRules struct {
+	rules       []robotsRule
+	crawlDelay  time.Duration
+	sitemaps    []string
+	disallowAll bool
+	// unreachable marks rules assumed because robots.txt could not be fetched
+	unreachable bool
+}
+
+type robotsRule struct {
+	allow   bool
+	length  int
+	pattern *regexp.Regexp
+}
+
+// Allowed reports whether u may be fetched. The most specific (longest) matching rule wins
+// and allow wins a tie, following RFC 9309.
+func (r *robotsRules) Allowed(u *url.URL) bool {
+	if r == nil {
+		return true
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+	if r.disallowAll {
+		return false
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	best := -1
+	allowed := true
+	for _, rule := range r.rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if rule.length > best || (rule.length == best && rule.allow) {
+			best = rule.length
+			allowed = rule.allow
+		}
+	}
+	return allowed
+}
+
+// fetchRobots loads robots.txt for the host of u. A missing file allows everything;
+// server errors and unreachable hosts disallow everything, as RFC 9309 requires.
+func (f *fetcher) fetchRobots(ctx context.Context, u *url.URL) *robotsRules {
+	robotsURL := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+	resp, err := f.get(ctx, robotsURL)
+	if err != nil {
+		return &robotsRules{disallowAll: true, unreachable: true}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return &robotsRules{disallowAll: true, unreachable: true}
+	case resp.StatusCode >= 400:
+		return &robotsRules{}
+	case resp.StatusCode != http.StatusOK:
+		return &robotsRules{}
+	}
+	return parseRobots(io.LimitReader(resp.Body, maxRobotsBytes), f.userAgent)
+}
+
+// parseRobots reads the groups matching the product token of userAgent, falling back to "*".
+func parseRobots(r io.Reader, userAgent string) *robotsRules {
+	token := strings.ToLower(productToken(userAgent))
+
+	type group struct {
+		agents []string
+		lines  [][2]string
+	}
+	var groups []*group
+	var current *group
+	var sitemaps []string
+	lastWasAgent := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || !lastWasAgent {
+				current = &group{}
+				groups = append(groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			lastWasAgent = true
+			continue
+		case "sitemap":
+			if value != "" {
+				sitemaps = append(sitemaps, value)
+			}
+		case "allow", "disallow", "crawl-delay":
+			if current != nil {
+				current.lines = append(current.lines, [2]string{key, value})
+			}
+		}
+		lastWasAgent = false
+	}
+
+	var matched, wildcard []*group
+	for _, g := range groups {
+		for _, agent := range g.agents {
+			if agent == token {
+				matched = append(matched, g)
+				break
+			}
+			if agent == "*" {
+				wildcard = append(wildcard, g)
+				break
+			}
+		}
+	}
+	if len(matched) == 0 {
+		matched = wildcard
+	}
+
+	rules := &robotsRules{sitemaps: sitemaps}
+	for _, g := range matched {
+		for _, kv := range g.lines {
+			switch kv[0] {
+			case "crawl-delay":
+				if secs, err := strconv.ParseFloat(kv[1], 64); err == nil && secs > 0 {
+					rules.crawlDelay = time.Duration(secs * float64(time.Second))
+				}
+			case "allow", "disallow":
+				// An empty disallow allows everything and adds no rule
+				if kv[1] == "" {
+					continue
+				}
+				rules.rules = append(rules.rules, robotsRule{
+					allow:   kv[0] == "allow",
+					length:  len(kv[1]),
+					pattern: robotsPattern(kv[1]),
+				})
+			}
+		}
+	}
+	return rules
+}
+
+// robotsPattern compiles a robots.txt path pattern: "*" matches any sequence and a trailing "$" anchors the end.
+func robotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// productToken returns the name part of a user agent, e.g. "VectorChatBot" for "VectorChatBot/1.0 (+url)".
+func productToken(userAgent string) string {
+	token := strings.TrimSpace(userAgent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+	return token
+}
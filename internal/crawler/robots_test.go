@@ -0,0 +1,71 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testRobots = `
+# comments are ignored
+User-agent: *
+Disallow: /
+
+User-agent: VectorChatBot
+User-agent: OtherBot
+Disallow: /private/
+Allow: /private/press/
+Disallow: /*.pdf$
+Crawl-delay: 2.5
+
+Sitemap: https://example.com/sitemap_index.xml
+`
+
+func TestParseRobotsSelectsOurGroup(t *testing.T) {
+	rules := parseRobots(strings.NewReader(testRobots), "VectorChatBot/1.0 (+https://vectorchat.local)")
+
+	cases := map[string]bool{
+		"https://example.com/":                      true,
+		"https://example.com/docs/install":          true,
+		"https://example.com/private/keys":          false,
+		"https://example.com/private/press/release": true,
+		"https://example.com/files/report.pdf":      false,
+		"https://example.com/files/report.pdf?x=1":  true,
+		"https://example.com/robots.txt":            true,
+	}
+	for raw, want := range cases {
+		u, _ := url.Parse(raw)
+		if got := rules.Allowed(u); got != want {
+			t.Errorf("Allowed(%s) = %v, want %v", raw, got, want)
+		}
+	}
+	if rules.crawlDelay != 2500*time.Millisecond {
+		t.Errorf("expected crawl-delay 2.5s, got %s", rules.crawlDelay)
+	}
+	if len(rules.sitemaps) != 1 || rules.sitemaps[0] != "https://example.com/sitemap_index.xml" {
+		t.Errorf("unexpected sitemaps %v", rules.sitemaps)
+	}
+}
+
+func TestParseRobotsFallsBackToWildcard(t *testing.T) {
+	rules := parseRobots(strings.NewReader(testRobots), "SomeOtherCrawler/2.0")
+
+	u, _ := url.Parse("https://example.com/docs")
+	if rules.Allowed(u) {
+		t.Error("expected the wildcard group to disallow everything")
+	}
+}
+
+func TestRobotsDisallowAll(t *testing.T) {
+	rules := &robotsRules{disallowAll: true}
+
+	page, _ := url.Parse("https://example.com/docs")
+	robots, _ := url.Parse("https://example.com/robots.txt")
+	if rules.Allowed(page) {
+		t.Error("expected pages to be disallowed")
+	}
+	if !rules.Allowed(robots) {
+		t.Error("expected robots.txt itself to stay fetchable")
+	}
+}
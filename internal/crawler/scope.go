@@ -0,0 +1,106 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// urlScope holds the compiled Include and Exclude globs of a crawl.
+type urlScope struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// Allows reports whether u is inside the crawl scope set by PathPrefix, Include and Exclude.
+// URLs outside the scope are neither indexed nor followed.
+func (o Options) Allows(u *url.URL) bool {
+	p := u.Path
+	if p == "" {
+		p = "/"
+	}
+	if o.PathPrefix != "" && !strings.HasPrefix(p, o.PathPrefix) {
+		return false
+	}
+	scope := o.scope
+	if scope == nil {
+		scope = compileScope(o.Include, o.Exclude)
+	}
+	for _, re := range scope.exclude {
+		if re.MatchString(p) {
+			return false
+		}
+	}
+	if len(o.Include) == 0 {
+		return true
+	}
+	for _, re := range scope.include {
+		if re.MatchString(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// withScope compiles the URL globs once, so a crawl does not recompile them for every link.
+func (o Options) withScope() Options {
+	if o.scope == nil {
+		o.scope = compileScope(o.Include, o.Exclude)
+	}
+	return o
+}
+
+// compileScope compiles the globs; invalid ones match nothing.
+func compileScope(include, exclude []string) *urlScope {
+	s := &urlScope{}
+	for _, pattern := range include {
+		if re, err := globRegexp(pattern); err == nil {
+			s.include = append(s.include, re)
+		}
+	}
+	for _, pattern := range exclude {
+		if re, err := globRegexp(pattern); err == nil {
+			s.exclude = append(s.exclude, re)
+		}
+	}
+	return s
+}
+
+// ValidatePattern checks that pattern is a usable URL path glob.
+// "*" matches within one path segment, "**" across segments and "?" a single character.
+func ValidatePattern(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return fmt.Errorf("empty URL pattern")
+	}
+	if _, err := globRegexp(pattern); err != nil {
+		return fmt.Errorf("invalid URL pattern %q: %w", pattern, err)
+	}
+	return nil
+}
+
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = strings.TrimSpace(pattern)
+	if !strings.HasPrefix(pattern, "/") && !strings.HasPrefix(pattern, "*") {
+		pattern = "/" + pattern
+	}
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,54 @@
+package crawler
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestOptionsAllows(t *testing.T) {
+	opts := Options{
+		Include:    []string{"/docs/**", "/blog/*"},
+		Exclude:    []string{"/docs/archive/**"},
+		PathPrefix: "/",
+	}
+
+	cases := map[string]bool{
+		"https://example.com/docs/install":          true,
+		"https://example.com/docs/guides/setup":     true,
+		"https://example.com/docs/archive/v1/setup": false,
+		"https://example.com/blog/launch":           true,
+		"https://example.com/blog/2024/launch":      false,
+		"https://example.com/pricing":               false,
+	}
+	// Crawls compile the globs up front; both forms must agree
+	for _, o := range []Options{opts, opts.withScope()} {
+		for raw, want := range cases {
+			u, _ := url.Parse(raw)
+			if got := o.Allows(u); got != want {
+				t.Errorf("Allows(%s) = %v, want %v (compiled: %v)", raw, got, want, o.scope != nil)
+			}
+		}
+	}
+}
+
+func TestOptionsAllowsPathPrefix(t *testing.T) {
+	opts := Options{PathPrefix: "/docs/"}
+
+	in, _ := url.Parse("https://example.com/docs/install")
+	out, _ := url.Parse("https://example.com/")
+	if !opts.Allows(in) {
+		t.Error("expected URL under the prefix to be allowed")
+	}
+	if opts.Allows(out) {
+		t.Error("expected URL outside the prefix to be rejected")
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	if err := ValidatePattern("docs/*.html"); err != nil {
+		t.Errorf("expected pattern to be valid, got %v", err)
+	}
+	if err := ValidatePattern("  "); err == nil {
+		t.Error("expected empty pattern to be rejected")
+	}
+}
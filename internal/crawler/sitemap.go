@@ -0,0 +1,160 @@
+package crawler
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// maxSitemapBytes bounds how much of a (decompressed) sitemap document is read
+	maxSitemapBytes = 50 << 20
+	// maxSitemapFiles bounds how many sitemaps are read when following sitemap indexes
+	maxSitemapFiles = 50
+	// maxSitemapURLs bounds how many page URLs are collected from sitemaps
+	maxSitemapURLs = 50000
+)
+
+// sitemapDoc decodes both <urlset> sitemaps and <sitemapindex> documents.
+type sitemapDoc struct {
+	URLs []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+// SitemapURLs returns the page URLs listed in the sitemaps of root's host that robots.txt allows.
+// Sitemaps declared in robots.txt are used when present, /sitemap.xml otherwise; indexes and gzip
+// sitemaps are followed. Requests identify as userAgent, which also selects the robots.txt group;
+// an empty userAgent uses VectorChatBot. auth, when set, is sent with requests to root's host.
+// complete is false when a sitemap could not be read or the listing was cut off.
+func SitemapURLs(ctx context.Context, client *http.Client, root, userAgent string, auth *Auth) (urls []string, complete bool, err error) {
+	rootURL, err := url.Parse(root)
+	if err != nil || rootURL.Scheme == "" || rootURL.Host == "" {
+		return nil, false, ErrInvalidRootURL
+	}
+	f := newFetcher(client, userAgent, 0)
+	f.authorize(rootURL.Host, auth)
+	robots := f.fetchRobots(ctx, rootURL)
+	listed, complete, err := f.sitemapURLs(ctx, sitemapLocations(rootURL, robots), maxSitemapURLs)
+	if err != nil {
+		return nil, false, err
+	}
+	allowed := listed[:0]
+	for _, raw := range listed {
+		if u, err := url.Parse(raw); err == nil && robots.Allowed(u) {
+			allowed = append(allowed, raw)
+		}
+	}
+	return allowed, complete, nil
+}
+
+// sitemapLocations returns the sitemaps declared in robots.txt, or the conventional /sitemap.xml.
+func sitemapLocations(root *url.URL, robots *robotsRules) []string {
+	if robots != nil && len(robots.sitemaps) > 0 {
+		return robots.sitemaps
+	}
+	return []string{(&url.URL{Scheme: root.Scheme, Host: root.Host, Path: "/sitemap.xml"}).String()}
+}
+
+// sitemapURLs reads the given sitemaps, following sitemap indexes, and returns up to limit page URLs.
+// complete is false when a sitemap failed to load or a limit left sitemaps or URLs unread; missing
+// sitemaps (404 or 410) are not a failure.
+func (f *fetcher) sitemapURLs(ctx context.Context, sitemaps []string, limit int) ([]string, bool, error) {
+	queue := append([]string{}, sitemaps...)
+	seen := make(map[string]bool, len(queue))
+	var urls []string
+	var lastErr error
+	complete := true
+
+	for fetched := 0; len(queue) > 0 && fetched < maxSitemapFiles && len(urls) < limit; fetched++ {
+		loc := queue[0]
+		queue = queue[1:]
+		if seen[loc] {
+			fetched--
+			continue
+		}
+		seen[loc] = true
+
+		doc, err := f.fetchSitemap(ctx, loc)
+		if err != nil {
+			lastErr = err
+			if !errors.Is(err, errGone) {
+				complete = false
+			}
+			continue
+		}
+		for _, s := range doc.Sitemaps {
+			if next := strings.TrimSpace(s.Loc); next != "" {
+				queue = append(queue, next)
+			}
+		}
+		for _, u := range doc.URLs {
+			page := strings.TrimSpace(u.Loc)
+			if page == "" {
+				continue
+			}
+			if len(urls) >= limit {
+				complete = false
+				break
+			}
+			urls = append(urls, page)
+		}
+	}
+	for _, loc := range queue {
+		if !seen[loc] {
+			complete = false
+		}
+	}
+
+	if len(urls) == 0 && lastErr != nil {
+		return nil, complete, lastErr
+	}
+	return urls, complete, nil
+}
+
+func (f *fetcher) fetchSitemap(ctx context.Context, loc string) (*sitemapDoc, error) {
+	u, err := url.Parse(loc)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid sitemap URL %q", loc)
+	}
+	resp, err := f.get(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sitemap: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusGone:
+		return nil, fmt.Errorf("sitemap %s returned status %d: %w", loc, resp.StatusCode, errGone)
+	default:
+		return nil, fmt.Errorf("sitemap %s returned status %d", loc, resp.StatusCode)
+	}
+
+	// Gzip sitemaps are usually served as application/gzip without Content-Encoding, so sniff the magic bytes
+	body := bufio.NewReader(resp.Body)
+	var r io.Reader = body
+	if magic, err := body.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip sitemap %s: %w", loc, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var doc sitemapDoc
+	if err := xml.NewDecoder(io.LimitReader(r, maxSitemapBytes)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse sitemap %s: %w", loc, err)
+	}
+	return &doc, nil
+}
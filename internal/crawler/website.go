@@ -3,6 +3,7 @@ package crawler
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
@@ -12,36 +13,106 @@ import (
 	"golang.org/x/net/html"
 )
 
-// Options defines minimal crawl limits.
+// Options defines crawl limits and scope.
 type Options struct {
 	MaxPages int // maximum pages to visit
 	MaxDepth int // maximum link depth from root (root = 0)
 	Timeout  time.Duration
+	// Include and Exclude are URL path globs; Exclude wins when both match
+	Include    []string
+	Exclude    []string
+	PathPrefix string // only crawl URLs whose path starts with this prefix
+	// SitemapOnly crawls the URLs listed in the site's sitemap instead of following links
+	SitemapOnly bool
+	// UserAgent identifies the crawler and selects its robots.txt group; defaults to VectorChatBot
+	UserAgent string
+	// Delay is the minimum time between requests to one host; a longer robots.txt crawl-delay wins
+	Delay time.Duration
+	// Auth is sent with requests to the root URL's host, for sites behind a login
+	Auth *Auth
+	// Known holds the validators of pages stored by a previous crawl, keyed by URL. The plain HTTP
+	// crawler revalidates pages whose links it does not need with them and reports unchanged ones
+	// as NotModified.
+	Known map[string]Validators
+
+	// scope is compiled from Include and Exclude when a crawl starts
+	scope *urlScope
+}
+
+// Validators are the HTTP cache validators of a previously fetched page.
+type Validators struct {
+	ETag         string
+	LastModified string
 }
 
 // Page represents extracted content from a webpage.
 type Page struct {
 	URL   string
 	Title string
-	Text  string
+	Text  string // page body as markdown
+	// HTTP validators of the fetched page, when the server sent them
+	ETag         string
+	LastModified string
+	// NotModified is set when the server confirmed the copy from the previous crawl is current;
+	// Text and Title are then empty
+	NotModified bool
+}
+
+// Result is the outcome of a crawl.
+type Result struct {
+	Pages []Page
+	// Complete is set when every page in scope was reached: none failed to load and no page
+	// limit cut the crawl short. Only then are pages missing from Pages known to be gone.
+	Complete bool
+}
+
+var (
+	// ErrDisallowedByRobots is returned when robots.txt blocks every page a crawl could fetch.
+	ErrDisallowedByRobots = errors.New("crawling disallowed by robots.txt")
+	// ErrInvalidRootURL is returned when the crawl's root URL is not an absolute URL.
+	ErrInvalidRootURL = errors.New("invalid root URL")
+
+	// errNotModified is returned by page loaders when a revalidated page has not changed
+	errNotModified = errors.New("not modified")
+	// errGone is returned for pages and sitemaps the server reports as removed (404 or 410)
+	errGone = errors.New("gone")
+)
+
+// CrawlWebsite performs a breadth‑first crawl within the same host, seeded with the root URL
+// and the URLs listed in the site's sitemaps. robots.txt rules and crawl-delay for our user
+// agent are honoured, and pages declaring the same canonical URL are indexed once.
+// It returns the pages with markdown text extracted from HTML bodies.
+func CrawlWebsite(ctx context.Context, root string, opts Options) (*Result, error) {
+	opts = opts.withDefaults()
+	f := newFetcher(&http.Client{Timeout: opts.Timeout}, opts.UserAgent, opts.Delay)
+	return crawlSite(ctx, root, opts, f, func(ctx context.Context, u *url.URL, known *Validators) (*html.Node, http.Header, error) {
+		return fetchHTML(ctx, f, u, known)
+	})
 }
 
-// CrawlWebsite performs a minimal breadth‑first crawl within the same host.
-// It returns a slice of pages with plain text extracted from HTML bodies.
-func CrawlWebsite(ctx context.Context, root string, opts Options) ([]Page, error) {
-	if opts.MaxPages <= 0 {
-		opts.MaxPages = 25
+func (o Options) withDefaults() Options {
+	if o.MaxPages <= 0 {
+		o.MaxPages = 25
 	}
-	if opts.MaxDepth < 0 {
-		opts.MaxDepth = 2
+	if o.MaxDepth < 0 {
+		o.MaxDepth = 2
 	}
-	if opts.Timeout <= 0 {
-		opts.Timeout = 15 * time.Second
+	if o.Timeout <= 0 {
+		o.Timeout = 15 * time.Second
 	}
+	return o.withScope()
+}
+
+// pageLoader returns the parsed document of u, or a nil document when u is not an HTML page.
+// When known is set the loader may revalidate the page and return errNotModified.
+type pageLoader func(ctx context.Context, u *url.URL, known *Validators) (*html.Node, http.Header, error)
 
+// crawlSite runs the crawl, loading pages with load. robots.txt and sitemaps are always
+// fetched over plain HTTP by f, which also spaces out requests to the host.
+func crawlSite(ctx context.Context, root string, opts Options, f *fetcher, load pageLoader) (*Result, error) {
 	rootURL, err := url.Parse(root)
 	if err != nil || rootURL.Scheme == "" || rootURL.Host == "" {
-		return nil, errors.New("invalid root URL")
+		return nil, ErrInvalidRootURL
 	}
 
 	type item struct {
@@ -49,13 +120,49 @@ func CrawlWebsite(ctx context.Context, root string, opts Options) ([]Page, error
 		depth int
 	}
 
-	client := &http.Client{Timeout: opts.Timeout}
-	queue := []item{{u: rootURL, depth: 0}}
+	f.authorize(rootURL.Host, opts.Auth)
+	robots := f.fetchRobots(ctx, rootURL)
+	f.setHostDelay(rootURL.Host, robots.crawlDelay)
+
+	queue := []item{}
 	visited := map[string]bool{}
 	pages := make([]Page, 0, 16)
+	blocked, loaded := 0, 0
+	var loadErr, goneErr error
+	// truncated is set when a limit left pages in scope unvisited
+	truncated := false
 
 	sameHost := func(u *url.URL) bool { return strings.EqualFold(u.Hostname(), rootURL.Hostname()) }
 
+	if !opts.SitemapOnly {
+		queue = append(queue, item{u: rootURL, depth: 0})
+	}
+
+	// Sitemap URLs seed the crawl one level below the root; in sitemap-only mode they are the whole crawl
+	var listed []string
+	if opts.SitemapOnly || opts.MaxDepth > 0 {
+		var complete bool
+		listed, complete, err = f.sitemapURLs(ctx, sitemapLocations(rootURL, robots), maxSitemapURLs)
+		if err != nil && opts.SitemapOnly {
+			return nil, err
+		}
+		truncated = !complete
+	}
+	for i, raw := range listed {
+		u, err := url.Parse(raw)
+		if err != nil || !sameHost(u) || !opts.Allows(u) {
+			continue
+		}
+		queue = append(queue, item{u: u, depth: 1})
+		if len(queue) >= opts.MaxPages*2 {
+			truncated = truncated || i < len(listed)-1
+			break
+		}
+	}
+	if opts.SitemapOnly && len(queue) == 0 {
+		return nil, errors.New("sitemap lists no crawlable URLs")
+	}
+
 	for len(queue) > 0 && len(pages) < opts.MaxPages {
 		it := queue[0]
 		queue = queue[1:]
@@ -66,38 +173,63 @@ func CrawlWebsite(ctx context.Context, root string, opts Options) ([]Page, error
 		}
 		visited[canon] = true
 
-		// Fetch
-		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, it.u.String(), nil)
-		req.Header.Set("User-Agent", "VectorChatBot/1.0 (+https://example.com)")
-		resp, err := client.Do(req)
-		if err != nil {
-			continue // skip errors
+		if !robots.Allowed(it.u) {
+			blocked++
+			continue
 		}
-		if resp.Body != nil {
-			defer resp.Body.Close()
+
+		// Pages whose links are not followed only need their body when it changed
+		var known *Validators
+		if v, ok := opts.Known[it.u.String()]; ok && (opts.SitemapOnly || it.depth >= opts.MaxDepth) {
+			known = &v
 		}
-		if ct := resp.Header.Get("Content-Type"); !strings.Contains(strings.ToLower(ct), "text/html") {
-			io.Copy(io.Discard, resp.Body)
+		doc, header, err := load(ctx, it.u, known)
+		switch {
+		case errors.Is(err, errNotModified):
+			loaded++
+			pages = append(pages, Page{URL: it.u.String(), ETag: known.ETag, LastModified: known.LastModified, NotModified: true})
 			continue
+		case errors.Is(err, errGone):
+			goneErr = err
+			continue // removed pages are not a failed crawl
+		case err != nil:
+			loadErr = err
+			continue // skip pages that fail to load
 		}
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			continue
+		loaded++
+		if doc == nil {
+			continue // skip non-HTML responses
 		}
 
-		// Parse HTML, extract text + title, and links
-		doc, err := html.Parse(strings.NewReader(string(body)))
-		if err != nil {
-			continue
+		pageURL := it.u
+		if href := extractCanonical(doc); href != "" {
+			if c, err := it.u.Parse(href); err == nil && sameHost(c) && opts.Allows(c) {
+				c.Fragment = ""
+				// Another URL already produced this canonical page
+				if key := canonicalURL(c); key != canon {
+					if visited[key] {
+						continue
+					}
+					visited[key] = true
+				}
+				pageURL = c
+			}
 		}
+
 		title := extractTitle(doc)
-		text := extractVisibleText(doc)
-		if strings.TrimSpace(text) != "" {
-			pages = append(pages, Page{URL: it.u.String(), Title: title, Text: text})
+		text := extractMarkdown(doc)
+		if strings.TrimSpace(text) != "" && opts.Allows(pageURL) {
+			pages = append(pages, Page{
+				URL:          pageURL.String(),
+				Title:        title,
+				Text:         text,
+				ETag:         header.Get("ETag"),
+				LastModified: header.Get("Last-Modified"),
+			})
 		}
 
 		// Enqueue links
-		if it.depth < opts.MaxDepth {
+		if !opts.SitemapOnly && it.depth < opts.MaxDepth {
 			for _, href := range extractLinks(doc) {
 				next, err := it.u.Parse(href)
 				if err != nil || next == nil {
@@ -106,7 +238,7 @@ func CrawlWebsite(ctx context.Context, root string, opts Options) ([]Page, error
 				if next.Fragment != "" {
 					next.Fragment = ""
 				}
-				if !sameHost(next) {
+				if !sameHost(next) || !opts.Allows(next) {
 					continue
 				}
 				canonNext := canonicalURL(next)
@@ -116,12 +248,71 @@ func CrawlWebsite(ctx context.Context, root string, opts Options) ([]Page, error
 				queue = append(queue, item{u: next, depth: it.depth + 1})
 				if len(queue)+len(pages) >= opts.MaxPages*2 {
 					// keep queue bounded roughly
+					truncated = true
 					break
 				}
 			}
 		}
 	}
-	return pages, nil
+
+	if len(queue) > 0 {
+		truncated = true
+	}
+	if len(pages) == 0 {
+		switch {
+		case blocked > 0 && robots.unreachable:
+			// Not a refusal: the host may be down, so the crawl is worth retrying
+			return nil, fmt.Errorf("robots.txt of %s could not be fetched", rootURL.Host)
+		case blocked > 0:
+			return nil, fmt.Errorf("%w: %s", ErrDisallowedByRobots, root)
+		case loaded == 0 && loadErr != nil:
+			return nil, fmt.Errorf("crawl of %s failed: %w", root, loadErr)
+		case loaded == 0 && goneErr != nil:
+			return nil, fmt.Errorf("crawl of %s failed: %w", root, goneErr)
+		}
+	}
+	return &Result{Pages: pages, Complete: loadErr == nil && !truncated}, nil
+}
+
+// fetchHTML fetches u and parses the body. It returns a nil document for non-HTML responses.
+// With known validators the request is conditional and an unchanged page returns errNotModified.
+func fetchHTML(ctx context.Context, f *fetcher, u *url.URL, known *Validators) (*html.Node, http.Header, error) {
+	var header http.Header
+	if known != nil {
+		header = http.Header{}
+		if known.ETag != "" {
+			header.Set("If-None-Match", known.ETag)
+		}
+		if known.LastModified != "" {
+			header.Set("If-Modified-Since", known.LastModified)
+		}
+	}
+	resp, err := f.getWithHeader(ctx, u, header)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, resp.Header, errNotModified
+	case http.StatusNotFound, http.StatusGone:
+		io.Copy(io.Discard, resp.Body)
+		return nil, resp.Header, fmt.Errorf("%s returned status %d: %w", u, resp.StatusCode, errGone)
+	}
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, resp.Header, fmt.Errorf("%s returned status %d", u, resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.Contains(strings.ToLower(ct), "text/html") {
+		io.Copy(io.Discard, resp.Body)
+		return nil, resp.Header, nil
+	}
+	doc, err := html.Parse(resp.Body)
+	if err != nil {
+		return nil, resp.Header, err
+	}
+	return doc, resp.Header, nil
 }
 
 func canonicalURL(u *url.URL) string {
@@ -158,22 +349,85 @@ func extractTitle(n *html.Node) string {
 	return strings.TrimSpace(title)
 }
 
-// extractVisibleText collects text nodes excluding script/style/noscript.
-func extractVisibleText(n *html.Node) string {
-	var b strings.Builder
+// blockElements end the current paragraph so the markdown chunker can split on them.
+var blockElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true, "header": true, "footer": true,
+	"nav": true, "aside": true, "ul": true, "ol": true, "li": true, "table": true, "tr": true, "br": true,
+	"blockquote": true, "pre": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// extractMarkdown collects visible text (excluding script/style/noscript) as lightweight markdown:
+// block elements become paragraphs and h1-h6 become markdown headings.
+func extractMarkdown(n *html.Node) string {
+	var paragraphs []string
+	var cur strings.Builder
+	prefix := ""
 	var skip = map[string]bool{"script": true, "style": true, "noscript": true}
+	endParagraph := func() {
+		if cur.Len() > 0 {
+			paragraphs = append(paragraphs, cur.String())
+			cur.Reset()
+		}
+		prefix = ""
+	}
 	var walk func(*html.Node)
 	walk = func(node *html.Node) {
 		if node.Type == html.ElementNode && skip[node.Data] {
 			return
 		}
+		block := node.Type == html.ElementNode && blockElements[node.Data]
+		if block {
+			endParagraph()
+			if len(node.Data) == 2 && node.Data[0] == 'h' && node.Data[1] >= '1' && node.Data[1] <= '6' {
+				prefix = strings.Repeat("#", int(node.Data[1]-'0')) + " "
+			}
+		}
 		if node.Type == html.TextNode {
-			s := strings.TrimSpace(node.Data)
+			s := strings.Join(strings.Fields(node.Data), " ")
 			if s != "" {
-				if b.Len() > 0 {
-					b.WriteString(" ")
+				if cur.Len() > 0 {
+					cur.WriteString(" ")
+				} else {
+					cur.WriteString(prefix)
+				}
+				cur.WriteString(s)
+			}
+		}
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if block {
+			endParagraph()
+		}
+	}
+	walk(n)
+	endParagraph()
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// extractCanonical returns the href of the first <link rel="canonical">.
+func extractCanonical(n *html.Node) string {
+	var href string
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		if href != "" {
+			return
+		}
+		if node.Type == html.ElementNode && node.Data == "link" {
+			var rel, h string
+			for _, a := range node.Attr {
+				switch strings.ToLower(a.Key) {
+				case "rel":
+					rel = a.Val
+				case "href":
+					h = a.Val
+				}
+			}
+			for _, r := range strings.Fields(strings.ToLower(rel)) {
+				if r == "canonical" {
+					href = strings.TrimSpace(h)
+					return
 				}
-				b.WriteString(s)
 			}
 		}
 		for c := node.FirstChild; c != nil; c = c.NextSibling {
@@ -181,7 +435,7 @@ func extractVisibleText(n *html.Node) string {
 		}
 	}
 	walk(n)
-	return strings.TrimSpace(b.String())
+	return href
 }
 
 // extractLinks returns hrefs from <a> tags.
@@ -0,0 +1,247 @@
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestCrawlWebsiteHonoursRobotsSitemapsAndCanonical(t *testing.T) {
+	var base string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "User-agent: VectorChatBot\nDisallow: /private/\n\nSitemap: %s/sitemap_index.xml\n", base)
+	})
+	mux.HandleFunc("/sitemap_index.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<sitemapindex><sitemap><loc>%s/pages.xml.gz</loc></sitemap></sitemapindex>`, base)
+	})
+	mux.HandleFunc("/pages.xml.gz", func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		fmt.Fprintf(gz, `<urlset><url><loc>%[1]s/docs</loc></url><url><loc>%[1]s/private/secret</loc></url></urlset>`, base)
+		gz.Close()
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Write(buf.Bytes())
+	})
+	page := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, body)
+		}
+	}
+	mux.HandleFunc("/", page(`<html><head><title>Home</title></head><body><p>Welcome</p><a href="/guide?utm_source=nav">Guide</a></body></html>`))
+	mux.HandleFunc("/docs", page(`<html><head><title>Docs</title></head><body><h1>Docs</h1><p>Read me</p></body></html>`))
+	mux.HandleFunc("/guide", page(`<html><head><link rel="canonical" href="/docs"></head><body><p>Same as docs</p></body></html>`))
+	mux.HandleFunc("/private/secret", page(`<html><body><p>Secret</p></body></html>`))
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	base = srv.URL
+
+	result, err := CrawlWebsite(context.Background(), srv.URL+"/", Options{MaxPages: 10, MaxDepth: 2})
+	if err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+	if !result.Complete {
+		t.Fatal("expected the crawl to be complete")
+	}
+
+	var urls []string
+	for _, p := range result.Pages {
+		urls = append(urls, p.URL)
+	}
+	sort.Strings(urls)
+	want := []string{srv.URL + "/", srv.URL + "/docs"}
+	if fmt.Sprint(urls) != fmt.Sprint(want) {
+		t.Fatalf("expected pages %v, got %v", want, urls)
+	}
+}
+
+func TestCrawlWebsiteDisallowedByRobots(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nDisallow: /\n")
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request for %s", r.URL.Path)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	if _, err := CrawlWebsite(context.Background(), srv.URL+"/", Options{MaxPages: 5}); err == nil {
+		t.Fatal("expected crawl to be refused by robots.txt")
+	}
+}
+
+func TestCrawlWebsiteSendsAuthOnlyToRootHost(t *testing.T) {
+	var leaked []string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "" || r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") != "" {
+			leaked = append(leaked, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><p>Elsewhere</p></body></html>`)
+	}))
+	defer other.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		session, err := r.Cookie("session")
+		if r.Header.Get("X-Api-Key") != "k-123" || !ok || user != "reader" || pass != "s3cret" || err != nil || session.Value != "abc" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><p>Private docs</p><a href="/moved">Moved</a></body></html>`)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL+"/landing", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	auth := &Auth{
+		Headers:  map[string]string{"x-api-key": "k-123"},
+		Cookies:  map[string]string{"session": "abc"},
+		Username: "reader",
+		Password: "s3cret",
+	}
+	result, err := CrawlWebsite(context.Background(), srv.URL+"/", Options{MaxPages: 5, MaxDepth: 1, Auth: auth})
+	if err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+	if len(result.Pages) == 0 || result.Pages[0].URL != srv.URL+"/" {
+		t.Fatalf("expected the protected root page, got %+v", result.Pages)
+	}
+	if len(leaked) > 0 {
+		t.Fatalf("credentials sent to another host for %v", leaked)
+	}
+}
+
+func TestCrawlWebsiteReportsPartialCrawls(t *testing.T) {
+	page := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, body)
+		}
+	}
+	tests := []struct {
+		name     string
+		broken   http.HandlerFunc
+		maxPages int
+		complete bool
+	}{
+		{"every page loaded", page(`<html><body><p>B</p></body></html>`), 10, true},
+		{"removed page", http.NotFound, 10, true},
+		{"server error", func(w http.ResponseWriter, r *http.Request) { http.Error(w, "down", http.StatusBadGateway) }, 10, false},
+		{"page limit", page(`<html><body><p>B</p></body></html>`), 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/", page(`<html><body><p>Home</p><a href="/a">A</a><a href="/b">B</a></body></html>`))
+			mux.HandleFunc("/a", page(`<html><body><p>A</p></body></html>`))
+			mux.HandleFunc("/b", tt.broken)
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			result, err := CrawlWebsite(context.Background(), srv.URL+"/", Options{MaxPages: tt.maxPages, MaxDepth: 1})
+			if err != nil {
+				t.Fatalf("crawl failed: %v", err)
+			}
+			if result.Complete != tt.complete {
+				t.Fatalf("Complete = %v, want %v (pages %d)", result.Complete, tt.complete, len(result.Pages))
+			}
+		})
+	}
+}
+
+func TestCrawlWebsiteRevalidatesKnownLeafPages(t *testing.T) {
+	var conditional []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			conditional = append(conditional, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><p>Home</p><a href="/docs">Docs</a></body></html>`)
+	})
+	mux.HandleFunc("/docs", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` && r.Header.Get("If-Modified-Since") == "Mon, 02 Jan 2006 15:04:05 GMT" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("ETag", `"v2"`)
+		fmt.Fprint(w, `<html><body><p>Docs</p></body></html>`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	known := map[string]Validators{
+		srv.URL + "/":     {ETag: `"home"`},
+		srv.URL + "/docs": {ETag: `"v1"`, LastModified: "Mon, 02 Jan 2006 15:04:05 GMT"},
+	}
+	result, err := CrawlWebsite(context.Background(), srv.URL+"/", Options{MaxPages: 5, MaxDepth: 1, Known: known})
+	if err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+	if len(conditional) > 0 {
+		t.Fatalf("pages whose links are followed were revalidated: %v", conditional)
+	}
+	if len(result.Pages) != 2 || result.Pages[0].NotModified || !result.Pages[1].NotModified || result.Pages[1].ETag != `"v1"` {
+		t.Fatalf("expected the root fetched and /docs not modified, got %+v", result.Pages)
+	}
+	if !result.Complete {
+		t.Fatal("a crawl with unchanged pages is still complete")
+	}
+}
+
+func TestAuthRedact(t *testing.T) {
+	auth := &Auth{Headers: map[string]string{"X-Api-Key": "k-123456"}, Password: "hunter22", Username: "bob"}
+	got := auth.Redact(`{"detail":[{"input":{"headers":{"X-Api-Key":"k-123456"}}}],"pw":"hunter22"}`)
+	if strings.Contains(got, "k-123456") || strings.Contains(got, "hunter22") {
+		t.Fatalf("secret left in %q", got)
+	}
+	if fmt.Sprint(auth) != "[REDACTED]" {
+		t.Fatalf("expected formatted auth to be redacted, got %q", fmt.Sprint(auth))
+	}
+}
+
+func TestSitemapURLsUsesConfiguredUserAgent(t *testing.T) {
+	var base string
+	agents := make(chan string, 8)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		agents <- r.UserAgent()
+		fmt.Fprintf(w, "User-agent: DocsBot\nDisallow: /internal/\n\nSitemap: %s/sitemap.xml\n", base)
+	})
+	mux.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+		agents <- r.UserAgent()
+		fmt.Fprintf(w, `<urlset><url><loc>%[1]s/docs</loc></url><url><loc>%[1]s/internal/notes</loc></url></urlset>`, base)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	base = srv.URL
+
+	urls, complete, err := SitemapURLs(context.Background(), srv.Client(), srv.URL+"/", "DocsBot/2.0", nil)
+	if err != nil {
+		t.Fatalf("sitemap read failed: %v", err)
+	}
+	if !complete || len(urls) != 1 || urls[0] != srv.URL+"/docs" {
+		t.Fatalf("got %v (complete %v), want only %s/docs", urls, complete, srv.URL)
+	}
+	close(agents)
+	for agent := range agents {
+		if agent != "DocsBot/2.0" {
+			t.Errorf("request sent with user agent %q, want DocsBot/2.0", agent)
+		}
+	}
+}
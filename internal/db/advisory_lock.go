@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+)
+
+// AdvisoryLock is a session-level Postgres advisory lock. It is held by a dedicated connection
+// and lost when that connection drops.
+type AdvisoryLock struct {
+	conn *sql.Conn
+	key  int64
+}
+
+// TryAdvisoryLock takes the advisory lock identified by key without waiting.
+// It returns nil when another session holds the lock.
+func (db *Database) TryAdvisoryLock(ctx context.Context, key int64) (*AdvisoryLock, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, apperrors.Wrap(err, "failed to reserve connection for advisory lock")
+	}
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, apperrors.Wrap(err, "failed to take advisory lock")
+	}
+	if !acquired {
+		conn.Close()
+		return nil, nil
+	}
+	return &AdvisoryLock{conn: conn, key: key}, nil
+}
+
+// Held reports whether the session holding the lock is still connected.
+func (l *AdvisoryLock) Held(ctx context.Context) bool {
+	return l.conn.PingContext(ctx) == nil
+}
+
+// Release unlocks and returns the connection to the pool.
+func (l *AdvisoryLock) Release(ctx context.Context) error {
+	defer l.conn.Close()
+	if _, err := l.conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, l.key); err != nil {
+		return apperrors.Wrap(err, "failed to release advisory lock")
+	}
+	return nil
+}
@@ -19,16 +19,16 @@ func NewChatMessageRepository(db *Database) *ChatMessageRepository {
 
 // Create saves a new chat message to the database.
 func (r *ChatMessageRepository) Create(ctx context.Context, message *ChatMessage) error {
-	query := `INSERT INTO chat_messages (id, chatbot_id, session_id, role, content, created_at)
-		 VALUES ($1, $2, $3, $4, $5, $6)`
-	_, err := r.db.ExecContext(ctx, query, message.ID, message.ChatbotID, message.SessionID, message.Role, message.Content, message.CreatedAt)
+	query := `INSERT INTO chat_messages (id, chatbot_id, session_id, role, content, sources, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := r.db.ExecContext(ctx, query, message.ID, message.ChatbotID, message.SessionID, message.Role, message.Content, message.Sources, message.CreatedAt)
 	return err
 }
 
 // FindLastBySessionID retrieves the most recent messages for a given session ID.
 func (r *ChatMessageRepository) FindLastBySessionID(ctx context.Context, sessionID uuid.UUID, limit int) ([]*ChatMessage, error) {
 	var messages []*ChatMessage
-	query := `SELECT id, chatbot_id, session_id, role, content, created_at
+	query := `SELECT id, chatbot_id, session_id, role, content, sources, created_at
 		 FROM chat_messages
 		 WHERE session_id = $1
 		 ORDER BY created_at ASC
@@ -50,7 +50,7 @@ func (r *ChatMessageRepository) FindLastBySessionID(ctx context.Context, session
 // FindRecentBySessionID retrieves the most recent messages for a given session ID.
 func (r *ChatMessageRepository) FindRecentBySessionID(ctx context.Context, sessionID uuid.UUID, limit int) ([]*ChatMessage, error) {
 	var messages []*ChatMessage
-	query := `SELECT id, chatbot_id, session_id, role, content, created_at
+	query := `SELECT id, chatbot_id, session_id, role, content, sources, created_at
 		 FROM chat_messages
 		 WHERE session_id = $1
 		 ORDER BY created_at DESC
@@ -72,7 +72,7 @@ func (r *ChatMessageRepository) FindRecentBySessionID(ctx context.Context, sessi
 // FindAllBySessionID retrieves all messages for a given session ID ordered chronologically.
 func (r *ChatMessageRepository) FindAllBySessionID(ctx context.Context, sessionID uuid.UUID) ([]*ChatMessage, error) {
 	var messages []*ChatMessage
-	query := `SELECT id, chatbot_id, session_id, role, content, created_at
+	query := `SELECT id, chatbot_id, session_id, role, content, sources, created_at
          FROM chat_messages
          WHERE session_id = $1
          ORDER BY created_at ASC`
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	apperrors "github.com/yourusername/vectorchat/internal/errors"
 )
 
@@ -31,10 +32,14 @@ func (r *ChatbotRepository) Create(ctx context.Context, chatbot *Chatbot) error
 	query := `
 		INSERT INTO chatbots (
 			id, user_id, organization_id, name, description, system_instructions,
-			model_name, temperature_param, max_tokens, use_max_tokens, save_messages, is_enabled, created_at, updated_at
+			model_name, temperature_param, max_tokens, use_max_tokens, save_messages, is_enabled, retrieval_mode,
+			retrieval_top_k, retrieval_min_similarity, revision_match_threshold, revision_inject_threshold,
+			history_limit, context_token_budget, rerank_enabled, condense_query, created_at, updated_at
 		) VALUES (
 			:id, :user_id, :organization_id, :name, :description, :system_instructions,
-			:model_name, :temperature_param, :max_tokens, :use_max_tokens, :save_messages, :is_enabled, :created_at, :updated_at
+			:model_name, :temperature_param, :max_tokens, :use_max_tokens, :save_messages, :is_enabled, :retrieval_mode,
+			:retrieval_top_k, :retrieval_min_similarity, :revision_match_threshold, :revision_inject_threshold,
+			:history_limit, :context_token_budget, :rerank_enabled, :condense_query, :created_at, :updated_at
 		)
 	`
 
@@ -67,10 +72,14 @@ func (r *ChatbotRepository) CreateTx(ctx context.Context, tx *Transaction, chatb
 	query := `
 		INSERT INTO chatbots (
 			id, user_id, organization_id, name, description, system_instructions,
-			model_name, temperature_param, max_tokens, use_max_tokens, save_messages, is_enabled, created_at, updated_at
+			model_name, temperature_param, max_tokens, use_max_tokens, save_messages, is_enabled, retrieval_mode,
+			retrieval_top_k, retrieval_min_similarity, revision_match_threshold, revision_inject_threshold,
+			history_limit, context_token_budget, rerank_enabled, condense_query, created_at, updated_at
 		) VALUES (
 			:id, :user_id, :organization_id, :name, :description, :system_instructions,
-			:model_name, :temperature_param, :max_tokens, :use_max_tokens, :save_messages, :is_enabled, :created_at, :updated_at
+			:model_name, :temperature_param, :max_tokens, :use_max_tokens, :save_messages, :is_enabled, :retrieval_mode,
+			:retrieval_top_k, :retrieval_min_similarity, :revision_match_threshold, :revision_inject_threshold,
+			:history_limit, :context_token_budget, :rerank_enabled, :condense_query, :created_at, :updated_at
 		)
 	`
 
@@ -93,7 +102,9 @@ func (r *ChatbotRepository) FindByID(ctx context.Context, id uuid.UUID) (*Chatbo
 	var chatbot Chatbot
 	query := `
 		SELECT id, user_id, organization_id, name, description, system_instructions,
-		       model_name, temperature_param, max_tokens, use_max_tokens, save_messages, is_enabled, created_at, updated_at
+		       model_name, temperature_param, max_tokens, use_max_tokens, save_messages, is_enabled, retrieval_mode,
+		       retrieval_top_k, retrieval_min_similarity, revision_match_threshold, revision_inject_threshold,
+		       history_limit, context_token_budget, rerank_enabled, condense_query, allowed_origins, created_at, updated_at
 		FROM chatbots
 		WHERE id = $1
 	`
@@ -114,7 +125,9 @@ func (r *ChatbotRepository) FindByIDAndScope(ctx context.Context, id uuid.UUID,
 	var chatbot Chatbot
 	query := `
 		SELECT id, user_id, organization_id, name, description, system_instructions,
-		       model_name, temperature_param, max_tokens, use_max_tokens, save_messages, is_enabled, created_at, updated_at
+		       model_name, temperature_param, max_tokens, use_max_tokens, save_messages, is_enabled, retrieval_mode,
+		       retrieval_top_k, retrieval_min_similarity, revision_match_threshold, revision_inject_threshold,
+		       history_limit, context_token_budget, rerank_enabled, condense_query, allowed_origins, created_at, updated_at
 		FROM chatbots
 		WHERE id = $1 AND (
 			($2::uuid IS NULL AND organization_id IS NULL AND user_id = $3)
@@ -138,7 +151,9 @@ func (r *ChatbotRepository) FindByUserID(ctx context.Context, userID string) ([]
 	var chatbots []*Chatbot
 	query := `
 		SELECT id, user_id, organization_id, name, description, system_instructions,
-		       model_name, temperature_param, max_tokens, use_max_tokens, save_messages, is_enabled, created_at, updated_at
+		       model_name, temperature_param, max_tokens, use_max_tokens, save_messages, is_enabled, retrieval_mode,
+		       retrieval_top_k, retrieval_min_similarity, revision_match_threshold, revision_inject_threshold,
+		       history_limit, context_token_budget, rerank_enabled, condense_query, allowed_origins, created_at, updated_at
 		FROM chatbots
 		WHERE user_id = $1 AND organization_id IS NULL
 		ORDER BY created_at DESC
@@ -157,7 +172,9 @@ func (r *ChatbotRepository) FindByOrgID(ctx context.Context, orgID uuid.UUID) ([
 	var chatbots []*Chatbot
 	query := `
 		SELECT id, user_id, organization_id, name, description, system_instructions,
-		       model_name, temperature_param, max_tokens, use_max_tokens, save_messages, is_enabled, created_at, updated_at
+		       model_name, temperature_param, max_tokens, use_max_tokens, save_messages, is_enabled, retrieval_mode,
+		       retrieval_top_k, retrieval_min_similarity, revision_match_threshold, revision_inject_threshold,
+		       history_limit, context_token_budget, rerank_enabled, condense_query, allowed_origins, created_at, updated_at
 		FROM chatbots
 		WHERE organization_id = $1
 		ORDER BY created_at DESC
@@ -184,7 +201,9 @@ func (r *ChatbotRepository) FindByUserIDWithPagination(ctx context.Context, user
 	var chatbots []*Chatbot
 	query := `
 		SELECT id, user_id, name, description, system_instructions,
-		       model_name, temperature_param, max_tokens, use_max_tokens, save_messages, is_enabled, created_at, updated_at
+		       model_name, temperature_param, max_tokens, use_max_tokens, save_messages, is_enabled, retrieval_mode,
+		       retrieval_top_k, retrieval_min_similarity, revision_match_threshold, revision_inject_threshold,
+		       history_limit, context_token_budget, rerank_enabled, condense_query, allowed_origins, created_at, updated_at
 		FROM chatbots
 		WHERE user_id = $1 AND organization_id IS NULL
 		ORDER BY created_at DESC
@@ -208,7 +227,11 @@ func (r *ChatbotRepository) Update(ctx context.Context, chatbot *Chatbot) error
 		SET name = :name, description = :description, system_instructions = :system_instructions,
 		    model_name = :model_name, temperature_param = :temperature_param,
 		    max_tokens = :max_tokens, use_max_tokens = :use_max_tokens,
-		    save_messages = :save_messages, is_enabled = :is_enabled, updated_at = :updated_at
+		    save_messages = :save_messages, is_enabled = :is_enabled, retrieval_mode = :retrieval_mode,
+		    retrieval_top_k = :retrieval_top_k, retrieval_min_similarity = :retrieval_min_similarity,
+		    revision_match_threshold = :revision_match_threshold, revision_inject_threshold = :revision_inject_threshold,
+		    history_limit = :history_limit, context_token_budget = :context_token_budget,
+		    rerank_enabled = :rerank_enabled, condense_query = :condense_query, updated_at = :updated_at
 		WHERE id = :id AND (
 			(:organization_id::uuid IS NULL AND organization_id IS NULL AND user_id = :user_id)
 			OR organization_id = :organization_id::uuid
@@ -241,7 +264,11 @@ func (r *ChatbotRepository) UpdateTx(ctx context.Context, tx *Transaction, chatb
 		SET name = :name, description = :description, system_instructions = :system_instructions,
 		    model_name = :model_name, temperature_param = :temperature_param,
 		    max_tokens = :max_tokens, use_max_tokens = :use_max_tokens,
-		    save_messages = :save_messages, is_enabled = :is_enabled, updated_at = :updated_at
+		    save_messages = :save_messages, is_enabled = :is_enabled, retrieval_mode = :retrieval_mode,
+		    retrieval_top_k = :retrieval_top_k, retrieval_min_similarity = :retrieval_min_similarity,
+		    revision_match_threshold = :revision_match_threshold, revision_inject_threshold = :revision_inject_threshold,
+		    history_limit = :history_limit, context_token_budget = :context_token_budget,
+		    rerank_enabled = :rerank_enabled, condense_query = :condense_query, updated_at = :updated_at
 		WHERE id = :id AND (
 			(:organization_id::uuid IS NULL AND organization_id IS NULL AND user_id = :user_id)
 			OR organization_id = :organization_id::uuid
@@ -392,6 +419,34 @@ func (r *ChatbotRepository) UpdateBasicInfo(ctx context.Context, id uuid.UUID, u
 	return nil
 }
 
+// UpdateAllowedOrigins replaces the origins allowed to use the chatbot's public widget API
+func (r *ChatbotRepository) UpdateAllowedOrigins(ctx context.Context, id uuid.UUID, userID string, orgID *uuid.UUID, origins []string) error {
+	query := `
+		UPDATE chatbots
+		SET allowed_origins = $1, updated_at = $2
+		WHERE id = $3 AND (
+			($4::uuid IS NULL AND organization_id IS NULL AND user_id = $5)
+			OR organization_id = $4
+		)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, pq.Array(origins), time.Now(), id, orgID, userID)
+	if err != nil {
+		return apperrors.Wrap(err, "failed to update chatbot allowed origins")
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return apperrors.Wrap(err, "failed to get rows affected")
+	}
+
+	if rowsAffected == 0 {
+		return apperrors.ErrChatbotNotFound
+	}
+
+	return nil
+}
+
 // UpdateSystemInstructions updates chatbot system instructions
 func (r *ChatbotRepository) UpdateSystemInstructions(ctx context.Context, id uuid.UUID, userID string, orgID *uuid.UUID, instructions string) error {
 	query := `
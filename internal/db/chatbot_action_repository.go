@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+)
+
+// ChatbotAction is an HTTP endpoint the chatbot's model may call as a tool during a conversation.
+// Parameters holds the JSON schema of the tool arguments. Extra request headers are stored sealed
+// in SealedHeaders; Headers is filled in by the service when they are opened.
+type ChatbotAction struct {
+	ID            uuid.UUID      `db:"id"`
+	ChatbotID     uuid.UUID      `db:"chatbot_id"`
+	Name          string         `db:"name"`
+	Description   string         `db:"description"`
+	Parameters    JSONDocument   `db:"parameters"`
+	HTTPMethod    string         `db:"http_method"`
+	EndpointURL   string         `db:"endpoint_url"`
+	SealedHeaders []byte         `db:"sealed_headers"`
+	Headers       RequestHeaders `db:"-"`
+	Enabled       bool           `db:"enabled"`
+	CreatedAt     time.Time      `db:"created_at"`
+	UpdatedAt     time.Time      `db:"updated_at"`
+}
+
+// JSONDocument is a raw JSON value stored as JSONB
+type JSONDocument json.RawMessage
+
+func (j JSONDocument) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+func (j *JSONDocument) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		*j = append((*j)[:0], v...)
+	case string:
+		*j = JSONDocument(v)
+	default:
+		return fmt.Errorf("unsupported type for JSONDocument: %T", value)
+	}
+	return nil
+}
+
+// RequestHeaders are extra HTTP headers (typically auth) sent with an action request
+type RequestHeaders map[string]string
+
+type ChatbotActionRepository struct {
+	db *Database
+}
+
+func NewChatbotActionRepository(db *Database) *ChatbotActionRepository {
+	return &ChatbotActionRepository{db: db}
+}
+
+const chatbotActionColumns = `id, chatbot_id, name, description, parameters, http_method, endpoint_url, sealed_headers, enabled, created_at, updated_at`
+
+// Create inserts a new action.
+func (r *ChatbotActionRepository) Create(ctx context.Context, a *ChatbotAction) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	a.CreatedAt = now
+	a.UpdatedAt = now
+
+	query := `
+		INSERT INTO chatbot_actions (` + chatbotActionColumns + `)
+		VALUES (:id, :chatbot_id, :name, :description, :parameters, :http_method, :endpoint_url, :sealed_headers, :enabled, :created_at, :updated_at)
+	`
+	if _, err := r.db.NamedExecContext(ctx, query, a); err != nil {
+		if IsDuplicateKeyError(err) {
+			return apperrors.Wrapf(apperrors.ErrInvalidChatbotParameters, "action %q already exists", a.Name)
+		}
+		return apperrors.Wrap(err, "failed to create chatbot action")
+	}
+	return nil
+}
+
+// Update saves all editable fields of an action.
+func (r *ChatbotActionRepository) Update(ctx context.Context, a *ChatbotAction) error {
+	a.UpdatedAt = time.Now().UTC()
+	query := `
+		UPDATE chatbot_actions
+		SET name = :name,
+		    description = :description,
+		    parameters = :parameters,
+		    http_method = :http_method,
+		    endpoint_url = :endpoint_url,
+		    sealed_headers = :sealed_headers,
+		    enabled = :enabled,
+		    updated_at = :updated_at
+		WHERE id = :id AND chatbot_id = :chatbot_id
+	`
+	result, err := r.db.NamedExecContext(ctx, query, a)
+	if err != nil {
+		if IsDuplicateKeyError(err) {
+			return apperrors.Wrapf(apperrors.ErrInvalidChatbotParameters, "action %q already exists", a.Name)
+		}
+		return apperrors.Wrap(err, "failed to update chatbot action")
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return apperrors.ErrNotFound
+	}
+	return nil
+}
+
+// FindByID returns an action scoped to its chatbot.
+func (r *ChatbotActionRepository) FindByID(ctx context.Context, chatbotID, id uuid.UUID) (*ChatbotAction, error) {
+	var action ChatbotAction
+	query := `SELECT ` + chatbotActionColumns + ` FROM chatbot_actions WHERE id = $1 AND chatbot_id = $2`
+	if err := r.db.GetContext(ctx, &action, query, id, chatbotID); err != nil {
+		if IsNoRowsError(err) {
+			return nil, apperrors.ErrNotFound
+		}
+		return nil, apperrors.Wrap(err, "failed to find chatbot action")
+	}
+	return &action, nil
+}
+
+// ListByChatbot returns all actions of a chatbot ordered by name.
+func (r *ChatbotActionRepository) ListByChatbot(ctx context.Context, chatbotID uuid.UUID) ([]*ChatbotAction, error) {
+	var actions []*ChatbotAction
+	query := `SELECT ` + chatbotActionColumns + ` FROM chatbot_actions WHERE chatbot_id = $1 ORDER BY name`
+	if err := r.db.SelectContext(ctx, &actions, query, chatbotID); err != nil {
+		return nil, apperrors.Wrap(err, "failed to list chatbot actions")
+	}
+	return actions, nil
+}
+
+// ListEnabledByChatbot returns the actions exposed to the model as tools.
+func (r *ChatbotActionRepository) ListEnabledByChatbot(ctx context.Context, chatbotID uuid.UUID) ([]*ChatbotAction, error) {
+	var actions []*ChatbotAction
+	query := `SELECT ` + chatbotActionColumns + ` FROM chatbot_actions WHERE chatbot_id = $1 AND enabled = TRUE ORDER BY name`
+	if err := r.db.SelectContext(ctx, &actions, query, chatbotID); err != nil {
+		return nil, apperrors.Wrap(err, "failed to list enabled chatbot actions")
+	}
+	return actions, nil
+}
+
+// Delete removes an action from a chatbot.
+func (r *ChatbotActionRepository) Delete(ctx context.Context, chatbotID, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM chatbot_actions WHERE id = $1 AND chatbot_id = $2`, id, chatbotID)
+	if err != nil {
+		return apperrors.Wrap(err, "failed to delete chatbot action")
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return apperrors.ErrNotFound
+	}
+	return nil
+}
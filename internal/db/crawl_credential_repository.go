@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+)
+
+// CrawlCredential holds the sealed credentials for crawling a website of a chatbot or shared
+// knowledge base. Secret is ciphertext; only the crawl credential service can open it.
+type CrawlCredential struct {
+	ID                    uuid.UUID  `db:"id"`
+	ChatbotID             *uuid.UUID `db:"chatbot_id"`
+	SharedKnowledgeBaseID *uuid.UUID `db:"shared_knowledge_base_id"`
+	RootURL               string     `db:"root_url"`
+	Secret                []byte     `db:"secret"`
+	CreatedAt             time.Time  `db:"created_at"`
+	UpdatedAt             time.Time  `db:"updated_at"`
+}
+
+type CrawlCredentialRepository struct {
+	db *Database
+}
+
+func NewCrawlCredentialRepository(db *Database) *CrawlCredentialRepository {
+	return &CrawlCredentialRepository{db: db}
+}
+
+// Upsert stores the credentials for the scope + root URL, replacing earlier ones.
+func (r *CrawlCredentialRepository) Upsert(ctx context.Context, c *CrawlCredential) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = now
+	}
+	c.UpdatedAt = now
+
+	conflict := "(shared_knowledge_base_id, root_url)"
+	if c.ChatbotID != nil {
+		conflict = "(chatbot_id, root_url)"
+	}
+	query := `
+		INSERT INTO crawl_credentials (id, chatbot_id, shared_knowledge_base_id, root_url, secret, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT ` + conflict + ` DO UPDATE
+			SET secret = EXCLUDED.secret,
+				updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at
+	`
+	if err := r.db.QueryRowxContext(ctx, query, c.ID, c.ChatbotID, c.SharedKnowledgeBaseID, c.RootURL, c.Secret, c.CreatedAt, c.UpdatedAt).Scan(&c.ID, &c.CreatedAt); err != nil {
+		return apperrors.Wrap(err, "failed to upsert crawl credentials")
+	}
+	return nil
+}
+
+// FindByID returns credentials by their identifier.
+func (r *CrawlCredentialRepository) FindByID(ctx context.Context, id uuid.UUID) (*CrawlCredential, error) {
+	var result CrawlCredential
+	if err := r.db.GetContext(ctx, &result, `SELECT * FROM crawl_credentials WHERE id = $1`, id); err != nil {
+		if IsNoRowsError(err) {
+			return nil, apperrors.ErrNotFound
+		}
+		return nil, apperrors.Wrap(err, "failed to find crawl credentials by id")
+	}
+	return &result, nil
+}
+
+// FindByScope returns the credentials for a chatbot or shared knowledge base + URL.
+func (r *CrawlCredentialRepository) FindByScope(ctx context.Context, chatbotID *uuid.UUID, sharedID *uuid.UUID, rootURL string) (*CrawlCredential, error) {
+	query := `
+		SELECT * FROM crawl_credentials
+		WHERE root_url = $1
+		  AND (
+		      (chatbot_id = $2 AND $2 IS NOT NULL)
+		   OR (shared_knowledge_base_id = $3 AND $3 IS NOT NULL)
+		  )
+	`
+	var result CrawlCredential
+	if err := r.db.GetContext(ctx, &result, query, rootURL, chatbotID, sharedID); err != nil {
+		if IsNoRowsError(err) {
+			return nil, apperrors.ErrNotFound
+		}
+		return nil, apperrors.Wrap(err, "failed to find crawl credentials")
+	}
+	return &result, nil
+}
+
+// ListByScope returns all credentials stored for the given chatbot or shared knowledge base.
+func (r *CrawlCredentialRepository) ListByScope(ctx context.Context, chatbotID *uuid.UUID, sharedID *uuid.UUID) ([]*CrawlCredential, error) {
+	query := `
+		SELECT * FROM crawl_credentials
+		WHERE (chatbot_id = $1 AND $1 IS NOT NULL)
+		   OR (shared_knowledge_base_id = $2 AND $2 IS NOT NULL)
+	`
+	var results []*CrawlCredential
+	if err := r.db.SelectContext(ctx, &results, query, chatbotID, sharedID); err != nil {
+		return nil, apperrors.Wrap(err, "failed to list crawl credentials")
+	}
+	return results, nil
+}
+
+// DeleteByScope removes the credentials for a chatbot or shared knowledge base + URL.
+func (r *CrawlCredentialRepository) DeleteByScope(ctx context.Context, chatbotID *uuid.UUID, sharedID *uuid.UUID, rootURL string) error {
+	query := `
+		DELETE FROM crawl_credentials
+		WHERE root_url = $1
+		  AND (
+		      (chatbot_id = $2 AND $2 IS NOT NULL)
+		   OR (shared_knowledge_base_id = $3 AND $3 IS NOT NULL)
+		  )
+	`
+	if _, err := r.db.ExecContext(ctx, query, rootURL, chatbotID, sharedID); err != nil {
+		return apperrors.Wrap(err, "failed to delete crawl credentials")
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+)
+
+// CrawlRun records one attempt of a crawl job: who ran it, for how long, and what it produced.
+type CrawlRun struct {
+	ID                    uuid.UUID  `db:"id"`
+	JobID                 uuid.UUID  `db:"job_id"`
+	Attempt               int        `db:"attempt"`
+	ScheduleID            *uuid.UUID `db:"schedule_id"`
+	ChatbotID             *uuid.UUID `db:"chatbot_id"`
+	SharedKnowledgeBaseID *uuid.UUID `db:"shared_knowledge_base_id"`
+	RootURL               string     `db:"root_url"`
+	Trigger               string     `db:"trigger"`
+	Status                string     `db:"status"`
+	WorkerID              string     `db:"worker_id"`
+	StartedAt             time.Time  `db:"started_at"`
+	HeartbeatAt           time.Time  `db:"heartbeat_at"`
+	FinishedAt            *time.Time `db:"finished_at"`
+	PagesFetched          int        `db:"pages_fetched"`
+	PagesAdded            int        `db:"pages_added"`
+	PagesChanged          int        `db:"pages_changed"`
+	PagesRemoved          int        `db:"pages_removed"`
+	PagesUnchanged        int        `db:"pages_unchanged"`
+	ChunksWritten         int        `db:"chunks_written"`
+	BytesFetched          int64      `db:"bytes_fetched"`
+	Error                 *string    `db:"error"`
+}
+
+// CrawlRunOutput is what a finished crawl attempt produced.
+type CrawlRunOutput struct {
+	Pages         CrawlPageCounts
+	PagesFetched  int
+	ChunksWritten int
+	BytesFetched  int64
+}
+
+type CrawlRunRepository struct {
+	db *Database
+}
+
+func NewCrawlRunRepository(db *Database) *CrawlRunRepository {
+	return &CrawlRunRepository{db: db}
+}
+
+// Start inserts a running attempt.
+func (r *CrawlRunRepository) Start(ctx context.Context, run *CrawlRun) error {
+	if run.ID == uuid.Nil {
+		run.ID = uuid.New()
+	}
+	if run.StartedAt.IsZero() {
+		run.StartedAt = time.Now().UTC()
+	}
+	run.HeartbeatAt = run.StartedAt
+	query := `
+		INSERT INTO crawl_runs (
+			id, job_id, attempt, schedule_id, chatbot_id, shared_knowledge_base_id, root_url,
+			trigger, status, worker_id, started_at, heartbeat_at
+		) VALUES (
+			:id, :job_id, :attempt, :schedule_id, :chatbot_id, :shared_knowledge_base_id, :root_url,
+			:trigger, :status, :worker_id, :started_at, :heartbeat_at
+		)
+	`
+	if _, err := r.db.NamedExecContext(ctx, query, run); err != nil {
+		return apperrors.Wrap(err, "failed to record crawl run")
+	}
+	return nil
+}
+
+// Heartbeat records that the worker of a running attempt is still at it.
+func (r *CrawlRunRepository) Heartbeat(ctx context.Context, id uuid.UUID) error {
+	if _, err := r.db.ExecContext(ctx, `UPDATE crawl_runs SET heartbeat_at = NOW() WHERE id = $1 AND status = 'running'`, id); err != nil {
+		return apperrors.Wrap(err, "failed to record crawl run heartbeat")
+	}
+	return nil
+}
+
+// Finish records the outcome of an attempt. output is only set for completed attempts.
+func (r *CrawlRunRepository) Finish(ctx context.Context, id uuid.UUID, status string, output *CrawlRunOutput, errMsg *string) error {
+	var out CrawlRunOutput
+	if output != nil {
+		out = *output
+	}
+	query := `
+		UPDATE crawl_runs
+		SET status = $2, finished_at = NOW(), error = $3,
+		    pages_fetched = $4, pages_added = $5, pages_changed = $6, pages_removed = $7, pages_unchanged = $8,
+		    chunks_written = $9, bytes_fetched = $10
+		WHERE id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, id, status, errMsg,
+		out.PagesFetched, out.Pages.Added, out.Pages.Changed, out.Pages.Removed, out.Pages.Unchanged,
+		out.ChunksWritten, out.BytesFetched); err != nil {
+		return apperrors.Wrap(err, "failed to finish crawl run")
+	}
+	return nil
+}
+
+// ListBySource returns the most recent attempts for a chatbot or shared knowledge base + URL,
+// newest first. before, when set, continues a previous page.
+func (r *CrawlRunRepository) ListBySource(ctx context.Context, chatbotID *uuid.UUID, sharedID *uuid.UUID, rootURL string, before *time.Time, limit int) ([]*CrawlRun, error) {
+	query := `
+		SELECT * FROM crawl_runs
+		WHERE root_url = $1
+		  AND (
+		      (chatbot_id = $2 AND $2 IS NOT NULL)
+		   OR (shared_knowledge_base_id = $3 AND $3 IS NOT NULL)
+		  )
+		  AND ($4::timestamptz IS NULL OR started_at < $4)
+		ORDER BY started_at DESC
+		LIMIT $5
+	`
+	var results []*CrawlRun
+	if err := r.db.SelectContext(ctx, &results, query, rootURL, chatbotID, sharedID, before, limit); err != nil {
+		return nil, apperrors.Wrap(err, "failed to list crawl runs")
+	}
+	return results, nil
+}
+
+// AbandonStale marks running attempts without a heartbeat since cutoff as abandoned; their worker
+// stopped without reporting back. Long crawls whose worker keeps reporting are left running.
+func (r *CrawlRunRepository) AbandonStale(ctx context.Context, cutoff time.Time) (int64, error) {
+	query := `
+		UPDATE crawl_runs
+		SET status = 'abandoned', finished_at = NOW(), error = 'worker stopped reporting'
+		WHERE status = 'running' AND heartbeat_at < $1
+	`
+	res, err := r.db.ExecContext(ctx, query, cutoff)
+	if err != nil {
+		return 0, apperrors.Wrap(err, "failed to abandon stale crawl runs")
+	}
+	return res.RowsAffected()
+}
+
+// DeleteBefore removes attempts started before cutoff.
+func (r *CrawlRunRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM crawl_runs WHERE started_at < $1`, cutoff)
+	if err != nil {
+		return 0, apperrors.Wrap(err, "failed to delete old crawl runs")
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pressly/goose/v3"
+	"github.com/yourusername/vectorchat/pkg/jobs"
+)
+
+// openTestDatabase connects to the database named by TEST_PG_CONNECTION, which needs the pgvector
+// extension, and migrates it. Tests using it are skipped when the variable is unset.
+func openTestDatabase(t *testing.T) *Database {
+	t.Helper()
+	connStr := os.Getenv("TEST_PG_CONNECTION")
+	if connStr == "" {
+		t.Skip("TEST_PG_CONNECTION is not set")
+	}
+	database, err := NewDatabase(connStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if err := goose.SetDialect("postgres"); err != nil {
+		t.Fatal(err)
+	}
+	if err := goose.Up(database.DB.DB, "migrations"); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	return database
+}
+
+// createTestKnowledgeBase adds a user owning a shared knowledge base; both are removed after the test.
+func createTestKnowledgeBase(t *testing.T, database *Database) uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+	userID := "test-" + uuid.NewString()
+	if _, err := database.ExecContext(ctx, `
+		INSERT INTO users (id, name, email, provider, created_at, updated_at)
+		VALUES ($1, 'Test', $2, 'test', NOW(), NOW())
+	`, userID, userID+"@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_, _ = database.ExecContext(context.Background(), `DELETE FROM users WHERE id = $1`, userID)
+	})
+	var kbID uuid.UUID
+	if err := database.GetContext(ctx, &kbID, `
+		INSERT INTO shared_knowledge_bases (owner_id, name) VALUES ($1, 'Test') RETURNING id
+	`, userID); err != nil {
+		t.Fatal(err)
+	}
+	return kbID
+}
+
+func TestCrawlRunRepositoryLifecycle(t *testing.T) {
+	database := openTestDatabase(t)
+	kbID := createTestKnowledgeBase(t, database)
+	repo := NewCrawlRunRepository(database)
+	ctx := context.Background()
+	rootURL := "https://example.com/" + uuid.NewString()
+	now := time.Now().UTC()
+
+	start := func(startedAt time.Time) *CrawlRun {
+		run := &CrawlRun{
+			JobID:                 uuid.New(),
+			Attempt:               1,
+			SharedKnowledgeBaseID: &kbID,
+			RootURL:               rootURL,
+			Trigger:               jobs.CrawlTriggerCron,
+			Status:                jobs.CrawlRunRunning,
+			WorkerID:              "crawl-worker-test",
+			StartedAt:             startedAt,
+		}
+		if err := repo.Start(ctx, run); err != nil {
+			t.Fatal(err)
+		}
+		return run
+	}
+	// A long crawl whose worker keeps reporting, one whose worker went quiet and a finished one
+	live := start(now.Add(-3 * time.Hour))
+	if err := repo.Heartbeat(ctx, live.ID); err != nil {
+		t.Fatal(err)
+	}
+	quiet := start(now.Add(-10 * time.Minute))
+	done := start(now.Add(-time.Hour))
+	if err := repo.Finish(ctx, done.ID, jobs.CrawlRunCompleted, &CrawlRunOutput{
+		Pages:         CrawlPageCounts{Added: 3, Changed: 2, Removed: 1, Unchanged: 6},
+		PagesFetched:  12,
+		ChunksWritten: 40,
+		BytesFetched:  2048,
+	}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := repo.AbandonStale(ctx, now.Add(-jobs.CrawlRunStaleAfter)); err != nil {
+		t.Fatal(err)
+	} else if n < 1 {
+		t.Fatalf("abandoned %d runs, want the quiet one", n)
+	}
+
+	runs, err := repo.ListBySource(ctx, nil, &kbID, rootURL, nil, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 3 || runs[0].ID != quiet.ID || runs[1].ID != done.ID || runs[2].ID != live.ID {
+		t.Fatalf("runs are not listed newest first: %+v", runs)
+	}
+	if runs[0].Status != jobs.CrawlRunAbandoned || runs[0].FinishedAt == nil {
+		t.Errorf("quiet run = %s, want abandoned", runs[0].Status)
+	}
+	if got := runs[1]; got.Status != jobs.CrawlRunCompleted || got.PagesFetched != 12 || got.PagesAdded != 3 ||
+		got.PagesChanged != 2 || got.PagesRemoved != 1 || got.PagesUnchanged != 6 || got.ChunksWritten != 40 || got.BytesFetched != 2048 {
+		t.Errorf("finished run = %+v", got)
+	}
+	if runs[2].Status != jobs.CrawlRunRunning {
+		t.Errorf("run with a recent heartbeat = %s, want still running", runs[2].Status)
+	}
+
+	older, err := repo.ListBySource(ctx, nil, &kbID, rootURL, &runs[1].StartedAt, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(older) != 1 || older[0].ID != live.ID {
+		t.Fatalf("page before the finished run = %+v, want only the oldest run", older)
+	}
+
+	if _, err := repo.DeleteBefore(ctx, now.Add(-2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if runs, err = repo.ListBySource(ctx, nil, &kbID, rootURL, nil, 10); err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("%d runs left after pruning, want 2", len(runs))
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	apperrors "github.com/yourusername/vectorchat/internal/errors"
 )
 
@@ -22,10 +23,28 @@ type CrawlSchedule struct {
 	NextRunAt              *time.Time `db:"next_run_at"`
 	LastStatus             *string    `db:"last_status"`
 	LastError              *string    `db:"last_error"`
+	LastPagesAdded         *int       `db:"last_pages_added"`
+	LastPagesChanged       *int       `db:"last_pages_changed"`
+	LastPagesRemoved       *int       `db:"last_pages_removed"`
+	LastPagesUnchanged     *int       `db:"last_pages_unchanged"`
+	MaxPages               int            `db:"max_pages"`
+	MaxDepth               int            `db:"max_depth"`
+	IncludePatterns        pq.StringArray `db:"include_patterns"`
+	ExcludePatterns        pq.StringArray `db:"exclude_patterns"`
+	PathPrefix             *string        `db:"path_prefix"`
+	SitemapOnly            bool           `db:"sitemap_only"`
 	CreatedAt              time.Time  `db:"created_at"`
 	UpdatedAt              time.Time  `db:"updated_at"`
 }
 
+// CrawlPageCounts summarises how a completed crawl changed the website source.
+type CrawlPageCounts struct {
+	Added     int
+	Changed   int
+	Removed   int
+	Unchanged int
+}
+
 type CrawlScheduleRepository struct {
 	db *Database
 }
@@ -50,10 +69,14 @@ func (r *CrawlScheduleRepository) Upsert(ctx context.Context, s *CrawlSchedule)
 		query = `
 			INSERT INTO crawl_schedules (
 				id, chatbot_id, shared_knowledge_base_id, root_url, cron_expr, timezone,
-				enabled, last_run_at, next_run_at, last_status, last_error, created_at, updated_at
+				enabled, last_run_at, next_run_at, last_status, last_error,
+				max_pages, max_depth, include_patterns, exclude_patterns, path_prefix, sitemap_only,
+				created_at, updated_at
 			) VALUES (
 				:id, :chatbot_id, :shared_knowledge_base_id, :root_url, :cron_expr, :timezone,
-				:enabled, :last_run_at, :next_run_at, :last_status, :last_error, :created_at, :updated_at
+				:enabled, :last_run_at, :next_run_at, :last_status, :last_error,
+				:max_pages, :max_depth, :include_patterns, :exclude_patterns, :path_prefix, :sitemap_only,
+				:created_at, :updated_at
 			)
 			ON CONFLICT (chatbot_id, root_url) DO UPDATE
 				SET cron_expr = EXCLUDED.cron_expr,
@@ -62,18 +85,27 @@ func (r *CrawlScheduleRepository) Upsert(ctx context.Context, s *CrawlSchedule)
 					next_run_at = EXCLUDED.next_run_at,
 					last_status = EXCLUDED.last_status,
 					last_error = EXCLUDED.last_error,
+					max_pages = EXCLUDED.max_pages,
+					max_depth = EXCLUDED.max_depth,
+					include_patterns = EXCLUDED.include_patterns,
+					exclude_patterns = EXCLUDED.exclude_patterns,
+					path_prefix = EXCLUDED.path_prefix,
+					sitemap_only = EXCLUDED.sitemap_only,
 					updated_at = EXCLUDED.updated_at
-			RETURNING id, chatbot_id, shared_knowledge_base_id, root_url, cron_expr, timezone, enabled,
-			          last_run_at, next_run_at, last_status, last_error, created_at, updated_at
+			RETURNING *
 		`
 	} else {
 		query = `
 			INSERT INTO crawl_schedules (
 				id, chatbot_id, shared_knowledge_base_id, root_url, cron_expr, timezone,
-				enabled, last_run_at, next_run_at, last_status, last_error, created_at, updated_at
+				enabled, last_run_at, next_run_at, last_status, last_error,
+				max_pages, max_depth, include_patterns, exclude_patterns, path_prefix, sitemap_only,
+				created_at, updated_at
 			) VALUES (
 				:id, :chatbot_id, :shared_knowledge_base_id, :root_url, :cron_expr, :timezone,
-				:enabled, :last_run_at, :next_run_at, :last_status, :last_error, :created_at, :updated_at
+				:enabled, :last_run_at, :next_run_at, :last_status, :last_error,
+				:max_pages, :max_depth, :include_patterns, :exclude_patterns, :path_prefix, :sitemap_only,
+				:created_at, :updated_at
 			)
 			ON CONFLICT (shared_knowledge_base_id, root_url) DO UPDATE
 				SET cron_expr = EXCLUDED.cron_expr,
@@ -82,9 +114,14 @@ func (r *CrawlScheduleRepository) Upsert(ctx context.Context, s *CrawlSchedule)
 					next_run_at = EXCLUDED.next_run_at,
 					last_status = EXCLUDED.last_status,
 					last_error = EXCLUDED.last_error,
+					max_pages = EXCLUDED.max_pages,
+					max_depth = EXCLUDED.max_depth,
+					include_patterns = EXCLUDED.include_patterns,
+					exclude_patterns = EXCLUDED.exclude_patterns,
+					path_prefix = EXCLUDED.path_prefix,
+					sitemap_only = EXCLUDED.sitemap_only,
 					updated_at = EXCLUDED.updated_at
-			RETURNING id, chatbot_id, shared_knowledge_base_id, root_url, cron_expr, timezone, enabled,
-			          last_run_at, next_run_at, last_status, last_error, created_at, updated_at
+			RETURNING *
 		`
 	}
 
@@ -164,17 +201,28 @@ func (r *CrawlScheduleRepository) ListActive(ctx context.Context) ([]*CrawlSched
 }
 
 // UpdateRunInfo updates execution metadata after a run attempt.
-func (r *CrawlScheduleRepository) UpdateRunInfo(ctx context.Context, id uuid.UUID, lastRun, nextRun *time.Time, status, errMsg *string) error {
+// Page counts are only replaced when pages is set, so failed runs keep the last successful counts.
+// nextRun is likewise kept when nil, for callers that do not know the schedule's cron expression.
+// updated_at is left alone: it marks changes to the schedule itself, which the scheduler watches
+// to re-register the job.
+func (r *CrawlScheduleRepository) UpdateRunInfo(ctx context.Context, id uuid.UUID, lastRun, nextRun *time.Time, status, errMsg *string, pages *CrawlPageCounts) error {
+	var added, changed, removed, unchanged *int
+	if pages != nil {
+		added, changed, removed, unchanged = &pages.Added, &pages.Changed, &pages.Removed, &pages.Unchanged
+	}
 	query := `
 		UPDATE crawl_schedules
 		SET last_run_at = $1,
-		    next_run_at = $2,
+		    next_run_at = COALESCE($2, next_run_at),
 		    last_status = $3,
 		    last_error = $4,
-		    updated_at = NOW()
+		    last_pages_added = COALESCE($6, last_pages_added),
+		    last_pages_changed = COALESCE($7, last_pages_changed),
+		    last_pages_removed = COALESCE($8, last_pages_removed),
+		    last_pages_unchanged = COALESCE($9, last_pages_unchanged)
 		WHERE id = $5
 	`
-	if _, err := r.db.ExecContext(ctx, query, lastRun, nextRun, status, errMsg, id); err != nil {
+	if _, err := r.db.ExecContext(ctx, query, lastRun, nextRun, status, errMsg, id, added, changed, removed, unchanged); err != nil {
 		return apperrors.Wrap(err, "failed to update crawl schedule run info")
 	}
 	return nil
@@ -36,6 +36,26 @@ func NewDatabase(connStr string) (*Database, error) {
 	return &Database{DB: db}, nil
 }
 
+// VectorDimensions returns the declared dimension of a vector column, or 0 when it is unconstrained
+func (db *Database) VectorDimensions(ctx context.Context, table, column string) (int, error) {
+	var dims int
+	query := `
+		SELECT atttypmod
+		FROM pg_attribute
+		WHERE attrelid = $1::regclass AND attname = $2
+	`
+
+	err := db.GetContext(ctx, &dims, query, table, column)
+	if err != nil {
+		return 0, apperrors.Wrapf(err, "failed to read %s.%s dimension", table, column)
+	}
+	if dims < 0 {
+		return 0, nil
+	}
+
+	return dims, nil
+}
+
 // Close closes the database connection
 func (db *Database) Close() error {
 	return db.DB.Close()
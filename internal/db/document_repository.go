@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -9,6 +10,67 @@ import (
 	apperrors "github.com/yourusername/vectorchat/internal/errors"
 )
 
+const (
+	// hybridRRFK is the rank constant k in the reciprocal rank fusion score 1/(k+rank)
+	hybridRRFK = 60
+	// hybridCandidateMultiplier controls how many candidates each ranker contributes per requested result
+	hybridCandidateMultiplier = 4
+)
+
+// hybridSearchQuery fuses a vector ranking and a full-text ranking with reciprocal rank fusion.
+// The scope predicate is injected with fmt.Sprintf and must reference $1.
+// Query terms are OR-ed so a single matching product code or error string is enough to rank a chunk.
+// The similarity cutoff only applies to the vector ranker so exact keyword hits are never dropped.
+// Args: $1 scope, $2 embedding, $3 query text, $4 candidates per ranker, $5 RRF k, $6 limit, $7 min similarity.
+const hybridSearchQuery = `
+	WITH vector_ranked AS (
+		SELECT id, ROW_NUMBER() OVER (ORDER BY embedding <=> $2) AS rank
+		FROM documents
+		WHERE %[1]s AND 1 - (embedding <=> $2) >= $7
+		ORDER BY embedding <=> $2
+		LIMIT $4
+	),
+	keyword_query AS (
+		-- each lexeme is quoted so tsquery operators in the question (: ! & ( ') are matched as text
+		SELECT to_tsquery('simple', string_agg('''' || replace(replace(lexeme, '\', '\\'), '''', '''''') || '''', ' | ')) AS q
+		FROM unnest(tsvector_to_array(to_tsvector('simple', $3))) AS lexeme
+	),
+	keyword_ranked AS (
+		SELECT d.id, ROW_NUMBER() OVER (ORDER BY ts_rank_cd(d.content_tsv, kq.q) DESC) AS rank
+		FROM documents d, keyword_query kq
+		WHERE %[1]s AND d.content_tsv @@ kq.q
+		ORDER BY ts_rank_cd(d.content_tsv, kq.q) DESC
+		LIMIT $4
+	),
+	fused AS (
+		SELECT id, SUM(1.0 / ($5 + rank)) AS score
+		FROM (
+			SELECT id, rank FROM vector_ranked
+			UNION ALL
+			SELECT id, rank FROM keyword_ranked
+		) ranked
+		GROUP BY id
+	)
+	SELECT d.id, d.content, d.embedding, d.chatbot_id, d.shared_knowledge_base_id, d.file_id, d.chunk_index, d.source_url,
+	       1 - (d.embedding <=> $2) AS similarity
+	FROM fused f
+	JOIN documents d ON d.id = f.id
+	ORDER BY f.score DESC
+	LIMIT $6
+`
+
+// scoredDocument is a document row returned together with its cosine similarity to the query
+type scoredDocument struct {
+	Document
+	Similarity float64 `db:"similarity"`
+}
+
+func (d *scoredDocument) toDocumentWithEmbedding() *DocumentWithEmbedding {
+	result := d.ToDocumentWithEmbedding()
+	result.Similarity = d.Similarity
+	return result
+}
+
 type DocumentRepository struct {
 	db *Database
 }
@@ -20,18 +82,21 @@ func NewDocumentRepository(db *Database) *DocumentRepository {
 // Store stores a document with its vector embedding
 func (r *DocumentRepository) Store(ctx context.Context, doc *Document) error {
 	query := `
-		INSERT INTO documents (id, content, embedding, chatbot_id, shared_knowledge_base_id, file_id, chunk_index)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO documents (id, content, embedding, chatbot_id, shared_knowledge_base_id, file_id, chunk_index, source_url, embedding_model, embedding_dimensions)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		ON CONFLICT (id) DO UPDATE
 		SET content = $2,
 		    embedding = $3,
 		    chatbot_id = $4,
 		    shared_knowledge_base_id = $5,
 		    file_id = $6,
-		    chunk_index = $7
+		    chunk_index = $7,
+		    source_url = $8,
+		    embedding_model = $9,
+		    embedding_dimensions = $10
 	`
 
-	_, err := r.db.ExecContext(ctx, query, doc.ID, doc.Content, doc.Embedding, doc.ChatbotID, doc.SharedKnowledgeBaseID, doc.FileID, doc.ChunkIndex)
+	_, err := r.db.ExecContext(ctx, query, doc.ID, doc.Content, doc.Embedding, doc.ChatbotID, doc.SharedKnowledgeBaseID, doc.FileID, doc.ChunkIndex, doc.SourceURL, doc.EmbeddingModel, doc.EmbeddingDimensions)
 	if err != nil {
 		return apperrors.Wrap(err, "failed to store document")
 	}
@@ -42,18 +107,21 @@ func (r *DocumentRepository) Store(ctx context.Context, doc *Document) error {
 // StoreWithEmbedding stores a document with embedding as float32 slice
 func (r *DocumentRepository) StoreWithEmbedding(ctx context.Context, doc *DocumentWithEmbedding) error {
 	query := `
-		INSERT INTO documents (id, content, embedding, chatbot_id, shared_knowledge_base_id, file_id, chunk_index)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO documents (id, content, embedding, chatbot_id, shared_knowledge_base_id, file_id, chunk_index, source_url, embedding_model, embedding_dimensions)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		ON CONFLICT (id) DO UPDATE
 		SET content = $2,
 		    embedding = $3,
 		    chatbot_id = $4,
 		    shared_knowledge_base_id = $5,
 		    file_id = $6,
-		    chunk_index = $7
+		    chunk_index = $7,
+		    source_url = $8,
+		    embedding_model = $9,
+		    embedding_dimensions = $10
 	`
 
-	_, err := r.db.ExecContext(ctx, query, doc.ID, doc.Content, pgvector.NewVector(doc.Embedding), doc.ChatbotID, doc.SharedKnowledgeBaseID, doc.FileID, doc.ChunkIndex)
+	_, err := r.db.ExecContext(ctx, query, doc.ID, doc.Content, pgvector.NewVector(doc.Embedding), doc.ChatbotID, doc.SharedKnowledgeBaseID, doc.FileID, doc.ChunkIndex, doc.SourceURL, doc.EmbeddingModel, embeddingDimensions(doc.Embedding))
 	if err != nil {
 		return apperrors.Wrap(err, "failed to store document with embedding")
 	}
@@ -64,18 +132,21 @@ func (r *DocumentRepository) StoreWithEmbedding(ctx context.Context, doc *Docume
 // StoreTx stores a document within a transaction
 func (r *DocumentRepository) StoreTx(ctx context.Context, tx *Transaction, doc *Document) error {
 	query := `
-		INSERT INTO documents (id, content, embedding, chatbot_id, shared_knowledge_base_id, file_id, chunk_index)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO documents (id, content, embedding, chatbot_id, shared_knowledge_base_id, file_id, chunk_index, source_url, embedding_model, embedding_dimensions)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		ON CONFLICT (id) DO UPDATE
 		SET content = $2,
 		    embedding = $3,
 		    chatbot_id = $4,
 		    shared_knowledge_base_id = $5,
 		    file_id = $6,
-		    chunk_index = $7
+		    chunk_index = $7,
+		    source_url = $8,
+		    embedding_model = $9,
+		    embedding_dimensions = $10
 	`
 
-	_, err := tx.ExecContext(ctx, query, doc.ID, doc.Content, doc.Embedding, doc.ChatbotID, doc.SharedKnowledgeBaseID, doc.FileID, doc.ChunkIndex)
+	_, err := tx.ExecContext(ctx, query, doc.ID, doc.Content, doc.Embedding, doc.ChatbotID, doc.SharedKnowledgeBaseID, doc.FileID, doc.ChunkIndex, doc.SourceURL, doc.EmbeddingModel, doc.EmbeddingDimensions)
 	if err != nil {
 		return apperrors.Wrap(err, "failed to store document")
 	}
@@ -86,18 +157,21 @@ func (r *DocumentRepository) StoreTx(ctx context.Context, tx *Transaction, doc *
 // StoreWithEmbeddingTx stores a document with embedding within a transaction
 func (r *DocumentRepository) StoreWithEmbeddingTx(ctx context.Context, tx *Transaction, doc *DocumentWithEmbedding) error {
 	query := `
-		INSERT INTO documents (id, content, embedding, chatbot_id, shared_knowledge_base_id, file_id, chunk_index)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO documents (id, content, embedding, chatbot_id, shared_knowledge_base_id, file_id, chunk_index, source_url, embedding_model, embedding_dimensions)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		ON CONFLICT (id) DO UPDATE
 		SET content = $2,
 		    embedding = $3,
 		    chatbot_id = $4,
 		    shared_knowledge_base_id = $5,
 		    file_id = $6,
-		    chunk_index = $7
+		    chunk_index = $7,
+		    source_url = $8,
+		    embedding_model = $9,
+		    embedding_dimensions = $10
 	`
 
-	_, err := tx.ExecContext(ctx, query, doc.ID, doc.Content, pgvector.NewVector(doc.Embedding), doc.ChatbotID, doc.SharedKnowledgeBaseID, doc.FileID, doc.ChunkIndex)
+	_, err := tx.ExecContext(ctx, query, doc.ID, doc.Content, pgvector.NewVector(doc.Embedding), doc.ChatbotID, doc.SharedKnowledgeBaseID, doc.FileID, doc.ChunkIndex, doc.SourceURL, doc.EmbeddingModel, embeddingDimensions(doc.Embedding))
 	if err != nil {
 		return apperrors.Wrap(err, "failed to store document with embedding")
 	}
@@ -109,7 +183,7 @@ func (r *DocumentRepository) StoreWithEmbeddingTx(ctx context.Context, tx *Trans
 func (r *DocumentRepository) FindByID(ctx context.Context, id string) (*Document, error) {
 	var doc Document
 	query := `
-		SELECT id, content, embedding, chatbot_id, shared_knowledge_base_id, file_id, chunk_index
+		SELECT id, content, embedding, chatbot_id, shared_knowledge_base_id, file_id, chunk_index, source_url
 		FROM documents
 		WHERE id = $1
 	`
@@ -129,7 +203,7 @@ func (r *DocumentRepository) FindByID(ctx context.Context, id string) (*Document
 func (r *DocumentRepository) FindSimilar(ctx context.Context, embedding []float32, limit int) ([]*DocumentWithEmbedding, error) {
 	var docs []*Document
 	query := `
-		SELECT id, content, embedding, chatbot_id, shared_knowledge_base_id, file_id, chunk_index
+		SELECT id, content, embedding, chatbot_id, shared_knowledge_base_id, file_id, chunk_index, source_url
 		FROM documents
 		ORDER BY embedding <=> $1
 		LIMIT $2
@@ -149,64 +223,107 @@ func (r *DocumentRepository) FindSimilar(ctx context.Context, embedding []float3
 	return result, nil
 }
 
-// FindSimilarByChatbot finds documents similar to the given embedding for a specific chatbot
-func (r *DocumentRepository) FindSimilarByChatbot(ctx context.Context, embedding []float32, chatbotID uuid.UUID, limit int) ([]*DocumentWithEmbedding, error) {
-	var docs []*Document
+// FindSimilarByChatbot finds documents similar to the embedding for a specific chatbot.
+// Documents below minSimilarity (cosine similarity) are skipped.
+func (r *DocumentRepository) FindSimilarByChatbot(ctx context.Context, embedding []float32, chatbotID uuid.UUID, limit int, minSimilarity float64) ([]*DocumentWithEmbedding, error) {
+	var docs []*scoredDocument
 	query := `
-		SELECT id, content, embedding, chatbot_id, shared_knowledge_base_id, file_id, chunk_index
+		SELECT id, content, embedding, chatbot_id, shared_knowledge_base_id, file_id, chunk_index, source_url,
+		       1 - (embedding <=> $2) AS similarity
 		FROM documents
 		WHERE chatbot_id = $1
+			AND 1 - (embedding <=> $2) >= $4
 		ORDER BY embedding <=> $2
 		LIMIT $3
 	`
 
-	err := r.db.SelectContext(ctx, &docs, query, chatbotID, pgvector.NewVector(embedding), limit)
+	err := r.db.SelectContext(ctx, &docs, query, chatbotID, pgvector.NewVector(embedding), limit, minSimilarity)
 	if err != nil {
 		return nil, apperrors.Wrap(err, "failed to find similar documents by chatbot")
 	}
 
-	// Convert to DocumentWithEmbedding
-	result := make([]*DocumentWithEmbedding, len(docs))
-	for i, doc := range docs {
-		result[i] = doc.ToDocumentWithEmbedding()
-	}
-
-	return result, nil
+	return toScoredDocuments(docs), nil
 }
 
-// FindSimilarBySharedKnowledgeBases finds documents similar to the embedding across shared KBs
-func (r *DocumentRepository) FindSimilarBySharedKnowledgeBases(ctx context.Context, embedding []float32, kbIDs []uuid.UUID, limit int) ([]*DocumentWithEmbedding, error) {
+// FindSimilarBySharedKnowledgeBases finds documents similar to the embedding across shared KBs.
+// Documents below minSimilarity (cosine similarity) are skipped.
+func (r *DocumentRepository) FindSimilarBySharedKnowledgeBases(ctx context.Context, embedding []float32, kbIDs []uuid.UUID, limit int, minSimilarity float64) ([]*DocumentWithEmbedding, error) {
 	if len(kbIDs) == 0 {
 		return []*DocumentWithEmbedding{}, nil
 	}
 
-	var docs []*Document
+	var docs []*scoredDocument
 	query := `
-		SELECT id, content, embedding, chatbot_id, shared_knowledge_base_id, file_id, chunk_index
+		SELECT id, content, embedding, chatbot_id, shared_knowledge_base_id, file_id, chunk_index, source_url,
+		       1 - (embedding <=> $2) AS similarity
 		FROM documents
 		WHERE shared_knowledge_base_id = ANY($1)
+			AND 1 - (embedding <=> $2) >= $4
 		ORDER BY embedding <=> $2
 		LIMIT $3
 	`
 
-	err := r.db.SelectContext(ctx, &docs, query, pq.Array(kbIDs), pgvector.NewVector(embedding), limit)
+	err := r.db.SelectContext(ctx, &docs, query, pq.Array(kbIDs), pgvector.NewVector(embedding), limit, minSimilarity)
 	if err != nil {
 		return nil, apperrors.Wrap(err, "failed to find similar documents by shared knowledge bases")
 	}
 
+	return toScoredDocuments(docs), nil
+}
+
+// FindHybridByChatbot ranks a chatbot's documents by fusing full-text and vector rankings
+func (r *DocumentRepository) FindHybridByChatbot(ctx context.Context, embedding []float32, queryText string, chatbotID uuid.UUID, limit int, minSimilarity float64) ([]*DocumentWithEmbedding, error) {
+	query := fmt.Sprintf(hybridSearchQuery, "chatbot_id = $1")
+
+	var docs []*scoredDocument
+	err := r.db.SelectContext(ctx, &docs, query, chatbotID, pgvector.NewVector(embedding), queryText, limit*hybridCandidateMultiplier, hybridRRFK, limit, minSimilarity)
+	if err != nil {
+		return nil, apperrors.Wrap(err, "failed to find hybrid documents by chatbot")
+	}
+
+	return toScoredDocuments(docs), nil
+}
+
+// FindHybridBySharedKnowledgeBases ranks documents across shared KBs by fusing full-text and vector rankings
+func (r *DocumentRepository) FindHybridBySharedKnowledgeBases(ctx context.Context, embedding []float32, queryText string, kbIDs []uuid.UUID, limit int, minSimilarity float64) ([]*DocumentWithEmbedding, error) {
+	if len(kbIDs) == 0 {
+		return []*DocumentWithEmbedding{}, nil
+	}
+
+	query := fmt.Sprintf(hybridSearchQuery, "shared_knowledge_base_id = ANY($1)")
+
+	var docs []*scoredDocument
+	err := r.db.SelectContext(ctx, &docs, query, pq.Array(kbIDs), pgvector.NewVector(embedding), queryText, limit*hybridCandidateMultiplier, hybridRRFK, limit, minSimilarity)
+	if err != nil {
+		return nil, apperrors.Wrap(err, "failed to find hybrid documents by shared knowledge bases")
+	}
+
+	return toScoredDocuments(docs), nil
+}
+
+// embeddingDimensions is the dimension recorded alongside a stored vector
+func embeddingDimensions(embedding []float32) *int {
+	if len(embedding) == 0 {
+		return nil
+	}
+	dims := len(embedding)
+	return &dims
+}
+
+func toScoredDocuments(docs []*scoredDocument) []*DocumentWithEmbedding {
 	result := make([]*DocumentWithEmbedding, len(docs))
 	for i, doc := range docs {
-		result[i] = doc.ToDocumentWithEmbedding()
+		result[i] = doc.toDocumentWithEmbedding()
 	}
-
-	return result, nil
+	return result
 }
 
 // FindByChatbotID finds all documents for a chatbot
 func (r *DocumentRepository) FindByChatbotID(ctx context.Context, chatbotID uuid.UUID) ([]*Document, error) {
 	var docs []*Document
 	query := `
-		SELECT id, content, embedding, chatbot_id, shared_knowledge_base_id, file_id, chunk_index
+		SELECT id, content, embedding, chatbot_id, shared_knowledge_base_id, file_id, chunk_index, source_url,
+		       embedding_model, embedding_dimensions
 		FROM documents
 		WHERE chatbot_id = $1
 		ORDER BY id
@@ -224,7 +341,8 @@ func (r *DocumentRepository) FindByChatbotID(ctx context.Context, chatbotID uuid
 func (r *DocumentRepository) FindBySharedKnowledgeBaseID(ctx context.Context, kbID uuid.UUID) ([]*Document, error) {
 	var docs []*Document
 	query := `
-		SELECT id, content, embedding, chatbot_id, shared_knowledge_base_id, file_id, chunk_index
+		SELECT id, content, embedding, chatbot_id, shared_knowledge_base_id, file_id, chunk_index, source_url,
+		       embedding_model, embedding_dimensions
 		FROM documents
 		WHERE shared_knowledge_base_id = $1
 		ORDER BY id
@@ -242,7 +360,7 @@ func (r *DocumentRepository) FindBySharedKnowledgeBaseID(ctx context.Context, kb
 func (r *DocumentRepository) FindByFileID(ctx context.Context, fileID uuid.UUID) ([]*Document, error) {
 	var docs []*Document
 	query := `
-		SELECT id, content, embedding, chatbot_id, shared_knowledge_base_id, file_id, chunk_index
+		SELECT id, content, embedding, chatbot_id, shared_knowledge_base_id, file_id, chunk_index, source_url
 		FROM documents
 		WHERE file_id = $1
 		ORDER BY chunk_index NULLS LAST, id
@@ -370,6 +488,18 @@ func (r *DocumentRepository) DeleteByFileIDTx(ctx context.Context, tx *Transacti
 	return nil
 }
 
+// DeleteByFileIDAndSourceURLTx deletes the chunks of one crawled page within a transaction
+func (r *DocumentRepository) DeleteByFileIDAndSourceURLTx(ctx context.Context, tx *Transaction, fileID uuid.UUID, sourceURL string) error {
+	query := `DELETE FROM documents WHERE file_id = $1 AND source_url = $2`
+
+	_, err := tx.ExecContext(ctx, query, fileID, sourceURL)
+	if err != nil {
+		return apperrors.Wrap(err, "failed to delete documents by file ID and source URL")
+	}
+
+	return nil
+}
+
 // DeleteByConnectorID is a no-op placeholder kept for compatibility with connector cleanup flows.
 func (r *DocumentRepository) DeleteByConnectorID(_ context.Context, _ uuid.UUID) error {
 	return nil
@@ -400,3 +530,143 @@ func (r *DocumentRepository) CountByChatbotID(ctx context.Context, chatbotID uui
 
 	return count, nil
 }
+
+// EmbeddingDimensions returns the declared dimension of documents.embedding, or 0 when it is unconstrained
+func (r *DocumentRepository) EmbeddingDimensions(ctx context.Context) (int, error) {
+	return r.db.VectorDimensions(ctx, "documents", "embedding")
+}
+
+// StoreShadowEmbedding records a re-embedded vector for a document under a running embedding migration.
+// The content hash lets the cutover skip documents whose content changed after they were re-embedded.
+func (r *DocumentRepository) StoreShadowEmbedding(ctx context.Context, migrationID uuid.UUID, doc *Document, embedding []float32, model string) error {
+	query := `
+		INSERT INTO document_embedding_shadows (migration_id, document_id, embedding, embedding_model, embedding_dimensions, content_hash)
+		VALUES ($1, $2, $3, $4, $5, md5($6::text))
+		ON CONFLICT (migration_id, document_id) DO UPDATE
+		SET embedding = $3,
+		    embedding_model = $4,
+		    embedding_dimensions = $5,
+		    content_hash = md5($6::text),
+		    created_at = NOW()
+	`
+
+	_, err := r.db.ExecContext(ctx, query, migrationID, doc.ID, pgvector.NewVector(embedding), model, len(embedding), doc.Content)
+	if err != nil {
+		return apperrors.Wrap(err, "failed to store shadow embedding")
+	}
+
+	return nil
+}
+
+// ApplyShadowEmbeddingsTx swaps the shadow vectors of a migration into documents.embedding and clears them.
+// Documents whose content changed since they were re-embedded keep their current vector.
+func (r *DocumentRepository) ApplyShadowEmbeddingsTx(ctx context.Context, tx *Transaction, migrationID uuid.UUID) (int64, error) {
+	query := `
+		UPDATE documents d
+		SET embedding = s.embedding,
+		    embedding_model = s.embedding_model,
+		    embedding_dimensions = s.embedding_dimensions
+		FROM document_embedding_shadows s
+		WHERE s.migration_id = $1
+			AND s.document_id = d.id
+			AND s.content_hash = md5(d.content)
+	`
+
+	result, err := tx.ExecContext(ctx, query, migrationID)
+	if err != nil {
+		return 0, apperrors.Wrap(err, "failed to apply shadow embeddings")
+	}
+
+	applied, err := result.RowsAffected()
+	if err != nil {
+		return 0, apperrors.Wrap(err, "failed to get rows affected")
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM document_embedding_shadows WHERE migration_id = $1`, migrationID); err != nil {
+		return 0, apperrors.Wrap(err, "failed to clear shadow embeddings")
+	}
+
+	return applied, nil
+}
+
+// documentScope is the predicate selecting the documents of a chatbot or shared knowledge base, bound to $1
+func documentScope(chatbotID, sharedKnowledgeBaseID *uuid.UUID) (string, uuid.UUID) {
+	if chatbotID != nil {
+		return "chatbot_id = $1", *chatbotID
+	}
+	return "shared_knowledge_base_id = $1", *sharedKnowledgeBaseID
+}
+
+// withoutShadowFilter selects the documents of a scope that have no shadow vector for their
+// current content in migration $2
+const withoutShadowFilter = `%s AND NOT EXISTS (
+		SELECT 1 FROM document_embedding_shadows s
+		WHERE s.migration_id = $2 AND s.document_id = d.id AND s.content_hash = md5(d.content)
+	)`
+
+// FindWithoutShadow returns up to limit documents of a knowledge base that still need a shadow
+// vector in the migration, so a re-embed can page through them without loading them all.
+func (r *DocumentRepository) FindWithoutShadow(ctx context.Context, migrationID uuid.UUID, chatbotID, sharedKnowledgeBaseID *uuid.UUID, limit int) ([]*Document, error) {
+	scope, id := documentScope(chatbotID, sharedKnowledgeBaseID)
+	query := `
+		SELECT d.id, d.content, d.chatbot_id, d.shared_knowledge_base_id, d.file_id, d.chunk_index, d.source_url, d.embedding_model
+		FROM documents d
+		WHERE ` + fmt.Sprintf(withoutShadowFilter, scope) + `
+		ORDER BY d.id
+		LIMIT $3
+	`
+
+	var docs []*Document
+	if err := r.db.SelectContext(ctx, &docs, query, id, migrationID, limit); err != nil {
+		return nil, apperrors.Wrap(err, "failed to find documents to re-embed")
+	}
+	return docs, nil
+}
+
+// CountWithoutShadow counts the documents of a knowledge base that still need a shadow vector in the migration
+func (r *DocumentRepository) CountWithoutShadow(ctx context.Context, migrationID uuid.UUID, chatbotID, sharedKnowledgeBaseID *uuid.UUID) (int64, error) {
+	scope, id := documentScope(chatbotID, sharedKnowledgeBaseID)
+	var count int64
+	query := `SELECT COUNT(*) FROM documents d WHERE ` + fmt.Sprintf(withoutShadowFilter, scope)
+	if err := r.db.GetContext(ctx, &count, query, id, migrationID); err != nil {
+		return 0, apperrors.Wrap(err, "failed to count documents to re-embed")
+	}
+	return count, nil
+}
+
+// CountWithoutShadowTx counts the documents still needing a shadow vector within a transaction
+func (r *DocumentRepository) CountWithoutShadowTx(ctx context.Context, tx *Transaction, migrationID uuid.UUID, chatbotID, sharedKnowledgeBaseID *uuid.UUID) (int64, error) {
+	scope, id := documentScope(chatbotID, sharedKnowledgeBaseID)
+	var count int64
+	query := `SELECT COUNT(*) FROM documents d WHERE ` + fmt.Sprintf(withoutShadowFilter, scope)
+	if err := tx.GetContext(ctx, &count, query, id, migrationID); err != nil {
+		return 0, apperrors.Wrap(err, "failed to count documents to re-embed")
+	}
+	return count, nil
+}
+
+// CountStaleByChatbotID returns how many of a chatbot's documents were not embedded with model
+func (r *DocumentRepository) CountStaleByChatbotID(ctx context.Context, chatbotID uuid.UUID, model string) (int64, error) {
+	var count int64
+	query := `SELECT COUNT(*) FROM documents WHERE chatbot_id = $1 AND embedding_model IS DISTINCT FROM $2`
+
+	err := r.db.GetContext(ctx, &count, query, chatbotID, model)
+	if err != nil {
+		return 0, apperrors.Wrap(err, "failed to count stale documents by chatbot ID")
+	}
+
+	return count, nil
+}
+
+// CountStaleBySharedKnowledgeBaseID returns how many of a shared KB's documents were not embedded with model
+func (r *DocumentRepository) CountStaleBySharedKnowledgeBaseID(ctx context.Context, kbID uuid.UUID, model string) (int64, error) {
+	var count int64
+	query := `SELECT COUNT(*) FROM documents WHERE shared_knowledge_base_id = $1 AND embedding_model IS DISTINCT FROM $2`
+
+	err := r.db.GetContext(ctx, &count, query, kbID, model)
+	if err != nil {
+		return 0, apperrors.Wrap(err, "failed to count stale documents by shared knowledge base ID")
+	}
+
+	return count, nil
+}
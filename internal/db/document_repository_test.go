@@ -0,0 +1,159 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestFindHybridBySharedKnowledgeBases(t *testing.T) {
+	database := openTestDatabase(t)
+	kbID := createTestKnowledgeBase(t, database)
+	repo := NewDocumentRepository(database)
+	ctx := context.Background()
+
+	dims, err := repo.EmbeddingDimensions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dims == 0 {
+		dims = 3
+	}
+	vector := func(x, y float32) []float32 {
+		v := make([]float32, dims)
+		v[0], v[1] = x, y
+		return v
+	}
+	store := func(content string, embedding []float32) string {
+		doc := &DocumentWithEmbedding{
+			ID:                    uuid.NewString(),
+			Content:               []byte(content),
+			Embedding:             embedding,
+			SharedKnowledgeBaseID: &kbID,
+		}
+		if err := repo.StoreWithEmbedding(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+		return doc.ID
+	}
+	// keywordOnly is far from the query vector, vectorOnly shares no terms with the question and
+	// both is found by both rankers
+	keywordOnly := store("Error ERR:42 means the upload failed, retry it's fine", vector(0, 1))
+	vectorOnly := store("Uploads go through the dashboard", vector(1, 0))
+	both := store("If the upload keeps failing, retry later", vector(0.8, 0.6))
+
+	t.Run("ranks documents found by both rankers first", func(t *testing.T) {
+		docs, err := repo.FindHybridBySharedKnowledgeBases(ctx, vector(1, 0), "retry ERR:42", []uuid.UUID{kbID}, 10, 0.5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(docs) != 3 {
+			t.Fatalf("got %d documents, want 3", len(docs))
+		}
+		if docs[0].ID != both {
+			t.Errorf("first result = %s, want the document matched by both rankers", docs[0].ID)
+		}
+		found := map[string]bool{}
+		for _, doc := range docs {
+			found[doc.ID] = true
+		}
+		if !found[keywordOnly] || !found[vectorOnly] {
+			t.Errorf("results %v miss a document only one ranker matched", found)
+		}
+	})
+
+	// tsquery operators in the question are matched as text instead of failing the query
+	for _, queryText := range []string{
+		`ERR:42`,
+		`it's`,
+		`retry & later | now`,
+		`!retry (later)`,
+		`C:\uploads\'retry'`,
+		`&|:!`,
+	} {
+		t.Run(queryText, func(t *testing.T) {
+			if _, err := repo.FindHybridBySharedKnowledgeBases(ctx, vector(0, 1), queryText, []uuid.UUID{kbID}, 10, 0.5); err != nil {
+				t.Fatalf("query %q failed: %v", queryText, err)
+			}
+		})
+	}
+	t.Run("matches quoted lexemes", func(t *testing.T) {
+		docs, err := repo.FindHybridBySharedKnowledgeBases(ctx, vector(1, 0), `ERR:42 & "it's"`, []uuid.UUID{kbID}, 10, 0.99)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := map[string]bool{}
+		for _, doc := range docs {
+			found[doc.ID] = true
+		}
+		if !found[keywordOnly] {
+			t.Errorf("results %v miss the document containing the quoted terms", found)
+		}
+	})
+}
+
+func TestFindWithoutShadow(t *testing.T) {
+	database := openTestDatabase(t)
+	kbID := createTestKnowledgeBase(t, database)
+	repo := NewDocumentRepository(database)
+	migrations := NewEmbeddingMigrationRepository(database)
+	ctx := context.Background()
+
+	dims, err := repo.EmbeddingDimensions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dims == 0 {
+		dims = 3
+	}
+	m := &EmbeddingMigration{SharedKnowledgeBaseID: &kbID, TargetModel: "test-model", TargetDimensions: dims, Status: "embedding"}
+	if err := migrations.Create(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	// the backslash must hash the same way when the shadow is stored and when documents are matched
+	for _, content := range []string{`C:\uploads\report.pdf`, "second", "third"} {
+		if err := repo.StoreWithEmbedding(ctx, &DocumentWithEmbedding{
+			ID:                    uuid.NewString(),
+			Content:               []byte(content),
+			Embedding:             make([]float32, dims),
+			SharedKnowledgeBaseID: &kbID,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	embedded := 0
+	for {
+		page, err := repo.FindWithoutShadow(ctx, m.ID, nil, &kbID, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, doc := range page {
+			if err := repo.StoreShadowEmbedding(ctx, m.ID, doc, make([]float32, dims), m.TargetModel); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if embedded += len(page); embedded > 3 {
+			t.Fatalf("paged through %d documents, want 3", embedded)
+		}
+	}
+	if embedded != 3 {
+		t.Fatalf("paged through %d documents, want 3", embedded)
+	}
+
+	// a document whose content changed needs a new shadow vector
+	if _, err := database.ExecContext(ctx, `UPDATE documents SET content = 'changed' WHERE shared_knowledge_base_id = $1 AND content = 'second'`, kbID); err != nil {
+		t.Fatal(err)
+	}
+	if missing, err := repo.CountWithoutShadow(ctx, m.ID, nil, &kbID); err != nil || missing != 1 {
+		t.Fatalf("documents without a shadow = %d, %v; want 1", missing, err)
+	}
+	if total, err := repo.CountWithoutShadow(ctx, uuid.Nil, nil, &kbID); err != nil || total != 3 {
+		t.Fatalf("documents without a shadow outside the migration = %d, %v; want 3", total, err)
+	}
+}
@@ -0,0 +1,213 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+)
+
+// EmbeddingMigration tracks a re-embed of one chatbot or shared knowledge base with a new embedding model.
+// New vectors are written to document_embedding_shadows and swapped into documents at cutover.
+type EmbeddingMigration struct {
+	ID                    uuid.UUID  `db:"id"`
+	ChatbotID             *uuid.UUID `db:"chatbot_id"`
+	SharedKnowledgeBaseID *uuid.UUID `db:"shared_knowledge_base_id"`
+	TargetModel           string     `db:"target_model"`
+	TargetDimensions      int        `db:"target_dimensions"`
+	Status                string     `db:"status"`
+	TotalDocuments        int        `db:"total_documents"`
+	EmbeddedDocuments     int        `db:"embedded_documents"`
+	Error                 *string    `db:"error"`
+	CreatedAt             time.Time  `db:"created_at"`
+	UpdatedAt             time.Time  `db:"updated_at"`
+	FinishedAt            *time.Time `db:"finished_at"`
+}
+
+type EmbeddingMigrationRepository struct {
+	db *Database
+}
+
+func NewEmbeddingMigrationRepository(db *Database) *EmbeddingMigrationRepository {
+	return &EmbeddingMigrationRepository{db: db}
+}
+
+const embeddingMigrationColumns = `id, chatbot_id, shared_knowledge_base_id, target_model, target_dimensions, status, total_documents, embedded_documents, error, created_at, updated_at, finished_at`
+
+// Create inserts a new migration.
+func (r *EmbeddingMigrationRepository) Create(ctx context.Context, m *EmbeddingMigration) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	m.CreatedAt = now
+	m.UpdatedAt = now
+
+	query := `
+		INSERT INTO embedding_migrations (` + embeddingMigrationColumns + `)
+		VALUES (:id, :chatbot_id, :shared_knowledge_base_id, :target_model, :target_dimensions, :status, :total_documents, :embedded_documents, :error, :created_at, :updated_at, :finished_at)
+	`
+	if _, err := r.db.NamedExecContext(ctx, query, m); err != nil {
+		return apperrors.Wrap(err, "failed to create embedding migration")
+	}
+	return nil
+}
+
+// FindByID returns a migration.
+func (r *EmbeddingMigrationRepository) FindByID(ctx context.Context, id uuid.UUID) (*EmbeddingMigration, error) {
+	var m EmbeddingMigration
+	query := `SELECT ` + embeddingMigrationColumns + ` FROM embedding_migrations WHERE id = $1`
+	if err := r.db.GetContext(ctx, &m, query, id); err != nil {
+		if IsNoRowsError(err) {
+			return nil, apperrors.ErrNotFound
+		}
+		return nil, apperrors.Wrap(err, "failed to find embedding migration")
+	}
+	return &m, nil
+}
+
+// ListRecent returns the most recently started migrations.
+func (r *EmbeddingMigrationRepository) ListRecent(ctx context.Context, limit int) ([]*EmbeddingMigration, error) {
+	var migrations []*EmbeddingMigration
+	query := `SELECT ` + embeddingMigrationColumns + ` FROM embedding_migrations ORDER BY created_at DESC LIMIT $1`
+	if err := r.db.SelectContext(ctx, &migrations, query, limit); err != nil {
+		return nil, apperrors.Wrap(err, "failed to list embedding migrations")
+	}
+	return migrations, nil
+}
+
+// UpdateProgress records the document counters of a running migration.
+func (r *EmbeddingMigrationRepository) UpdateProgress(ctx context.Context, id uuid.UUID, embedded, total int) error {
+	query := `
+		UPDATE embedding_migrations
+		SET embedded_documents = $2, total_documents = $3, updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, id, embedded, total); err != nil {
+		return apperrors.Wrap(err, "failed to update embedding migration progress")
+	}
+	return nil
+}
+
+// MarkDoneTx completes a migration as part of the cutover transaction.
+func (r *EmbeddingMigrationRepository) MarkDoneTx(ctx context.Context, tx *Transaction, id uuid.UUID) error {
+	query := `
+		UPDATE embedding_migrations
+		SET status = 'done', error = NULL, updated_at = NOW(), finished_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := tx.ExecContext(ctx, query, id); err != nil {
+		return apperrors.Wrap(err, "failed to complete embedding migration")
+	}
+	return nil
+}
+
+// MarkFailed records the failure reason and drops the migration's shadow vectors.
+func (r *EmbeddingMigrationRepository) MarkFailed(ctx context.Context, id uuid.UUID, errMsg string) error {
+	query := `
+		UPDATE embedding_migrations
+		SET status = 'failed', error = $2, updated_at = NOW(), finished_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, id, errMsg); err != nil {
+		return apperrors.Wrap(err, "failed to mark embedding migration failed")
+	}
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM document_embedding_shadows WHERE migration_id = $1`, id); err != nil {
+		return apperrors.Wrap(err, "failed to clear shadow embeddings")
+	}
+	return nil
+}
+
+// knowledgeBaseTable picks the table holding a knowledge base's embedding model
+func knowledgeBaseTable(chatbotID, sharedKnowledgeBaseID *uuid.UUID) (string, uuid.UUID) {
+	if chatbotID != nil {
+		return "chatbots", *chatbotID
+	}
+	return "shared_knowledge_bases", *sharedKnowledgeBaseID
+}
+
+// KnowledgeBaseModel returns the embedding model recorded for a chatbot or shared knowledge base,
+// or "" when none is recorded yet.
+func (r *EmbeddingMigrationRepository) KnowledgeBaseModel(ctx context.Context, chatbotID, sharedKnowledgeBaseID *uuid.UUID) (string, error) {
+	table, id := knowledgeBaseTable(chatbotID, sharedKnowledgeBaseID)
+	var model string
+	query := `SELECT COALESCE(embedding_model, '') FROM ` + table + ` WHERE id = $1`
+	if err := r.db.GetContext(ctx, &model, query, id); err != nil {
+		if IsNoRowsError(err) {
+			return "", apperrors.ErrNotFound
+		}
+		return "", apperrors.Wrap(err, "failed to read knowledge base embedding model")
+	}
+	return model, nil
+}
+
+// PinKnowledgeBaseModel records model for a knowledge base that has none yet and returns the
+// model it is embedded with.
+func (r *EmbeddingMigrationRepository) PinKnowledgeBaseModel(ctx context.Context, chatbotID, sharedKnowledgeBaseID *uuid.UUID, model string) (string, error) {
+	table, id := knowledgeBaseTable(chatbotID, sharedKnowledgeBaseID)
+	var pinned string
+	query := `UPDATE ` + table + ` SET embedding_model = COALESCE(embedding_model, $2) WHERE id = $1 RETURNING embedding_model`
+	if err := r.db.GetContext(ctx, &pinned, query, id, model); err != nil {
+		if IsNoRowsError(err) {
+			return "", apperrors.ErrNotFound
+		}
+		return "", apperrors.Wrap(err, "failed to record knowledge base embedding model")
+	}
+	return pinned, nil
+}
+
+// ShareKnowledgeBaseModelTx returns a knowledge base's embedding model and keeps it from being
+// switched until tx ends. Ingestion holds it while storing documents embedded with that model.
+func (r *EmbeddingMigrationRepository) ShareKnowledgeBaseModelTx(ctx context.Context, tx *Transaction, chatbotID, sharedKnowledgeBaseID *uuid.UUID) (string, error) {
+	return r.lockKnowledgeBaseModelTx(ctx, tx, chatbotID, sharedKnowledgeBaseID, "FOR SHARE")
+}
+
+// LockKnowledgeBaseModelTx returns a knowledge base's embedding model and holds off ingestion
+// until tx ends. It waits for documents being stored to commit.
+func (r *EmbeddingMigrationRepository) LockKnowledgeBaseModelTx(ctx context.Context, tx *Transaction, chatbotID, sharedKnowledgeBaseID *uuid.UUID) (string, error) {
+	return r.lockKnowledgeBaseModelTx(ctx, tx, chatbotID, sharedKnowledgeBaseID, "FOR UPDATE")
+}
+
+func (r *EmbeddingMigrationRepository) lockKnowledgeBaseModelTx(ctx context.Context, tx *Transaction, chatbotID, sharedKnowledgeBaseID *uuid.UUID, lock string) (string, error) {
+	table, id := knowledgeBaseTable(chatbotID, sharedKnowledgeBaseID)
+	var model string
+	query := `SELECT COALESCE(embedding_model, '') FROM ` + table + ` WHERE id = $1 ` + lock
+	if err := tx.GetContext(ctx, &model, query, id); err != nil {
+		if IsNoRowsError(err) {
+			return "", apperrors.ErrNotFound
+		}
+		return "", apperrors.Wrap(err, "failed to lock knowledge base embedding model")
+	}
+	return model, nil
+}
+
+// SetKnowledgeBaseModelTx switches a knowledge base to model as part of the cutover transaction.
+func (r *EmbeddingMigrationRepository) SetKnowledgeBaseModelTx(ctx context.Context, tx *Transaction, chatbotID, sharedKnowledgeBaseID *uuid.UUID, model string) error {
+	table, id := knowledgeBaseTable(chatbotID, sharedKnowledgeBaseID)
+	if _, err := tx.ExecContext(ctx, `UPDATE `+table+` SET embedding_model = $2 WHERE id = $1`, id, model); err != nil {
+		return apperrors.Wrap(err, "failed to switch knowledge base embedding model")
+	}
+	return nil
+}
+
+// SharedKnowledgeBaseModels returns the embedding model of each shared knowledge base, "" when none is recorded.
+func (r *EmbeddingMigrationRepository) SharedKnowledgeBaseModels(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	models := make(map[uuid.UUID]string, len(ids))
+	if len(ids) == 0 {
+		return models, nil
+	}
+	var rows []struct {
+		ID    uuid.UUID `db:"id"`
+		Model string    `db:"embedding_model"`
+	}
+	query := `SELECT id, COALESCE(embedding_model, '') AS embedding_model FROM shared_knowledge_bases WHERE id = ANY($1)`
+	if err := r.db.SelectContext(ctx, &rows, query, pq.Array(ids)); err != nil {
+		return nil, apperrors.Wrap(err, "failed to read shared knowledge base embedding models")
+	}
+	for _, row := range rows {
+		models[row.ID] = row.Model
+	}
+	return models, nil
+}
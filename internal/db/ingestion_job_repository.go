@@ -0,0 +1,169 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+)
+
+// IngestionJob tracks an upload (file, text or website) while it is converted, chunked and embedded
+// in the background. Payload holds the uploaded bytes until the job finishes and is only read via LoadPayload.
+type IngestionJob struct {
+	ID                    uuid.UUID  `db:"id"`
+	ChatbotID             *uuid.UUID `db:"chatbot_id"`
+	SharedKnowledgeBaseID *uuid.UUID `db:"shared_knowledge_base_id"`
+	Kind                  string     `db:"kind"`
+	Source                string     `db:"source"`
+	Payload               []byte     `db:"payload"`
+	Status                string     `db:"status"`
+	TotalChunks           int        `db:"total_chunks"`
+	ProcessedChunks       int        `db:"processed_chunks"`
+	FileID                *uuid.UUID `db:"file_id"`
+	Error                 *string    `db:"error"`
+	CreatedAt             time.Time  `db:"created_at"`
+	UpdatedAt             time.Time  `db:"updated_at"`
+	FinishedAt            *time.Time `db:"finished_at"`
+}
+
+type IngestionJobRepository struct {
+	db *Database
+}
+
+func NewIngestionJobRepository(db *Database) *IngestionJobRepository {
+	return &IngestionJobRepository{db: db}
+}
+
+const ingestionJobColumns = `id, chatbot_id, shared_knowledge_base_id, kind, source, status, total_chunks, processed_chunks, file_id, error, created_at, updated_at, finished_at`
+
+// Create inserts a new job.
+func (r *IngestionJobRepository) Create(ctx context.Context, job *IngestionJob) error {
+	if job.ID == uuid.Nil {
+		job.ID = uuid.New()
+	}
+	now := time.Now().UTC()
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	query := `
+		INSERT INTO ingestion_jobs (` + ingestionJobColumns + `, payload)
+		VALUES (:id, :chatbot_id, :shared_knowledge_base_id, :kind, :source, :status, :total_chunks, :processed_chunks, :file_id, :error, :created_at, :updated_at, :finished_at, :payload)
+	`
+	if _, err := r.db.NamedExecContext(ctx, query, job); err != nil {
+		return apperrors.Wrap(err, "failed to create ingestion job")
+	}
+	return nil
+}
+
+// FindByID returns a job without its payload.
+func (r *IngestionJobRepository) FindByID(ctx context.Context, id uuid.UUID) (*IngestionJob, error) {
+	var job IngestionJob
+	query := `SELECT ` + ingestionJobColumns + ` FROM ingestion_jobs WHERE id = $1`
+	if err := r.db.GetContext(ctx, &job, query, id); err != nil {
+		if IsNoRowsError(err) {
+			return nil, apperrors.ErrNotFound
+		}
+		return nil, apperrors.Wrap(err, "failed to find ingestion job")
+	}
+	return &job, nil
+}
+
+// LoadPayload returns the uploaded content of a job that has not finished yet.
+func (r *IngestionJobRepository) LoadPayload(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	var payload []byte
+	if err := r.db.GetContext(ctx, &payload, `SELECT payload FROM ingestion_jobs WHERE id = $1`, id); err != nil {
+		if IsNoRowsError(err) {
+			return nil, apperrors.ErrNotFound
+		}
+		return nil, apperrors.Wrap(err, "failed to load ingestion job payload")
+	}
+	return payload, nil
+}
+
+// Claim moves a job to converting for the caller. Queued jobs are claimed directly; jobs already
+// in progress only when their worker stopped reporting before staleBefore.
+func (r *IngestionJobRepository) Claim(ctx context.Context, id uuid.UUID, staleBefore time.Time) (bool, error) {
+	query := `
+		UPDATE ingestion_jobs
+		SET status = 'converting', processed_chunks = 0, total_chunks = 0, updated_at = NOW()
+		WHERE id = $1
+		  AND (status = 'queued' OR (status IN ('converting', 'chunking', 'embedding') AND updated_at < $2))
+	`
+	result, err := r.db.ExecContext(ctx, query, id, staleBefore)
+	if err != nil {
+		return false, apperrors.Wrap(err, "failed to claim ingestion job")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, apperrors.Wrap(err, "failed to claim ingestion job")
+	}
+	return rows > 0, nil
+}
+
+// Touch records that the worker of a running job is still alive.
+func (r *IngestionJobRepository) Touch(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE ingestion_jobs
+		SET updated_at = NOW()
+		WHERE id = $1 AND status IN ('converting', 'chunking', 'embedding')
+	`
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return apperrors.Wrap(err, "failed to touch ingestion job")
+	}
+	return nil
+}
+
+// ListStale returns unfinished jobs that have not been updated since before, oldest first.
+func (r *IngestionJobRepository) ListStale(ctx context.Context, before time.Time) ([]*IngestionJob, error) {
+	var jobs []*IngestionJob
+	query := `
+		SELECT ` + ingestionJobColumns + ` FROM ingestion_jobs
+		WHERE status IN ('queued', 'converting', 'chunking', 'embedding') AND updated_at < $1
+		ORDER BY created_at
+	`
+	if err := r.db.SelectContext(ctx, &jobs, query, before); err != nil {
+		return nil, apperrors.Wrap(err, "failed to list stale ingestion jobs")
+	}
+	return jobs, nil
+}
+
+// UpdateProgress moves a running job to status and records its chunk counters.
+func (r *IngestionJobRepository) UpdateProgress(ctx context.Context, id uuid.UUID, status string, processed, total int) error {
+	query := `
+		UPDATE ingestion_jobs
+		SET status = $2, processed_chunks = $3, total_chunks = $4, updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, id, status, processed, total); err != nil {
+		return apperrors.Wrap(err, "failed to update ingestion job progress")
+	}
+	return nil
+}
+
+// MarkDone completes a job, links the stored file and drops the payload.
+func (r *IngestionJobRepository) MarkDone(ctx context.Context, id uuid.UUID, fileID *uuid.UUID) error {
+	query := `
+		UPDATE ingestion_jobs
+		SET status = 'done', processed_chunks = total_chunks, file_id = $2, payload = NULL, error = NULL,
+		    updated_at = NOW(), finished_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, id, fileID); err != nil {
+		return apperrors.Wrap(err, "failed to complete ingestion job")
+	}
+	return nil
+}
+
+// MarkFailed records the failure reason and drops the payload.
+func (r *IngestionJobRepository) MarkFailed(ctx context.Context, id uuid.UUID, errMsg string) error {
+	query := `
+		UPDATE ingestion_jobs
+		SET status = 'failed', error = $2, payload = NULL, updated_at = NOW(), finished_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, id, errMsg); err != nil {
+		return apperrors.Wrap(err, "failed to mark ingestion job failed")
+	}
+	return nil
+}
@@ -31,10 +31,10 @@ func (r *LLMUsageRepository) Create(ctx context.Context, usage *LLMUsage) error
 		usage.CreatedAt = time.Now()
 	}
 
-	query := `INSERT INTO llm_usage (id, user_id, org_id, trace_id, model_alias, provider, prompt_tokens, completion_tokens, created_at)
-              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	query := `INSERT INTO llm_usage (id, user_id, org_id, trace_id, model_alias, provider, prompt_tokens, completion_tokens, cached_tokens, cost_usd, created_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
 
-	_, err := r.db.ExecContext(ctx, query, usage.ID, usage.UserID, usage.OrgID, usage.TraceID, usage.ModelAlias, usage.Provider, usage.PromptTokens, usage.CompletionTokens, usage.CreatedAt)
+	_, err := r.db.ExecContext(ctx, query, usage.ID, usage.UserID, usage.OrgID, usage.TraceID, usage.ModelAlias, usage.Provider, usage.PromptTokens, usage.CompletionTokens, usage.CachedTokens, usage.CostUSD, usage.CreatedAt)
 	if err != nil {
 		return apperrors.Wrap(err, "failed to insert llm usage")
 	}
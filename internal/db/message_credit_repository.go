@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+)
+
+// MessageCreditRepository persists consumed message credits. The balance of a pool and period in
+// message_credit_balances is what limits are enforced against; message_credit_usage is the per-reply
+// history, only read to seed the balance of a period that has none yet.
+type MessageCreditRepository struct {
+	db *Database
+}
+
+// NewMessageCreditRepository creates a repository instance.
+func NewMessageCreditRepository(db *Database) *MessageCreditRepository {
+	return &MessageCreditRepository{db: db}
+}
+
+// Create records a reply in the credit history. It does not take a credit; see Reserve.
+func (r *MessageCreditRepository) Create(ctx context.Context, usage *MessageCreditUsage) error {
+	if usage == nil {
+		return apperrors.Wrap(apperrors.ErrInvalidUserData, "message credit payload is nil")
+	}
+
+	if usage.ID == uuid.Nil {
+		usage.ID = uuid.New()
+	}
+	if usage.CreatedAt.IsZero() {
+		usage.CreatedAt = time.Now()
+	}
+
+	query := `INSERT INTO message_credit_usage (id, chatbot_id, user_id, org_id, created_at)
+              VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := r.db.ExecContext(ctx, query, usage.ID, usage.ChatbotID, usage.UserID, usage.OrgID, usage.CreatedAt)
+	if err != nil {
+		return apperrors.Wrap(err, "failed to insert message credit usage")
+	}
+
+	return nil
+}
+
+// CountInPeriod counts credits consumed in [start, end). When orgID is set the
+// organization's pool is counted, otherwise the user's personal pool.
+func (r *MessageCreditRepository) CountInPeriod(ctx context.Context, userID string, orgID *uuid.UUID, start, end time.Time) (int64, error) {
+	var (
+		count int64
+		err   error
+	)
+	if orgID != nil {
+		query := `SELECT COUNT(*) FROM message_credit_usage WHERE org_id = $1 AND created_at >= $2 AND created_at < $3`
+		err = r.db.GetContext(ctx, &count, query, *orgID, start, end)
+	} else {
+		query := `SELECT COUNT(*) FROM message_credit_usage WHERE user_id = $1 AND org_id IS NULL AND created_at >= $2 AND created_at < $3`
+		err = r.db.GetContext(ctx, &count, query, userID, start, end)
+	}
+	if err != nil {
+		return 0, apperrors.Wrap(err, "failed to count message credit usage")
+	}
+	return count, nil
+}
+
+// creditPool names the pool a credit is drawn from in message_credit_balances.
+func creditPool(userID string, orgID *uuid.UUID) string {
+	if orgID != nil {
+		return "org:" + orgID.String()
+	}
+	return "user:" + userID
+}
+
+// createBalance starts the balance of a pool for the period, seeded with the replies already
+// recorded in it, e.g. before a plan change moved the period start. It is a no-op when another
+// request created it first.
+func (r *MessageCreditRepository) createBalance(ctx context.Context, userID string, orgID *uuid.UUID, start, end time.Time) error {
+	used, err := r.CountInPeriod(ctx, userID, orgID, start, end)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO message_credit_balances (pool, period_start, period_end, used)
+              VALUES ($1, $2, $3, $4)
+              ON CONFLICT (pool, period_start) DO NOTHING`
+	if _, err := r.db.ExecContext(ctx, query, creditPool(userID, orgID), start, end, used); err != nil {
+		return apperrors.Wrap(err, "failed to create message credit balance")
+	}
+	return nil
+}
+
+// takeCredit increments the balance if it is below limit. ok is false both at the limit and when
+// the period has no balance yet.
+func (r *MessageCreditRepository) takeCredit(ctx context.Context, pool string, start time.Time, limit int64) (used int64, ok bool, err error) {
+	query := `UPDATE message_credit_balances SET used = used + 1
+              WHERE pool = $1 AND period_start = $2 AND used < $3
+              RETURNING used`
+	err = r.db.GetContext(ctx, &used, query, pool, start, limit)
+	if IsNoRowsError(err) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, apperrors.Wrap(err, "failed to reserve message credit")
+	}
+	return used, true, nil
+}
+
+// balance reads the credits used by the pool in the period starting at start.
+func (r *MessageCreditRepository) balance(ctx context.Context, pool string, start time.Time) (used int64, exists bool, err error) {
+	query := `SELECT used FROM message_credit_balances WHERE pool = $1 AND period_start = $2`
+	err = r.db.GetContext(ctx, &used, query, pool, start)
+	if IsNoRowsError(err) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, apperrors.Wrap(err, "failed to read message credit balance")
+	}
+	return used, true, nil
+}
+
+// Reserve takes one credit from the pool for the period [start, end) if fewer than limit are used.
+// It returns the credits used afterwards, or ok false and the current usage once the limit is reached.
+// The history is only counted for the first reservation of a period.
+func (r *MessageCreditRepository) Reserve(ctx context.Context, userID string, orgID *uuid.UUID, start, end time.Time, limit int64) (used int64, ok bool, err error) {
+	pool := creditPool(userID, orgID)
+	if used, ok, err = r.takeCredit(ctx, pool, start, limit); err != nil || ok {
+		return used, ok, err
+	}
+
+	used, exists, err := r.balance(ctx, pool, start)
+	if err != nil || exists {
+		return used, false, err
+	}
+	if err := r.createBalance(ctx, userID, orgID, start, end); err != nil {
+		return 0, false, err
+	}
+	if used, ok, err = r.takeCredit(ctx, pool, start, limit); err != nil || ok {
+		return used, ok, err
+	}
+	used, _, err = r.balance(ctx, pool, start)
+	return used, false, err
+}
+
+// Release returns a credit reserved for a reply that was never produced.
+func (r *MessageCreditRepository) Release(ctx context.Context, userID string, orgID *uuid.UUID, start time.Time) error {
+	query := `UPDATE message_credit_balances SET used = used - 1
+              WHERE pool = $1 AND period_start = $2 AND used > 0`
+	if _, err := r.db.ExecContext(ctx, query, creditPool(userID, orgID), start); err != nil {
+		return apperrors.Wrap(err, "failed to release message credit")
+	}
+	return nil
+}
+
+// UsedInPeriod returns the credits used by the pool in the period starting at start, falling
+// back to the recorded replies before the first reservation of the period.
+func (r *MessageCreditRepository) UsedInPeriod(ctx context.Context, userID string, orgID *uuid.UUID, start, end time.Time) (int64, error) {
+	used, exists, err := r.balance(ctx, creditPool(userID, orgID), start)
+	if err != nil || exists {
+		return used, err
+	}
+	return r.CountInPeriod(ctx, userID, orgID, start, end)
+}
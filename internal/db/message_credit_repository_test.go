@@ -0,0 +1,154 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// newCreditPool returns a user with an empty credit pool; its balances and history are removed after the test.
+func newCreditPool(t *testing.T, database *Database) string {
+	t.Helper()
+	userID := "test-" + uuid.NewString()
+	t.Cleanup(func() {
+		ctx := context.Background()
+		_, _ = database.ExecContext(ctx, `DELETE FROM message_credit_balances WHERE pool = $1`, creditPool(userID, nil))
+		_, _ = database.ExecContext(ctx, `DELETE FROM message_credit_usage WHERE user_id = $1`, userID)
+	})
+	return userID
+}
+
+func TestMessageCreditRepositoryReserveAtLimit(t *testing.T) {
+	database := openTestDatabase(t)
+	repo := NewMessageCreditRepository(database)
+	ctx := context.Background()
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	userID := newCreditPool(t, database)
+
+	for want := int64(1); want <= 3; want++ {
+		used, ok, err := repo.Reserve(ctx, userID, nil, start, end, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok || used != want {
+			t.Fatalf("reservation %d = %d, %v; want %d, true", want, used, ok, want)
+		}
+	}
+	used, ok, err := repo.Reserve(ctx, userID, nil, start, end, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok || used != 3 {
+		t.Fatalf("reservation at the limit = %d, %v; want 3, false", used, ok)
+	}
+
+	if err := repo.Release(ctx, userID, nil, start); err != nil {
+		t.Fatal(err)
+	}
+	if used, err := repo.UsedInPeriod(ctx, userID, nil, start, end); err != nil || used != 2 {
+		t.Fatalf("used after a release = %d, %v; want 2", used, err)
+	}
+	if used, ok, err := repo.Reserve(ctx, userID, nil, start, end, 3); err != nil || !ok || used != 3 {
+		t.Fatalf("reservation after a release = %d, %v, %v; want 3, true", used, ok, err)
+	}
+
+	// the next period starts from its own balance
+	next := end
+	if used, ok, err := repo.Reserve(ctx, userID, nil, next, next.AddDate(0, 1, 0), 3); err != nil || !ok || used != 1 {
+		t.Fatalf("first reservation of the next period = %d, %v, %v; want 1, true", used, ok, err)
+	}
+}
+
+func TestMessageCreditRepositoryReleaseWithoutReservation(t *testing.T) {
+	database := openTestDatabase(t)
+	repo := NewMessageCreditRepository(database)
+	ctx := context.Background()
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	userID := newCreditPool(t, database)
+
+	if err := repo.Release(ctx, userID, nil, start); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := repo.Reserve(ctx, userID, nil, start, end, 5); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := repo.Release(ctx, userID, nil, start); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if used, err := repo.UsedInPeriod(ctx, userID, nil, start, end); err != nil || used != 0 {
+		t.Fatalf("used after releasing more than was reserved = %d, %v; want 0", used, err)
+	}
+}
+
+func TestMessageCreditRepositorySeedsBalanceFromHistory(t *testing.T) {
+	database := openTestDatabase(t)
+	repo := NewMessageCreditRepository(database)
+	ctx := context.Background()
+	start := time.Now().UTC().Add(-time.Hour)
+	end := start.AddDate(0, 1, 0)
+	userID := newCreditPool(t, database)
+
+	for range 2 {
+		if err := repo.Create(ctx, &MessageCreditUsage{UserID: userID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if used, err := repo.UsedInPeriod(ctx, userID, nil, start, end); err != nil || used != 2 {
+		t.Fatalf("used before the first reservation = %d, %v; want the 2 recorded replies", used, err)
+	}
+	if used, ok, err := repo.Reserve(ctx, userID, nil, start, end, 3); err != nil || !ok || used != 3 {
+		t.Fatalf("first reservation = %d, %v, %v; want 3, true", used, ok, err)
+	}
+	// once the balance exists the history no longer counts
+	if err := repo.Create(ctx, &MessageCreditUsage{UserID: userID}); err != nil {
+		t.Fatal(err)
+	}
+	if used, err := repo.UsedInPeriod(ctx, userID, nil, start, end); err != nil || used != 3 {
+		t.Fatalf("used = %d, %v; want the balance of 3", used, err)
+	}
+}
+
+func TestMessageCreditRepositoryConcurrentReservations(t *testing.T) {
+	database := openTestDatabase(t)
+	repo := NewMessageCreditRepository(database)
+	ctx := context.Background()
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	userID := newCreditPool(t, database)
+
+	const limit = 10
+	var (
+		granted atomic.Int64
+		wg      sync.WaitGroup
+	)
+	for range 3 * limit {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok, err := repo.Reserve(ctx, userID, nil, start, end, limit)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if ok {
+				granted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := granted.Load(); got != limit {
+		t.Errorf("granted %d reservations, want %d", got, limit)
+	}
+	if used, err := repo.UsedInPeriod(ctx, userID, nil, start, end); err != nil || used != limit {
+		t.Errorf("used = %d, %v; want %d", used, err, limit)
+	}
+}
@@ -0,0 +1,37 @@
+-- +goose Up
+-- Full-text index over document content for hybrid (keyword + vector) retrieval.
+-- The 'simple' configuration avoids stemming so product codes and error strings match exactly.
+ALTER TABLE documents
+    ADD COLUMN IF NOT EXISTS content_tsv tsvector;
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION documents_content_tsv_update() RETURNS trigger AS $$
+BEGIN
+    NEW.content_tsv := to_tsvector('simple', convert_from(NEW.content, 'UTF8'));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER documents_content_tsv_trigger
+    BEFORE INSERT OR UPDATE OF content ON documents
+    FOR EACH ROW EXECUTE FUNCTION documents_content_tsv_update();
+
+UPDATE documents SET content_tsv = to_tsvector('simple', convert_from(content, 'UTF8'));
+
+CREATE INDEX IF NOT EXISTS idx_documents_content_tsv ON documents USING GIN (content_tsv);
+
+ALTER TABLE chatbots
+    ADD COLUMN IF NOT EXISTS retrieval_mode VARCHAR(20) NOT NULL DEFAULT 'vector'
+        CHECK (retrieval_mode IN ('vector', 'hybrid'));
+
+-- +goose Down
+ALTER TABLE chatbots
+    DROP COLUMN IF EXISTS retrieval_mode;
+
+DROP INDEX IF EXISTS idx_documents_content_tsv;
+DROP TRIGGER IF EXISTS documents_content_tsv_trigger ON documents;
+DROP FUNCTION IF EXISTS documents_content_tsv_update();
+
+ALTER TABLE documents
+    DROP COLUMN IF EXISTS content_tsv;
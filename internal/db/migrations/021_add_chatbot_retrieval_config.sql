@@ -0,0 +1,24 @@
+-- +goose Up
+-- Per-chatbot retrieval tuning. Defaults match the previously hard-coded values.
+ALTER TABLE chatbots
+    ADD COLUMN IF NOT EXISTS retrieval_top_k INTEGER NOT NULL DEFAULT 5
+        CHECK (retrieval_top_k BETWEEN 1 AND 50),
+    ADD COLUMN IF NOT EXISTS retrieval_min_similarity DOUBLE PRECISION NOT NULL DEFAULT 0
+        CHECK (retrieval_min_similarity BETWEEN 0 AND 1),
+    ADD COLUMN IF NOT EXISTS revision_match_threshold DOUBLE PRECISION NOT NULL DEFAULT 0.95
+        CHECK (revision_match_threshold BETWEEN 0 AND 1),
+    ADD COLUMN IF NOT EXISTS revision_inject_threshold DOUBLE PRECISION NOT NULL DEFAULT 0.85
+        CHECK (revision_inject_threshold BETWEEN 0 AND 1),
+    ADD COLUMN IF NOT EXISTS history_limit INTEGER NOT NULL DEFAULT 20
+        CHECK (history_limit BETWEEN 0 AND 200),
+    ADD COLUMN IF NOT EXISTS context_token_budget INTEGER NOT NULL DEFAULT 0
+        CHECK (context_token_budget >= 0);
+
+-- +goose Down
+ALTER TABLE chatbots
+    DROP COLUMN IF EXISTS context_token_budget,
+    DROP COLUMN IF EXISTS history_limit,
+    DROP COLUMN IF EXISTS revision_inject_threshold,
+    DROP COLUMN IF EXISTS revision_match_threshold,
+    DROP COLUMN IF EXISTS retrieval_min_similarity,
+    DROP COLUMN IF EXISTS retrieval_top_k;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Page URL for chunks ingested from websites, used when citing answer sources
+ALTER TABLE documents
+    ADD COLUMN IF NOT EXISTS source_url TEXT;
+
+-- Citations returned with assistant messages
+ALTER TABLE chat_messages
+    ADD COLUMN IF NOT EXISTS sources JSONB;
+
+-- +goose Down
+ALTER TABLE chat_messages
+    DROP COLUMN IF EXISTS sources;
+
+ALTER TABLE documents
+    DROP COLUMN IF EXISTS source_url;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE chatbots
+    ADD COLUMN IF NOT EXISTS rerank_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE chatbots
+    DROP COLUMN IF EXISTS rerank_enabled;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE chatbots
+    ADD COLUMN IF NOT EXISTS condense_query BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE chatbots
+    DROP COLUMN IF EXISTS condense_query;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE llm_usage
+    ADD COLUMN IF NOT EXISTS cached_tokens INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS cost_usd NUMERIC(12, 6) NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE llm_usage
+    DROP COLUMN IF EXISTS cost_usd,
+    DROP COLUMN IF EXISTS cached_tokens;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- History of the replies charged to a message credit pool
+CREATE TABLE message_credit_usage (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    chatbot_id UUID REFERENCES chatbots(id) ON DELETE SET NULL,
+    user_id TEXT NOT NULL,
+    org_id UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_message_credit_usage_user_created_at ON message_credit_usage (user_id, created_at DESC) WHERE org_id IS NULL;
+CREATE INDEX idx_message_credit_usage_org_created_at ON message_credit_usage (org_id, created_at DESC) WHERE org_id IS NOT NULL;
+
+-- Credits consumed per pool and billing period, which limits are enforced against. Replies reserve
+-- a credit with a conditional increment, so concurrent requests cannot overshoot the limit.
+-- pool is 'user:<user id>' or 'org:<organization id>'.
+CREATE TABLE message_credit_balances (
+    pool TEXT NOT NULL,
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end TIMESTAMPTZ NOT NULL,
+    used BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (pool, period_start)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS message_credit_balances;
+DROP TABLE IF EXISTS message_credit_usage;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Action headers usually carry credentials, so they are stored AES-GCM sealed
+CREATE TABLE chatbot_actions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    chatbot_id UUID NOT NULL REFERENCES chatbots(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    parameters JSONB NOT NULL DEFAULT '{"type":"object","properties":{}}'::jsonb,
+    http_method TEXT NOT NULL DEFAULT 'POST',
+    endpoint_url TEXT NOT NULL,
+    sealed_headers BYTEA,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT chatbot_actions_method_ck CHECK (http_method IN ('GET','POST','PUT','PATCH','DELETE'))
+);
+
+CREATE UNIQUE INDEX idx_chatbot_actions_chatbot_name ON chatbot_actions(chatbot_id, name);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_chatbot_actions_chatbot_name;
+DROP TABLE IF EXISTS chatbot_actions;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE chatbots
+    ADD COLUMN IF NOT EXISTS allowed_origins TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE chatbots
+    DROP COLUMN IF EXISTS allowed_origins;
@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE ingestion_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    chatbot_id UUID REFERENCES chatbots(id) ON DELETE CASCADE,
+    shared_knowledge_base_id UUID REFERENCES shared_knowledge_bases(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('file', 'text', 'website')),
+    source TEXT NOT NULL,
+    payload BYTEA,
+    status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'converting', 'chunking', 'embedding', 'done', 'failed')),
+    total_chunks INTEGER NOT NULL DEFAULT 0,
+    processed_chunks INTEGER NOT NULL DEFAULT 0,
+    file_id UUID REFERENCES files(id) ON DELETE SET NULL,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ,
+    CONSTRAINT ingestion_jobs_scope_check CHECK (
+        (chatbot_id IS NOT NULL AND shared_knowledge_base_id IS NULL) OR
+        (chatbot_id IS NULL AND shared_knowledge_base_id IS NOT NULL)
+    )
+);
+
+CREATE INDEX idx_ingestion_jobs_chatbot_created_at ON ingestion_jobs (chatbot_id, created_at DESC) WHERE chatbot_id IS NOT NULL;
+CREATE INDEX idx_ingestion_jobs_shared_kb_created_at ON ingestion_jobs (shared_knowledge_base_id, created_at DESC) WHERE shared_knowledge_base_id IS NOT NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS ingestion_jobs;
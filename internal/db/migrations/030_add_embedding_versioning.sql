@@ -0,0 +1,62 @@
+-- +goose Up
+-- Record which model produced each stored vector so models can be switched without downtime.
+-- Rows embedded before this migration keep a NULL model.
+ALTER TABLE documents
+    ADD COLUMN IF NOT EXISTS embedding_model TEXT,
+    ADD COLUMN IF NOT EXISTS embedding_dimensions INTEGER;
+
+UPDATE documents SET embedding_dimensions = vector_dims(embedding) WHERE embedding IS NOT NULL;
+
+-- The embedding model each knowledge base is searched with. Queries and new documents are embedded
+-- with it, and a re-embed switches it at cutover. NULL means the server's configured model; it is
+-- recorded on the next ingestion.
+ALTER TABLE chatbots ADD COLUMN IF NOT EXISTS embedding_model TEXT;
+ALTER TABLE shared_knowledge_bases ADD COLUMN IF NOT EXISTS embedding_model TEXT;
+
+-- A re-embed run over one chatbot or shared knowledge base
+CREATE TABLE embedding_migrations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    chatbot_id UUID REFERENCES chatbots(id) ON DELETE CASCADE,
+    shared_knowledge_base_id UUID REFERENCES shared_knowledge_bases(id) ON DELETE CASCADE,
+    target_model TEXT NOT NULL,
+    target_dimensions INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'embedding' CHECK (status IN ('embedding', 'done', 'failed')),
+    total_documents INTEGER NOT NULL DEFAULT 0,
+    embedded_documents INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ,
+    CONSTRAINT embedding_migrations_scope_check CHECK (
+        (chatbot_id IS NOT NULL AND shared_knowledge_base_id IS NULL) OR
+        (chatbot_id IS NULL AND shared_knowledge_base_id IS NOT NULL)
+    )
+);
+
+CREATE INDEX idx_embedding_migrations_created_at ON embedding_migrations (created_at DESC);
+
+-- Shadow vectors written by a running migration; retrieval never reads them.
+-- They are copied into documents.embedding in a single transaction at cutover.
+CREATE TABLE document_embedding_shadows (
+    migration_id UUID NOT NULL REFERENCES embedding_migrations(id) ON DELETE CASCADE,
+    document_id TEXT NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    embedding vector NOT NULL,
+    embedding_model TEXT NOT NULL,
+    embedding_dimensions INTEGER NOT NULL,
+    content_hash TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (migration_id, document_id)
+);
+
+CREATE INDEX idx_document_embedding_shadows_document_id ON document_embedding_shadows (document_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS document_embedding_shadows;
+DROP TABLE IF EXISTS embedding_migrations;
+
+ALTER TABLE shared_knowledge_bases DROP COLUMN IF EXISTS embedding_model;
+ALTER TABLE chatbots DROP COLUMN IF EXISTS embedding_model;
+
+ALTER TABLE documents
+    DROP COLUMN IF EXISTS embedding_dimensions,
+    DROP COLUMN IF EXISTS embedding_model;
@@ -0,0 +1,32 @@
+-- +goose Up
+-- One row per crawled page of a website source, so re-crawls only re-embed pages whose content changed.
+-- size_bytes is the page's text length, so a re-crawl that revalidates a page without downloading
+-- it still knows its size.
+CREATE TABLE website_pages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    file_id UUID NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    title TEXT,
+    etag TEXT,
+    last_modified TEXT,
+    content_hash TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    fetched_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT website_pages_file_url_key UNIQUE (file_id, url)
+);
+
+-- Page counts of the last completed crawl
+ALTER TABLE crawl_schedules
+    ADD COLUMN IF NOT EXISTS last_pages_added INTEGER,
+    ADD COLUMN IF NOT EXISTS last_pages_changed INTEGER,
+    ADD COLUMN IF NOT EXISTS last_pages_removed INTEGER,
+    ADD COLUMN IF NOT EXISTS last_pages_unchanged INTEGER;
+
+-- +goose Down
+ALTER TABLE crawl_schedules
+    DROP COLUMN IF EXISTS last_pages_unchanged,
+    DROP COLUMN IF EXISTS last_pages_removed,
+    DROP COLUMN IF EXISTS last_pages_changed,
+    DROP COLUMN IF EXISTS last_pages_added;
+
+DROP TABLE IF EXISTS website_pages;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Crawl scope of each schedule; defaults match the previous fixed crawl limits.
+ALTER TABLE crawl_schedules
+    ADD COLUMN IF NOT EXISTS max_pages INTEGER NOT NULL DEFAULT 25,
+    ADD COLUMN IF NOT EXISTS max_depth INTEGER NOT NULL DEFAULT 2,
+    ADD COLUMN IF NOT EXISTS include_patterns TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS exclude_patterns TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS path_prefix TEXT,
+    ADD COLUMN IF NOT EXISTS sitemap_only BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE crawl_schedules
+    DROP COLUMN IF EXISTS sitemap_only,
+    DROP COLUMN IF EXISTS path_prefix,
+    DROP COLUMN IF EXISTS exclude_patterns,
+    DROP COLUMN IF EXISTS include_patterns,
+    DROP COLUMN IF EXISTS max_depth,
+    DROP COLUMN IF EXISTS max_pages;
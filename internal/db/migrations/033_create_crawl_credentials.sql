@@ -0,0 +1,21 @@
+-- +goose Up
+-- Credentials sent with crawls of a website behind a login. The secret column holds the
+-- AES-GCM sealed headers, cookies and basic-auth pair; crawl jobs only carry the row ID.
+CREATE TABLE crawl_credentials (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    chatbot_id UUID REFERENCES chatbots(id) ON DELETE CASCADE,
+    shared_knowledge_base_id UUID REFERENCES shared_knowledge_bases(id) ON DELETE CASCADE,
+    root_url TEXT NOT NULL,
+    secret BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT crawl_credentials_scope_ck CHECK (
+        (chatbot_id IS NOT NULL AND shared_knowledge_base_id IS NULL) OR
+        (chatbot_id IS NULL AND shared_knowledge_base_id IS NOT NULL)
+    ),
+    CONSTRAINT crawl_credentials_chatbot_root_uniq UNIQUE (chatbot_id, root_url),
+    CONSTRAINT crawl_credentials_shared_root_uniq UNIQUE (shared_knowledge_base_id, root_url)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS crawl_credentials;
@@ -0,0 +1,40 @@
+-- +goose Up
+-- One row per attempt of a crawl job, kept for CRAWL_RUN_RETENTION. crawl_schedules only holds
+-- the outcome of the last run; this is the history behind it, including manual crawls of the URL.
+-- heartbeat_at is the last time the run's worker reported it alive. Runs are abandoned when it goes
+-- quiet rather than by age, so long crawls are not closed while their worker is still at it.
+CREATE TABLE crawl_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_id UUID NOT NULL,
+    attempt INTEGER NOT NULL DEFAULT 1,
+    schedule_id UUID REFERENCES crawl_schedules(id) ON DELETE SET NULL,
+    chatbot_id UUID REFERENCES chatbots(id) ON DELETE CASCADE,
+    shared_knowledge_base_id UUID REFERENCES shared_knowledge_bases(id) ON DELETE CASCADE,
+    root_url TEXT NOT NULL,
+    trigger TEXT NOT NULL CHECK (trigger IN ('cron', 'manual')),
+    status TEXT NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'completed', 'retrying', 'failed', 'interrupted', 'abandoned')),
+    worker_id TEXT NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ,
+    heartbeat_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    pages_fetched INTEGER NOT NULL DEFAULT 0,
+    pages_added INTEGER NOT NULL DEFAULT 0,
+    pages_changed INTEGER NOT NULL DEFAULT 0,
+    pages_removed INTEGER NOT NULL DEFAULT 0,
+    pages_unchanged INTEGER NOT NULL DEFAULT 0,
+    chunks_written INTEGER NOT NULL DEFAULT 0,
+    bytes_fetched BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    CONSTRAINT crawl_runs_scope_check CHECK (
+        (chatbot_id IS NOT NULL AND shared_knowledge_base_id IS NULL) OR
+        (chatbot_id IS NULL AND shared_knowledge_base_id IS NOT NULL)
+    )
+);
+
+CREATE INDEX idx_crawl_runs_chatbot_url_started_at ON crawl_runs (chatbot_id, root_url, started_at DESC) WHERE chatbot_id IS NOT NULL;
+CREATE INDEX idx_crawl_runs_shared_kb_url_started_at ON crawl_runs (shared_knowledge_base_id, root_url, started_at DESC) WHERE shared_knowledge_base_id IS NOT NULL;
+CREATE INDEX idx_crawl_runs_started_at ON crawl_runs (started_at);
+CREATE INDEX idx_crawl_runs_running_heartbeat ON crawl_runs (heartbeat_at) WHERE status = 'running';
+
+-- +goose Down
+DROP TABLE IF EXISTS crawl_runs;
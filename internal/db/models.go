@@ -1,9 +1,13 @@
 package db
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/pgvector/pgvector-go"
 )
 
@@ -60,8 +64,24 @@ type Chatbot struct {
 	UseMaxTokens       bool       `json:"use_max_tokens" db:"use_max_tokens"`
 	SaveMessages       bool       `json:"save_messages" db:"save_messages"`
 	IsEnabled          bool       `json:"is_enabled" db:"is_enabled"`
-	CreatedAt          time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt          time.Time  `json:"updated_at" db:"updated_at"`
+	RetrievalMode      string     `json:"retrieval_mode" db:"retrieval_mode"`
+	RetrievalConfig    `json:"retrieval_config"`
+	AllowedOrigins     pq.StringArray `json:"allowed_origins" db:"allowed_origins"`
+	CreatedAt          time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// RetrievalConfig holds the per-chatbot knobs used when building the RAG prompt.
+// It is embedded in Chatbot so its columns are read and written with the chatbot row.
+type RetrievalConfig struct {
+	TopK                    int     `json:"top_k" db:"retrieval_top_k"`
+	MinSimilarity           float64 `json:"min_similarity" db:"retrieval_min_similarity"`
+	RevisionMatchThreshold  float64 `json:"revision_match_threshold" db:"revision_match_threshold"`
+	RevisionInjectThreshold float64 `json:"revision_inject_threshold" db:"revision_inject_threshold"`
+	HistoryLimit            int     `json:"history_limit" db:"history_limit"`
+	ContextTokenBudget      int     `json:"context_token_budget" db:"context_token_budget"`
+	RerankEnabled           bool    `json:"rerank_enabled" db:"rerank_enabled"`
+	CondenseQuery           bool    `json:"condense_query" db:"condense_query"`
 }
 
 type Document struct {
@@ -72,6 +92,9 @@ type Document struct {
 	FileID                *uuid.UUID      `json:"file_id,omitempty" db:"file_id"`
 	ChunkIndex            *int            `json:"chunk_index,omitempty" db:"chunk_index"`
 	SharedKnowledgeBaseID *uuid.UUID      `json:"shared_knowledge_base_id,omitempty" db:"shared_knowledge_base_id"`
+	SourceURL             *string         `json:"source_url,omitempty" db:"source_url"`
+	EmbeddingModel        *string         `json:"embedding_model,omitempty" db:"embedding_model"`
+	EmbeddingDimensions   *int            `json:"embedding_dimensions,omitempty" db:"embedding_dimensions"`
 }
 
 type DocumentWithEmbedding struct {
@@ -82,6 +105,9 @@ type DocumentWithEmbedding struct {
 	FileID                *uuid.UUID `json:"file_id,omitempty"`
 	ChunkIndex            *int       `json:"chunk_index,omitempty"`
 	SharedKnowledgeBaseID *uuid.UUID `json:"shared_knowledge_base_id,omitempty"`
+	SourceURL             *string    `json:"source_url,omitempty"`
+	EmbeddingModel        *string    `json:"embedding_model,omitempty"`
+	Similarity            float64    `json:"similarity"`
 }
 
 func (d *Document) ToDocumentWithEmbedding() *DocumentWithEmbedding {
@@ -93,6 +119,8 @@ func (d *Document) ToDocumentWithEmbedding() *DocumentWithEmbedding {
 		FileID:                d.FileID,
 		ChunkIndex:            d.ChunkIndex,
 		SharedKnowledgeBaseID: d.SharedKnowledgeBaseID,
+		SourceURL:             d.SourceURL,
+		EmbeddingModel:        d.EmbeddingModel,
 	}
 }
 
@@ -122,12 +150,50 @@ type ChatbotSharedKnowledgeBase struct {
 }
 
 type ChatMessage struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	ChatbotID uuid.UUID `json:"chatbot_id" db:"chatbot_id"`
-	SessionID uuid.UUID `json:"session_id" db:"session_id"`
-	Role      string    `json:"role" db:"role"`
-	Content   string    `json:"content" db:"content"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ID        uuid.UUID      `json:"id" db:"id"`
+	ChatbotID uuid.UUID      `json:"chatbot_id" db:"chatbot_id"`
+	SessionID uuid.UUID      `json:"session_id" db:"session_id"`
+	Role      string         `json:"role" db:"role"`
+	Content   string         `json:"content" db:"content"`
+	Sources   MessageSources `json:"sources,omitempty" db:"sources"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
+
+// MessageSource is a citation for a chunk that was used to answer a message
+type MessageSource struct {
+	DocumentID string     `json:"document_id"`
+	FileID     *uuid.UUID `json:"file_id,omitempty"`
+	FileName   string     `json:"file_name,omitempty"`
+	SourceURL  string     `json:"source_url,omitempty"`
+	ChunkIndex *int       `json:"chunk_index,omitempty"`
+	Similarity float64    `json:"similarity"`
+}
+
+// MessageSources is stored as JSONB on chat_messages
+type MessageSources []MessageSource
+
+func (m MessageSources) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return json.Marshal([]MessageSource(m))
+}
+
+func (m *MessageSources) Scan(value any) error {
+	if value == nil {
+		*m = nil
+		return nil
+	}
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported type for MessageSources: %T", value)
+	}
+	return json.Unmarshal(data, (*[]MessageSource)(m))
 }
 
 type LLMUsage struct {
@@ -139,9 +205,21 @@ type LLMUsage struct {
 	Provider         *string   `json:"provider,omitempty" db:"provider"`
 	PromptTokens     int       `json:"prompt_tokens" db:"prompt_tokens"`
 	CompletionTokens int       `json:"completion_tokens" db:"completion_tokens"`
+	CachedTokens     int       `json:"cached_tokens" db:"cached_tokens"`
+	CostUSD          float64   `json:"cost_usd" db:"cost_usd"`
 	CreatedAt        time.Time `json:"created_at" db:"created_at"`
 }
 
+// MessageCreditUsage is one consumed message credit, recorded per assistant reply.
+// Credits belong to the organization when OrgID is set, otherwise to UserID.
+type MessageCreditUsage struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	ChatbotID *uuid.UUID `json:"chatbot_id,omitempty" db:"chatbot_id"`
+	UserID    string     `json:"user_id" db:"user_id"`
+	OrgID     *uuid.UUID `json:"org_id,omitempty" db:"org_id"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
 type AnswerRevision struct {
 	ID                uuid.UUID       `json:"id" db:"id"`
 	ChatbotID         uuid.UUID       `json:"chatbot_id" db:"chatbot_id"`
@@ -1,21 +1,53 @@
 package db
 
+import "context"
+
 type Repositories struct {
 	User       *UserRepository
 	APIKey     *APIKeyRepository
 	Chat       *ChatbotRepository
 	Document   *DocumentRepository
 	File       *FileRepository
+	Pages      *WebsitePageRepository
 	Message    *ChatMessageRepository
 	Revision   *RevisionRepository
 	SharedKB   *SharedKnowledgeBaseRepository
 	Schedule   *CrawlScheduleRepository
+	CrawlAuth  *CrawlCredentialRepository
+	CrawlRuns  *CrawlRunRepository
 	LLMUsage   *LLMUsageRepository
+	Credits    *MessageCreditRepository
+	Actions    *ChatbotActionRepository
+	Ingestion  *IngestionJobRepository
+	Embeddings *EmbeddingMigrationRepository
 	Org        *OrganizationRepository
 	OrgMembers *OrganizationMemberRepository
 	OrgInvites *OrganizationInviteRepository
 }
 
+// EmbeddingColumn is a vector column holding embeddings of the configured model.
+type EmbeddingColumn struct {
+	Name string
+	// Dimensions is the declared vector size, 0 when unconstrained
+	Dimensions int
+}
+
+// EmbeddingColumns reads the declared dimension of every column new embeddings are written to.
+func (r *Repositories) EmbeddingColumns(ctx context.Context) ([]EmbeddingColumn, error) {
+	documents, err := r.Document.EmbeddingDimensions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	questions, err := r.Revision.QuestionEmbeddingDimensions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return []EmbeddingColumn{
+		{Name: "documents.embedding", Dimensions: documents},
+		{Name: "answer_revisions.question_embedding", Dimensions: questions},
+	}, nil
+}
+
 // NewRepositories creates all repository instances
 func NewRepositories(db *Database) *Repositories {
 	return &Repositories{
@@ -24,11 +56,18 @@ func NewRepositories(db *Database) *Repositories {
 		Chat:       NewChatbotRepository(db),
 		Document:   NewDocumentRepository(db),
 		File:       NewFileRepository(db),
+		Pages:      NewWebsitePageRepository(db),
 		Message:    NewChatMessageRepository(db),
 		Revision:   NewRevisionRepository(db),
 		SharedKB:   NewSharedKnowledgeBaseRepository(db),
 		Schedule:   NewCrawlScheduleRepository(db),
+		CrawlAuth:  NewCrawlCredentialRepository(db),
+		CrawlRuns:  NewCrawlRunRepository(db),
 		LLMUsage:   NewLLMUsageRepository(db),
+		Credits:    NewMessageCreditRepository(db),
+		Actions:    NewChatbotActionRepository(db),
+		Ingestion:  NewIngestionJobRepository(db),
+		Embeddings: NewEmbeddingMigrationRepository(db),
 		Org:        NewOrganizationRepository(db),
 		OrgMembers: NewOrganizationMemberRepository(db),
 		OrgInvites: NewOrganizationInviteRepository(db),
@@ -21,6 +21,12 @@ func NewRevisionRepository(db *Database) *RevisionRepository {
 	return &RevisionRepository{db: db}
 }
 
+// QuestionEmbeddingDimensions returns the declared dimension of answer_revisions.question_embedding,
+// or 0 when it is unconstrained
+func (r *RevisionRepository) QuestionEmbeddingDimensions(ctx context.Context) (int, error) {
+	return r.db.VectorDimensions(ctx, "answer_revisions", "question_embedding")
+}
+
 // CreateRevision creates a new answer revision
 func (r *RevisionRepository) CreateRevision(ctx context.Context, revision *AnswerRevision) error {
 	query := `
@@ -209,6 +215,20 @@ func (r *RevisionRepository) UpdateRevision(ctx context.Context, id uuid.UUID, u
 	return nil
 }
 
+// UpdateQuestionEmbeddingTx replaces the question vector of a revision during an embedding cutover.
+// Revisions whose question changed since it was embedded are left alone.
+func (r *RevisionRepository) UpdateQuestionEmbeddingTx(ctx context.Context, tx *Transaction, id uuid.UUID, question string, embedding []float32) error {
+	query := `
+		UPDATE answer_revisions
+		SET question_embedding = $3
+		WHERE id = $1 AND question = $2
+	`
+	if _, err := tx.ExecContext(ctx, query, id, question, pgvector.NewVector(embedding)); err != nil {
+		return apperrors.Wrap(err, "failed to update revision question embedding")
+	}
+	return nil
+}
+
 // DeactivateRevision sets a revision as inactive
 func (r *RevisionRepository) DeactivateRevision(ctx context.Context, id uuid.UUID) error {
 	query := `
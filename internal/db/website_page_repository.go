@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+)
+
+// WebsitePage is one crawled page of a website source file. ContentHash is the SHA-256 of the
+// extracted page text and decides whether a re-crawl has to re-embed the page.
+type WebsitePage struct {
+	ID           uuid.UUID `db:"id"`
+	FileID       uuid.UUID `db:"file_id"`
+	URL          string    `db:"url"`
+	Title        *string   `db:"title"`
+	ETag         *string   `db:"etag"`
+	LastModified *string   `db:"last_modified"`
+	ContentHash  string    `db:"content_hash"`
+	SizeBytes    int64     `db:"size_bytes"`
+	FetchedAt    time.Time `db:"fetched_at"`
+}
+
+// WebsitePageWithChunks is a page together with the number of chunks indexed for it.
+type WebsitePageWithChunks struct {
+	WebsitePage
+	ChunkCount int `db:"chunk_count"`
+}
+
+type WebsitePageRepository struct {
+	db *Database
+}
+
+func NewWebsitePageRepository(db *Database) *WebsitePageRepository {
+	return &WebsitePageRepository{db: db}
+}
+
+const websitePageColumns = `id, file_id, url, title, etag, last_modified, content_hash, size_bytes, fetched_at`
+
+// FindByFileID returns the pages recorded for a website source.
+func (r *WebsitePageRepository) FindByFileID(ctx context.Context, fileID uuid.UUID) ([]*WebsitePage, error) {
+	var pages []*WebsitePage
+	query := `SELECT ` + websitePageColumns + ` FROM website_pages WHERE file_id = $1 ORDER BY url`
+	if err := r.db.SelectContext(ctx, &pages, query, fileID); err != nil {
+		return nil, apperrors.Wrap(err, "failed to find website pages by file ID")
+	}
+	return pages, nil
+}
+
+// FindByFileIDWithChunkCounts returns the pages of a website source with their indexed chunk counts.
+func (r *WebsitePageRepository) FindByFileIDWithChunkCounts(ctx context.Context, fileID uuid.UUID) ([]*WebsitePageWithChunks, error) {
+	var pages []*WebsitePageWithChunks
+	query := `
+		SELECT p.id, p.file_id, p.url, p.title, p.etag, p.last_modified, p.content_hash, p.size_bytes, p.fetched_at,
+		       (SELECT COUNT(*) FROM documents d WHERE d.file_id = p.file_id AND d.source_url = p.url) AS chunk_count
+		FROM website_pages p
+		WHERE p.file_id = $1
+		ORDER BY p.url
+	`
+	if err := r.db.SelectContext(ctx, &pages, query, fileID); err != nil {
+		return nil, apperrors.Wrap(err, "failed to list website pages")
+	}
+	return pages, nil
+}
+
+// FindByID returns a page by its identifier.
+func (r *WebsitePageRepository) FindByID(ctx context.Context, id uuid.UUID) (*WebsitePage, error) {
+	var page WebsitePage
+	query := `SELECT ` + websitePageColumns + ` FROM website_pages WHERE id = $1`
+	if err := r.db.GetContext(ctx, &page, query, id); err != nil {
+		if IsNoRowsError(err) {
+			return nil, apperrors.ErrNotFound
+		}
+		return nil, apperrors.Wrap(err, "failed to find website page")
+	}
+	return &page, nil
+}
+
+// UpsertTx records a page fetch within a transaction.
+func (r *WebsitePageRepository) UpsertTx(ctx context.Context, tx *Transaction, page *WebsitePage) error {
+	if page.ID == uuid.Nil {
+		page.ID = uuid.New()
+	}
+	query := `
+		INSERT INTO website_pages (` + websitePageColumns + `)
+		VALUES (:id, :file_id, :url, :title, :etag, :last_modified, :content_hash, :size_bytes, :fetched_at)
+		ON CONFLICT (file_id, url) DO UPDATE
+		SET title = EXCLUDED.title,
+		    etag = EXCLUDED.etag,
+		    last_modified = EXCLUDED.last_modified,
+		    content_hash = EXCLUDED.content_hash,
+		    size_bytes = EXCLUDED.size_bytes,
+		    fetched_at = EXCLUDED.fetched_at
+	`
+	if _, err := tx.NamedExecContext(ctx, query, page); err != nil {
+		return apperrors.Wrap(err, "failed to upsert website page")
+	}
+	return nil
+}
+
+// DeleteTx removes a page record within a transaction.
+func (r *WebsitePageRepository) DeleteTx(ctx context.Context, tx *Transaction, id uuid.UUID) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM website_pages WHERE id = $1`, id); err != nil {
+		return apperrors.Wrap(err, "failed to delete website page")
+	}
+	return nil
+}
@@ -47,6 +47,7 @@ var (
 	ErrUnauthorizedOrganizationAccess  = errors.New("unauthorized access to organization")
 	ErrOrganizationInviteInvalid       = errors.New("invalid or expired organization invite")
 	ErrOrganizationAlreadyExists       = errors.New("organization already exists")
+	ErrIngestionJobBusy                = errors.New("ingestion job is already running")
 )
 
 // WithDetails adds context details to an error
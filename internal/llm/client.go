@@ -1,20 +1,67 @@
 package llm
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+)
 
-// ChatRequest represents a simple chat completion request.
+// Message roles accepted in ChatRequest.Messages.
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// Message is a single role-tagged turn of a conversation.
+// Assistant turns may carry the tool calls the model made; tool turns answer one call by ToolCallID.
+type Message struct {
+	Role       string
+	Content    string
+	ToolCalls  []ToolCall
+	ToolCallID string
+	Name       string
+}
+
+// ToolDefinition describes a function the model may call. Parameters is a JSON schema object.
+type ToolDefinition struct {
+	Name        string
+	Description string
+	Parameters  json.RawMessage
+}
+
+// ToolCall is a function invocation requested by the model. Arguments is a JSON object string.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments string
+}
+
+// ChatRequest represents a chat completion request.
+// Messages takes precedence; Prompt is sent as a single user message when Messages is empty.
 type ChatRequest struct {
 	Prompt      string
+	Messages    []Message
 	Model       string
 	Temperature float64
 	MaxTokens   *int
+	Tools       []ToolDefinition
 	StreamFn    func(context.Context, string) error
 }
 
-// ChatResponse contains the generated message content.
+// ChatResponse contains the generated message content and any tool calls the model requested.
 type ChatResponse struct {
-	Content string
-	Usage   Usage
+	Content   string
+	ToolCalls []ToolCall
+	Usage     Usage
+}
+
+// chatMessages returns the request as role-tagged messages, wrapping a bare Prompt as a user turn.
+func (r ChatRequest) chatMessages() []Message {
+	if len(r.Messages) > 0 {
+		return r.Messages
+	}
+	return []Message{{Role: RoleUser, Content: r.Prompt}}
 }
 
 // Client provides a minimal interface for chat-capable LLM providers.
@@ -23,10 +70,22 @@ type Client interface {
 	ListModels(ctx context.Context) ([]ModelInfo, error)
 }
 
-// Usage captures estimated token usage for a completion.
+// Usage captures token usage and cost for a completion.
 type Usage struct {
-	PromptTokens     int
-	CompletionTokens int
+	PromptTokens       int
+	CompletionTokens   int
+	CachedPromptTokens int
+	CostUSD            float64
+}
+
+// Add returns the sum of two usages, used when a reply takes several completions.
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		PromptTokens:       u.PromptTokens + other.PromptTokens,
+		CompletionTokens:   u.CompletionTokens + other.CompletionTokens,
+		CachedPromptTokens: u.CachedPromptTokens + other.CachedPromptTokens,
+		CostUSD:            u.CostUSD + other.CostUSD,
+	}
 }
 
 // ModelInfo describes an available model alias/id and optional provider hint.
@@ -7,7 +7,6 @@ import (
 	"net/http"
 	"strings"
 
-	"github.com/pkoukk/tiktoken-go"
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/openai"
 	apperrors "github.com/yourusername/vectorchat/internal/errors"
@@ -19,10 +18,12 @@ type OpenAIClient struct {
 	baseURL      string
 	defaultModel string
 	httpClient   *http.Client
+	prices       PriceTable
 }
 
 // NewOpenAIClient creates a new OpenAI-compatible client pointing at the provided baseURL.
-func NewOpenAIClient(apiKey, baseURL, defaultModel string, httpClient *http.Client) *OpenAIClient {
+// prices is used to compute Usage.CostUSD; a nil table leaves cost at zero.
+func NewOpenAIClient(apiKey, baseURL, defaultModel string, httpClient *http.Client, prices PriceTable) *OpenAIClient {
 	if defaultModel == "" {
 		defaultModel = "gpt-4o-mini"
 	}
@@ -34,10 +35,11 @@ func NewOpenAIClient(apiKey, baseURL, defaultModel string, httpClient *http.Clie
 		baseURL:      strings.TrimRight(baseURL, "/"),
 		defaultModel: defaultModel,
 		httpClient:   httpClient,
+		prices:       prices,
 	}
 }
 
-// Chat sends a prompt and returns the generated completion along with estimated usage.
+// Chat sends a prompt and returns the generated completion along with token usage and cost.
 func (c *OpenAIClient) Chat(ctx context.Context, req ChatRequest) (ChatResponse, error) {
 	model := req.Model
 	if model == "" {
@@ -58,8 +60,12 @@ func (c *OpenAIClient) Chat(ctx context.Context, req ChatRequest) (ChatResponse,
 		return ChatResponse{}, apperrors.Wrap(err, "failed to create OpenAI client")
 	}
 
+	messages, err := toMessageContent(req)
+	if err != nil {
+		return ChatResponse{}, err
+	}
+
 	completionBuilder := &strings.Builder{}
-	promptTokens := estimateTokens(req.Prompt)
 
 	callOptions := []llms.CallOption{
 		llms.WithTemperature(req.Temperature),
@@ -69,23 +75,25 @@ func (c *OpenAIClient) Chat(ctx context.Context, req ChatRequest) (ChatResponse,
 		callOptions = append(callOptions, llms.WithMaxTokens(*req.MaxTokens))
 	}
 
+	if len(req.Tools) > 0 {
+		callOptions = append(callOptions, llms.WithTools(toTools(req.Tools)))
+	}
+
 	if req.StreamFn != nil {
 		callOptions = append(callOptions, llms.WithStreamingFunc(func(callCtx context.Context, chunk []byte) error {
 			if len(chunk) == 0 {
 				return nil
 			}
+			if len(req.Tools) > 0 && isToolCallDelta(chunk) {
+				return nil
+			}
 			text := string(chunk)
 			completionBuilder.WriteString(text)
 			return req.StreamFn(callCtx, text)
 		}))
 	}
 
-	response, err := llm.GenerateContent(ctx, []llms.MessageContent{
-		{
-			Role:  llms.ChatMessageTypeHuman,
-			Parts: []llms.ContentPart{llms.TextPart(req.Prompt)},
-		},
-	}, callOptions...)
+	response, err := llm.GenerateContent(ctx, messages, callOptions...)
 	if err != nil {
 		return ChatResponse{}, apperrors.Wrap(err, "failed to generate completion")
 	}
@@ -97,12 +105,123 @@ func (c *OpenAIClient) Chat(ctx context.Context, req ChatRequest) (ChatResponse,
 		}
 	}
 
-	usage := Usage{
-		PromptTokens:     promptTokens,
-		CompletionTokens: estimateTokens(content),
+	var toolCalls []ToolCall
+	for _, choice := range response.Choices {
+		for _, call := range choice.ToolCalls {
+			if call.FunctionCall == nil {
+				continue
+			}
+			toolCalls = append(toolCalls, ToolCall{
+				ID:        call.ID,
+				Name:      call.FunctionCall.Name,
+				Arguments: call.FunctionCall.Arguments,
+			})
+		}
 	}
 
-	return ChatResponse{Content: content, Usage: usage}, nil
+	usage, ok := usageFromChoices(response.Choices)
+	if !ok {
+		// Provider did not report usage; count with the model family's tokenizer instead
+		for _, msg := range req.chatMessages() {
+			usage.PromptTokens += CountTokens(model, msg.Content)
+		}
+		usage.CompletionTokens = CountTokens(model, content)
+		for _, call := range toolCalls {
+			usage.CompletionTokens += CountTokens(model, call.Name+call.Arguments)
+		}
+	}
+	usage.CostUSD = c.prices.Cost(model, usage)
+
+	return ChatResponse{Content: content, ToolCalls: toolCalls, Usage: usage}, nil
+}
+
+// toMessageContent maps role-tagged messages onto langchaingo message types.
+func toMessageContent(req ChatRequest) ([]llms.MessageContent, error) {
+	messages := req.chatMessages()
+	out := make([]llms.MessageContent, 0, len(messages))
+	for _, msg := range messages {
+		var role llms.ChatMessageType
+		switch msg.Role {
+		case RoleSystem:
+			role = llms.ChatMessageTypeSystem
+		case RoleUser:
+			role = llms.ChatMessageTypeHuman
+		case RoleAssistant:
+			role = llms.ChatMessageTypeAI
+		case RoleTool:
+			out = append(out, llms.MessageContent{
+				Role: llms.ChatMessageTypeTool,
+				Parts: []llms.ContentPart{llms.ToolCallResponse{
+					ToolCallID: msg.ToolCallID,
+					Name:       msg.Name,
+					Content:    msg.Content,
+				}},
+			})
+			continue
+		default:
+			return nil, fmt.Errorf("unsupported message role %q", msg.Role)
+		}
+
+		var parts []llms.ContentPart
+		if msg.Content != "" || len(msg.ToolCalls) == 0 {
+			parts = append(parts, llms.TextPart(msg.Content))
+		}
+		for _, call := range msg.ToolCalls {
+			parts = append(parts, llms.ToolCall{
+				ID:   call.ID,
+				Type: "function",
+				FunctionCall: &llms.FunctionCall{
+					Name:      call.Name,
+					Arguments: call.Arguments,
+				},
+			})
+		}
+		out = append(out, llms.MessageContent{Role: role, Parts: parts})
+	}
+	return out, nil
+}
+
+// isToolCallDelta reports whether a streamed chunk is a tool call fragment rather than answer text.
+// langchaingo forwards tool call deltas to the streaming func as a JSON array of calls.
+func isToolCallDelta(chunk []byte) bool {
+	if chunk[0] != '[' {
+		return false
+	}
+	var calls []struct {
+		Function *json.RawMessage `json:"function"`
+	}
+	if err := json.Unmarshal(chunk, &calls); err != nil || len(calls) == 0 {
+		return false
+	}
+	for _, call := range calls {
+		if call.Function == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// emptyToolParameters is sent for tools without a schema; OpenAI requires an object schema.
+var emptyToolParameters = json.RawMessage(`{"type":"object","properties":{}}`)
+
+// toTools maps tool definitions onto langchaingo function tools.
+func toTools(defs []ToolDefinition) []llms.Tool {
+	tools := make([]llms.Tool, 0, len(defs))
+	for _, def := range defs {
+		params := def.Parameters
+		if len(params) == 0 {
+			params = emptyToolParameters
+		}
+		tools = append(tools, llms.Tool{
+			Type: "function",
+			Function: &llms.FunctionDefinition{
+				Name:        def.Name,
+				Description: def.Description,
+				Parameters:  params,
+			},
+		})
+	}
+	return tools
 }
 
 // ListModels queries the OpenAI-compatible /models endpoint and returns available ids.
@@ -151,18 +270,42 @@ func (c *OpenAIClient) ListModels(ctx context.Context) ([]ModelInfo, error) {
 	return models, nil
 }
 
-func estimateTokens(text string) int {
-	if strings.TrimSpace(text) == "" {
-		return 0
-	}
-	enc, err := tiktoken.EncodingForModel("gpt-4o-mini")
-	if err != nil {
-		enc, err = tiktoken.GetEncoding("cl100k_base")
-	}
-	if err != nil {
-		return (len([]rune(text)) / 4) + 1
+// usageFromChoices reads provider-reported token counts from langchaingo generation info.
+// Streaming responses carry the same fields because the client requests include_usage.
+func usageFromChoices(choices []*llms.ContentChoice) (Usage, bool) {
+	for _, choice := range choices {
+		if choice == nil || choice.GenerationInfo == nil {
+			continue
+		}
+		prompt, hasPrompt := intFromInfo(choice.GenerationInfo, "PromptTokens")
+		completion, hasCompletion := intFromInfo(choice.GenerationInfo, "CompletionTokens")
+		if !hasPrompt && !hasCompletion {
+			continue
+		}
+		if prompt == 0 && completion == 0 {
+			continue
+		}
+		cached, _ := intFromInfo(choice.GenerationInfo, "PromptCachedTokens")
+		return Usage{
+			PromptTokens:       prompt,
+			CompletionTokens:   completion,
+			CachedPromptTokens: cached,
+		}, true
 	}
+	return Usage{}, false
+}
 
-	tokens := enc.Encode(text, nil, nil)
-	return len(tokens)
+func intFromInfo(info map[string]any, key string) (int, bool) {
+	switch v := info[key].(type) {
+	case int:
+		return v, true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	default:
+		return 0, false
+	}
 }
@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+)
+
+// ModelPrice is the USD price per million tokens for a model.
+type ModelPrice struct {
+	Input       float64 `json:"input"`
+	CachedInput float64 `json:"cached_input"`
+	Output      float64 `json:"output"`
+}
+
+// PriceTable maps model ids (with or without provider prefix) to prices.
+type PriceTable map[string]ModelPrice
+
+// DefaultPriceTable holds list prices for the models offered out of the box.
+func DefaultPriceTable() PriceTable {
+	return PriceTable{
+		"gpt-4o-mini":  {Input: 0.15, CachedInput: 0.075, Output: 0.60},
+		"gpt-4o":       {Input: 2.50, CachedInput: 1.25, Output: 10.00},
+		"gpt-4.1-mini": {Input: 0.40, CachedInput: 0.10, Output: 1.60},
+		"gpt-4.1":      {Input: 2.00, CachedInput: 0.50, Output: 8.00},
+	}
+}
+
+// ParsePriceTable merges a JSON price table over the defaults, e.g.
+// {"gemini-default": {"input": 0.1, "cached_input": 0.025, "output": 0.4}}.
+func ParsePriceTable(raw string) (PriceTable, error) {
+	table := DefaultPriceTable()
+	if strings.TrimSpace(raw) == "" {
+		return table, nil
+	}
+
+	var overrides PriceTable
+	if err := json.Unmarshal([]byte(raw), &overrides); err != nil {
+		return nil, apperrors.Wrap(err, "failed to parse LLM price table")
+	}
+	for model, price := range overrides {
+		table[strings.ToLower(model)] = price
+	}
+	return table, nil
+}
+
+// Cost returns the USD cost of the usage for model, or 0 when the model has no price.
+func (t PriceTable) Cost(model string, usage Usage) float64 {
+	price, ok := t.lookup(model)
+	if !ok {
+		return 0
+	}
+
+	cached := usage.CachedPromptTokens
+	if cached > usage.PromptTokens {
+		cached = usage.PromptTokens
+	}
+	uncached := usage.PromptTokens - cached
+
+	cachedPrice := price.CachedInput
+	if cachedPrice == 0 {
+		cachedPrice = price.Input
+	}
+
+	return (float64(uncached)*price.Input +
+		float64(cached)*cachedPrice +
+		float64(usage.CompletionTokens)*price.Output) / 1_000_000
+}
+
+func (t PriceTable) lookup(model string) (ModelPrice, bool) {
+	id := strings.ToLower(model)
+	if price, ok := t[id]; ok {
+		return price, true
+	}
+	if idx := strings.LastIndex(id, "/"); idx >= 0 {
+		price, ok := t[id[idx+1:]]
+		return price, ok
+	}
+	return ModelPrice{}, false
+}
@@ -0,0 +1,73 @@
+package llm
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPriceTableCost(t *testing.T) {
+	table := PriceTable{
+		"gpt-4o-mini":   {Input: 0.15, CachedInput: 0.075, Output: 0.60},
+		"no-cache-tier": {Input: 1, Output: 2},
+	}
+	cases := []struct {
+		name  string
+		model string
+		usage Usage
+		want  float64
+	}{
+		{"prompt and completion", "gpt-4o-mini", Usage{PromptTokens: 1_000_000, CompletionTokens: 1_000_000}, 0.75},
+		{"cached prompt tokens use the cached price", "gpt-4o-mini", Usage{PromptTokens: 1_000_000, CachedPromptTokens: 400_000}, 0.6*0.15 + 0.4*0.075},
+		{"cached tokens are capped at the prompt", "gpt-4o-mini", Usage{PromptTokens: 100_000, CachedPromptTokens: 500_000}, 0.1 * 0.075},
+		{"missing cached price falls back to input", "no-cache-tier", Usage{PromptTokens: 1_000_000, CachedPromptTokens: 1_000_000}, 1},
+		{"lookup ignores case", "GPT-4o-Mini", Usage{CompletionTokens: 1_000_000}, 0.60},
+		{"provider prefix is stripped", "openai/gpt-4o-mini", Usage{PromptTokens: 1_000_000}, 0.15},
+		{"unknown model costs nothing", "mystery-model", Usage{PromptTokens: 1_000_000, CompletionTokens: 1_000_000}, 0},
+		{"unknown prefixed model costs nothing", "acme/mystery-model", Usage{PromptTokens: 1_000_000}, 0},
+		{"no usage", "gpt-4o-mini", Usage{}, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := table.Cost(tc.model, tc.usage); math.Abs(got-tc.want) > 1e-9 {
+				t.Errorf("Cost(%q) = %v, want %v", tc.model, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParsePriceTable(t *testing.T) {
+	t.Run("empty keeps the defaults", func(t *testing.T) {
+		table, err := ParsePriceTable("  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(table) != len(DefaultPriceTable()) {
+			t.Errorf("table has %d models, want the %d defaults", len(table), len(DefaultPriceTable()))
+		}
+	})
+
+	t.Run("overrides merge over the defaults", func(t *testing.T) {
+		table, err := ParsePriceTable(`{"Gemini-Default": {"input": 0.1, "cached_input": 0.025, "output": 0.4}, "gpt-4o": {"input": 1, "output": 4}}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := table["gemini-default"]; got != (ModelPrice{Input: 0.1, CachedInput: 0.025, Output: 0.4}) {
+			t.Errorf("added model = %+v", got)
+		}
+		if got := table["gpt-4o"]; got != (ModelPrice{Input: 1, Output: 4}) {
+			t.Errorf("overridden model = %+v", got)
+		}
+		if got := table["gpt-4o-mini"]; got != DefaultPriceTable()["gpt-4o-mini"] {
+			t.Errorf("untouched default = %+v", got)
+		}
+		if got := table.Cost("google/gemini-default", Usage{PromptTokens: 1_000_000}); math.Abs(got-0.1) > 1e-9 {
+			t.Errorf("cost of an added model = %v, want 0.1", got)
+		}
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		if _, err := ParsePriceTable(`{"gpt-4o": {"input": "cheap"}}`); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
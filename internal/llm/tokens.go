@@ -0,0 +1,85 @@
+package llm
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+)
+
+// encodingLoad is the outcome of loading one BPE encoding, successful or not
+type encodingLoad struct {
+	once sync.Once
+	enc  *tiktoken.Tiktoken
+	err  error
+}
+
+var (
+	// encodings maps an encoding name to its *encodingLoad
+	encodings sync.Map
+	// getEncoding is replaced in tests
+	getEncoding = tiktoken.GetEncoding
+)
+
+// encodingForModel picks the tokenizer for a model family.
+// Non-OpenAI families have no public BPE, so they are approximated with cl100k_base.
+func encodingForModel(model string) string {
+	id := strings.ToLower(model)
+	if idx := strings.LastIndex(id, "/"); idx >= 0 {
+		id = id[idx+1:]
+	}
+
+	switch {
+	case strings.HasPrefix(id, "gpt-4o"),
+		strings.HasPrefix(id, "gpt-4.1"),
+		strings.HasPrefix(id, "gpt-4.5"),
+		strings.HasPrefix(id, "gpt-5"),
+		strings.HasPrefix(id, "chatgpt-4o"),
+		strings.HasPrefix(id, "o1"),
+		strings.HasPrefix(id, "o3"),
+		strings.HasPrefix(id, "o4"):
+		return tiktoken.MODEL_O200K_BASE
+	default:
+		return tiktoken.MODEL_CL100K_BASE
+	}
+}
+
+// loadEncoding loads a BPE encoding once per process. tiktoken downloads the file on first use
+// unless TIKTOKEN_CACHE_DIR holds it, so a failed load is kept: later calls use the heuristic
+// instead of retrying the download, and callers of other encodings never wait on it.
+func loadEncoding(name string) (*tiktoken.Tiktoken, error) {
+	v, _ := encodings.LoadOrStore(name, &encodingLoad{})
+	load := v.(*encodingLoad)
+	load.once.Do(func() {
+		load.enc, load.err = getEncoding(name)
+		if load.err != nil {
+			slog.Warn("tokenizer unavailable, estimating token counts from text length", "encoding", name, "error", load.err)
+		}
+	})
+	return load.enc, load.err
+}
+
+// approximateTokens is the fallback when no tokenizer can be loaded
+func approximateTokens(text string) int {
+	return (len([]rune(text)) / 4) + 1
+}
+
+// CountTokens counts tokens in text with the tokenizer of the model's family.
+// It only falls back to a character heuristic when no tokenizer can be loaded.
+func CountTokens(model, text string) int {
+	if strings.TrimSpace(text) == "" {
+		return 0
+	}
+
+	enc, err := loadEncoding(encodingForModel(model))
+	if err != nil {
+		return approximateTokens(text)
+	}
+	return len(enc.Encode(text, nil, nil))
+}
+
+// EstimateTokens counts tokens in text using the default chat model's tokenizer.
+func EstimateTokens(text string) int {
+	return CountTokens("gpt-4o-mini", text)
+}
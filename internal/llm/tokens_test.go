@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/pkoukk/tiktoken-go"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestUsageFromChoices(t *testing.T) {
+	cases := []struct {
+		name    string
+		choices []*llms.ContentChoice
+		want    Usage
+		wantOK  bool
+	}{
+		{"no choices", nil, Usage{}, false},
+		{"no generation info", []*llms.ContentChoice{nil, {Content: "hi"}}, Usage{}, false},
+		{"int counts", []*llms.ContentChoice{{GenerationInfo: map[string]any{"PromptTokens": 12, "CompletionTokens": 5}}}, Usage{PromptTokens: 12, CompletionTokens: 5}, true},
+		{"float counts from decoded JSON", []*llms.ContentChoice{{GenerationInfo: map[string]any{"PromptTokens": float64(40), "CompletionTokens": float64(8), "PromptCachedTokens": float64(32)}}}, Usage{PromptTokens: 40, CompletionTokens: 8, CachedPromptTokens: 32}, true},
+		{"int64 counts", []*llms.ContentChoice{{GenerationInfo: map[string]any{"PromptTokens": int64(7), "CompletionTokens": int32(3)}}}, Usage{PromptTokens: 7, CompletionTokens: 3}, true},
+		{"zero counts are not reported usage", []*llms.ContentChoice{{GenerationInfo: map[string]any{"PromptTokens": 0, "CompletionTokens": 0}}}, Usage{}, false},
+		{"unreadable counts", []*llms.ContentChoice{{GenerationInfo: map[string]any{"PromptTokens": "12"}}}, Usage{}, false},
+		{"first choice with usage wins", []*llms.ContentChoice{
+			{GenerationInfo: map[string]any{"StopReason": "stop"}},
+			{GenerationInfo: map[string]any{"PromptTokens": 9, "CompletionTokens": 2}},
+			{GenerationInfo: map[string]any{"PromptTokens": 100, "CompletionTokens": 100}},
+		}, Usage{PromptTokens: 9, CompletionTokens: 2}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := usageFromChoices(tc.choices)
+			if ok != tc.wantOK || got != tc.want {
+				t.Errorf("usageFromChoices = %+v, %v; want %+v, %v", got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestEncodingForModel(t *testing.T) {
+	cases := map[string]string{
+		"gpt-4o-mini":               tiktoken.MODEL_O200K_BASE,
+		"openai/GPT-4.1":            tiktoken.MODEL_O200K_BASE,
+		"o3-mini":                   tiktoken.MODEL_O200K_BASE,
+		"gpt-3.5-turbo":             tiktoken.MODEL_CL100K_BASE,
+		"gpt-4":                     tiktoken.MODEL_CL100K_BASE,
+		"anthropic/claude-sonnet-4": tiktoken.MODEL_CL100K_BASE,
+	}
+	for model, want := range cases {
+		if got := encodingForModel(model); got != want {
+			t.Errorf("encodingForModel(%q) = %q, want %q", model, got, want)
+		}
+	}
+}
+
+func TestLoadEncodingCachesFailures(t *testing.T) {
+	loads := 0
+	getEncoding = func(string) (*tiktoken.Tiktoken, error) {
+		loads++
+		return nil, errors.New("download failed")
+	}
+	t.Cleanup(func() { getEncoding = tiktoken.GetEncoding })
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := loadEncoding("test_unavailable_base"); err == nil {
+				t.Error("expected the load error")
+			}
+		}()
+	}
+	wg.Wait()
+	if _, err := loadEncoding("test_unavailable_base"); err == nil {
+		t.Error("expected the cached load error")
+	}
+	if loads != 1 {
+		t.Errorf("encoding loaded %d times, want once", loads)
+	}
+}
+
+func TestApproximateTokens(t *testing.T) {
+	if got := approximateTokens("abcdefgh"); got != 3 {
+		t.Errorf("approximateTokens = %d, want 3", got)
+	}
+	if got := CountTokens("gpt-4o-mini", "   "); got != 0 {
+		t.Errorf("CountTokens of blank text = %d, want 0", got)
+	}
+}
@@ -3,14 +3,17 @@ package middleware
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/yourusername/vectorchat/internal/db"
 	"github.com/yourusername/vectorchat/internal/services"
 	"github.com/yourusername/vectorchat/pkg/constants"
+	"github.com/yourusername/vectorchat/pkg/models"
 	stripe_sub "github.com/yourusername/vectorchat/pkg/stripe_sub"
 )
 
@@ -18,13 +21,15 @@ import (
 type SubscriptionLimitsMiddleware struct {
 	svc         *stripe_sub.Service
 	chatService *services.ChatService
+	credits     *services.MessageCreditService
 }
 
 // NewSubscriptionLimitsMiddleware creates a new subscription limits middleware
-func NewSubscriptionLimitsMiddleware(svc *stripe_sub.Service, chatService *services.ChatService) *SubscriptionLimitsMiddleware {
+func NewSubscriptionLimitsMiddleware(svc *stripe_sub.Service, chatService *services.ChatService, credits *services.MessageCreditService) *SubscriptionLimitsMiddleware {
 	return &SubscriptionLimitsMiddleware{
 		svc:         svc,
 		chatService: chatService,
+		credits:     credits,
 	}
 }
 
@@ -62,6 +67,10 @@ func (s *SubscriptionLimitsMiddleware) CheckLimit(limitKey string) fiber.Handler
 			return s.checkDataSourcesLimit(c, user.ID, limits)
 		case constants.LimitTrainingData:
 			return s.checkTrainingDataLimit(c, user.ID, limits)
+		case constants.LimitEmbedWebsites:
+			return s.checkEmbedWebsitesLimit(c, limits)
+		case constants.LimitCrawlPages:
+			return s.checkCrawlLimits(c, limits)
 		default:
 			return c.Next()
 		}
@@ -122,6 +131,68 @@ func (s *SubscriptionLimitsMiddleware) checkDataSourcesLimit(c *fiber.Ctx, userI
 	return c.Next()
 }
 
+// checkEmbedWebsitesLimit caps the widget origin allow-list unless the plan embeds on unlimited websites
+func (s *SubscriptionLimitsMiddleware) checkEmbedWebsitesLimit(c *fiber.Ctx, limits map[string]interface{}) error {
+	if unlimited, _ := limits[constants.LimitEmbedWebsites].(bool); unlimited {
+		return c.Next()
+	}
+
+	var req models.AllowedOriginsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if len(req.AllowedOrigins) > constants.DefaultEmbedOrigins {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Your plan allows embedding on a single website. Please upgrade your plan.",
+			"code":  "LIMIT_REACHED",
+			"limit": constants.DefaultEmbedOrigins,
+			"used":  len(req.AllowedOrigins),
+		})
+	}
+
+	return c.Next()
+}
+
+// checkCrawlLimits rejects crawl options asking for more pages or a deeper crawl than the plan allows
+func (s *SubscriptionLimitsMiddleware) checkCrawlLimits(c *fiber.Ctx, limits map[string]interface{}) error {
+	var req struct {
+		Options *models.CrawlOptions `json:"options"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if req.Options == nil {
+		return c.Next()
+	}
+
+	maxPages := getIntLimit(limits, constants.LimitCrawlPages, constants.DefaultCrawlPages)
+	if req.Options.MaxPages > maxPages {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":     "Crawl page limit exceeded. Please upgrade your plan.",
+			"code":      "LIMIT_REACHED",
+			"limit":     maxPages,
+			"requested": req.Options.MaxPages,
+		})
+	}
+
+	maxDepth := getIntLimit(limits, constants.LimitCrawlDepth, constants.DefaultCrawlDepth)
+	if req.Options.MaxDepth != nil && *req.Options.MaxDepth > maxDepth {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":     "Crawl depth limit exceeded. Please upgrade your plan.",
+			"code":      "LIMIT_REACHED",
+			"limit":     maxDepth,
+			"requested": *req.Options.MaxDepth,
+		})
+	}
+
+	return c.Next()
+}
+
 func (s *SubscriptionLimitsMiddleware) checkTrainingDataLimit(c *fiber.Ctx, userID string, limits map[string]interface{}) error {
 	chatID := c.Params("chatID")
 	if chatID == "" {
@@ -166,27 +237,13 @@ func getDefaultLimits() map[string]interface{} {
 		constants.LimitDataSources:    constants.DefaultDataSources,
 		constants.LimitEmbedWebsites:  true,
 		constants.LimitAPIAccess:      true,
+		constants.LimitCrawlPages:     constants.DefaultCrawlPages,
+		constants.LimitCrawlDepth:     constants.DefaultCrawlDepth,
 	}
 }
 
 func getIntLimit(limits map[string]interface{}, key string, defaultVal int) int {
-	if val, ok := limits[key]; ok {
-		switch v := val.(type) {
-		case int:
-			return v
-		case float64:
-			return int(v)
-		case int64:
-			return int(v)
-		case string:
-			// Extract number from string like "5 data sources"
-			var num int
-			if _, err := parseStringWithNumber(v, &num); err == nil {
-				return num
-			}
-		}
-	}
-	return defaultVal
+	return services.PlanIntLimit(limits, key, defaultVal)
 }
 
 func getStringLimit(limits map[string]interface{}, key string, defaultVal string) string {
@@ -224,13 +281,6 @@ func parseDataSize(sizeStr string) int64 {
 
 func parseStringWithNumber(s string, dest interface{}) (int, error) {
 	switch d := dest.(type) {
-	case *int:
-		n, err := parseIntFromString(s)
-		if err != nil {
-			return 0, err
-		}
-		*d = n
-		return 1, nil
 	case *float64:
 		f, err := parseFloatFromString(s)
 		if err != nil {
@@ -242,56 +292,76 @@ func parseStringWithNumber(s string, dest interface{}) (int, error) {
 	return 0, nil
 }
 
-func parseIntFromString(s string) (int, error) {
-	// Simple parser - extracts first number from string
+func parseFloatFromString(s string) (float64, error) {
 	for _, part := range strings.Fields(s) {
-		var n int
-		if _, err := fmt.Sscanf(part, "%d", &n); err == nil {
+		if n, err := strconv.ParseFloat(part, 64); err == nil {
 			return n, nil
 		}
 	}
 	return 0, fmt.Errorf("no number found")
 }
 
-func parseFloatFromString(s string) (float64, error) {
-	for _, part := range strings.Fields(s) {
-		if n, err := strconv.ParseFloat(part, 64); err == nil {
-			return n, nil
-		}
+// messageCreditReleaseKey is the local holding the func that gives back the request's credit
+const messageCreditReleaseKey = "message_credit_release"
+
+// MessageCreditRelease returns the func giving back the credit CheckMessageCredits reserved for the
+// request. Streaming handlers write their reply after the middleware has returned with a 200, so they
+// call it themselves when the reply fails. It releases at most once and is a no-op without a reservation.
+func MessageCreditRelease(c *fiber.Ctx) func() {
+	if release, ok := c.Locals(messageCreditReleaseKey).(func()); ok {
+		return release
 	}
-	return 0, fmt.Errorf("no number found")
+	return func() {}
 }
 
-// CheckMessageCredits checks if user has message credits remaining
+// CheckMessageCredits takes a message credit from the chatbot owner's pool and rejects chat requests once
+// none are left. The credit is given back when the request fails.
+// Credits are resolved from the chatbot rather than the caller, so it also guards the public widget API.
 func (s *SubscriptionLimitsMiddleware) CheckMessageCredits() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		user, ok := c.Locals("user").(*db.User)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "User not authenticated",
+		chatbot, err := s.chatService.GetChatbotByID(c.Context(), c.Params("chatID"))
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Chatbot not found",
 			})
 		}
 
-		plan, _, err := s.svc.GetUserPlan(context.Background(), &user.ID, user.Email)
+		status, ok, err := s.credits.ReserveForChatbot(c.Context(), chatbot)
 		if err != nil {
+			slog.Error("Failed to check message credits", "error", err, "chat_id", chatbot.ID)
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to retrieve subscription",
 			})
 		}
 
-		limits := getDefaultLimits()
-		if plan != nil {
-			if features, ok := plan.PlanDefinition["features"].(map[string]interface{}); ok {
-				limits = features
-			}
+		if !ok {
+			return c.Status(fiber.StatusPaymentRequired).JSON(fiber.Map{
+				"error":        "Message credit limit reached. Please upgrade your plan.",
+				"code":         "LIMIT_REACHED",
+				"limit":        status.Limit,
+				"used":         status.Used,
+				"remaining":    status.Remaining,
+				"period_start": status.PeriodStart,
+				"period_end":   status.PeriodEnd,
+			})
 		}
 
-		maxCredits := getIntLimit(limits, constants.LimitMessageCredits, constants.DefaultMessageCredits)
-
-		// TODO: Implement message credit tracking
-		// For now, just pass through
-		c.Locals("message_credits_limit", maxCredits)
+		var releaseOnce sync.Once
+		release := func() {
+			releaseOnce.Do(func() {
+				if err := s.credits.ReleaseForChatbot(context.Background(), chatbot, status.PeriodStart); err != nil {
+					slog.Warn("Failed to release message credit", "error", err, "chat_id", chatbot.ID)
+				}
+			})
+		}
+		c.Locals("message_credits_limit", status.Limit)
+		c.Locals("message_credits_remaining", status.Remaining)
+		c.Locals(messageCreditReleaseKey, release)
 
-		return c.Next()
+		err = c.Next()
+		if err != nil || c.Response().StatusCode() >= fiber.StatusBadRequest {
+			release()
+		}
+		return err
 	}
 }
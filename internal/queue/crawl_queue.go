@@ -0,0 +1,147 @@
+package queue
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/yourusername/vectorchat/pkg/jobs"
+)
+
+// CrawlInFlightError is returned by CrawlQueue.Enqueue when a crawl of the same knowledge base
+// and root URL is already queued or running.
+type CrawlInFlightError struct {
+	JobID uuid.UUID
+}
+
+func (e *CrawlInFlightError) Error() string {
+	return fmt.Sprintf("crawl already queued or running as job %s", e.JobID)
+}
+
+// CrawlQueue publishes crawl jobs, allowing one queued or running job per knowledge base and
+// root URL. Claims live in a key-value bucket shared by the API, schedulers and workers; workers
+// release them when a job completes or is dead-lettered.
+type CrawlQueue struct {
+	js       nats.JetStreamContext
+	inFlight nats.KeyValue
+}
+
+// NewCrawlQueue binds to the in-flight bucket declared by EnsureStreams.
+func NewCrawlQueue(js nats.JetStreamContext) (*CrawlQueue, error) {
+	kv, err := js.KeyValue(jobs.CrawlInFlightBucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open crawl in-flight bucket: %w", err)
+	}
+	return &CrawlQueue{js: js, inFlight: kv}, nil
+}
+
+// Enqueue claims the source of payload and publishes the job. JetStream also drops a publish
+// whose message ID it has seen within the duplicate window, so two schedulers firing the same
+// tick, or one request retried, enqueue a single job.
+func (q *CrawlQueue) Enqueue(payload jobs.CrawlJobPayload) error {
+	if err := q.Claim(payload); err != nil {
+		return err
+	}
+	return q.Publish(payload)
+}
+
+// Claim reserves the source of payload for its job, returning a *CrawlInFlightError while another
+// job holds it. Callers that prepare the job after claiming must then Publish or Release it.
+func (q *CrawlQueue) Claim(payload jobs.CrawlJobPayload) error {
+	key := crawlSourceKey(payload)
+	if _, err := q.inFlight.Create(key, []byte(payload.JobID.String())); err != nil {
+		if errors.Is(err, nats.ErrKeyExists) {
+			return q.inFlightError(key)
+		}
+		return fmt.Errorf("failed to claim crawl: %w", err)
+	}
+	return nil
+}
+
+// Publish queues a job whose source it claimed, releasing the claim when the job is not queued.
+func (q *CrawlQueue) Publish(payload jobs.CrawlJobPayload) error {
+	key := crawlSourceKey(payload)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		_ = q.inFlight.Delete(key)
+		return err
+	}
+	ack, err := q.js.Publish(jobs.CrawlSubject, body, nats.MsgId(crawlMsgID(payload)))
+	if err != nil {
+		_ = q.inFlight.Delete(key)
+		return err
+	}
+	if ack.Duplicate {
+		// The same job was published moments ago and has already finished
+		_ = q.inFlight.Delete(key)
+		return &CrawlInFlightError{JobID: payload.JobID}
+	}
+	return nil
+}
+
+// Touch renews the claim of a job a worker is running, so long crawls and retries keep it. A claim
+// that expired is taken again while the source is free; one another job holds is left alone.
+func (q *CrawlQueue) Touch(payload jobs.CrawlJobPayload) {
+	key := crawlSourceKey(payload)
+	entry, err := q.inFlight.Get(key)
+	if errors.Is(err, nats.ErrKeyNotFound) {
+		_, _ = q.inFlight.Create(key, []byte(payload.JobID.String()))
+		return
+	}
+	if err != nil || !claimedBy(entry, payload) {
+		return
+	}
+	// Update fails when the claim changed hands since it was read
+	_, _ = q.inFlight.Update(key, entry.Value(), entry.Revision())
+}
+
+// Release frees the source of payload for new crawls, unless another job holds it by now.
+func (q *CrawlQueue) Release(payload jobs.CrawlJobPayload) {
+	key := crawlSourceKey(payload)
+	entry, err := q.inFlight.Get(key)
+	if err != nil || !claimedBy(entry, payload) {
+		return
+	}
+	_ = q.inFlight.Delete(key, nats.LastRevision(entry.Revision()))
+}
+
+// claimedBy reports whether an in-flight entry is the claim of payload's job.
+func claimedBy(entry nats.KeyValueEntry, payload jobs.CrawlJobPayload) bool {
+	return string(entry.Value()) == payload.JobID.String()
+}
+
+func (q *CrawlQueue) inFlightError(key string) error {
+	inFlight := &CrawlInFlightError{}
+	if entry, err := q.inFlight.Get(key); err == nil {
+		inFlight.JobID, _ = uuid.Parse(string(entry.Value()))
+	}
+	return inFlight
+}
+
+// crawlSourceKey names the knowledge base and root URL of a job as a bucket key.
+func crawlSourceKey(payload jobs.CrawlJobPayload) string {
+	scope := "unscoped"
+	switch {
+	case payload.ChatbotID != nil:
+		scope = "chatbot." + payload.ChatbotID.String()
+	case payload.SharedKnowledgeBaseID != nil:
+		scope = "shared." + payload.SharedKnowledgeBaseID.String()
+	}
+	sum := sha256.Sum256([]byte(payload.RootURL))
+	return scope + "." + hex.EncodeToString(sum[:16])
+}
+
+// crawlMsgID keys scheduled jobs on their schedule and one-off jobs on their source, within the
+// dedupe window the job was requested in.
+func crawlMsgID(payload jobs.CrawlJobPayload) string {
+	window := strconv.FormatInt(payload.RequestedAt.Truncate(jobs.CrawlDedupeWindow).Unix(), 10)
+	if payload.ScheduleID != uuid.Nil {
+		return "schedule." + payload.ScheduleID.String() + "." + window
+	}
+	return crawlSourceKey(payload) + "." + window
+}
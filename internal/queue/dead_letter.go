@@ -0,0 +1,129 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/yourusername/vectorchat/pkg/jobs"
+)
+
+// maxDeliveriesAdvisory is published by JetStream when it stops redelivering an unacknowledged crawl job.
+const maxDeliveriesAdvisory = "$JS.EVENT.ADVISORY.CONSUMER.MAX_DELIVERIES." + jobs.CrawlStream + "." + jobs.CrawlConsumer
+
+// CrawlRetries decides what happens to a failed crawl job: it is redelivered after the next
+// back-off delay or, once it fails permanently or runs out of attempts, moved to the DLQ with
+// the errors of every attempt. Attempts are kept in a key-value bucket keyed by stream sequence
+// so any worker can pick up the history. Jobs that finish or are dead-lettered release their
+// claim in crawls.
+type CrawlRetries struct {
+	js       nats.JetStreamContext
+	attempts nats.KeyValue
+	crawls   *CrawlQueue
+}
+
+// NewCrawlRetries binds to the attempts bucket declared by EnsureStreams.
+func NewCrawlRetries(js nats.JetStreamContext, crawls *CrawlQueue) (*CrawlRetries, error) {
+	kv, err := js.KeyValue(jobs.CrawlAttemptsBucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open crawl attempts bucket: %w", err)
+	}
+	return &CrawlRetries{js: js, attempts: kv, crawls: crawls}, nil
+}
+
+// Fail records cause for msg and either naks it with the back-off delay of its attempt or,
+// when permanent is set or the attempt was the last, dead-letters it. It reports whether msg
+// was dead-lettered.
+func (r *CrawlRetries) Fail(msg *nats.Msg, payload jobs.CrawlJobPayload, cause error, permanent bool) (bool, error) {
+	meta, err := msg.Metadata()
+	if err != nil {
+		_ = msg.Nak()
+		return false, err
+	}
+	attempt := int(meta.NumDelivered)
+	key := attemptKey(meta.Sequence.Stream)
+	history := append(r.history(key), jobs.CrawlAttempt{Attempt: attempt, Error: cause.Error(), FailedAt: time.Now().UTC()})
+
+	if !permanent && attempt < jobs.CrawlMaxDeliver {
+		if raw, err := json.Marshal(history); err == nil {
+			_, _ = r.attempts.Put(key, raw)
+		}
+		return false, msg.NakWithDelay(jobs.CrawlRetryDelay(attempt))
+	}
+
+	if err := r.deadLetter(payload, cause.Error(), permanent, history); err != nil {
+		// Keep the job queued rather than lose it; it is dead-lettered on a later attempt
+		_ = msg.NakWithDelay(jobs.CrawlRetryDelay(attempt))
+		return false, err
+	}
+	_ = r.attempts.Delete(key)
+	return true, msg.Term()
+}
+
+// Succeeded forgets the failed attempts of msg and frees its website for new crawls.
+func (r *CrawlRetries) Succeeded(msg *nats.Msg, payload jobs.CrawlJobPayload) {
+	r.crawls.Release(payload)
+	if meta, err := msg.Metadata(); err == nil && meta.NumDelivered > 1 {
+		_ = r.attempts.Delete(attemptKey(meta.Sequence.Stream))
+	}
+}
+
+// WatchExhausted dead-letters jobs JetStream gave up on without a worker reporting the last
+// failure, e.g. because the worker died mid-crawl. Subscribers share a queue group so each
+// advisory is handled once.
+func (r *CrawlRetries) WatchExhausted(nc *nats.Conn) (*nats.Subscription, error) {
+	return nc.QueueSubscribe(maxDeliveriesAdvisory, jobs.CrawlConsumer, func(m *nats.Msg) {
+		var advisory struct {
+			StreamSeq  uint64 `json:"stream_seq"`
+			Deliveries uint64 `json:"deliveries"`
+		}
+		if err := json.Unmarshal(m.Data, &advisory); err != nil {
+			return
+		}
+		raw, err := r.js.GetMsg(jobs.CrawlStream, advisory.StreamSeq)
+		if err != nil {
+			return
+		}
+		var payload jobs.CrawlJobPayload
+		if err := json.Unmarshal(raw.Data, &payload); err != nil {
+			return
+		}
+		key := attemptKey(advisory.StreamSeq)
+		cause := fmt.Sprintf("not acknowledged after %d deliveries", advisory.Deliveries)
+		if err := r.deadLetter(payload, cause, false, r.history(key)); err == nil {
+			_ = r.attempts.Delete(key)
+		}
+	})
+}
+
+func (r *CrawlRetries) history(key string) []jobs.CrawlAttempt {
+	var history []jobs.CrawlAttempt
+	if entry, err := r.attempts.Get(key); err == nil {
+		_ = json.Unmarshal(entry.Value(), &history)
+	}
+	return history
+}
+
+func (r *CrawlRetries) deadLetter(payload jobs.CrawlJobPayload, cause string, permanent bool, history []jobs.CrawlAttempt) error {
+	data, err := json.Marshal(jobs.CrawlDeadLetter{
+		Job:            payload,
+		Error:          cause,
+		Permanent:      permanent,
+		Attempts:       history,
+		DeadLetteredAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := r.js.Publish(jobs.CrawlDLQSubject, data); err != nil {
+		return fmt.Errorf("failed to dead-letter crawl job %s: %w", payload.JobID, err)
+	}
+	r.crawls.Release(payload)
+	return nil
+}
+
+func attemptKey(streamSeq uint64) string {
+	return strconv.FormatUint(streamSeq, 10)
+}
@@ -1,6 +1,7 @@
 package queue
 
 import (
+	"errors"
 	"time"
 
 	"github.com/nats-io/nats.go"
@@ -22,7 +23,9 @@ func Connect(url, user, pass string) (*nats.Conn, error) {
 	return nats.Connect(url, opts...)
 }
 
-// EnsureStreams declares the crawl job stream and DLQ if they do not exist.
+// EnsureStreams declares the crawl job stream, its consumer, DLQ, attempts and in-flight buckets, the schedule
+// change stream and the ingestion job stream. The crawl consumer is updated in place when its
+// retry policy changes.
 func EnsureStreams(js nats.JetStreamContext) error {
 	// main stream
 	_, err := js.StreamInfo(jobs.CrawlStream)
@@ -35,6 +38,8 @@ func EnsureStreams(js nats.JetStreamContext) error {
 			MaxBytes:  512 * 1024 * 1024, // 512MB
 			MaxMsgs:   -1,
 			MaxAge:    0,
+			// drops crawl jobs published twice for the same schedule tick or request window
+			Duplicates: 2 * jobs.CrawlDedupeWindow,
 		}); err != nil {
 			return err
 		}
@@ -42,10 +47,49 @@ func EnsureStreams(js nats.JetStreamContext) error {
 		return err
 	}
 
+	// crawl workers; failed jobs are redelivered with back-off until MaxDeliver
+	consumer := &nats.ConsumerConfig{
+		Durable:       jobs.CrawlConsumer,
+		FilterSubject: jobs.CrawlSubject,
+		AckPolicy:     nats.AckExplicitPolicy,
+		MaxDeliver:    jobs.CrawlMaxDeliver,
+		BackOff:       jobs.CrawlBackOff,
+	}
+	if _, err = js.ConsumerInfo(jobs.CrawlStream, jobs.CrawlConsumer); errors.Is(err, nats.ErrConsumerNotFound) {
+		_, err = js.AddConsumer(jobs.CrawlStream, consumer)
+	} else if err == nil {
+		_, err = js.UpdateConsumer(jobs.CrawlStream, consumer)
+	}
+	if err != nil {
+		return err
+	}
+
+	// failed attempts of queued jobs, so dead letters carry the whole history
+	if _, err = js.KeyValue(jobs.CrawlAttemptsBucket); errors.Is(err, nats.ErrBucketNotFound) {
+		_, err = js.CreateKeyValue(&nats.KeyValueConfig{
+			Bucket: jobs.CrawlAttemptsBucket,
+			TTL:    24 * time.Hour,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	// websites with a queued or running crawl job
+	if _, err = js.KeyValue(jobs.CrawlInFlightBucket); errors.Is(err, nats.ErrBucketNotFound) {
+		_, err = js.CreateKeyValue(&nats.KeyValueConfig{
+			Bucket: jobs.CrawlInFlightBucket,
+			TTL:    jobs.CrawlInFlightTTL,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
 	// DLQ
-	if _, err = js.StreamInfo(jobs.CrawlStream + ".DLQ"); err == nats.ErrStreamNotFound {
+	if _, err = js.StreamInfo(jobs.CrawlDLQStream); err == nats.ErrStreamNotFound {
 		if _, err = js.AddStream(&nats.StreamConfig{
-			Name:      jobs.CrawlStream + ".DLQ",
+			Name:      jobs.CrawlDLQStream,
 			Subjects:  []string{jobs.CrawlDLQSubject},
 			Retention: nats.LimitsPolicy,
 			Storage:   nats.FileStorage,
@@ -57,5 +101,59 @@ func EnsureStreams(js nats.JetStreamContext) error {
 		return err
 	}
 
+	// schedule changes are only interesting to schedulers that are running right now
+	if _, err = js.StreamInfo(jobs.CrawlScheduleEventStream); err == nats.ErrStreamNotFound {
+		if _, err = js.AddStream(&nats.StreamConfig{
+			Name:      jobs.CrawlScheduleEventStream,
+			Subjects:  []string{jobs.CrawlScheduleEventSubject},
+			Retention: nats.LimitsPolicy,
+			Storage:   nats.FileStorage,
+			MaxAge:    time.Hour,
+			MaxBytes:  16 * 1024 * 1024,
+		}); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	// ingestion jobs; uploads themselves live in Postgres, messages only carry the job ID
+	if _, err = js.StreamInfo(jobs.IngestionStream); err == nats.ErrStreamNotFound {
+		if _, err = js.AddStream(&nats.StreamConfig{
+			Name:      jobs.IngestionStream,
+			Subjects:  []string{jobs.IngestionSubject},
+			Retention: nats.WorkQueuePolicy,
+			Storage:   nats.FileStorage,
+			MaxBytes:  64 * 1024 * 1024,
+		}); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
 	return nil
 }
+
+// KeepInProgress extends the ack deadline of msg every interval until the returned stop function
+// is called, so long-running jobs are not redelivered to another worker. onBeat, when set, runs
+// on every beat to renew other state the job holds.
+func KeepInProgress(msg *nats.Msg, interval time.Duration, onBeat func()) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = msg.InProgress()
+				if onBeat != nil {
+					onBeat()
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,207 @@
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+	"github.com/yourusername/vectorchat/internal/llm"
+)
+
+// Candidate is a passage to be scored against a query
+type Candidate struct {
+	ID   string
+	Text string
+}
+
+// Result references a candidate by its position in the input slice
+type Result struct {
+	Index int
+	Score float64
+}
+
+// Usage is the LLM usage of a rerank call, for rerankers that score with a chat model
+type Usage struct {
+	Model string
+	llm.Usage
+}
+
+// Reranker reorders retrieved candidates by relevance to the query.
+// Implementations return at most topN results, best first, and the LLM usage of the call, if any.
+type Reranker interface {
+	Rerank(ctx context.Context, query string, candidates []Candidate, topN int) ([]Result, *Usage, error)
+}
+
+// maxPassageChars bounds how much of each candidate is sent to the scorer
+const maxPassageChars = 1200
+
+// LLMReranker scores candidates with a single listwise prompt against an llm.Client
+type LLMReranker struct {
+	client llm.Client
+	model  string
+}
+
+// NewLLMReranker creates a reranker backed by the chat completion client
+func NewLLMReranker(client llm.Client, model string) *LLMReranker {
+	return &LLMReranker{client: client, model: model}
+}
+
+var scoresPattern = regexp.MustCompile(`(?s)\[.*\]`)
+
+// indexScore is one entry of a scorer's response
+type indexScore struct {
+	Index int     `json:"index"`
+	Score float64 `json:"score"`
+}
+
+// bestScores maps each candidate index to the highest score it was given, ignoring out-of-range indexes
+func bestScores(scored []indexScore, candidates int) map[int]float64 {
+	scores := make(map[int]float64, len(scored))
+	for _, s := range scored {
+		if s.Index < 0 || s.Index >= candidates {
+			continue
+		}
+		if prev, ok := scores[s.Index]; !ok || s.Score > prev {
+			scores[s.Index] = s.Score
+		}
+	}
+	return scores
+}
+
+// Rerank asks the model to grade every passage from 0 to 10 and keeps the best topN.
+// Usage is returned whenever the model answered, even if its scores could not be read.
+func (r *LLMReranker) Rerank(ctx context.Context, query string, candidates []Candidate, topN int) ([]Result, *Usage, error) {
+	if len(candidates) == 0 {
+		return nil, nil, nil
+	}
+
+	var passages strings.Builder
+	for i, c := range candidates {
+		passages.WriteString(fmt.Sprintf("[%d] %s\n\n", i, truncate(c.Text, maxPassageChars)))
+	}
+
+	prompt := fmt.Sprintf(`You are a search relevance grader. Score how well each passage answers the query on a scale from 0 (irrelevant) to 10 (directly answers it).
+Return only a JSON array like [{"index": 0, "score": 7}], with one entry per passage and no other text.
+
+Query: %s
+
+Passages:
+%s`, strings.TrimSpace(query), passages.String())
+
+	resp, err := r.client.Chat(ctx, llm.ChatRequest{
+		Prompt:      prompt,
+		Model:       r.model,
+		Temperature: 0,
+	})
+	if err != nil {
+		return nil, nil, apperrors.Wrap(err, "failed to score rerank candidates")
+	}
+	usage := &Usage{Model: r.model, Usage: resp.Usage}
+
+	raw := scoresPattern.FindString(resp.Content)
+	if raw == "" {
+		return nil, usage, fmt.Errorf("rerank response did not contain scores")
+	}
+
+	var scored []indexScore
+	if err := json.Unmarshal([]byte(raw), &scored); err != nil {
+		return nil, usage, apperrors.Wrap(err, "failed to decode rerank scores")
+	}
+	scores := bestScores(scored, len(candidates))
+
+	results := make([]Result, len(candidates))
+	for i := range candidates {
+		results[i] = Result{Index: i, Score: scores[i]}
+	}
+	return topResults(results, topN), usage, nil
+}
+
+// HTTPReranker calls a local reranking service using the text-embeddings-inference /rerank contract
+type HTTPReranker struct {
+	endpoint string
+	http     *http.Client
+}
+
+// NewHTTPReranker creates a reranker that posts to baseURL + "/rerank"
+func NewHTTPReranker(baseURL string) *HTTPReranker {
+	return &HTTPReranker{
+		endpoint: strings.TrimRight(baseURL, "/") + "/rerank",
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Rerank sends the query and candidate texts to the service and keeps the best topN
+func (r *HTTPReranker) Rerank(ctx context.Context, query string, candidates []Candidate, topN int) ([]Result, *Usage, error) {
+	if len(candidates) == 0 {
+		return nil, nil, nil
+	}
+
+	texts := make([]string, len(candidates))
+	for i, c := range candidates {
+		texts[i] = truncate(c.Text, maxPassageChars)
+	}
+
+	body, err := json.Marshal(map[string]any{"query": query, "texts": texts})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, nil, apperrors.Wrap(err, "rerank request failed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		return nil, nil, fmt.Errorf("rerank service returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var scored []indexScore
+	if err := json.NewDecoder(resp.Body).Decode(&scored); err != nil {
+		return nil, nil, apperrors.Wrap(err, "failed to decode rerank response")
+	}
+
+	// A candidate listed twice is kept once, so it cannot take two of the topN slots
+	scores := bestScores(scored, len(candidates))
+	results := make([]Result, 0, len(scores))
+	for i := range candidates {
+		if score, ok := scores[i]; ok {
+			results = append(results, Result{Index: i, Score: score})
+		}
+	}
+	return topResults(results, topN), nil, nil
+}
+
+// topResults sorts by score descending, keeping the original order for ties
+func topResults(results []Result, topN int) []Result {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if topN > 0 && len(results) > topN {
+		results = results[:topN]
+	}
+	return results
+}
+
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit])
+}
@@ -0,0 +1,122 @@
+package rerank
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/yourusername/vectorchat/internal/llm"
+)
+
+type fakeChatClient struct {
+	content string
+}
+
+func (c *fakeChatClient) Chat(context.Context, llm.ChatRequest) (llm.ChatResponse, error) {
+	return llm.ChatResponse{Content: c.content, Usage: llm.Usage{PromptTokens: 100, CompletionTokens: 20}}, nil
+}
+
+func (c *fakeChatClient) ListModels(context.Context) ([]llm.ModelInfo, error) {
+	return nil, nil
+}
+
+func candidates(n int) []Candidate {
+	out := make([]Candidate, n)
+	for i := range out {
+		out[i] = Candidate{ID: string(rune('a' + i)), Text: "passage"}
+	}
+	return out
+}
+
+func indexes(results []Result) []int {
+	out := make([]int, len(results))
+	for i, r := range results {
+		out[i] = r.Index
+	}
+	return out
+}
+
+func TestLLMRerankerParsesScores(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		topN    int
+		want    []int
+		wantErr bool
+	}{
+		{"plain array", `[{"index": 0, "score": 2}, {"index": 1, "score": 9}, {"index": 2, "score": 5}]`, 3, []int{1, 2, 0}, false},
+		{"wrapped in prose and a code fence", "Here are the scores:\n```json\n[{\"index\": 2, \"score\": 8}, {\"index\": 0, \"score\": 1}]\n```", 2, []int{2, 0}, false},
+		{"unscored passages rank last", `[{"index": 1, "score": 3}]`, 3, []int{1, 0, 2}, false},
+		{"out of range indexes are ignored", `[{"index": 7, "score": 10}, {"index": -1, "score": 10}, {"index": 2, "score": 4}]`, 1, []int{2}, false},
+		{"duplicate indexes keep the highest score", `[{"index": 0, "score": 9}, {"index": 1, "score": 5}, {"index": 0, "score": 1}]`, 1, []int{0}, false},
+		{"fractional scores", `[{"index": 0, "score": 6.5}, {"index": 1, "score": 6.75}]`, 2, []int{1, 0}, false},
+		{"no array", `I cannot grade these passages.`, 3, nil, true},
+		{"malformed array", `[{"index": 0, "score": "high"}]`, 3, nil, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewLLMReranker(&fakeChatClient{content: tc.content}, "gpt-4o-mini")
+			results, usage, err := r.Rerank(context.Background(), "query", candidates(3), tc.topN)
+			if usage == nil || usage.Model != "gpt-4o-mini" || usage.PromptTokens != 100 {
+				t.Errorf("usage = %+v, want the model's usage even when scores cannot be read", usage)
+			}
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", indexes(results))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := indexes(results); !slices.Equal(got, tc.want) {
+				t.Errorf("order = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHTTPRerankerDedupesIndexes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]indexScore{
+			{Index: 1, Score: 0.2},
+			{Index: 0, Score: 0.5},
+			{Index: 1, Score: 0.9},
+			{Index: 5, Score: 1},
+		})
+	}))
+	defer srv.Close()
+
+	results, _, err := NewHTTPReranker(srv.URL).Rerank(context.Background(), "query", candidates(3), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Index != 1 || results[0].Score != 0.9 || results[1].Index != 0 {
+		t.Errorf("results = %+v, want candidate 1 once with its best score, then candidate 0", results)
+	}
+}
+
+func TestTopResults(t *testing.T) {
+	cases := []struct {
+		name    string
+		results []Result
+		topN    int
+		want    []int
+	}{
+		{"sorts by score", []Result{{0, 1}, {1, 3}, {2, 2}}, 0, []int{1, 2, 0}},
+		{"ties keep the input order", []Result{{0, 5}, {1, 7}, {2, 5}, {3, 5}}, 0, []int{1, 0, 2, 3}},
+		{"cuts to topN", []Result{{0, 1}, {1, 3}, {2, 2}}, 2, []int{1, 2}},
+		{"topN above the result count", []Result{{0, 1}, {1, 3}}, 5, []int{1, 0}},
+		{"no results", nil, 3, []int{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := indexes(topResults(tc.results, tc.topN)); !slices.Equal(got, tc.want) {
+				t.Errorf("order = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
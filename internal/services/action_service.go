@@ -0,0 +1,422 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/vectorchat/internal/db"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+	"github.com/yourusername/vectorchat/internal/llm"
+	"github.com/yourusername/vectorchat/pkg/constants"
+	"github.com/yourusername/vectorchat/pkg/models"
+)
+
+// ActionService manages per-chatbot HTTP actions and executes them when the model calls them as tools.
+// Action headers are sealed with AES-256-GCM under a key derived from ACTION_SECRETS_KEY.
+type ActionService struct {
+	repo *db.ChatbotActionRepository
+	http *http.Client
+	box  *secretBox // nil when no key is configured
+}
+
+// NewActionService creates the service. Without a secret, actions cannot store headers.
+func NewActionService(repo *db.ChatbotActionRepository, secret string) *ActionService {
+	return &ActionService{
+		repo: repo,
+		http: newActionHTTPClient(),
+		box:  newSecretBox(secret),
+	}
+}
+
+// errActionAddressBlocked is returned when an action endpoint resolves to an address inside the
+// deployment's network.
+var errActionAddressBlocked = errors.New("action endpoint resolves to a private or reserved address")
+
+// reservedActionPrefixes are non-public ranges not covered by the netip.Addr predicates.
+var reservedActionPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT, also some cloud metadata services
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"), // NAT64 can reach private IPv4 addresses
+}
+
+// newActionHTTPClient returns a client that refuses to connect to loopback, private, link-local
+// (including the 169.254.169.254 metadata service) and reserved addresses. The check runs on
+// the resolved address of every connection, so redirects and DNS rebinding are covered as well.
+// Proxies are not used since they would connect on the client's behalf.
+func newActionHTTPClient() *http.Client {
+	return actionHTTPClient(actionDialControl)
+}
+
+// actionHTTPClient builds the action client around a dial control; tests swap the control.
+func actionHTTPClient(control func(network, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{Timeout: constants.ActionTimeout, Control: control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: constants.ActionTimeout, Transport: transport}
+}
+
+func actionDialControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !isPublicActionAddr(addr) {
+		return errActionAddressBlocked
+	}
+	return nil
+}
+
+// isPublicActionAddr reports whether an action may connect to addr.
+func isPublicActionAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() || addr.IsMulticast() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, prefix := range reservedActionPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// actionNamePattern matches the function names accepted by OpenAI-compatible providers
+var actionNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// pathParamPattern matches {name} placeholders in an action URL
+var pathParamPattern = regexp.MustCompile(`\{([a-zA-Z0-9_]+)\}`)
+
+var allowedActionMethods = map[string]struct{}{
+	http.MethodGet:    {},
+	http.MethodPost:   {},
+	http.MethodPut:    {},
+	http.MethodPatch:  {},
+	http.MethodDelete: {},
+}
+
+const defaultActionParameters = `{"type":"object","properties":{}}`
+
+// List returns all actions registered for a chatbot.
+func (s *ActionService) List(ctx context.Context, chatbotID uuid.UUID) (*models.ChatbotActionListResponse, error) {
+	actions, err := s.repo.ListByChatbot(ctx, chatbotID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &models.ChatbotActionListResponse{Actions: make([]models.ChatbotActionResponse, 0, len(actions))}
+	for _, a := range actions {
+		if err := s.openHeaders(a); err != nil {
+			return nil, err
+		}
+		resp.Actions = append(resp.Actions, toActionResponse(a))
+	}
+	return resp, nil
+}
+
+// Create validates and stores a new action for a chatbot.
+func (s *ActionService) Create(ctx context.Context, chatbotID uuid.UUID, req *models.ChatbotActionRequest) (*models.ChatbotActionResponse, error) {
+	existing, err := s.repo.ListByChatbot(ctx, chatbotID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= constants.MaxChatbotActions {
+		return nil, apperrors.Wrapf(apperrors.ErrInvalidChatbotParameters, "a chatbot can have at most %d actions", constants.MaxChatbotActions)
+	}
+
+	action := &db.ChatbotAction{ID: uuid.New(), ChatbotID: chatbotID, Enabled: true}
+	if err := applyActionRequest(action, req); err != nil {
+		return nil, err
+	}
+	if err := s.sealHeaders(action); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, action); err != nil {
+		return nil, err
+	}
+	resp := toActionResponse(action)
+	return &resp, nil
+}
+
+// Update replaces an action's definition. Stored headers are kept when the request omits them.
+func (s *ActionService) Update(ctx context.Context, chatbotID, actionID uuid.UUID, req *models.ChatbotActionRequest) (*models.ChatbotActionResponse, error) {
+	action, err := s.repo.FindByID(ctx, chatbotID, actionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.openHeaders(action); err != nil {
+		return nil, err
+	}
+	if err := applyActionRequest(action, req); err != nil {
+		return nil, err
+	}
+	if err := s.sealHeaders(action); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, action); err != nil {
+		return nil, err
+	}
+	resp := toActionResponse(action)
+	return &resp, nil
+}
+
+// Delete removes an action from a chatbot.
+func (s *ActionService) Delete(ctx context.Context, chatbotID, actionID uuid.UUID) error {
+	return s.repo.Delete(ctx, chatbotID, actionID)
+}
+
+// Tools returns the chatbot's enabled actions as tool definitions, keyed by name for dispatch.
+func (s *ActionService) Tools(ctx context.Context, chatbotID uuid.UUID) ([]llm.ToolDefinition, map[string]*db.ChatbotAction, error) {
+	actions, err := s.repo.ListEnabledByChatbot(ctx, chatbotID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(actions) == 0 {
+		return nil, nil, nil
+	}
+	tools := make([]llm.ToolDefinition, 0, len(actions))
+	byName := make(map[string]*db.ChatbotAction, len(actions))
+	for _, a := range actions {
+		if err := s.openHeaders(a); err != nil {
+			return nil, nil, err
+		}
+		tools = append(tools, llm.ToolDefinition{
+			Name:        a.Name,
+			Description: a.Description,
+			Parameters:  json.RawMessage(a.Parameters),
+		})
+		byName[a.Name] = a
+	}
+	return tools, byName, nil
+}
+
+// Execute calls the action endpoint with the model's JSON arguments and returns the response body.
+// {name} placeholders in the URL are filled from the arguments; the rest are sent as the query
+// string for GET/DELETE and as a JSON body otherwise.
+func (s *ActionService) Execute(ctx context.Context, action *db.ChatbotAction, arguments string) (string, error) {
+	args := map[string]any{}
+	if strings.TrimSpace(arguments) != "" {
+		if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+			return "", apperrors.Wrap(err, "invalid tool arguments")
+		}
+	}
+
+	var paramErr error
+	endpoint := pathParamPattern.ReplaceAllStringFunc(action.EndpointURL, func(match string) string {
+		key := match[1 : len(match)-1]
+		value, ok := args[key]
+		if !ok {
+			return match
+		}
+		delete(args, key)
+		segment, err := pathParamValue(key, value)
+		if err != nil && paramErr == nil {
+			paramErr = err
+		}
+		return segment
+	})
+	if paramErr != nil {
+		return "", paramErr
+	}
+
+	var body io.Reader
+	if action.HTTPMethod == http.MethodGet || action.HTTPMethod == http.MethodDelete {
+		if len(args) > 0 {
+			parsed, err := url.Parse(endpoint)
+			if err != nil {
+				return "", apperrors.Wrap(err, "invalid action url")
+			}
+			query := parsed.Query()
+			for key, value := range args {
+				query.Set(key, fmt.Sprint(value))
+			}
+			parsed.RawQuery = query.Encode()
+			endpoint = parsed.String()
+		}
+	} else {
+		payload, err := json.Marshal(args)
+		if err != nil {
+			return "", err
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, action.HTTPMethod, endpoint, body)
+	if err != nil {
+		return "", apperrors.Wrap(err, "failed to build action request")
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range action.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return "", apperrors.Wrap(err, "action request failed")
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, constants.MaxActionResponseBytes))
+	if err != nil {
+		return "", apperrors.Wrap(err, "failed to read action response")
+	}
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("action returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return string(data), nil
+}
+
+// pathParamValue escapes an argument for a {name} placeholder. url.PathEscape leaves "." and ".."
+// as they are and an empty value drops the segment, either of which would let the model move to
+// another endpoint path, so those are refused.
+func pathParamValue(key string, value any) (string, error) {
+	raw := fmt.Sprint(value)
+	if raw == "" || raw == "." || raw == ".." {
+		return "", fmt.Errorf("invalid tool arguments: %s cannot be %q", key, raw)
+	}
+	return url.PathEscape(raw), nil
+}
+
+// sealHeaders stores the action's headers in sealed form.
+func (s *ActionService) sealHeaders(action *db.ChatbotAction) error {
+	action.SealedHeaders = nil
+	if len(action.Headers) == 0 {
+		return nil
+	}
+	if s.box == nil {
+		return apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "action headers are not enabled on this server")
+	}
+	plain, err := json.Marshal(action.Headers)
+	if err != nil {
+		return apperrors.Wrap(err, "failed to encode action headers")
+	}
+	if action.SealedHeaders, err = s.box.seal(plain, actionHeadersAAD(action)); err != nil {
+		return apperrors.Wrap(err, "failed to seal action headers")
+	}
+	return nil
+}
+
+// openHeaders fills in action.Headers from the sealed headers.
+func (s *ActionService) openHeaders(action *db.ChatbotAction) error {
+	if len(action.SealedHeaders) == 0 {
+		action.Headers = nil
+		return nil
+	}
+	if s.box == nil {
+		return fmt.Errorf("headers of action %q cannot be opened: ACTION_SECRETS_KEY is not set", action.Name)
+	}
+	plain, err := s.box.open(action.SealedHeaders, actionHeadersAAD(action))
+	if err != nil {
+		return fmt.Errorf("headers of action %q: %w", action.Name, err)
+	}
+	var headers db.RequestHeaders
+	if err := json.Unmarshal(plain, &headers); err != nil {
+		return apperrors.Wrap(err, "failed to decode action headers")
+	}
+	action.Headers = headers
+	return nil
+}
+
+// actionHeadersAAD binds sealed headers to their action, so a row copied to another action does not open.
+func actionHeadersAAD(action *db.ChatbotAction) []byte {
+	return []byte(action.ChatbotID.String() + "\n" + action.ID.String())
+}
+
+func applyActionRequest(action *db.ChatbotAction, req *models.ChatbotActionRequest) error {
+	if req == nil {
+		return apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "request body is required")
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if !actionNamePattern.MatchString(name) {
+		return apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "name must be 1-64 letters, digits, underscores or dashes")
+	}
+
+	method := strings.ToUpper(strings.TrimSpace(req.Method))
+	if method == "" {
+		method = http.MethodPost
+	}
+	if _, ok := allowedActionMethods[method]; !ok {
+		return apperrors.Wrapf(apperrors.ErrInvalidChatbotParameters, "unsupported method %q", req.Method)
+	}
+
+	endpoint := strings.TrimSpace(req.URL)
+	parsed, err := url.Parse(endpoint)
+	if err != nil || !isHTTPURL(endpoint) || parsed.Host == "" {
+		return apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "url must be an absolute http(s) URL")
+	}
+	// Hostnames are checked when connecting; literal addresses and localhost are rejected up front
+	host := parsed.Hostname()
+	if addr, err := netip.ParseAddr(host); (err == nil && !isPublicActionAddr(addr)) || strings.EqualFold(host, "localhost") {
+		return apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "url must point to a public host")
+	}
+
+	params := json.RawMessage(defaultActionParameters)
+	if len(bytes.TrimSpace(req.Parameters)) > 0 && string(bytes.TrimSpace(req.Parameters)) != "null" {
+		var schema map[string]any
+		if err := json.Unmarshal(req.Parameters, &schema); err != nil {
+			return apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "parameters must be a JSON schema object")
+		}
+		if t, ok := schema["type"]; ok && t != "object" {
+			return apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, `parameters schema must have type "object"`)
+		}
+		params = req.Parameters
+	}
+
+	action.Name = name
+	action.Description = strings.TrimSpace(req.Description)
+	action.HTTPMethod = method
+	action.EndpointURL = endpoint
+	action.Parameters = db.JSONDocument(params)
+	if req.Headers != nil {
+		action.Headers = db.RequestHeaders(req.Headers)
+	}
+	if req.Enabled != nil {
+		action.Enabled = *req.Enabled
+	}
+	return nil
+}
+
+func toActionResponse(a *db.ChatbotAction) models.ChatbotActionResponse {
+	names := make([]string, 0, len(a.Headers))
+	for name := range a.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return models.ChatbotActionResponse{
+		ID:          a.ID,
+		ChatbotID:   a.ChatbotID,
+		Name:        a.Name,
+		Description: a.Description,
+		Parameters:  json.RawMessage(a.Parameters),
+		Method:      a.HTTPMethod,
+		URL:         a.EndpointURL,
+		HeaderNames: names,
+		Enabled:     a.Enabled,
+		CreatedAt:   a.CreatedAt,
+		UpdatedAt:   a.UpdatedAt,
+	}
+}
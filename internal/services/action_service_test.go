@@ -0,0 +1,175 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/vectorchat/internal/db"
+	"github.com/yourusername/vectorchat/pkg/models"
+)
+
+func TestIsPublicActionAddr(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.100.100.200", false},
+		{"0.0.0.0", false},
+		{"fd00:ec2::254", false},
+		{"fe80::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+	}
+	for _, tt := range tests {
+		if got := isPublicActionAddr(netip.MustParseAddr(tt.addr)); got != tt.public {
+			t.Errorf("isPublicActionAddr(%s) = %v, want %v", tt.addr, got, tt.public)
+		}
+	}
+}
+
+func TestExecuteRefusesPrivateAddresses(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	svc := NewActionService(nil, "")
+	action := &db.ChatbotAction{Name: "lookup", HTTPMethod: http.MethodGet, EndpointURL: srv.URL + "/orders/{id}"}
+	_, err := svc.Execute(context.Background(), action, `{"id":"1"}`)
+	if !errors.Is(err, errActionAddressBlocked) {
+		t.Fatalf("expected blocked address error, got %v", err)
+	}
+	if called {
+		t.Fatal("action endpoint on loopback was called")
+	}
+}
+
+func TestApplyActionRequestRejectsPrivateHosts(t *testing.T) {
+	for _, u := range []string{"http://localhost:8080/hook", "http://127.0.0.1/hook", "http://[::1]/hook", "http://169.254.169.254/latest/meta-data"} {
+		if err := applyActionRequest(&db.ChatbotAction{}, &models.ChatbotActionRequest{Name: "hook", URL: u}); err == nil {
+			t.Errorf("expected %s to be rejected", u)
+		}
+	}
+	if err := applyActionRequest(&db.ChatbotAction{}, &models.ChatbotActionRequest{Name: "hook", URL: "https://api.example.com/hook"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestActionHeadersSealAndOpen(t *testing.T) {
+	svc := NewActionService(nil, "test-key")
+	action := &db.ChatbotAction{ID: uuid.New(), ChatbotID: uuid.New(), Name: "lookup", Headers: db.RequestHeaders{"Authorization": "Bearer t-123"}}
+	if err := svc.sealHeaders(action); err != nil {
+		t.Fatalf("seal failed: %v", err)
+	}
+	if bytes.Contains(action.SealedHeaders, []byte("t-123")) {
+		t.Fatal("sealed headers contain plaintext")
+	}
+
+	stored := &db.ChatbotAction{ID: action.ID, ChatbotID: action.ChatbotID, Name: action.Name, SealedHeaders: action.SealedHeaders}
+	if err := svc.openHeaders(stored); err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	if stored.Headers["Authorization"] != "Bearer t-123" {
+		t.Fatalf("unexpected headers %v", stored.Headers)
+	}
+
+	// Sealed headers only open for the action they were stored for
+	stored.ID = uuid.New()
+	if err := svc.openHeaders(stored); err == nil {
+		t.Fatal("expected headers moved to another action not to open")
+	}
+
+	if err := NewActionService(nil, "").sealHeaders(&db.ChatbotAction{Headers: db.RequestHeaders{"X-Key": "k"}}); err == nil {
+		t.Fatal("expected headers to be refused without a key")
+	}
+}
+
+func TestExecuteRejectsDotSegments(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	svc := &ActionService{http: srv.Client()}
+	action := &db.ChatbotAction{Name: "lookup", HTTPMethod: http.MethodGet, EndpointURL: srv.URL + "/orders/{id}/items"}
+	for _, id := range []string{`".."`, `"."`, `""`} {
+		if _, err := svc.Execute(context.Background(), action, `{"id":`+id+`}`); err == nil {
+			t.Errorf("expected id %s to be rejected", id)
+		}
+	}
+	if len(paths) != 0 {
+		t.Fatalf("endpoint called with %v", paths)
+	}
+
+	for _, id := range []string{`"../admin"`, `"..."`, `"a b"`, `42`} {
+		if _, err := svc.Execute(context.Background(), action, `{"id":`+id+`}`); err != nil {
+			t.Errorf("id %s: %v", id, err)
+		}
+	}
+	want := []string{"/orders/..%2Fadmin/items", "/orders/.../items", "/orders/a%20b/items", "/orders/42/items"}
+	if strings.Join(paths, " ") != strings.Join(want, " ") {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+}
+
+func TestActionDialControl(t *testing.T) {
+	for _, address := range []string{"10.0.0.5:80", "172.31.255.1:443", "192.168.0.10:8080", "127.0.0.1:5432", "169.254.169.254:80", "[fd00::1]:443", "[::ffff:10.0.0.1]:80"} {
+		if err := actionDialControl("tcp", address, nil); !errors.Is(err, errActionAddressBlocked) {
+			t.Errorf("dial to %s = %v, want blocked", address, err)
+		}
+	}
+	if err := actionDialControl("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("dial to a public address refused: %v", err)
+	}
+}
+
+func TestExecuteRefusesRedirectsToPrivateAddresses(t *testing.T) {
+	var internalCalled atomic.Bool
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		internalCalled.Store(true)
+	}))
+	defer internal.Close()
+
+	for _, target := range []string{internal.URL + "/admin", "http://169.254.169.254/latest/meta-data"} {
+		public := httptest.NewServer(http.RedirectHandler(target, http.StatusFound))
+		publicAddr := public.Listener.Addr().String()
+		// only the first hop stands in for a public host; every other connection is checked as usual
+		svc := &ActionService{http: actionHTTPClient(func(network, address string, c syscall.RawConn) error {
+			if address == publicAddr {
+				return nil
+			}
+			return actionDialControl(network, address, c)
+		})}
+
+		action := &db.ChatbotAction{Name: "lookup", HTTPMethod: http.MethodGet, EndpointURL: public.URL + "/orders"}
+		_, err := svc.Execute(context.Background(), action, "")
+		public.Close()
+		if !errors.Is(err, errActionAddressBlocked) {
+			t.Errorf("redirect to %s = %v, want blocked", target, err)
+		}
+	}
+	if internalCalled.Load() {
+		t.Fatal("redirect reached the internal server")
+	}
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yourusername/vectorchat/internal/db"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+	"github.com/yourusername/vectorchat/internal/llm"
+	"github.com/yourusername/vectorchat/pkg/constants"
+	"github.com/yourusername/vectorchat/pkg/models"
+)
+
+func floatPtr(v float64) *float64 { return &v }
+
+func TestApplyRetrievalConfig(t *testing.T) {
+	cases := []struct {
+		name    string
+		req     models.RetrievalConfigRequest
+		wantErr bool
+		check   func(db.RetrievalConfig) bool
+	}{
+		{name: "empty request keeps the defaults", check: func(c db.RetrievalConfig) bool { return c == defaultRetrievalConfig() }},
+		{name: "top_k lower bound", req: models.RetrievalConfigRequest{TopK: intPtr(1)}, check: func(c db.RetrievalConfig) bool { return c.TopK == 1 }},
+		{name: "top_k upper bound", req: models.RetrievalConfigRequest{TopK: intPtr(constants.MaxRetrievalTopK)}, check: func(c db.RetrievalConfig) bool { return c.TopK == constants.MaxRetrievalTopK }},
+		{name: "top_k zero", req: models.RetrievalConfigRequest{TopK: intPtr(0)}, wantErr: true},
+		{name: "top_k above max", req: models.RetrievalConfigRequest{TopK: intPtr(constants.MaxRetrievalTopK + 1)}, wantErr: true},
+		{name: "min_similarity bounds", req: models.RetrievalConfigRequest{MinSimilarity: floatPtr(1)}, check: func(c db.RetrievalConfig) bool { return c.MinSimilarity == 1 }},
+		{name: "min_similarity negative", req: models.RetrievalConfigRequest{MinSimilarity: floatPtr(-0.1)}, wantErr: true},
+		{name: "min_similarity above one", req: models.RetrievalConfigRequest{MinSimilarity: floatPtr(1.1)}, wantErr: true},
+		{name: "revision_match_threshold above one", req: models.RetrievalConfigRequest{RevisionMatchThreshold: floatPtr(1.5)}, wantErr: true},
+		{name: "revision_inject_threshold negative", req: models.RetrievalConfigRequest{RevisionInjectThreshold: floatPtr(-1)}, wantErr: true},
+		{name: "history_limit zero", req: models.RetrievalConfigRequest{HistoryLimit: intPtr(0)}, check: func(c db.RetrievalConfig) bool { return c.HistoryLimit == 0 }},
+		{name: "history_limit negative", req: models.RetrievalConfigRequest{HistoryLimit: intPtr(-1)}, wantErr: true},
+		{name: "history_limit above max", req: models.RetrievalConfigRequest{HistoryLimit: intPtr(constants.MaxHistoryLimit + 1)}, wantErr: true},
+		{name: "context_token_budget negative", req: models.RetrievalConfigRequest{ContextTokenBudget: intPtr(-1)}, wantErr: true},
+		{name: "context_token_budget zero disables it", req: models.RetrievalConfigRequest{ContextTokenBudget: intPtr(0)}, check: func(c db.RetrievalConfig) bool { return c.ContextTokenBudget == 0 }},
+
+		// the inject threshold may never exceed the match threshold, whichever field the request sets
+		{name: "inject threshold equal to match threshold", req: models.RetrievalConfigRequest{RevisionMatchThreshold: floatPtr(0.9), RevisionInjectThreshold: floatPtr(0.9)}, check: func(c db.RetrievalConfig) bool {
+			return c.RevisionMatchThreshold == 0.9 && c.RevisionInjectThreshold == 0.9
+		}},
+		{name: "inject threshold above match threshold", req: models.RetrievalConfigRequest{RevisionMatchThreshold: floatPtr(0.8), RevisionInjectThreshold: floatPtr(0.9)}, wantErr: true},
+		{name: "inject threshold raised above the stored match threshold", req: models.RetrievalConfigRequest{RevisionInjectThreshold: floatPtr(constants.DefaultRevisionMatchThreshold + 0.01)}, wantErr: true},
+		{name: "match threshold lowered below the stored inject threshold", req: models.RetrievalConfigRequest{RevisionMatchThreshold: floatPtr(constants.DefaultRevisionInjectThreshold - 0.01)}, wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := defaultRetrievalConfig()
+			err := applyRetrievalConfig(&cfg, &tc.req)
+			if tc.wantErr {
+				if !apperrors.Is(err, apperrors.ErrInvalidChatbotParameters) {
+					t.Fatalf("error = %v, want ErrInvalidChatbotParameters", err)
+				}
+				if cfg != defaultRetrievalConfig() {
+					t.Errorf("config changed to %+v by a rejected request", cfg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tc.check(cfg) {
+				t.Errorf("config = %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestFitDocumentsToTokenBudget(t *testing.T) {
+	doc := func(id, content string) *db.DocumentWithEmbedding {
+		return &db.DocumentWithEmbedding{ID: id, Content: []byte(content)}
+	}
+	short := doc("short", "refunds are processed within five days")
+	long := doc("long", strings.Repeat("shipping takes a week to most countries ", 20))
+	tail := doc("tail", "contact support by email")
+	docs := []*db.DocumentWithEmbedding{short, long, tail}
+
+	shortTokens := llm.EstimateTokens(string(short.Content))
+	tailTokens := llm.EstimateTokens(string(tail.Content))
+	longTokens := llm.EstimateTokens(string(long.Content))
+
+	cases := []struct {
+		name   string
+		budget int
+		want   []string
+	}{
+		{"no budget keeps everything", 0, []string{"short", "long", "tail"}},
+		{"negative budget keeps everything", -1, []string{"short", "long", "tail"}},
+		{"budget fits everything", shortTokens + longTokens + tailTokens, []string{"short", "long", "tail"}},
+		{"an oversized chunk is skipped for smaller ones after it", shortTokens + tailTokens, []string{"short", "tail"}},
+		{"budget below the first chunk", shortTokens - 1, []string{"tail"}},
+		{"budget below every chunk", 1, []string{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := fitDocumentsToTokenBudget(docs, tc.budget)
+			ids := make([]string, 0, len(got))
+			for _, d := range got {
+				ids = append(ids, d.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("kept %v, want %v", ids, tc.want)
+			}
+		})
+	}
+}
+
+func TestCapRerankCandidates(t *testing.T) {
+	docs := []*db.DocumentWithEmbedding{
+		{ID: "chatbot-1", Similarity: 0.9},
+		{ID: "chatbot-2", Similarity: 0.4},
+		{ID: "shared-1", Similarity: 0.8},
+		{ID: "shared-2", Similarity: 0.7},
+		{ID: "other-model-1", Similarity: 0.6},
+	}
+	if got := capRerankCandidates(docs, 10); len(got) != len(docs) {
+		t.Fatalf("kept %d candidates under the limit, want all %d", len(got), len(docs))
+	}
+	got := capRerankCandidates(docs, 3)
+	ids := make([]string, 0, len(got))
+	for _, d := range got {
+		ids = append(ids, d.ID)
+	}
+	if want := "chatbot-1,shared-1,shared-2"; strings.Join(ids, ",") != want {
+		t.Errorf("kept %v, want %s", ids, want)
+	}
+}
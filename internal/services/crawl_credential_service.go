@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"golang.org/x/net/http/httpguts"
+
+	"github.com/yourusername/vectorchat/internal/crawler"
+	"github.com/yourusername/vectorchat/internal/db"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+	"github.com/yourusername/vectorchat/pkg/constants"
+	"github.com/yourusername/vectorchat/pkg/models"
+)
+
+// reservedCrawlHeaders are managed by the HTTP client or by the cookie and basic-auth fields.
+var reservedCrawlHeaders = map[string]bool{
+	"Host": true, "Content-Length": true, "Transfer-Encoding": true, "Connection": true, "Cookie": true,
+}
+
+// CrawlCredentialService stores the credentials sent with crawls of protected websites.
+// They are keyed by knowledge base and root URL, so scheduled, queued and one-off crawls of a
+// source share them, and sealed with AES-256-GCM under a key derived from CRAWLER_CREDENTIALS_KEY.
+// Crawl jobs refer to them by ID; only workers open them.
+type CrawlCredentialService struct {
+	repo *db.CrawlCredentialRepository
+	box  *secretBox // nil when no key is configured
+}
+
+// NewCrawlCredentialService creates the service. Without a secret, credentials cannot be stored.
+func NewCrawlCredentialService(repo *db.CrawlCredentialRepository, secret string) *CrawlCredentialService {
+	return &CrawlCredentialService{repo: repo, box: newSecretBox(secret)}
+}
+
+// Save stores auth for the target's rootURL. A nil auth keeps the stored credentials and an
+// empty one removes them.
+func (s *CrawlCredentialService) Save(ctx context.Context, target KnowledgeBaseTarget, rootURL string, auth *models.CrawlAuth) error {
+	if auth == nil {
+		return nil
+	}
+	if err := target.validate(); err != nil {
+		return err
+	}
+	if auth.IsEmpty() {
+		return s.Delete(ctx, target, rootURL)
+	}
+	auth, err := normalizeCrawlAuth(auth)
+	if err != nil {
+		return err
+	}
+	secret, err := s.seal(target, rootURL, auth)
+	if err != nil {
+		return err
+	}
+	chatbotID, sharedID := target.fileOwner()
+	return s.repo.Upsert(ctx, &db.CrawlCredential{
+		ChatbotID:             chatbotID,
+		SharedKnowledgeBaseID: sharedID,
+		RootURL:               rootURL,
+		Secret:                secret,
+	})
+}
+
+// Reference returns the ID of the credentials stored for rootURL, or nil when there are none.
+func (s *CrawlCredentialService) Reference(ctx context.Context, target KnowledgeBaseTarget, rootURL string) (*uuid.UUID, error) {
+	cred, err := s.repo.FindByScope(ctx, target.ChatbotID, target.SharedKnowledgeBaseID, rootURL)
+	if err != nil {
+		if apperrors.Is(err, apperrors.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &cred.ID, nil
+}
+
+// Resolve opens the credentials referenced by a crawl job, checking they belong to the target.
+func (s *CrawlCredentialService) Resolve(ctx context.Context, target KnowledgeBaseTarget, id uuid.UUID) (*crawler.Auth, error) {
+	cred, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !sameUUID(cred.ChatbotID, target.ChatbotID) || !sameUUID(cred.SharedKnowledgeBaseID, target.SharedKnowledgeBaseID) {
+		return nil, apperrors.ErrUnauthorizedKnowledgeBaseAccess
+	}
+	return s.crawlerAuth(target, cred)
+}
+
+// ForSource opens the credentials stored for rootURL, returning nil when there are none.
+func (s *CrawlCredentialService) ForSource(ctx context.Context, target KnowledgeBaseTarget, rootURL string) (*crawler.Auth, error) {
+	cred, err := s.repo.FindByScope(ctx, target.ChatbotID, target.SharedKnowledgeBaseID, rootURL)
+	if err != nil {
+		if apperrors.Is(err, apperrors.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return s.crawlerAuth(target, cred)
+}
+
+// Summaries describes the stored credentials of the target by root URL, without their values.
+func (s *CrawlCredentialService) Summaries(ctx context.Context, target KnowledgeBaseTarget) (map[string]*models.CrawlAuthSummary, error) {
+	creds, err := s.repo.ListByScope(ctx, target.ChatbotID, target.SharedKnowledgeBaseID)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]*models.CrawlAuthSummary, len(creds))
+	for _, cred := range creds {
+		auth, err := s.open(target, cred)
+		if err != nil {
+			return nil, err
+		}
+		summary := &models.CrawlAuthSummary{BasicAuth: auth.Username != "" || auth.Password != ""}
+		for name := range auth.Headers {
+			summary.Headers = append(summary.Headers, http.CanonicalHeaderKey(name))
+		}
+		for name := range auth.Cookies {
+			summary.Cookies = append(summary.Cookies, name)
+		}
+		sort.Strings(summary.Headers)
+		sort.Strings(summary.Cookies)
+		out[cred.RootURL] = summary
+	}
+	return out, nil
+}
+
+// Delete removes the credentials stored for rootURL.
+func (s *CrawlCredentialService) Delete(ctx context.Context, target KnowledgeBaseTarget, rootURL string) error {
+	return s.repo.DeleteByScope(ctx, target.ChatbotID, target.SharedKnowledgeBaseID, rootURL)
+}
+
+func (s *CrawlCredentialService) crawlerAuth(target KnowledgeBaseTarget, cred *db.CrawlCredential) (*crawler.Auth, error) {
+	auth, err := s.open(target, cred)
+	if err != nil {
+		return nil, err
+	}
+	return &crawler.Auth{Headers: auth.Headers, Cookies: auth.Cookies, Username: auth.Username, Password: auth.Password}, nil
+}
+
+// seal encrypts auth as nonce || ciphertext.
+func (s *CrawlCredentialService) seal(target KnowledgeBaseTarget, rootURL string, auth *models.CrawlAuth) ([]byte, error) {
+	if s.box == nil {
+		return nil, apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "crawl credentials are not enabled on this server")
+	}
+	plain, err := json.Marshal(auth)
+	if err != nil {
+		return nil, apperrors.Wrap(err, "failed to encode crawl credentials")
+	}
+	sealed, err := s.box.seal(plain, credentialAAD(target, rootURL))
+	if err != nil {
+		return nil, apperrors.Wrap(err, "failed to seal crawl credentials")
+	}
+	return sealed, nil
+}
+
+func (s *CrawlCredentialService) open(target KnowledgeBaseTarget, cred *db.CrawlCredential) (*models.CrawlAuth, error) {
+	if s.box == nil {
+		return nil, fmt.Errorf("crawl credentials for %s cannot be opened: CRAWLER_CREDENTIALS_KEY is not set", cred.RootURL)
+	}
+	plain, err := s.box.open(cred.Secret, credentialAAD(target, cred.RootURL))
+	if err != nil {
+		if errors.Is(err, errSecretCorrupt) {
+			return nil, fmt.Errorf("crawl credentials for %s are corrupt", cred.RootURL)
+		}
+		return nil, fmt.Errorf("crawl credentials for %s cannot be opened with the configured key", cred.RootURL)
+	}
+	var auth models.CrawlAuth
+	if err := json.Unmarshal(plain, &auth); err != nil {
+		return nil, apperrors.Wrap(err, "failed to decode crawl credentials")
+	}
+	return &auth, nil
+}
+
+// credentialAAD binds sealed credentials to their knowledge base and URL, so a row copied
+// to another source does not open.
+func credentialAAD(target KnowledgeBaseTarget, rootURL string) []byte {
+	return []byte(target.namespace() + "\n" + rootURL)
+}
+
+// normalizeCrawlAuth validates auth and returns a copy with canonical header names.
+func normalizeCrawlAuth(auth *models.CrawlAuth) (*models.CrawlAuth, error) {
+	if len(auth.Headers) > constants.MaxCrawlAuthEntries || len(auth.Cookies) > constants.MaxCrawlAuthEntries {
+		return nil, apperrors.Wrapf(apperrors.ErrInvalidChatbotParameters, "at most %d headers and %d cookies are allowed", constants.MaxCrawlAuthEntries, constants.MaxCrawlAuthEntries)
+	}
+	basic := auth.Username != "" || auth.Password != ""
+	normalized := &models.CrawlAuth{Cookies: auth.Cookies, Username: auth.Username, Password: auth.Password}
+	for name, value := range auth.Headers {
+		canonical := http.CanonicalHeaderKey(strings.TrimSpace(name))
+		if !httpguts.ValidHeaderFieldName(canonical) || !httpguts.ValidHeaderFieldValue(value) {
+			return nil, apperrors.Wrapf(apperrors.ErrInvalidChatbotParameters, "invalid header %q", name)
+		}
+		if reservedCrawlHeaders[canonical] {
+			return nil, apperrors.Wrapf(apperrors.ErrInvalidChatbotParameters, "header %q is managed by the crawler; use the cookies or basic auth fields instead", canonical)
+		}
+		if canonical == "Authorization" && basic {
+			return nil, apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "an Authorization header cannot be combined with basic auth")
+		}
+		if normalized.Headers == nil {
+			normalized.Headers = make(map[string]string, len(auth.Headers))
+		}
+		normalized.Headers[canonical] = value
+	}
+	for name, value := range auth.Cookies {
+		if !httpguts.ValidHeaderFieldName(name) || strings.ContainsAny(value, "; \t\r\n\",\\") {
+			return nil, apperrors.Wrapf(apperrors.ErrInvalidChatbotParameters, "invalid cookie %q", name)
+		}
+	}
+	if auth.Password != "" && auth.Username == "" {
+		return nil, apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "basic auth requires a username")
+	}
+	if strings.Contains(auth.Username, ":") {
+		return nil, apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "basic auth username cannot contain ':'")
+	}
+	return normalized, nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/vectorchat/internal/db"
+	"github.com/yourusername/vectorchat/pkg/models"
+)
+
+func TestCrawlCredentialsSealAndOpen(t *testing.T) {
+	svc := NewCrawlCredentialService(nil, "test-key")
+	chatbotID := uuid.New()
+	target := KnowledgeBaseTarget{ChatbotID: &chatbotID}
+	auth := &models.CrawlAuth{Headers: map[string]string{"X-Api-Key": "k-123"}, Username: "reader", Password: "s3cret"}
+
+	secret, err := svc.seal(target, "https://docs.example.com", auth)
+	if err != nil {
+		t.Fatalf("seal failed: %v", err)
+	}
+	if bytes.Contains(secret, []byte("k-123")) || bytes.Contains(secret, []byte("s3cret")) {
+		t.Fatal("sealed credentials contain plaintext")
+	}
+
+	cred := &db.CrawlCredential{ChatbotID: &chatbotID, RootURL: "https://docs.example.com", Secret: secret}
+	opened, err := svc.open(target, cred)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	if opened.Headers["X-Api-Key"] != "k-123" || opened.Username != "reader" || opened.Password != "s3cret" {
+		t.Fatalf("unexpected credentials %+v", opened)
+	}
+
+	// Sealed credentials only open for the source they were stored for
+	cred.RootURL = "https://other.example.com"
+	if _, err := svc.open(target, cred); err == nil {
+		t.Fatal("expected credentials moved to another URL not to open")
+	}
+	if _, err := NewCrawlCredentialService(nil, "other-key").open(target, &db.CrawlCredential{RootURL: "https://docs.example.com", Secret: secret}); err == nil {
+		t.Fatal("expected credentials not to open with another key")
+	}
+}
+
+func TestNormalizeCrawlAuth(t *testing.T) {
+	auth, err := normalizeCrawlAuth(&models.CrawlAuth{Headers: map[string]string{" x-api-key": "k"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if auth.Headers["X-Api-Key"] != "k" {
+		t.Fatalf("expected canonical header name, got %v", auth.Headers)
+	}
+
+	invalid := []*models.CrawlAuth{
+		{Headers: map[string]string{"Host": "example.com"}},
+		{Headers: map[string]string{"X-Bad": "line\nbreak"}},
+		{Headers: map[string]string{"Authorization": "Bearer t"}, Username: "u"},
+		{Cookies: map[string]string{"session": "a;b"}},
+		{Password: "no-user"},
+	}
+	for _, a := range invalid {
+		if _, err := normalizeCrawlAuth(a); err == nil {
+			t.Errorf("expected %+v to be rejected", *a)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/yourusername/vectorchat/internal/crawler"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+)
+
+// IsPermanentCrawlError reports whether a failed crawl job would fail the same way if retried:
+// its knowledge base or credentials are gone, its input is invalid, or robots.txt forbids it.
+// Anything else, such as an unreachable site or database, is treated as transient.
+func IsPermanentCrawlError(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, crawler.ErrDisallowedByRobots),
+		errors.Is(err, crawler.ErrInvalidRootURL),
+		apperrors.Is(err, apperrors.ErrInvalidChatbotParameters),
+		apperrors.Is(err, apperrors.ErrNotFound),
+		apperrors.Is(err, apperrors.ErrChatbotNotFound),
+		apperrors.Is(err, apperrors.ErrSharedKnowledgeBaseNotFound),
+		apperrors.Is(err, apperrors.ErrUnauthorizedChatbotAccess),
+		apperrors.Is(err, apperrors.ErrUnauthorizedKnowledgeBaseAccess):
+		return true
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/yourusername/vectorchat/internal/crawler"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+)
+
+func TestIsPermanentCrawlError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"robots", fmt.Errorf("%w: https://example.com", crawler.ErrDisallowedByRobots), true},
+		{"invalid url", crawler.ErrInvalidRootURL, true},
+		{"no content", apperrors.Wrapf(apperrors.ErrInvalidChatbotParameters, "crawl of %s returned no content", "https://example.com"), true},
+		{"knowledge base deleted", apperrors.Wrap(apperrors.ErrSharedKnowledgeBaseNotFound, "lookup failed"), true},
+		{"credentials moved", apperrors.ErrUnauthorizedKnowledgeBaseAccess, true},
+		{"site down", errors.New("crawl of https://example.com failed: connection refused"), false},
+		{"timeout", context.DeadlineExceeded, false},
+		{"nil", nil, false},
+	}
+	for _, tc := range cases {
+		if got := IsPermanentCrawlError(tc.err); got != tc.want {
+			t.Errorf("%s: IsPermanentCrawlError = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
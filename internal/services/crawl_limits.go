@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/vectorchat/internal/db"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+	"github.com/yourusername/vectorchat/pkg/constants"
+	stripe_sub "github.com/yourusername/vectorchat/pkg/stripe_sub"
+)
+
+// CrawlLimits are the page and depth ceilings a plan allows for one website crawl.
+type CrawlLimits struct {
+	MaxPages int
+	MaxDepth int
+}
+
+// CrawlLimitService resolves the crawl limits of the plan that pays for a knowledge base, so
+// scheduled and queued crawls are held to the same limits as requests checked by the API.
+type CrawlLimitService struct {
+	subs         *stripe_sub.Service
+	chatRepo     *db.ChatbotRepository
+	sharedKBRepo *db.SharedKnowledgeBaseRepository
+	orgRepo      *db.OrganizationRepository
+	userRepo     *db.UserRepository
+}
+
+func NewCrawlLimitService(subs *stripe_sub.Service, chatRepo *db.ChatbotRepository, sharedKBRepo *db.SharedKnowledgeBaseRepository, orgRepo *db.OrganizationRepository, userRepo *db.UserRepository) *CrawlLimitService {
+	return &CrawlLimitService{
+		subs:         subs,
+		chatRepo:     chatRepo,
+		sharedKBRepo: sharedKBRepo,
+		orgRepo:      orgRepo,
+		userRepo:     userRepo,
+	}
+}
+
+// ForTarget returns the crawl limits of the knowledge base owner's plan; organization knowledge
+// bases use the plan of the organization creator.
+func (s *CrawlLimitService) ForTarget(ctx context.Context, target KnowledgeBaseTarget) (CrawlLimits, error) {
+	var (
+		userID string
+		orgID  *uuid.UUID
+	)
+	if chatbotID, sharedID := target.fileOwner(); chatbotID != nil {
+		chatbot, err := s.chatRepo.FindByID(ctx, *chatbotID)
+		if err != nil {
+			return CrawlLimits{}, err
+		}
+		userID, orgID = chatbot.UserID, chatbot.OrganizationID
+	} else {
+		kb, err := s.sharedKBRepo.FindByID(ctx, *sharedID)
+		if err != nil {
+			return CrawlLimits{}, err
+		}
+		userID, orgID = kb.OwnerID, kb.OrganizationID
+	}
+
+	plan, _, err := billingPlan(ctx, s.subs, s.orgRepo, s.userRepo, userID, orgID)
+	if err != nil {
+		return CrawlLimits{}, err
+	}
+	limits := CrawlLimits{MaxPages: constants.DefaultCrawlPages, MaxDepth: constants.DefaultCrawlDepth}
+	if plan != nil {
+		if features, ok := plan.PlanDefinition["features"].(map[string]interface{}); ok {
+			limits.MaxPages = PlanIntLimit(features, constants.LimitCrawlPages, constants.DefaultCrawlPages)
+			limits.MaxDepth = PlanIntLimit(features, constants.LimitCrawlDepth, constants.DefaultCrawlDepth)
+		}
+	}
+	return limits, nil
+}
+
+// billingPlan returns the plan and subscription paying for userID's resources, or for the
+// organization's when orgID is set, which are billed to the organization creator.
+func billingPlan(ctx context.Context, subs *stripe_sub.Service, orgRepo *db.OrganizationRepository, userRepo *db.UserRepository, userID string, orgID *uuid.UUID) (*stripe_sub.Plan, *stripe_sub.Subscription, error) {
+	billingUserID := userID
+	var billingEmail string
+
+	if orgID != nil {
+		org, err := orgRepo.FindByID(ctx, *orgID)
+		if err != nil {
+			return nil, nil, err
+		}
+		billingUserID = org.CreatedBy
+		if org.BillingEmail != nil {
+			billingEmail = *org.BillingEmail
+		}
+	}
+
+	if billingEmail == "" {
+		user, err := userRepo.FindByID(ctx, billingUserID)
+		if err != nil {
+			return nil, nil, apperrors.Wrap(err, "failed to load billing user")
+		}
+		billingEmail = user.Email
+	}
+
+	plan, sub, err := subs.GetUserPlan(ctx, &billingUserID, billingEmail)
+	if err != nil {
+		return nil, nil, apperrors.Wrap(err, "failed to retrieve subscription")
+	}
+	return plan, sub, nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/vectorchat/internal/crawler"
+	"github.com/yourusername/vectorchat/internal/db"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+	"github.com/yourusername/vectorchat/pkg/constants"
+	"github.com/yourusername/vectorchat/pkg/models"
+)
+
+// crawlRequestTimeout bounds each crawler request
+const crawlRequestTimeout = 40 * time.Second
+
+// normalizeCrawlOptions validates opts and fills in defaults; nil yields the default options.
+// Plan limits are enforced before this by the subscription middleware, the hard ceilings here apply to everyone.
+func normalizeCrawlOptions(opts *models.CrawlOptions) (models.CrawlOptions, error) {
+	out := models.CrawlOptions{MaxPages: constants.DefaultCrawlPages}
+	depth := constants.DefaultCrawlDepth
+	if opts == nil {
+		out.MaxDepth = &depth
+		return out, nil
+	}
+
+	switch {
+	case opts.MaxPages < 0 || opts.MaxPages > constants.MaxCrawlPages:
+		return out, apperrors.Wrapf(apperrors.ErrInvalidChatbotParameters, "max_pages must be between 1 and %d", constants.MaxCrawlPages)
+	case opts.MaxPages > 0:
+		out.MaxPages = opts.MaxPages
+	}
+	if opts.MaxDepth != nil {
+		if *opts.MaxDepth < 0 || *opts.MaxDepth > constants.MaxCrawlDepth {
+			return out, apperrors.Wrapf(apperrors.ErrInvalidChatbotParameters, "max_depth must be between 0 and %d", constants.MaxCrawlDepth)
+		}
+		depth = *opts.MaxDepth
+	}
+	out.MaxDepth = &depth
+
+	var err error
+	if out.Include, err = normalizeURLPatterns("include", opts.Include); err != nil {
+		return out, err
+	}
+	if out.Exclude, err = normalizeURLPatterns("exclude", opts.Exclude); err != nil {
+		return out, err
+	}
+
+	out.PathPrefix = strings.TrimSpace(opts.PathPrefix)
+	if out.PathPrefix != "" && !strings.HasPrefix(out.PathPrefix, "/") {
+		return out, apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "path_prefix must start with /")
+	}
+	out.SitemapOnly = opts.SitemapOnly
+	return out, nil
+}
+
+func normalizeURLPatterns(field string, patterns []string) ([]string, error) {
+	if len(patterns) > constants.MaxCrawlURLPatterns {
+		return nil, apperrors.Wrapf(apperrors.ErrInvalidChatbotParameters, "at most %d %s patterns are allowed", constants.MaxCrawlURLPatterns, field)
+	}
+	out := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if err := crawler.ValidatePattern(p); err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, err.Error())
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// crawlerOptions converts normalized options for the crawler backends.
+func crawlerOptions(opts models.CrawlOptions) crawler.Options {
+	out := crawler.Options{
+		MaxPages:    opts.MaxPages,
+		MaxDepth:    constants.DefaultCrawlDepth,
+		Timeout:     crawlRequestTimeout,
+		Include:     opts.Include,
+		Exclude:     opts.Exclude,
+		PathPrefix:  opts.PathPrefix,
+		SitemapOnly: opts.SitemapOnly,
+	}
+	if opts.MaxDepth != nil {
+		out.MaxDepth = *opts.MaxDepth
+	}
+	return out
+}
+
+// CrawlOptionsForSchedule returns the crawl options stored on a schedule.
+func CrawlOptionsForSchedule(s *db.CrawlSchedule) models.CrawlOptions {
+	depth := s.MaxDepth
+	opts := models.CrawlOptions{
+		MaxPages:    s.MaxPages,
+		MaxDepth:    &depth,
+		Include:     []string(s.IncludePatterns),
+		Exclude:     []string(s.ExcludePatterns),
+		SitemapOnly: s.SitemapOnly,
+	}
+	if s.PathPrefix != nil {
+		opts.PathPrefix = *s.PathPrefix
+	}
+	return opts
+}
+
+// applyCrawlOptions stores normalized options on a schedule.
+func applyCrawlOptions(s *db.CrawlSchedule, opts models.CrawlOptions) {
+	s.MaxPages = opts.MaxPages
+	s.MaxDepth = constants.DefaultCrawlDepth
+	if opts.MaxDepth != nil {
+		s.MaxDepth = *opts.MaxDepth
+	}
+	s.IncludePatterns = pq.StringArray(append([]string{}, opts.Include...))
+	s.ExcludePatterns = pq.StringArray(append([]string{}, opts.Exclude...))
+	s.PathPrefix = optionalString(opts.PathPrefix)
+	s.SitemapOnly = opts.SitemapOnly
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"strings"
 	"time"
@@ -12,28 +13,37 @@ import (
 	"github.com/robfig/cron/v3"
 	"github.com/yourusername/vectorchat/internal/db"
 	apperrors "github.com/yourusername/vectorchat/internal/errors"
+	"github.com/yourusername/vectorchat/internal/queue"
 	"github.com/yourusername/vectorchat/pkg/jobs"
 	"github.com/yourusername/vectorchat/pkg/models"
 )
 
 // CrawlScheduleService manages persistence and validation of crawl schedules for both chatbot and shared knowledge bases.
 type CrawlScheduleService struct {
-	repo       *db.CrawlScheduleRepository
-	kbService  *KnowledgeBaseService
-	timeNowUTC func() time.Time
-	js         nats.JetStreamContext
+	repo        *db.CrawlScheduleRepository
+	runs        *db.CrawlRunRepository
+	kbService   *KnowledgeBaseService
+	credentials *CrawlCredentialService
+	timeNowUTC  func() time.Time
+	js          nats.JetStreamContext
+	crawls      *queue.CrawlQueue
 }
 
-func NewCrawlScheduleService(repo *db.CrawlScheduleRepository, kbService *KnowledgeBaseService, js nats.JetStreamContext) *CrawlScheduleService {
+// NewCrawlScheduleService enqueues crawls through crawls; without it crawls cannot be triggered from the API.
+func NewCrawlScheduleService(repo *db.CrawlScheduleRepository, runs *db.CrawlRunRepository, kbService *KnowledgeBaseService, credentials *CrawlCredentialService, js nats.JetStreamContext, crawls *queue.CrawlQueue) *CrawlScheduleService {
 	return &CrawlScheduleService{
-		repo:       repo,
-		kbService:  kbService,
-		timeNowUTC: func() time.Time { return time.Now().UTC() },
-		js:         js,
+		repo:        repo,
+		runs:        runs,
+		kbService:   kbService,
+		credentials: credentials,
+		timeNowUTC:  func() time.Time { return time.Now().UTC() },
+		js:          js,
+		crawls:      crawls,
 	}
 }
 
 // Upsert creates or updates a schedule for the given target and URL.
+// Credentials in req.Auth are stored encrypted for the URL; the response only names them.
 func (s *CrawlScheduleService) Upsert(ctx context.Context, target KnowledgeBaseTarget, req *models.CrawlScheduleRequest) (*models.CrawlScheduleResponse, error) {
 	if err := target.validate(); err != nil {
 		return nil, err
@@ -63,6 +73,10 @@ func (s *CrawlScheduleService) Upsert(ctx context.Context, target KnowledgeBaseT
 	if _, err := time.LoadLocation(tz); err != nil {
 		return nil, apperrors.Wrap(err, "invalid timezone")
 	}
+	opts, err := normalizeCrawlOptions(req.Options)
+	if err != nil {
+		return nil, err
+	}
 
 	schedule := &db.CrawlSchedule{
 		ChatbotID:             target.ChatbotID,
@@ -72,36 +86,64 @@ func (s *CrawlScheduleService) Upsert(ctx context.Context, target KnowledgeBaseT
 		Timezone:              tz,
 		Enabled:               req.Enabled,
 	}
+	if schedule.Enabled {
+		schedule.NextRunAt = NextCrawlRun(cronExpr, tz, s.timeNowUTC())
+	}
+	applyCrawlOptions(schedule, opts)
 
+	if err := s.credentials.Save(ctx, target, root, req.Auth); err != nil {
+		return nil, err
+	}
 	if err := s.repo.Upsert(ctx, schedule); err != nil {
 		return nil, err
 	}
+	s.publishChange(schedule.ID, jobs.CrawlScheduleUpserted)
 
 	// Immediately enqueue an initial crawl through the queue if JetStream is configured.
-	if s.js != nil {
-		if err := s.enqueueJob(ctx, schedule); err != nil {
+	if s.crawls != nil {
+		var inFlight *queue.CrawlInFlightError
+		if err := s.enqueueJob(ctx, target, schedule); errors.As(err, &inFlight) {
+			slog.Info("skipped initial crawl; website already being crawled", "schedule_id", schedule.ID, "job_id", inFlight.JobID)
+		} else if err != nil {
 			// log only; don't fail the API to avoid blocking users
-			return toCrawlScheduleResponse(schedule), nil
+			slog.Warn("failed to enqueue initial crawl", "schedule_id", schedule.ID, "error", err)
 		}
 	}
 
-	return toCrawlScheduleResponse(schedule), nil
+	resp := toCrawlScheduleResponse(schedule)
+	if summaries, err := s.credentials.Summaries(ctx, target); err == nil {
+		resp.Auth = summaries[schedule.RootURL]
+	}
+	return resp, nil
 }
 
 // EnqueueOnce pushes a one-off crawl job (no schedule persisted).
-func (s *CrawlScheduleService) EnqueueOnce(ctx context.Context, target KnowledgeBaseTarget, url string) error {
+// Without explicit options the crawl reuses the options of a schedule for the same URL, if any.
+// auth replaces the credentials stored for the URL, which the job references by ID, once the URL is claimed.
+// No job is added while a crawl of the URL is queued or running; the response then says so.
+func (s *CrawlScheduleService) EnqueueOnce(ctx context.Context, target KnowledgeBaseTarget, url string, opts *models.CrawlOptions, auth *models.CrawlAuth) (*models.CrawlNowResponse, error) {
 	if err := target.validate(); err != nil {
-		return err
+		return nil, err
 	}
 	root := strings.TrimSpace(url)
 	if root == "" {
-		return apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "url is required")
+		return nil, apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "url is required")
 	}
 	if _, err := s.kbService.ParseURL(root); err != nil {
-		return apperrors.Wrap(err, "invalid url")
+		return nil, apperrors.Wrap(err, "invalid url")
 	}
-	if s.js == nil {
-		return apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "queue unavailable")
+	if s.crawls == nil {
+		return nil, apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "queue unavailable")
+	}
+	if opts == nil {
+		if sched, err := s.repo.FindByScope(ctx, target.ChatbotID, target.SharedKnowledgeBaseID, root); err == nil {
+			scheduled := CrawlOptionsForSchedule(sched)
+			opts = &scheduled
+		}
+	}
+	normalized, err := normalizeCrawlOptions(opts)
+	if err != nil {
+		return nil, err
 	}
 	payload := jobs.CrawlJobPayload{
 		JobID:                 uuid.New(),
@@ -110,16 +152,46 @@ func (s *CrawlScheduleService) EnqueueOnce(ctx context.Context, target Knowledge
 		RequestedAt:           s.timeNowUTC(),
 		ChatbotID:             target.ChatbotID,
 		SharedKnowledgeBaseID: target.SharedKnowledgeBaseID,
+		Options:               &normalized,
+		Trigger:               jobs.CrawlTriggerManual,
 	}
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return err
+	// Claim the URL before touching its credentials, which a running crawl may still be using
+	var inFlight *queue.CrawlInFlightError
+	if err := s.crawls.Claim(payload); errors.As(err, &inFlight) {
+		return crawlAlreadyRunning(inFlight), nil
+	} else if err != nil {
+		return nil, err
 	}
-	_, err = s.js.Publish(jobs.CrawlSubject, body)
-	return err
+	if err := s.credentials.Save(ctx, target, root, auth); err != nil {
+		s.crawls.Release(payload)
+		return nil, err
+	}
+	if payload.CredentialsID, err = s.credentials.Reference(ctx, target, root); err != nil {
+		s.crawls.Release(payload)
+		return nil, err
+	}
+	if err := s.crawls.Publish(payload); errors.As(err, &inFlight) {
+		return crawlAlreadyRunning(inFlight), nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &models.CrawlNowResponse{Status: models.CrawlEnqueued, Message: "Crawl enqueued", JobID: &payload.JobID}, nil
 }
 
-func (s *CrawlScheduleService) enqueueJob(ctx context.Context, sched *db.CrawlSchedule) error {
+func crawlAlreadyRunning(inFlight *queue.CrawlInFlightError) *models.CrawlNowResponse {
+	resp := &models.CrawlNowResponse{Status: models.CrawlAlreadyRunning, Message: "A crawl of this URL is already queued or running"}
+	if inFlight.JobID != uuid.Nil {
+		resp.JobID = &inFlight.JobID
+	}
+	return resp
+}
+
+func (s *CrawlScheduleService) enqueueJob(ctx context.Context, target KnowledgeBaseTarget, sched *db.CrawlSchedule) error {
+	opts := CrawlOptionsForSchedule(sched)
+	credentialsID, err := s.credentials.Reference(ctx, target, sched.RootURL)
+	if err != nil {
+		return err
+	}
 	payload := jobs.CrawlJobPayload{
 		JobID:                 uuid.New(),
 		ScheduleID:            sched.ID,
@@ -127,18 +199,48 @@ func (s *CrawlScheduleService) enqueueJob(ctx context.Context, sched *db.CrawlSc
 		RequestedAt:           s.timeNowUTC(),
 		ChatbotID:             sched.ChatbotID,
 		SharedKnowledgeBaseID: sched.SharedKnowledgeBaseID,
+		Options:               &opts,
+		CredentialsID:         credentialsID,
+		// the initial crawl is requested by whoever saved the schedule
+		Trigger: jobs.CrawlTriggerManual,
 	}
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return err
-	}
-	_, err = s.js.Publish(jobs.CrawlSubject, body)
+	err = s.crawls.Enqueue(payload)
 	if err == nil {
 		slog.Info("crawl job enqueued", "schedule_id", sched.ID, "url", sched.RootURL)
 	}
 	return err
 }
 
+// publishChange tells running schedulers to reload a schedule. Failures are only logged:
+// schedulers also resync from the database periodically.
+func (s *CrawlScheduleService) publishChange(id uuid.UUID, action string) {
+	if s.js == nil {
+		return
+	}
+	body, err := json.Marshal(jobs.CrawlScheduleEvent{ScheduleID: id, Action: action, ChangedAt: s.timeNowUTC()})
+	if err == nil {
+		_, err = s.js.Publish(jobs.CrawlScheduleEventSubject, body)
+	}
+	if err != nil {
+		slog.Warn("failed to publish crawl schedule change", "schedule_id", id, "action", action, "error", err)
+	}
+}
+
+// NextCrawlRun returns the next time after the given instant at which a cron expression fires in
+// the named timezone, or nil when either cannot be parsed.
+func NextCrawlRun(cronExpr, timezone string, after time.Time) *time.Time {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil
+	}
+	sched, err := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow).Parse(cronExpr)
+	if err != nil {
+		return nil
+	}
+	next := sched.Next(after.In(loc)).UTC()
+	return &next
+}
+
 // validateCron ensures the expression is acceptable by robfig/cron and not empty.
 func validateCron(expr string) error {
 	parser := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
@@ -159,14 +261,21 @@ func (s *CrawlScheduleService) List(ctx context.Context, target KnowledgeBaseTar
 		return nil, err
 	}
 
+	summaries, err := s.credentials.Summaries(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
 	resp := models.CrawlScheduleListResponse{Schedules: make([]models.CrawlScheduleResponse, 0, len(items))}
 	for _, it := range items {
-		resp.Schedules = append(resp.Schedules, *toCrawlScheduleResponse(it))
+		item := toCrawlScheduleResponse(it)
+		item.Auth = summaries[it.RootURL]
+		resp.Schedules = append(resp.Schedules, *item)
 	}
 	return &resp, nil
 }
 
-// Delete removes a schedule, ensuring it belongs to the provided target.
+// Delete removes a schedule and the credentials stored for its URL, ensuring it belongs to the provided target.
 func (s *CrawlScheduleService) Delete(ctx context.Context, target KnowledgeBaseTarget, id uuid.UUID) error {
 	if err := target.validate(); err != nil {
 		return err
@@ -180,19 +289,90 @@ func (s *CrawlScheduleService) Delete(ctx context.Context, target KnowledgeBaseT
 		return err
 	}
 
-	if (target.ChatbotID != nil && sched.ChatbotID != nil && *target.ChatbotID == *sched.ChatbotID) ||
-		(target.SharedKnowledgeBaseID != nil && sched.SharedKnowledgeBaseID != nil && *target.SharedKnowledgeBaseID == *sched.SharedKnowledgeBaseID) {
-		return s.repo.Delete(ctx, id)
+	if target.owns(sched) {
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		s.publishChange(id, jobs.CrawlScheduleDeleted)
+		return s.credentials.Delete(ctx, target, sched.RootURL)
 	}
 
 	return apperrors.ErrUnauthorizedKnowledgeBaseAccess
 }
 
+// ListRuns returns the crawl history of a schedule's URL, newest first, including manual crawls
+// of the URL. before, when set, continues from the NextBefore of a previous page.
+func (s *CrawlScheduleService) ListRuns(ctx context.Context, target KnowledgeBaseTarget, id uuid.UUID, before *time.Time, limit int) (*models.CrawlRunListResponse, error) {
+	if err := target.validate(); err != nil {
+		return nil, err
+	}
+	if id == uuid.Nil {
+		return nil, apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "schedule id is required")
+	}
+	if limit <= 0 || limit > maxCrawlRunPage {
+		return nil, apperrors.Wrapf(apperrors.ErrInvalidChatbotParameters, "limit must be between 1 and %d", maxCrawlRunPage)
+	}
+
+	sched, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !target.owns(sched) {
+		return nil, apperrors.ErrUnauthorizedKnowledgeBaseAccess
+	}
+
+	runs, err := s.runs.ListBySource(ctx, sched.ChatbotID, sched.SharedKnowledgeBaseID, sched.RootURL, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	resp := &models.CrawlRunListResponse{Runs: make([]models.CrawlRunResponse, 0, len(runs))}
+	for _, run := range runs {
+		resp.Runs = append(resp.Runs, toCrawlRunResponse(run))
+	}
+	if len(runs) == limit {
+		next := runs[len(runs)-1].StartedAt
+		resp.NextBefore = &next
+	}
+	return resp, nil
+}
+
+// maxCrawlRunPage caps the page size of ListRuns.
+const maxCrawlRunPage = 100
+
+func toCrawlRunResponse(run *db.CrawlRun) models.CrawlRunResponse {
+	return models.CrawlRunResponse{
+		ID:           run.ID,
+		JobID:        run.JobID,
+		Attempt:      run.Attempt,
+		Trigger:      run.Trigger,
+		Status:       run.Status,
+		WorkerID:     run.WorkerID,
+		StartedAt:    run.StartedAt,
+		FinishedAt:   run.FinishedAt,
+		PagesFetched: run.PagesFetched,
+		Pages: models.CrawlPageCounts{
+			Added:     run.PagesAdded,
+			Changed:   run.PagesChanged,
+			Removed:   run.PagesRemoved,
+			Unchanged: run.PagesUnchanged,
+		},
+		ChunksWritten: run.ChunksWritten,
+		BytesFetched:  run.BytesFetched,
+		Error:         run.Error,
+	}
+}
+
+// owns reports whether sched belongs to the knowledge base of t.
+func (t KnowledgeBaseTarget) owns(sched *db.CrawlSchedule) bool {
+	return (t.ChatbotID != nil && sched.ChatbotID != nil && *t.ChatbotID == *sched.ChatbotID) ||
+		(t.SharedKnowledgeBaseID != nil && sched.SharedKnowledgeBaseID != nil && *t.SharedKnowledgeBaseID == *sched.SharedKnowledgeBaseID)
+}
+
 func toCrawlScheduleResponse(sched *db.CrawlSchedule) *models.CrawlScheduleResponse {
 	if sched == nil {
 		return nil
 	}
-	return &models.CrawlScheduleResponse{
+	resp := &models.CrawlScheduleResponse{
 		ID:                    sched.ID,
 		URL:                   sched.RootURL,
 		CronExpr:              sched.CronExpr,
@@ -202,9 +382,26 @@ func toCrawlScheduleResponse(sched *db.CrawlSchedule) *models.CrawlScheduleRespo
 		NextRunAt:             sched.NextRunAt,
 		LastStatus:            sched.LastStatus,
 		LastError:             sched.LastError,
+		Options:               CrawlOptionsForSchedule(sched),
 		ChatbotID:             sched.ChatbotID,
 		SharedKnowledgeBaseID: sched.SharedKnowledgeBaseID,
 		CreatedAt:             sched.CreatedAt,
 		UpdatedAt:             sched.UpdatedAt,
 	}
+	if sched.LastPagesAdded != nil {
+		resp.LastRunPages = &models.CrawlPageCounts{
+			Added:     *sched.LastPagesAdded,
+			Changed:   derefInt(sched.LastPagesChanged),
+			Removed:   derefInt(sched.LastPagesRemoved),
+			Unchanged: derefInt(sched.LastPagesUnchanged),
+		}
+	}
+	return resp
+}
+
+func derefInt(v *int) int {
+	if v == nil {
+		return 0
+	}
+	return *v
 }
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/vectorchat/internal/db"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+	"github.com/yourusername/vectorchat/pkg/jobs"
+)
+
+func TestNextCrawlRunUsesScheduleTimezone(t *testing.T) {
+	after := time.Date(2025, time.January, 15, 12, 0, 0, 0, time.UTC)
+
+	// 03:00 in Berlin is 02:00 UTC in winter
+	next := NextCrawlRun("0 3 * * *", "Europe/Berlin", after)
+	if next == nil {
+		t.Fatal("expected a next run")
+	}
+	if want := time.Date(2025, time.January, 16, 2, 0, 0, 0, time.UTC); !next.Equal(want) || next.Location() != time.UTC {
+		t.Fatalf("next run = %v, want %v", next, want)
+	}
+
+	if NextCrawlRun("not a cron", "UTC", after) != nil {
+		t.Fatal("expected no next run for an invalid expression")
+	}
+	if NextCrawlRun("0 3 * * *", "Mars/Olympus", after) != nil {
+		t.Fatal("expected no next run for an unknown timezone")
+	}
+}
+
+func TestListRunsValidatesArguments(t *testing.T) {
+	chatbotID := uuid.New()
+	target := KnowledgeBaseTarget{ChatbotID: &chatbotID}
+	tests := []struct {
+		name   string
+		target KnowledgeBaseTarget
+		id     uuid.UUID
+		limit  int
+	}{
+		{"no knowledge base", KnowledgeBaseTarget{}, uuid.New(), 20},
+		{"missing schedule", target, uuid.Nil, 20},
+		{"zero limit", target, uuid.New(), 0},
+		{"limit above page size", target, uuid.New(), maxCrawlRunPage + 1},
+	}
+	// Invalid requests are rejected before any query, so the service needs no repositories
+	s := &CrawlScheduleService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.ListRuns(context.Background(), tt.target, tt.id, nil, tt.limit)
+			if !apperrors.Is(err, apperrors.ErrInvalidChatbotParameters) {
+				t.Fatalf("ListRuns error = %v, want invalid parameters", err)
+			}
+		})
+	}
+}
+
+func TestToCrawlRunResponse(t *testing.T) {
+	started := time.Date(2025, time.March, 2, 4, 0, 0, 0, time.UTC)
+	finished := started.Add(3 * time.Minute)
+	errMsg := "robots.txt disallows the site"
+	run := &db.CrawlRun{
+		ID:             uuid.New(),
+		JobID:          uuid.New(),
+		Attempt:        2,
+		RootURL:        "https://example.com",
+		Trigger:        jobs.CrawlTriggerCron,
+		Status:         jobs.CrawlRunCompleted,
+		WorkerID:       "crawl-worker-1",
+		StartedAt:      started,
+		HeartbeatAt:    finished,
+		FinishedAt:     &finished,
+		PagesFetched:   12,
+		PagesAdded:     3,
+		PagesChanged:   2,
+		PagesRemoved:   1,
+		PagesUnchanged: 6,
+		ChunksWritten:  40,
+		BytesFetched:   2048,
+		Error:          &errMsg,
+	}
+
+	resp := toCrawlRunResponse(run)
+	if resp.ID != run.ID || resp.JobID != run.JobID || resp.Attempt != 2 || resp.Trigger != jobs.CrawlTriggerCron ||
+		resp.Status != jobs.CrawlRunCompleted || resp.WorkerID != "crawl-worker-1" {
+		t.Errorf("identity fields = %+v", resp)
+	}
+	if !resp.StartedAt.Equal(started) || resp.FinishedAt == nil || !resp.FinishedAt.Equal(finished) {
+		t.Errorf("times = %v - %v, want %v - %v", resp.StartedAt, resp.FinishedAt, started, finished)
+	}
+	if resp.PagesFetched != 12 || resp.Pages.Added != 3 || resp.Pages.Changed != 2 || resp.Pages.Removed != 1 || resp.Pages.Unchanged != 6 {
+		t.Errorf("page counts = %d fetched, %+v", resp.PagesFetched, resp.Pages)
+	}
+	if resp.ChunksWritten != 40 || resp.BytesFetched != 2048 {
+		t.Errorf("output = %d chunks, %d bytes", resp.ChunksWritten, resp.BytesFetched)
+	}
+	if resp.Error == nil || *resp.Error != errMsg {
+		t.Errorf("error = %v, want %q", resp.Error, errMsg)
+	}
+}
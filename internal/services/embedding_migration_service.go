@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/vectorchat/internal/db"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+	"github.com/yourusername/vectorchat/internal/vectorize"
+	"github.com/yourusername/vectorchat/pkg/constants"
+)
+
+// reembedPageSize is how many documents are embedded before their shadow vectors are written
+const reembedPageSize = 256
+
+// maxCutoverAttempts bounds how often the cutover goes back to embed documents that were
+// ingested while the previous pass was running
+const maxCutoverAttempts = 5
+
+// EmbeddingMigrationResult summarises a finished re-embed.
+type EmbeddingMigrationResult struct {
+	Migration *db.EmbeddingMigration
+	// Applied is how many documents were cut over to the new model
+	Applied int64
+	// Stale is how many documents in scope still carry another model, e.g. because they changed mid-run
+	Stale int64
+}
+
+// EmbeddingMigrationService re-embeds a knowledge base with the configured embedding model.
+// Retrieval keeps reading the existing vectors while new ones are written to a shadow table;
+// the swap happens in a single transaction once every document has been re-embedded, and
+// also moves the chatbot's revision questions and the knowledge base's recorded model over,
+// so queries and new uploads use the new model from then on. The cutover holds off ingestion
+// into the knowledge base and only commits when no document is left without a new vector.
+type EmbeddingMigrationService struct {
+	repo         *db.EmbeddingMigrationRepository
+	documentRepo *db.DocumentRepository
+	revisionRepo *db.RevisionRepository
+	vectorizer   vectorize.Vectorizer
+	db           *db.Database
+}
+
+func NewEmbeddingMigrationService(repo *db.EmbeddingMigrationRepository, documentRepo *db.DocumentRepository, revisionRepo *db.RevisionRepository, vectorizer vectorize.Vectorizer, database *db.Database) *EmbeddingMigrationService {
+	return &EmbeddingMigrationService{
+		repo:         repo,
+		documentRepo: documentRepo,
+		revisionRepo: revisionRepo,
+		vectorizer:   vectorizer,
+		db:           database,
+	}
+}
+
+// Run re-embeds every document of the target and cuts retrieval over to the new vectors.
+// progress, when set, is called with the migration after each page of documents.
+func (s *EmbeddingMigrationService) Run(ctx context.Context, target KnowledgeBaseTarget, progress func(*db.EmbeddingMigration)) (*EmbeddingMigrationResult, error) {
+	if err := target.validate(); err != nil {
+		return nil, err
+	}
+
+	chatbotID, sharedID := target.fileOwner()
+	total, err := s.documentRepo.CountWithoutShadow(ctx, uuid.Nil, chatbotID, sharedID)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &db.EmbeddingMigration{
+		ChatbotID:             target.ChatbotID,
+		SharedKnowledgeBaseID: target.SharedKnowledgeBaseID,
+		TargetModel:           s.vectorizer.Model(),
+		Status:                constants.EmbeddingMigrationEmbedding,
+		TotalDocuments:        int(total),
+	}
+	if m.TargetDimensions, err = s.probeDimensions(ctx); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, m); err != nil {
+		return nil, err
+	}
+
+	result, err := s.run(ctx, target, m, progress)
+	if err != nil {
+		if markErr := s.repo.MarkFailed(context.Background(), m.ID, err.Error()); markErr != nil {
+			slog.Warn("failed to mark embedding migration failed", "migration_id", m.ID, "error", markErr)
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *EmbeddingMigrationService) run(ctx context.Context, target KnowledgeBaseTarget, m *db.EmbeddingMigration, progress func(*db.EmbeddingMigration)) (*EmbeddingMigrationResult, error) {
+	var applied int64
+	for attempt := 1; ; attempt++ {
+		if err := s.embedDocuments(ctx, target, m, progress); err != nil {
+			return nil, err
+		}
+		revisions, questions, err := s.embedRevisions(ctx, target, m)
+		if err != nil {
+			return nil, err
+		}
+
+		var done bool
+		applied, done, err = s.cutover(ctx, target, m, revisions, questions)
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			break
+		}
+		if attempt == maxCutoverAttempts {
+			return nil, fmt.Errorf("documents were still being added after %d cutover attempts", attempt)
+		}
+	}
+
+	var (
+		stale int64
+		err   error
+	)
+	if target.ChatbotID != nil && *target.ChatbotID != uuid.Nil {
+		stale, err = s.documentRepo.CountStaleByChatbotID(ctx, *target.ChatbotID, m.TargetModel)
+	} else {
+		stale, err = s.documentRepo.CountStaleBySharedKnowledgeBaseID(ctx, *target.SharedKnowledgeBaseID, m.TargetModel)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	done, err := s.repo.FindByID(ctx, m.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddingMigrationResult{Migration: done, Applied: applied, Stale: stale}, nil
+}
+
+// cutover swaps the shadow vectors in and switches the knowledge base to the target model. It locks
+// the knowledge base's model first, which waits for ingestions storing documents and holds off new
+// ones, then gives up with done false when documents arrived since they were last embedded.
+func (s *EmbeddingMigrationService) cutover(ctx context.Context, target KnowledgeBaseTarget, m *db.EmbeddingMigration, revisions []*db.AnswerRevision, questions [][]float32) (applied int64, done bool, err error) {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return 0, false, apperrors.Wrap(err, "failed to start cutover transaction")
+	}
+	defer tx.Rollback()
+
+	chatbotID, sharedID := target.fileOwner()
+	if _, err := s.repo.LockKnowledgeBaseModelTx(ctx, tx, chatbotID, sharedID); err != nil {
+		return 0, false, err
+	}
+	missing, err := s.documentRepo.CountWithoutShadowTx(ctx, tx, m.ID, chatbotID, sharedID)
+	if err != nil {
+		return 0, false, err
+	}
+	if missing > 0 {
+		slog.Info("documents added during re-embed, embedding them before the cutover", "migration_id", m.ID, "documents", missing)
+		return 0, false, nil
+	}
+
+	if applied, err = s.documentRepo.ApplyShadowEmbeddingsTx(ctx, tx, m.ID); err != nil {
+		return 0, false, err
+	}
+	for i, rev := range revisions {
+		if err := s.revisionRepo.UpdateQuestionEmbeddingTx(ctx, tx, rev.ID, rev.Question, questions[i]); err != nil {
+			return 0, false, err
+		}
+	}
+	if err := s.repo.SetKnowledgeBaseModelTx(ctx, tx, chatbotID, sharedID, m.TargetModel); err != nil {
+		return 0, false, err
+	}
+	if err := s.repo.MarkDoneTx(ctx, tx, m.ID); err != nil {
+		return 0, false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, false, apperrors.Wrap(err, "failed to commit embedding cutover")
+	}
+	return applied, true, nil
+}
+
+// embedDocuments writes shadow vectors page by page for every document of the target that has
+// none for its current content, including documents ingested since the run started, and records progress.
+func (s *EmbeddingMigrationService) embedDocuments(ctx context.Context, target KnowledgeBaseTarget, m *db.EmbeddingMigration, progress func(*db.EmbeddingMigration)) error {
+	chatbotID, sharedID := target.fileOwner()
+	remaining, err := s.documentRepo.CountWithoutShadow(ctx, m.ID, chatbotID, sharedID)
+	if err != nil {
+		return err
+	}
+	m.TotalDocuments = max(m.TotalDocuments, m.EmbeddedDocuments+int(remaining))
+
+	for {
+		page, err := s.documentRepo.FindWithoutShadow(ctx, m.ID, chatbotID, sharedID, reembedPageSize)
+		if err != nil {
+			return err
+		}
+		if len(page) == 0 {
+			return nil
+		}
+
+		texts := make([]string, len(page))
+		for i, doc := range page {
+			texts[i] = string(doc.Content)
+		}
+		vectors, err := s.vectorizer.VectorizeBatch(ctx, texts, nil)
+		if err != nil {
+			return apperrors.Wrap(err, "failed to re-embed documents")
+		}
+
+		for i, doc := range page {
+			if len(vectors[i]) != m.TargetDimensions {
+				return fmt.Errorf("document %s embedded with %d dimensions, expected %d", doc.ID, len(vectors[i]), m.TargetDimensions)
+			}
+			if err := s.documentRepo.StoreShadowEmbedding(ctx, m.ID, doc, vectors[i], m.TargetModel); err != nil {
+				return err
+			}
+		}
+
+		m.EmbeddedDocuments += len(page)
+		m.TotalDocuments = max(m.TotalDocuments, m.EmbeddedDocuments)
+		if err := s.repo.UpdateProgress(ctx, m.ID, m.EmbeddedDocuments, m.TotalDocuments); err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(m)
+		}
+	}
+}
+
+// embedRevisions embeds the revision questions of a chatbot target with the target model.
+// Shared knowledge bases have no revisions.
+func (s *EmbeddingMigrationService) embedRevisions(ctx context.Context, target KnowledgeBaseTarget, m *db.EmbeddingMigration) ([]*db.AnswerRevision, [][]float32, error) {
+	chatbotID, _ := target.fileOwner()
+	if chatbotID == nil {
+		return nil, nil, nil
+	}
+	revisions, err := s.revisionRepo.GetRevisionsByChat(ctx, *chatbotID, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	texts := make([]string, len(revisions))
+	for i, rev := range revisions {
+		texts[i] = rev.Question
+	}
+	vectors, err := s.vectorizer.VectorizeBatch(ctx, texts, nil)
+	if err != nil {
+		return nil, nil, apperrors.Wrap(err, "failed to re-embed revision questions")
+	}
+	for i, rev := range revisions {
+		if len(vectors[i]) != m.TargetDimensions {
+			return nil, nil, fmt.Errorf("revision %s embedded with %d dimensions, expected %d", rev.ID, len(vectors[i]), m.TargetDimensions)
+		}
+	}
+	return revisions, vectors, nil
+}
+
+// probeDimensions embeds a sample text to learn the target dimension and checks it fits documents.embedding.
+// Moving to a different dimension needs a schema change, which cannot be cut over per knowledge base.
+func (s *EmbeddingMigrationService) probeDimensions(ctx context.Context) (int, error) {
+	sample, err := s.vectorizer.VectorizeText(ctx, "dimension probe")
+	if err != nil {
+		return 0, apperrors.Wrap(err, "failed to reach embedding backend")
+	}
+
+	column, err := s.documentRepo.EmbeddingDimensions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if column > 0 && column != len(sample) {
+		return 0, fmt.Errorf("model %s produces %d dimensions but documents.embedding is vector(%d)", s.vectorizer.Model(), len(sample), column)
+	}
+	return len(sample), nil
+}
+
+// Get returns a migration by ID.
+func (s *EmbeddingMigrationService) Get(ctx context.Context, id uuid.UUID) (*db.EmbeddingMigration, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+// ListRecent returns the latest migrations, newest first.
+func (s *EmbeddingMigrationService) ListRecent(ctx context.Context, limit int) ([]*db.EmbeddingMigration, error) {
+	return s.repo.ListRecent(ctx, limit)
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/yourusername/vectorchat/internal/crawler"
+	"github.com/yourusername/vectorchat/internal/db"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+	"github.com/yourusername/vectorchat/pkg/constants"
+	"github.com/yourusername/vectorchat/pkg/docprocessor"
+	"github.com/yourusername/vectorchat/pkg/jobs"
+	"github.com/yourusername/vectorchat/pkg/models"
+)
+
+// IngestionService accepts knowledge base uploads and processes them in the background.
+// Jobs are recorded in ingestion_jobs and published on JetStream for the ingestion worker;
+// without a queue they run in-process.
+type IngestionService struct {
+	repo        *db.IngestionJobRepository
+	kbService   *KnowledgeBaseService
+	credentials *CrawlCredentialService
+	js          nats.JetStreamContext
+	timeNowUTC  func() time.Time
+}
+
+func NewIngestionService(repo *db.IngestionJobRepository, kbService *KnowledgeBaseService, credentials *CrawlCredentialService, js nats.JetStreamContext) *IngestionService {
+	return &IngestionService{
+		repo:        repo,
+		kbService:   kbService,
+		credentials: credentials,
+		js:          js,
+		timeNowUTC:  func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// EnqueueFile stores an uploaded file and queues it for conversion and indexing.
+func (s *IngestionService) EnqueueFile(ctx context.Context, target KnowledgeBaseTarget, fileHeader *multipart.FileHeader) (*models.IngestionJobResponse, error) {
+	if err := target.validate(); err != nil {
+		return nil, err
+	}
+	if fileHeader.Size > docprocessor.MaxFileBytes {
+		return nil, apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "file exceeds maximum size (10MB)")
+	}
+	if err := s.kbService.docProcessor.ValidateFilename(ctx, fileHeader.Filename); err != nil {
+		return nil, err
+	}
+
+	src, err := fileHeader.Open()
+	if err != nil {
+		return nil, apperrors.Wrap(err, "failed to open uploaded file")
+	}
+	defer src.Close()
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, src); err != nil {
+		return nil, apperrors.Wrap(err, "failed to read file")
+	}
+
+	return s.enqueue(ctx, target, constants.IngestionKindFile, fileHeader.Filename, buf.Bytes())
+}
+
+// EnqueueText queues plain text for indexing.
+func (s *IngestionService) EnqueueText(ctx context.Context, target KnowledgeBaseTarget, text string) (*models.IngestionJobResponse, error) {
+	if err := target.validate(); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "text is required")
+	}
+	return s.enqueue(ctx, target, constants.IngestionKindText, "text", []byte(text))
+}
+
+// EnqueueWebsite queues a crawl of rootURL for indexing. The crawl options are kept as the job payload;
+// credentials are stored with the source and looked up again when the job runs.
+func (s *IngestionService) EnqueueWebsite(ctx context.Context, target KnowledgeBaseTarget, rootURL string, opts *models.CrawlOptions, auth *models.CrawlAuth) (*models.IngestionJobResponse, error) {
+	if err := target.validate(); err != nil {
+		return nil, err
+	}
+	root := strings.TrimSpace(rootURL)
+	if parsed, err := s.kbService.ParseURL(root); err != nil || !isHTTPURL(root) || parsed.Host == "" {
+		return nil, apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "url must be an absolute http(s) URL")
+	}
+	normalized, err := normalizeCrawlOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(normalized)
+	if err != nil {
+		return nil, apperrors.Wrap(err, "failed to encode crawl options")
+	}
+	if err := s.credentials.Save(ctx, target, root, auth); err != nil {
+		return nil, err
+	}
+	return s.enqueue(ctx, target, constants.IngestionKindWebsite, root, payload)
+}
+
+// Get returns a job if it belongs to the target knowledge base.
+func (s *IngestionService) Get(ctx context.Context, target KnowledgeBaseTarget, jobID uuid.UUID) (*models.IngestionJobResponse, error) {
+	if err := target.validate(); err != nil {
+		return nil, err
+	}
+	job, err := s.repo.FindByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if !sameUUID(job.ChatbotID, target.ChatbotID) || !sameUUID(job.SharedKnowledgeBaseID, target.SharedKnowledgeBaseID) {
+		return nil, apperrors.ErrNotFound
+	}
+	return toIngestionJobResponse(job), nil
+}
+
+// Process runs a queued job to completion. Ingestion failures are recorded on the job;
+// the returned error is only set when the job itself could not be loaded or updated.
+// Deliveries of a job another worker is still running return apperrors.ErrIngestionJobBusy;
+// jobs whose worker stopped reporting for constants.IngestionStaleAfter are taken over.
+func (s *IngestionService) Process(ctx context.Context, jobID uuid.UUID) error {
+	job, err := s.repo.FindByID(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	if job.Status == constants.IngestionDone || job.Status == constants.IngestionFailed {
+		return nil
+	}
+	claimed, err := s.repo.Claim(ctx, job.ID, s.timeNowUTC().Add(-constants.IngestionStaleAfter))
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return apperrors.ErrIngestionJobBusy
+	}
+	stop := s.keepAlive(ctx, job.ID)
+	defer stop()
+
+	// Files are stored under the job ID, so a run interrupted after storing its file only completes the job
+	if job.Kind != constants.IngestionKindWebsite {
+		if file, err := s.kbService.fileRepo.FindByID(ctx, job.ID); err == nil {
+			return s.repo.MarkDone(ctx, job.ID, &file.ID)
+		} else if !apperrors.Is(err, apperrors.ErrFileNotFound) {
+			return err
+		}
+	}
+
+	payload, err := s.repo.LoadPayload(ctx, jobID)
+	if err != nil {
+		return err
+	}
+
+	target := KnowledgeBaseTarget{ChatbotID: job.ChatbotID, SharedKnowledgeBaseID: job.SharedKnowledgeBaseID}
+	progress := func(status string, processed, total int) {
+		if err := s.repo.UpdateProgress(ctx, job.ID, status, processed, total); err != nil {
+			slog.Warn("failed to record ingestion progress", "job_id", job.ID, "error", err)
+		}
+	}
+
+	var file *db.File
+	switch job.Kind {
+	case constants.IngestionKindFile:
+		file, err = s.kbService.IngestFile(ctx, target, job.ID, job.Source, payload, progress)
+	case constants.IngestionKindText:
+		file, err = s.kbService.IngestText(ctx, target, job.ID, string(payload), progress)
+	case constants.IngestionKindWebsite:
+		// Jobs queued before crawl options existed have no payload and use the defaults
+		var opts *models.CrawlOptions
+		if len(payload) > 0 {
+			opts = &models.CrawlOptions{}
+			if err = json.Unmarshal(payload, opts); err != nil {
+				err = apperrors.Wrap(err, "invalid crawl options")
+				break
+			}
+		}
+		var auth *crawler.Auth
+		if auth, err = s.credentials.ForSource(ctx, target, job.Source); err != nil {
+			break
+		}
+		var result *WebsiteIngestResult
+		if result, err = s.kbService.IngestWebsite(ctx, target, job.Source, opts, auth, progress); err == nil {
+			file = result.File
+		}
+	default:
+		err = apperrors.Wrapf(apperrors.ErrInvalidChatbotParameters, "unknown ingestion kind %q", job.Kind)
+	}
+	if err != nil {
+		slog.Error("ingestion job failed", "job_id", job.ID, "kind", job.Kind, "source", job.Source, "error", err)
+		return s.repo.MarkFailed(ctx, job.ID, err.Error())
+	}
+
+	slog.Info("ingestion job completed", "job_id", job.ID, "kind", job.Kind, "source", job.Source)
+	return s.repo.MarkDone(ctx, job.ID, &file.ID)
+}
+
+// RecoverStale requeues jobs that were queued or running when their process stopped: in-process
+// jobs die with the API, and a worker that crashed mid-job leaves it in progress. It returns how
+// many jobs were requeued.
+func (s *IngestionService) RecoverStale(ctx context.Context) (int, error) {
+	stale, err := s.repo.ListStale(ctx, s.timeNowUTC().Add(-constants.IngestionStaleAfter))
+	if err != nil {
+		return 0, err
+	}
+	for _, job := range stale {
+		s.dispatch(job.ID)
+	}
+	return len(stale), nil
+}
+
+// keepAlive touches a running job every constants.IngestionHeartbeat so it is not taken for stale.
+func (s *IngestionService) keepAlive(ctx context.Context, jobID uuid.UUID) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(constants.IngestionHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.repo.Touch(ctx, jobID); err != nil {
+					slog.Warn("failed to record ingestion heartbeat", "job_id", jobID, "error", err)
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+func (s *IngestionService) enqueue(ctx context.Context, target KnowledgeBaseTarget, kind, source string, payload []byte) (*models.IngestionJobResponse, error) {
+	job := &db.IngestionJob{
+		ChatbotID:             target.ChatbotID,
+		SharedKnowledgeBaseID: target.SharedKnowledgeBaseID,
+		Kind:                  kind,
+		Source:                source,
+		Payload:               payload,
+		Status:                constants.IngestionQueued,
+	}
+	if err := s.repo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	s.dispatch(job.ID)
+	return toIngestionJobResponse(job), nil
+}
+
+// dispatch hands a job to the queue, or runs it in this process when there is none.
+func (s *IngestionService) dispatch(jobID uuid.UUID) {
+	if s.js == nil {
+		s.processInBackground(jobID)
+	} else if err := s.publish(jobID); err != nil {
+		slog.Warn("failed to publish ingestion job; processing in-process", "job_id", jobID, "error", err)
+		s.processInBackground(jobID)
+	}
+}
+
+func (s *IngestionService) publish(jobID uuid.UUID) error {
+	body, err := json.Marshal(jobs.IngestionJobPayload{JobID: jobID, RequestedAt: s.timeNowUTC()})
+	if err != nil {
+		return err
+	}
+	_, err = s.js.Publish(jobs.IngestionSubject, body)
+	return err
+}
+
+// processInBackground runs a job in this process when it cannot be handed to the queue.
+func (s *IngestionService) processInBackground(jobID uuid.UUID) {
+	go func() {
+		if err := s.Process(context.Background(), jobID); err != nil && !apperrors.Is(err, apperrors.ErrIngestionJobBusy) {
+			slog.Error("ingestion job could not be processed", "job_id", jobID, "error", err)
+		}
+	}()
+}
+
+func sameUUID(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func toIngestionJobResponse(job *db.IngestionJob) *models.IngestionJobResponse {
+	resp := &models.IngestionJobResponse{
+		ID:                    job.ID,
+		ChatbotID:             job.ChatbotID,
+		SharedKnowledgeBaseID: job.SharedKnowledgeBaseID,
+		Kind:                  job.Kind,
+		Source:                job.Source,
+		Status:                job.Status,
+		TotalChunks:           job.TotalChunks,
+		ProcessedChunks:       job.ProcessedChunks,
+		FileID:                job.FileID,
+		CreatedAt:             job.CreatedAt,
+		UpdatedAt:             job.UpdatedAt,
+		FinishedAt:            job.FinishedAt,
+	}
+	if job.Error != nil {
+		resp.Error = *job.Error
+	}
+	return resp
+}
@@ -2,10 +2,11 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log/slog"
-	"mime/multipart"
 	"net"
 	"net/url"
 	"path/filepath"
@@ -19,7 +20,9 @@ import (
 	"github.com/yourusername/vectorchat/internal/db"
 	apperrors "github.com/yourusername/vectorchat/internal/errors"
 	"github.com/yourusername/vectorchat/internal/vectorize"
+	"github.com/yourusername/vectorchat/pkg/constants"
 	"github.com/yourusername/vectorchat/pkg/docprocessor"
+	"github.com/yourusername/vectorchat/pkg/models"
 )
 
 type KnowledgeBaseTarget struct {
@@ -58,48 +61,86 @@ func (t KnowledgeBaseTarget) fileOwner() (chatbotID *uuid.UUID, sharedID *uuid.U
 type KnowledgeBaseService struct {
 	fileRepo     *db.FileRepository
 	documentRepo *db.DocumentRepository
-	vectorizer   vectorize.Vectorizer
+	pageRepo     *db.WebsitePageRepository
+	modelRepo    *db.EmbeddingMigrationRepository
+	vectorizers  *vectorize.Registry
 	docProcessor *docprocessor.Processor
 	webCrawler   crawler.WebCrawler
 	db           *db.Database
+	crawl        CrawlSettings
+	crawlLimits  *CrawlLimitService
 
-	crawlerDisabled atomic.Bool
+	// crawl4ai is skipped while unreachable and re-probed at most once per crawl.ProbeInterval
+	crawlerDown     atomic.Bool
+	crawlerProbedAt atomic.Int64
+}
+
+// CrawlSettings configures the fallback crawlers used when crawl4ai is unavailable.
+type CrawlSettings struct {
+	// UserAgent and Delay set the identity and per-host spacing of the built-in crawlers; UserAgent
+	// also identifies the sitemap reads of crawl4ai sitemap-only crawls
+	UserAgent string
+	Delay     time.Duration
+	// Browser renders JavaScript pages before the plain HTTP crawler is tried; nil skips it
+	Browser crawler.WebCrawler
+	// ProbeInterval is how often an unreachable crawl4ai is checked again
+	ProbeInterval time.Duration
 }
 
 func NewKnowledgeBaseService(
 	fileRepo *db.FileRepository,
 	documentRepo *db.DocumentRepository,
-	vectorizer vectorize.Vectorizer,
+	pageRepo *db.WebsitePageRepository,
+	modelRepo *db.EmbeddingMigrationRepository,
+	vectorizers *vectorize.Registry,
 	docProcessor *docprocessor.Processor,
 	webCrawler crawler.WebCrawler,
+	crawl CrawlSettings,
+	crawlLimits *CrawlLimitService,
 	database *db.Database,
 ) *KnowledgeBaseService {
 	return &KnowledgeBaseService{
 		fileRepo:     fileRepo,
 		documentRepo: documentRepo,
-		vectorizer:   vectorizer,
+		pageRepo:     pageRepo,
+		modelRepo:    modelRepo,
+		vectorizers:  vectorizers,
 		docProcessor: docProcessor,
 		webCrawler:   webCrawler,
 		db:           database,
+		crawl:        crawl,
+		crawlLimits:  crawlLimits,
 	}
 }
 
-// IngestFile converts, chunks, and indexes an uploaded file into the specified knowledge base.
-func (s *KnowledgeBaseService) IngestFile(ctx context.Context, target KnowledgeBaseTarget, fileHeader *multipart.FileHeader) (*db.File, error) {
+// IngestionProgress receives the current ingestion stage and chunk counters.
+type IngestionProgress func(status string, processed, total int)
+
+func (p IngestionProgress) report(status string, processed, total int) {
+	if p != nil {
+		p(status, processed, total)
+	}
+}
+
+// IngestFile converts, chunks, and indexes file content into the specified knowledge base.
+// fileID names the stored file; ingestion jobs pass their own ID so a re-run can tell whether the
+// file was already stored. uuid.Nil picks a new ID.
+func (s *KnowledgeBaseService) IngestFile(ctx context.Context, target KnowledgeBaseTarget, fileID uuid.UUID, filename string, data []byte, progress IngestionProgress) (*db.File, error) {
 	if err := target.validate(); err != nil {
 		return nil, err
 	}
 
-	processed, err := s.docProcessor.ProcessFile(ctx, fileHeader)
+	progress.report(constants.IngestionConverting, 0, 0)
+	processed, err := s.docProcessor.ProcessFileData(ctx, filename, data)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.storeProcessedMarkdown(ctx, target, processed.Filename, processed.OriginalSize, processed.Markdown, processed.Hash, processed.ProcessedAt)
+	return s.storeProcessedMarkdown(ctx, target, fileID, processed.Filename, processed.OriginalSize, processed.Markdown, processed.Hash, processed.ProcessedAt, progress)
 }
 
-// IngestText chunks and indexes arbitrary text into the target knowledge base.
-func (s *KnowledgeBaseService) IngestText(ctx context.Context, target KnowledgeBaseTarget, text string) (*db.File, error) {
+// IngestText chunks and indexes arbitrary text into the target knowledge base. fileID is as for IngestFile.
+func (s *KnowledgeBaseService) IngestText(ctx context.Context, target KnowledgeBaseTarget, fileID uuid.UUID, text string, progress IngestionProgress) (*db.File, error) {
 	if err := target.validate(); err != nil {
 		return nil, err
 	}
@@ -109,41 +150,225 @@ func (s *KnowledgeBaseService) IngestText(ctx context.Context, target KnowledgeB
 		return nil, err
 	}
 
-	return s.storeProcessedMarkdown(ctx, target, processed.Filename, processed.OriginalSize, processed.Markdown, processed.Hash, processed.ProcessedAt)
+	return s.storeProcessedMarkdown(ctx, target, fileID, processed.Filename, processed.OriginalSize, processed.Markdown, processed.Hash, processed.ProcessedAt, progress)
+}
+
+// WebsiteIngestResult describes how a crawl changed a website source.
+type WebsiteIngestResult struct {
+	File      *db.File
+	Added     int
+	Changed   int
+	Removed   int
+	Unchanged int
+	// Fetched counts the crawled pages with content, Bytes their text
+	Fetched int
+	Bytes   int64
+	// Chunks counts the chunks embedded for added and changed pages
+	Chunks int
+}
+
+// applyCrawlLimits lowers the page and depth limits of opts to those of the knowledge base
+// owner's plan. Schedules keep the options they were created with, so a crawl scheduled under
+// a larger plan runs within the current plan instead of failing.
+func (s *KnowledgeBaseService) applyCrawlLimits(ctx context.Context, target KnowledgeBaseTarget, opts *models.CrawlOptions) error {
+	if s.crawlLimits == nil {
+		return nil
+	}
+	limits, err := s.crawlLimits.ForTarget(ctx, target)
+	if err != nil {
+		return apperrors.Wrap(err, "failed to resolve crawl limits")
+	}
+	if opts.MaxPages > limits.MaxPages {
+		slog.Info("crawl page limit lowered to plan limit", "target", target.namespace(), "requested", opts.MaxPages, "limit", limits.MaxPages)
+		opts.MaxPages = limits.MaxPages
+	}
+	if opts.MaxDepth != nil && *opts.MaxDepth > limits.MaxDepth {
+		slog.Info("crawl depth lowered to plan limit", "target", target.namespace(), "requested", *opts.MaxDepth, "limit", limits.MaxDepth)
+		depth := limits.MaxDepth
+		opts.MaxDepth = &depth
+	}
+	return nil
 }
 
 // IngestWebsite crawls a website starting from rootURL and indexes discovered content.
-func (s *KnowledgeBaseService) IngestWebsite(ctx context.Context, target KnowledgeBaseTarget, rootURL string) (*db.File, error) {
+// Re-crawls of the same host update the existing source in place: pages are revalidated with
+// their stored ETag and Last-Modified, only pages whose content hash changed are re-embedded,
+// and everything is swapped in one transaction so a failed crawl leaves the previous version
+// searchable. Pages missing from the crawl are only removed when it was complete, so a fetch
+// error or page limit never drops pages that still exist.
+// opts limits which pages are crawled, capped at the owner's plan limits; nil uses the default options. auth, when set, is sent
+// to the site for portals behind a login.
+func (s *KnowledgeBaseService) IngestWebsite(ctx context.Context, target KnowledgeBaseTarget, rootURL string, opts *models.CrawlOptions, auth *crawler.Auth, progress IngestionProgress) (*WebsiteIngestResult, error) {
 	if err := target.validate(); err != nil {
 		return nil, err
 	}
 	if strings.TrimSpace(rootURL) == "" {
 		return nil, apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "url is required")
 	}
+	crawlOpts, err := normalizeCrawlOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyCrawlLimits(ctx, target, &crawlOpts); err != nil {
+		return nil, err
+	}
 
 	host := rootURL
 	if u, err := s.ParseURL(rootURL); err == nil {
 		host = u.Hostname()
 	}
 
-	// Ensure we don't keep multiple versions of the same website in a KB
-	if err := s.removeExistingWebsiteSources(ctx, target, host); err != nil {
+	file, stale, err := s.findWebsiteSource(ctx, target, host)
+	if err != nil {
 		return nil, err
 	}
+	known := map[string]*db.WebsitePage{}
+	if file != nil {
+		existing, err := s.pageRepo.FindByFileID(ctx, file.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range existing {
+			known[p.URL] = p
+		}
+	}
+	// Sources crawled before pages were tracked have no page rows; rebuild them from scratch
+	legacy := file != nil && len(known) == 0
 
-	fileID := uuid.New()
-	file := &db.File{
-		ID:                    fileID,
-		Filename:              fmt.Sprintf("website-%s-%s", host, time.Now().Format("20060102-150405")),
-		UploadedAt:            time.Now().UTC(),
-		SizeBytes:             0,
-		ChatbotID:             nil,
-		SharedKnowledgeBaseID: nil,
+	progress.report(constants.IngestionConverting, 0, 0)
+	crawlerOpts := crawlerOptions(crawlOpts)
+	crawlerOpts.Auth = auth
+	crawlerOpts.Known = make(map[string]crawler.Validators, len(known))
+	for url, p := range known {
+		if p.ETag != nil || p.LastModified != nil {
+			crawlerOpts.Known[url] = crawler.Validators{ETag: derefString(p.ETag), LastModified: derefString(p.LastModified)}
+		}
 	}
-	if chatbotID, sharedID := target.fileOwner(); chatbotID != nil {
-		file.ChatbotID = chatbotID
+	crawled, err := s.crawlWebsite(ctx, rootURL, crawlerOpts)
+	if err != nil {
+		return nil, err
+	}
+	pages := make([]crawler.Page, 0, len(crawled.Pages))
+	seen := make(map[string]bool, len(crawled.Pages))
+	for _, page := range crawled.Pages {
+		if seen[page.URL] {
+			continue
+		}
+		if page.NotModified {
+			if known[page.URL] == nil {
+				continue
+			}
+		} else if strings.TrimSpace(page.Text) == "" {
+			continue
+		}
+		seen[page.URL] = true
+		pages = append(pages, page)
+	}
+	if len(pages) == 0 {
+		return nil, apperrors.Wrapf(apperrors.ErrInvalidChatbotParameters, "crawl of %s returned no content", rootURL)
+	}
+
+	now := time.Now().UTC()
+	isNew := file == nil
+	if isNew {
+		file = &db.File{ID: uuid.New()}
+		if chatbotID, sharedID := target.fileOwner(); chatbotID != nil {
+			file.ChatbotID = chatbotID
+		} else {
+			file.SharedKnowledgeBaseID = sharedID
+		}
+	}
+	file.Filename = fmt.Sprintf("website-%s-%s", host, now.Format("20060102-150405"))
+	file.UploadedAt = now
+	file.SizeBytes = 0
+	for _, page := range pages {
+		if page.NotModified {
+			file.SizeBytes += known[page.URL].SizeBytes
+		} else {
+			file.SizeBytes += int64(len(page.Text))
+		}
+	}
+
+	result := &WebsiteIngestResult{Fetched: len(pages), Bytes: file.SizeBytes}
+	progress.report(constants.IngestionChunking, 0, 0)
+	type pageChunk struct {
+		page  int
+		index int
+		url   string
+		text  string
+	}
+	var chunks []pageChunk
+	records := make([]*db.WebsitePage, len(pages))
+	dirty := make([]bool, len(pages))
+	for pi, page := range pages {
+		if page.NotModified {
+			// The server confirmed the stored copy; only the fetch time moves
+			unchanged := *known[page.URL]
+			unchanged.FetchedAt = now
+			records[pi] = &unchanged
+			result.Unchanged++
+			continue
+		}
+		records[pi] = &db.WebsitePage{
+			ID:           uuid.New(),
+			FileID:       file.ID,
+			URL:          page.URL,
+			Title:        optionalString(page.Title),
+			ETag:         optionalString(page.ETag),
+			LastModified: optionalString(page.LastModified),
+			ContentHash:  contentHash(page.Text),
+			SizeBytes:    int64(len(page.Text)),
+			FetchedAt:    now,
+		}
+		prev, ok := known[page.URL]
+		if ok {
+			records[pi].ID = prev.ID
+		}
+		switch {
+		case !ok:
+			result.Added++
+		case prev.ContentHash != records[pi].ContentHash:
+			result.Changed++
+		default:
+			result.Unchanged++
+			continue
+		}
+		dirty[pi] = true
+		// Each page is its own document; the page row ID ties chunks back to it
+		wrapped := s.docProcessor.WrapPageWithMetadata(page.Text, records[pi].ID.String(), file.Filename, page.URL, page.Title, file.ID, now)
+		for ci, chunk := range wrapped {
+			chunks = append(chunks, pageChunk{page: pi, index: ci, url: page.URL, text: chunk})
+		}
+	}
+	var removed []*db.WebsitePage
+	if crawled.Complete {
+		for url, p := range known {
+			if !seen[url] {
+				removed = append(removed, p)
+			}
+		}
 	} else {
-		file.SharedKnowledgeBaseID = sharedID
+		// Pages a partial crawl did not reach may still exist, so they are kept as they are
+		for url, p := range known {
+			if !seen[url] {
+				file.SizeBytes += p.SizeBytes
+			}
+		}
+	}
+	result.Removed = len(removed)
+	result.Chunks = len(chunks)
+
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		texts[i] = c.text
+	}
+	vectorizer, err := s.vectorizerFor(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	embeddings, err := s.embedChunks(ctx, vectorizer, texts, progress)
+	if err != nil {
+		return nil, err
 	}
 
 	tx, err := s.db.BeginTx(ctx)
@@ -152,60 +377,205 @@ func (s *KnowledgeBaseService) IngestWebsite(ctx context.Context, target Knowled
 	}
 	defer tx.Rollback()
 
-	if err := s.fileRepo.CreateTx(ctx, tx, file); err != nil {
-		return nil, apperrors.Wrap(err, "failed to insert website source")
+	if err := s.holdModelTx(ctx, tx, target, vectorizer.Model()); err != nil {
+		return nil, err
 	}
 
-	pages, err := s.crawlWebsite(ctx, rootURL, crawler.Options{MaxPages: 25, MaxDepth: 2, Timeout: 40 * time.Second})
-	if err != nil {
-		return nil, err
+	if isNew {
+		if err := s.fileRepo.CreateTx(ctx, tx, file); err != nil {
+			return nil, apperrors.Wrap(err, "failed to insert website source")
+		}
+	} else if err := s.fileRepo.UpdateTx(ctx, tx, file); err != nil {
+		return nil, apperrors.Wrap(err, "failed to update website source")
 	}
 
-	const chunkSize = 1000
-	var totalBytes int64
-	for pi, page := range pages {
-		if strings.TrimSpace(page.Text) == "" {
-			continue
+	// Older duplicate sources of the same host are folded into this one
+	for _, f := range stale {
+		if err := s.documentRepo.DeleteByFileIDTx(ctx, tx, f.ID); err != nil {
+			return nil, apperrors.Wrap(err, "failed to delete duplicate website documents")
 		}
-		chunks := s.docProcessor.ChunkText(page.Text, chunkSize)
-		for ci, chunk := range chunks {
-			totalBytes += int64(len(chunk))
-			emb, err := s.vectorizer.VectorizeText(ctx, chunk)
-			if err != nil {
-				return nil, apperrors.Wrapf(err, "failed to vectorize page %d chunk %d", pi, ci)
-			}
+		if err := s.fileRepo.DeleteTx(ctx, tx, f.ID); err != nil {
+			return nil, apperrors.Wrap(err, "failed to delete duplicate website source")
+		}
+	}
+	if legacy {
+		if err := s.documentRepo.DeleteByFileIDTx(ctx, tx, file.ID); err != nil {
+			return nil, apperrors.Wrap(err, "failed to delete untracked website documents")
+		}
+	}
 
-			docID := fmt.Sprintf("%s-web-%d-%d-%s", target.namespace(), pi, ci, uuid.New().String())
-			doc := &db.DocumentWithEmbedding{
-				ID:                    docID,
-				Content:               []byte(chunk),
-				Embedding:             emb,
-				ChatbotID:             target.ChatbotID,
-				SharedKnowledgeBaseID: target.SharedKnowledgeBaseID,
-				FileID:                &fileID,
-				ChunkIndex:            intPtr(ci),
-			}
+	for _, p := range removed {
+		if err := s.documentRepo.DeleteByFileIDAndSourceURLTx(ctx, tx, file.ID, p.URL); err != nil {
+			return nil, apperrors.Wrapf(err, "failed to delete documents of removed page %s", p.URL)
+		}
+		if err := s.pageRepo.DeleteTx(ctx, tx, p.ID); err != nil {
+			return nil, err
+		}
+	}
 
-			if err := s.documentRepo.StoreWithEmbeddingTx(ctx, tx, doc); err != nil {
-				return nil, apperrors.Wrapf(err, "failed to store page %d chunk %d", pi, ci)
+	for pi, page := range pages {
+		if dirty[pi] && !legacy {
+			if err := s.documentRepo.DeleteByFileIDAndSourceURLTx(ctx, tx, file.ID, page.URL); err != nil {
+				return nil, apperrors.Wrapf(err, "failed to delete previous documents of page %s", page.URL)
 			}
 		}
+		if err := s.pageRepo.UpsertTx(ctx, tx, records[pi]); err != nil {
+			return nil, err
+		}
 	}
 
-	file.SizeBytes = totalBytes
-	if err := s.fileRepo.UpdateTx(ctx, tx, file); err != nil {
-		return nil, apperrors.Wrap(err, "failed to update website source size")
+	fileID := file.ID
+	embeddingModel := vectorizer.Model()
+	for i, c := range chunks {
+		pageURL := c.url
+		docID := fmt.Sprintf("%s-web-%d-%d-%s", target.namespace(), c.page, c.index, uuid.New().String())
+		doc := &db.DocumentWithEmbedding{
+			ID:                    docID,
+			Content:               []byte(c.text),
+			Embedding:             embeddings[i],
+			ChatbotID:             target.ChatbotID,
+			SharedKnowledgeBaseID: target.SharedKnowledgeBaseID,
+			FileID:                &fileID,
+			ChunkIndex:            intPtr(c.index),
+			SourceURL:             &pageURL,
+			EmbeddingModel:        &embeddingModel,
+		}
+
+		if err := s.documentRepo.StoreWithEmbeddingTx(ctx, tx, doc); err != nil {
+			return nil, apperrors.Wrapf(err, "failed to store page %d chunk %d", c.page, c.index)
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
 		return nil, apperrors.Wrap(err, "failed to commit website ingestion")
 	}
 
+	result.File = file
+	slog.Info("website ingested", "url", rootURL, "file_id", file.ID, "added", result.Added, "changed", result.Changed, "removed", result.Removed, "unchanged", result.Unchanged)
+	return result, nil
+}
+
+// ListWebsitePages returns the crawled pages of a website source owned by target.
+func (s *KnowledgeBaseService) ListWebsitePages(ctx context.Context, target KnowledgeBaseTarget, sourceID uuid.UUID) (*models.WebsitePagesResponse, error) {
+	file, err := s.websiteSource(ctx, target, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := s.pageRepo.FindByFileIDWithChunkCounts(ctx, file.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.WebsitePagesResponse{
+		SourceID: file.ID,
+		Source:   file.Filename,
+		Pages:    make([]models.WebsitePageInfo, 0, len(pages)),
+	}
+	for _, p := range pages {
+		info := models.WebsitePageInfo{ID: p.ID, URL: p.URL, FetchedAt: p.FetchedAt, Chunks: p.ChunkCount}
+		if p.Title != nil {
+			info.Title = *p.Title
+		}
+		resp.Pages = append(resp.Pages, info)
+	}
+	return resp, nil
+}
+
+// DeleteWebsitePage removes one page and its chunks from a website source.
+// A later crawl adds the page back if the site still links to it.
+func (s *KnowledgeBaseService) DeleteWebsitePage(ctx context.Context, target KnowledgeBaseTarget, sourceID, pageID uuid.UUID) error {
+	file, err := s.websiteSource(ctx, target, sourceID)
+	if err != nil {
+		return err
+	}
+	page, err := s.pageRepo.FindByID(ctx, pageID)
+	if err != nil {
+		return err
+	}
+	if page.FileID != file.ID {
+		return apperrors.Wrap(apperrors.ErrNotFound, "page does not belong to website source")
+	}
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return apperrors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	if err := s.documentRepo.DeleteByFileIDAndSourceURLTx(ctx, tx, file.ID, page.URL); err != nil {
+		return apperrors.Wrap(err, "failed to delete page documents")
+	}
+	if err := s.pageRepo.DeleteTx(ctx, tx, page.ID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return apperrors.Wrap(err, "failed to commit transaction")
+	}
+	return nil
+}
+
+// websiteSource loads a file and checks it is a website source of target.
+func (s *KnowledgeBaseService) websiteSource(ctx context.Context, target KnowledgeBaseTarget, sourceID uuid.UUID) (*db.File, error) {
+	if err := target.validate(); err != nil {
+		return nil, err
+	}
+	file, err := s.fileRepo.FindByID(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if chatbotID, sharedID := target.fileOwner(); chatbotID != nil {
+		if file.ChatbotID == nil || *file.ChatbotID != *chatbotID {
+			return nil, apperrors.Wrap(apperrors.ErrUnauthorizedChatbotAccess, "website source does not belong to chatbot")
+		}
+	} else if file.SharedKnowledgeBaseID == nil || *file.SharedKnowledgeBaseID != *sharedID {
+		return nil, apperrors.ErrUnauthorizedKnowledgeBaseAccess
+	}
+	if !strings.HasPrefix(file.Filename, "website-") {
+		return nil, apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "not a website source")
+	}
 	return file, nil
 }
 
-// removeExistingWebsiteSources deletes prior crawls for the same host in the target KB.
-func (s *KnowledgeBaseService) removeExistingWebsiteSources(ctx context.Context, target KnowledgeBaseTarget, host string) error {
+// vectorizerFor returns the vectorizer of the model the target is embedded with. Knowledge bases
+// without a model yet are recorded with the configured one.
+func (s *KnowledgeBaseService) vectorizerFor(ctx context.Context, target KnowledgeBaseTarget) (vectorize.Vectorizer, error) {
+	chatbotID, sharedID := target.fileOwner()
+	model, err := s.modelRepo.PinKnowledgeBaseModel(ctx, chatbotID, sharedID, s.vectorizers.Default().Model())
+	if err != nil {
+		return nil, err
+	}
+	return s.vectorizers.For(model)
+}
+
+// holdModelTx keeps an embedding migration from switching the target's model until tx ends, and
+// fails when it already switched away from the model the chunks were embedded with.
+func (s *KnowledgeBaseService) holdModelTx(ctx context.Context, tx *db.Transaction, target KnowledgeBaseTarget, model string) error {
+	chatbotID, sharedID := target.fileOwner()
+	current, err := s.modelRepo.ShareKnowledgeBaseModelTx(ctx, tx, chatbotID, sharedID)
+	if err != nil {
+		return err
+	}
+	if current != model {
+		return apperrors.Wrapf(apperrors.ErrVectorizationFailed, "knowledge base switched to embedding model %s during ingestion, try again", current)
+	}
+	return nil
+}
+
+// embedChunks vectorizes chunks in batches outside of any transaction so slow embedding calls don't hold database locks.
+func (s *KnowledgeBaseService) embedChunks(ctx context.Context, vectorizer vectorize.Vectorizer, chunks []string, progress IngestionProgress) ([][]float32, error) {
+	progress.report(constants.IngestionEmbedding, 0, len(chunks))
+	embeddings, err := vectorizer.VectorizeBatch(ctx, chunks, func(embedded int) {
+		progress.report(constants.IngestionEmbedding, embedded, len(chunks))
+	})
+	if err != nil {
+		return nil, apperrors.Wrap(err, "failed to vectorize chunks")
+	}
+	return embeddings, nil
+}
+
+// findWebsiteSource returns the most recent website source for host in the target KB,
+// along with any older duplicates left behind by earlier crawls.
+func (s *KnowledgeBaseService) findWebsiteSource(ctx context.Context, target KnowledgeBaseTarget, host string) (*db.File, []*db.File, error) {
 	var files []*db.File
 	var err error
 	if target.ChatbotID != nil {
@@ -214,39 +584,62 @@ func (s *KnowledgeBaseService) removeExistingWebsiteSources(ctx context.Context,
 		files, err = s.fileRepo.FindNonTextBySharedKnowledgeBaseID(ctx, *target.SharedKnowledgeBaseID)
 	}
 	if err != nil {
-		return apperrors.Wrap(err, "failed to list existing website sources")
+		return nil, nil, apperrors.Wrap(err, "failed to list existing website sources")
 	}
 
-	prefix := fmt.Sprintf("website-%s", host)
+	// Filenames are website-<host>-<yyyymmdd>-<hhmmss>; files are ordered newest first
+	var current *db.File
+	var stale []*db.File
 	for _, f := range files {
-		if !strings.HasPrefix(f.Filename, prefix) {
+		if !isWebsiteSourceFor(f.Filename, host) {
 			continue
 		}
-		tx, txErr := s.db.BeginTx(ctx)
-		if txErr != nil {
-			return apperrors.Wrap(txErr, "failed to start cleanup transaction")
-		}
-		if err := s.documentRepo.DeleteByFileIDTx(ctx, tx, f.ID); err != nil {
-			tx.Rollback()
-			return apperrors.Wrap(err, "failed to delete existing website documents")
-		}
-		if err := s.fileRepo.DeleteTx(ctx, tx, f.ID); err != nil {
-			tx.Rollback()
-			return apperrors.Wrap(err, "failed to delete existing website file")
-		}
-		if err := tx.Commit(); err != nil {
-			return apperrors.Wrap(err, "failed to commit website cleanup")
+		if current == nil {
+			current = f
+		} else {
+			stale = append(stale, f)
 		}
 	}
-	return nil
+	return current, stale, nil
+}
+
+func isWebsiteSourceFor(filename, host string) bool {
+	rest, ok := strings.CutPrefix(filename, "website-"+host+"-")
+	if !ok {
+		return false
+	}
+	_, err := time.Parse("20060102-150405", rest)
+	return err == nil
+}
+
+func contentHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+func optionalString(v string) *string {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+func derefString(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
 }
 
-func (s *KnowledgeBaseService) storeProcessedMarkdown(ctx context.Context, target KnowledgeBaseTarget, originalFilename string, originalSize int64, markdown string, docID string, ingestedAt time.Time) (*db.File, error) {
+func (s *KnowledgeBaseService) storeProcessedMarkdown(ctx context.Context, target KnowledgeBaseTarget, fileID uuid.UUID, originalFilename string, originalSize int64, markdown string, docID string, ingestedAt time.Time, progress IngestionProgress) (*db.File, error) {
 	if err := target.validate(); err != nil {
 		return nil, err
 	}
 
-	fileID := uuid.New()
+	if fileID == uuid.Nil {
+		fileID = uuid.New()
+	}
 	baseName := filepath.Base(originalFilename)
 	file := &db.File{
 		ID:        fileID,
@@ -265,36 +658,47 @@ func (s *KnowledgeBaseService) storeProcessedMarkdown(ctx context.Context, targe
 		file.SharedKnowledgeBaseID = sharedID
 	}
 
+	progress.report(constants.IngestionChunking, 0, 0)
+	chunks := s.docProcessor.WrapMarkdownWithMetadata(markdown, docID, baseName, fileID, file.UploadedAt)
+	if len(chunks) == 0 {
+		return nil, apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "file did not produce any indexable content")
+	}
+
+	vectorizer, err := s.vectorizerFor(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	embeddings, err := s.embedChunks(ctx, vectorizer, chunks, progress)
+	if err != nil {
+		return nil, err
+	}
+
 	tx, err := s.db.BeginTx(ctx)
 	if err != nil {
 		return nil, apperrors.Wrap(err, "failed to start transaction")
 	}
 	defer tx.Rollback()
 
-	if err := s.fileRepo.CreateTx(ctx, tx, file); err != nil {
-		return nil, apperrors.Wrap(err, "failed to insert file metadata")
+	if err := s.holdModelTx(ctx, tx, target, vectorizer.Model()); err != nil {
+		return nil, err
 	}
 
-	chunks := s.docProcessor.WrapMarkdownWithMetadata(markdown, docID, baseName, fileID, file.UploadedAt)
-	if len(chunks) == 0 {
-		return nil, apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "file did not produce any indexable content")
+	if err := s.fileRepo.CreateTx(ctx, tx, file); err != nil {
+		return nil, apperrors.Wrap(err, "failed to insert file metadata")
 	}
 
 	docIDBase := fmt.Sprintf("%s-%s", target.namespace(), baseName)
+	embeddingModel := vectorizer.Model()
 	for idx, chunk := range chunks {
-		emb, err := s.vectorizer.VectorizeText(ctx, chunk)
-		if err != nil {
-			return nil, apperrors.Wrapf(err, "failed to vectorize chunk %d", idx)
-		}
-
 		doc := &db.DocumentWithEmbedding{
 			ID:                    fmt.Sprintf("%s-%d", docIDBase, idx),
 			Content:               []byte(chunk),
-			Embedding:             emb,
+			Embedding:             embeddings[idx],
 			ChatbotID:             target.ChatbotID,
 			SharedKnowledgeBaseID: target.SharedKnowledgeBaseID,
 			FileID:                &fileID,
 			ChunkIndex:            intPtr(idx),
+			EmbeddingModel:        &embeddingModel,
 		}
 
 		if err := s.documentRepo.StoreWithEmbeddingTx(ctx, tx, doc); err != nil {
@@ -309,20 +713,23 @@ func (s *KnowledgeBaseService) storeProcessedMarkdown(ctx context.Context, targe
 	return file, nil
 }
 
-func (s *KnowledgeBaseService) crawlWebsite(ctx context.Context, rootURL string, opts crawler.Options) ([]crawler.Page, error) {
-	if s.webCrawler != nil && !s.crawlerDisabled.Load() {
-		pages, err := s.webCrawler.Crawl(ctx, rootURL, opts)
-		if err == nil && len(pages) > 0 {
-			return pages, nil
+func (s *KnowledgeBaseService) crawlWebsite(ctx context.Context, rootURL string, opts crawler.Options) (*crawler.Result, error) {
+	opts.UserAgent = s.crawl.UserAgent
+	opts.Delay = s.crawl.Delay
+	if s.webCrawler != nil && s.crawlerAvailable(ctx) {
+		result, err := s.webCrawler.Crawl(ctx, rootURL, opts)
+		if err == nil && len(result.Pages) > 0 {
+			return result, nil
 		}
 		if err != nil {
-			disable := false
+			down := false
 			var opErr *net.OpError
 			if errors.As(err, &opErr) {
-				disable = errors.Is(opErr.Err, syscall.ECONNREFUSED)
+				down = errors.Is(opErr.Err, syscall.ECONNREFUSED)
 			}
-			if disable && s.crawlerDisabled.CompareAndSwap(false, true) {
-				slog.Info("crawl4ai unavailable; disabling external crawler", "url", rootURL, "error", err)
+			if down && s.crawlerDown.CompareAndSwap(false, true) {
+				s.crawlerProbedAt.Store(time.Now().UnixNano())
+				slog.Info("crawl4ai unavailable; using fallback crawler until it recovers", "url", rootURL, "error", err)
 			} else {
 				slog.Warn("crawl4ai crawl failed; using fallback crawler", "error", err, "url", rootURL)
 			}
@@ -330,9 +737,47 @@ func (s *KnowledgeBaseService) crawlWebsite(ctx context.Context, rootURL string,
 			slog.Warn("crawl4ai returned no pages; using fallback crawler", "url", rootURL)
 		}
 	}
+
+	if s.crawl.Browser != nil {
+		result, err := s.crawl.Browser.Crawl(ctx, rootURL, opts)
+		if err == nil && len(result.Pages) > 0 {
+			return result, nil
+		}
+		if errors.Is(err, crawler.ErrDisallowedByRobots) || ctx.Err() != nil {
+			return nil, err
+		}
+		slog.Warn("headless browser crawl failed; using plain HTTP crawler", "error", err, "url", rootURL)
+	}
 	return crawler.CrawlWebsite(ctx, rootURL, opts)
 }
 
+// crawlerAvailable reports whether crawl4ai should be tried. While it is marked down, one caller
+// per probe interval checks its health endpoint and re-enables it once it answers again.
+func (s *KnowledgeBaseService) crawlerAvailable(ctx context.Context) bool {
+	if !s.crawlerDown.Load() {
+		return true
+	}
+	checker, ok := s.webCrawler.(crawler.HealthChecker)
+	if !ok {
+		return false
+	}
+	last := s.crawlerProbedAt.Load()
+	now := time.Now()
+	if now.Sub(time.Unix(0, last)) < s.crawl.ProbeInterval || !s.crawlerProbedAt.CompareAndSwap(last, now.UnixNano()) {
+		return false
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := checker.Health(probeCtx); err != nil {
+		slog.Debug("crawl4ai still unavailable", "error", err)
+		return false
+	}
+	s.crawlerDown.Store(false)
+	slog.Info("crawl4ai reachable again; re-enabling external crawler")
+	return true
+}
+
 // ParseURL parses a string URL for reuse in handlers/services.
 func (s *KnowledgeBaseService) ParseURL(raw string) (*url.URL, error) {
 	return url.Parse(raw)
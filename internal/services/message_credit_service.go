@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/vectorchat/internal/db"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+	"github.com/yourusername/vectorchat/pkg/constants"
+	"github.com/yourusername/vectorchat/pkg/models"
+	stripe_sub "github.com/yourusername/vectorchat/pkg/stripe_sub"
+)
+
+// Message credit scopes
+const (
+	CreditScopePersonal     = "personal"
+	CreditScopeOrganization = "organization"
+)
+
+// MessageCreditService meters assistant replies against the plan's monthly message credits.
+// Personal chatbots draw from the owner's pool, org chatbots from the organization's pool,
+// which is billed to the organization creator's subscription.
+type MessageCreditService struct {
+	subs       *stripe_sub.Service
+	creditRepo *db.MessageCreditRepository
+	orgRepo    *db.OrganizationRepository
+	userRepo   *db.UserRepository
+	timeNowUTC func() time.Time
+}
+
+func NewMessageCreditService(subs *stripe_sub.Service, creditRepo *db.MessageCreditRepository, orgRepo *db.OrganizationRepository, userRepo *db.UserRepository) *MessageCreditService {
+	return &MessageCreditService{
+		subs:       subs,
+		creditRepo: creditRepo,
+		orgRepo:    orgRepo,
+		userRepo:   userRepo,
+		timeNowUTC: func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// ReserveForChatbot takes one credit for a reply of the chatbot from the pool that pays for it.
+// The check and the consumption are a single conditional update, so concurrent requests cannot
+// overshoot the limit. ok is false, and nothing is taken, once the pool has no credits left.
+func (s *MessageCreditService) ReserveForChatbot(ctx context.Context, chatbot *db.Chatbot) (status *models.MessageCreditUsageResponse, ok bool, err error) {
+	if chatbot == nil {
+		return nil, false, apperrors.Wrap(apperrors.ErrChatbotNotFound, "chatbot is required")
+	}
+	status, err = s.period(ctx, chatbot.UserID, chatbot.OrganizationID)
+	if err != nil {
+		return nil, false, err
+	}
+	status.Used, ok, err = s.creditRepo.Reserve(ctx, chatbot.UserID, chatbot.OrganizationID, status.PeriodStart, status.PeriodEnd, status.Limit)
+	if err != nil {
+		return nil, false, err
+	}
+	status.Remaining = max(status.Limit-status.Used, 0)
+	return status, ok, nil
+}
+
+// ReleaseForChatbot returns a credit taken by ReserveForChatbot for a reply that failed.
+func (s *MessageCreditService) ReleaseForChatbot(ctx context.Context, chatbot *db.Chatbot, periodStart time.Time) error {
+	return s.creditRepo.Release(ctx, chatbot.UserID, chatbot.OrganizationID, periodStart)
+}
+
+// Status returns credit usage for the user's personal pool, or the organization's pool when orgID is set.
+func (s *MessageCreditService) Status(ctx context.Context, userID string, orgID *uuid.UUID) (*models.MessageCreditUsageResponse, error) {
+	status, err := s.period(ctx, userID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	status.Used, err = s.creditRepo.UsedInPeriod(ctx, userID, orgID, status.PeriodStart, status.PeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+	status.Remaining = max(status.Limit-status.Used, 0)
+	return status, nil
+}
+
+// period resolves the pool's limit and current billing period; usage is left to the caller.
+func (s *MessageCreditService) period(ctx context.Context, userID string, orgID *uuid.UUID) (*models.MessageCreditUsageResponse, error) {
+	scope := CreditScopePersonal
+	if orgID != nil {
+		scope = CreditScopeOrganization
+	}
+
+	plan, sub, err := billingPlan(ctx, s.subs, s.orgRepo, s.userRepo, userID, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := int64(constants.DefaultMessageCredits)
+	if plan != nil {
+		if features, ok := plan.PlanDefinition["features"].(map[string]interface{}); ok {
+			limit = int64(PlanIntLimit(features, constants.LimitMessageCredits, constants.DefaultMessageCredits))
+		}
+	}
+
+	start, end := s.billingPeriod(sub)
+	return &models.MessageCreditUsageResponse{
+		Scope:       scope,
+		Limit:       limit,
+		PeriodStart: start,
+		PeriodEnd:   end,
+	}, nil
+}
+
+// billingPeriod follows the active subscription's period and falls back to the current calendar month.
+func (s *MessageCreditService) billingPeriod(sub *stripe_sub.Subscription) (time.Time, time.Time) {
+	now := s.timeNowUTC()
+	if sub != nil && stripe_sub.IsSubscriptionActive(sub, now) &&
+		sub.CurrentPeriodStart != nil && sub.CurrentPeriodEnd != nil &&
+		sub.CurrentPeriodEnd.After(now) {
+		return sub.CurrentPeriodStart.UTC(), sub.CurrentPeriodEnd.UTC()
+	}
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PlanIntLimit reads an integer limit from plan features. Values may be numbers or strings
+// such as "5 data sources"; a missing or unreadable value yields defaultVal.
+func PlanIntLimit(limits map[string]interface{}, key string, defaultVal int) int {
+	if val, ok := limits[key]; ok {
+		switch v := val.(type) {
+		case int:
+			return v
+		case float64:
+			return int(v)
+		case int64:
+			return int(v)
+		case string:
+			// Extract number from string like "5 data sources"
+			for _, part := range strings.Fields(v) {
+				var n int
+				if _, err := fmt.Sscanf(part, "%d", &n); err == nil {
+					return n
+				}
+			}
+		}
+	}
+	return defaultVal
+}
@@ -0,0 +1,31 @@
+package services
+
+import "testing"
+
+func TestPlanIntLimit(t *testing.T) {
+	features := map[string]interface{}{
+		"int":     5,
+		"float":   float64(2000),
+		"int64":   int64(7),
+		"text":    "10 data sources",
+		"garbage": "unlimited",
+		"bool":    true,
+	}
+	cases := []struct {
+		key  string
+		want int
+	}{
+		{"int", 5},
+		{"float", 2000},
+		{"int64", 7},
+		{"text", 10},
+		{"garbage", 3},
+		{"bool", 3},
+		{"missing", 3},
+	}
+	for _, tc := range cases {
+		if got := PlanIntLimit(features, tc.key, 3); got != tc.want {
+			t.Errorf("%s: PlanIntLimit = %d, want %d", tc.key, got, tc.want)
+		}
+	}
+}
@@ -2,13 +2,16 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 
 	"github.com/nats-io/nats.go"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
 	"github.com/yourusername/vectorchat/pkg/jobs"
 	"github.com/yourusername/vectorchat/pkg/models"
 )
 
-// QueueMetricsService exposes lightweight JetStream stats for the crawl queue.
+// QueueMetricsService exposes lightweight JetStream stats for the crawl queue and manages its dead letters.
 type QueueMetricsService struct {
 	js nats.JetStreamContext
 }
@@ -26,7 +29,7 @@ func (s *QueueMetricsService) GetCrawlMetrics(ctx context.Context) (*models.Craw
 	if err != nil {
 		return nil, err
 	}
-	ci, err := s.js.ConsumerInfo(jobs.CrawlStream, "crawler-workers", nats.Context(ctx))
+	ci, err := s.js.ConsumerInfo(jobs.CrawlStream, jobs.CrawlConsumer, nats.Context(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -42,3 +45,85 @@ func (s *QueueMetricsService) GetCrawlMetrics(ctx context.Context) (*models.Craw
 
 	return &m, nil
 }
+
+// ListCrawlDeadLetters returns up to limit dead-lettered crawl jobs stored after the DLQ sequence after.
+func (s *QueueMetricsService) ListCrawlDeadLetters(ctx context.Context, after uint64, limit int) (*jobs.CrawlDeadLetterList, error) {
+	if s == nil || s.js == nil {
+		return nil, nats.ErrInvalidConnection
+	}
+	si, err := s.js.StreamInfo(jobs.CrawlDLQStream, nats.Context(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	list := &jobs.CrawlDeadLetterList{Total: si.State.Msgs, Items: []jobs.CrawlDeadLetter{}}
+	seq := max(after+1, si.State.FirstSeq)
+	for ; seq <= si.State.LastSeq && len(list.Items) < limit; seq++ {
+		letter, err := s.crawlDeadLetter(ctx, seq)
+		if err != nil {
+			if apperrors.Is(err, apperrors.ErrNotFound) {
+				continue // replayed or deleted
+			}
+			return nil, err
+		}
+		list.Items = append(list.Items, *letter)
+	}
+	if seq <= si.State.LastSeq && len(list.Items) > 0 {
+		list.NextAfter = list.Items[len(list.Items)-1].Sequence
+	}
+	return list, nil
+}
+
+// ReplayCrawlDeadLetter re-enqueues the job of a dead letter and removes it from the DLQ.
+// The job starts over with a fresh set of attempts.
+func (s *QueueMetricsService) ReplayCrawlDeadLetter(ctx context.Context, seq uint64) (*jobs.CrawlDeadLetter, error) {
+	if s == nil || s.js == nil {
+		return nil, nats.ErrInvalidConnection
+	}
+	letter, err := s.crawlDeadLetter(ctx, seq)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(letter.Job)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.js.Publish(jobs.CrawlSubject, data, nats.Context(ctx)); err != nil {
+		return nil, apperrors.Wrap(err, "failed to re-enqueue crawl job")
+	}
+	if err := s.js.DeleteMsg(jobs.CrawlDLQStream, seq, nats.Context(ctx)); err != nil {
+		return nil, apperrors.Wrap(err, "crawl job re-enqueued but not removed from the DLQ")
+	}
+	return letter, nil
+}
+
+// PurgeCrawlDeadLetters drops every dead letter and returns how many were removed.
+func (s *QueueMetricsService) PurgeCrawlDeadLetters(ctx context.Context) (uint64, error) {
+	if s == nil || s.js == nil {
+		return 0, nats.ErrInvalidConnection
+	}
+	si, err := s.js.StreamInfo(jobs.CrawlDLQStream, nats.Context(ctx))
+	if err != nil {
+		return 0, err
+	}
+	if err := s.js.PurgeStream(jobs.CrawlDLQStream, nats.Context(ctx)); err != nil {
+		return 0, err
+	}
+	return si.State.Msgs, nil
+}
+
+func (s *QueueMetricsService) crawlDeadLetter(ctx context.Context, seq uint64) (*jobs.CrawlDeadLetter, error) {
+	raw, err := s.js.GetMsg(jobs.CrawlDLQStream, seq, nats.Context(ctx))
+	if err != nil {
+		if errors.Is(err, nats.ErrMsgNotFound) {
+			return nil, apperrors.Wrapf(apperrors.ErrNotFound, "dead letter %d", seq)
+		}
+		return nil, err
+	}
+	var letter jobs.CrawlDeadLetter
+	if err := json.Unmarshal(raw.Data, &letter); err != nil {
+		return nil, apperrors.Wrapf(err, "failed to decode dead letter %d", seq)
+	}
+	letter.Sequence = raw.Sequence
+	return &letter, nil
+}
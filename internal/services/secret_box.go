@@ -0,0 +1,59 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+var (
+	errSecretCorrupt = errors.New("sealed value is corrupt")
+	errSecretKey     = errors.New("sealed value cannot be opened with the configured key")
+)
+
+// secretBox seals small secrets stored in the database with AES-256-GCM under a key derived
+// from a configured secret. Sealed values are nonce || ciphertext.
+type secretBox struct {
+	aead cipher.AEAD
+}
+
+// newSecretBox returns nil when no secret is configured.
+func newSecretBox(secret string) *secretBox {
+	if secret == "" {
+		return nil
+	}
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		panic(fmt.Sprintf("failed to init secret cipher: %v", err))
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(fmt.Sprintf("failed to init secret cipher: %v", err))
+	}
+	return &secretBox{aead: aead}
+}
+
+// seal encrypts plain; aad binds the sealed value to the row it is stored in.
+func (b *secretBox) seal(plain, aad []byte) ([]byte, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return b.aead.Seal(nonce, nonce, plain, aad), nil
+}
+
+func (b *secretBox) open(sealed, aad []byte) ([]byte, error) {
+	size := b.aead.NonceSize()
+	if len(sealed) < size {
+		return nil, errSecretCorrupt
+	}
+	plain, err := b.aead.Open(nil, sealed[:size], sealed[size:], aad)
+	if err != nil {
+		return nil, errSecretKey
+	}
+	return plain, nil
+}
@@ -17,6 +17,7 @@ type SharedKnowledgeBaseService struct {
 	fileRepo     *db.FileRepository
 	documentRepo *db.DocumentRepository
 	ingestion    *KnowledgeBaseService
+	jobs         *IngestionService
 }
 
 func NewSharedKnowledgeBaseService(
@@ -24,6 +25,7 @@ func NewSharedKnowledgeBaseService(
 	fileRepo *db.FileRepository,
 	documentRepo *db.DocumentRepository,
 	ingestion *KnowledgeBaseService,
+	jobs *IngestionService,
 ) *SharedKnowledgeBaseService {
 	return &SharedKnowledgeBaseService{
 		CommonService: NewCommonService(),
@@ -31,6 +33,7 @@ func NewSharedKnowledgeBaseService(
 		fileRepo:      fileRepo,
 		documentRepo:  documentRepo,
 		ingestion:     ingestion,
+		jobs:          jobs,
 	}
 }
 
@@ -168,47 +171,47 @@ func (s *SharedKnowledgeBaseService) ListTextSources(ctx context.Context, ownerI
 	}, nil
 }
 
-func (s *SharedKnowledgeBaseService) ProcessFileUpload(ctx context.Context, ownerID string, orgCtx *OrganizationContext, kbID uuid.UUID, fileHeader *multipart.FileHeader) (*models.SharedKnowledgeBaseFileUploadResponse, error) {
+// ProcessFileUpload queues a file for background ingestion into the knowledge base.
+func (s *SharedKnowledgeBaseService) ProcessFileUpload(ctx context.Context, ownerID string, orgCtx *OrganizationContext, kbID uuid.UUID, fileHeader *multipart.FileHeader) (*models.IngestionJobResponse, error) {
 	if _, err := s.ensureOwnership(ctx, ownerID, orgCtx, kbID); err != nil {
 		return nil, err
 	}
 
 	target := KnowledgeBaseTarget{SharedKnowledgeBaseID: &kbID}
-	file, err := s.ingestion.IngestFile(ctx, target, fileHeader)
-	if err != nil {
+	return s.jobs.EnqueueFile(ctx, target, fileHeader)
+}
+
+// ProcessTextUpload queues text for background ingestion into the knowledge base.
+func (s *SharedKnowledgeBaseService) ProcessTextUpload(ctx context.Context, ownerID string, orgCtx *OrganizationContext, kbID uuid.UUID, text string) (*models.IngestionJobResponse, error) {
+	if _, err := s.ensureOwnership(ctx, ownerID, orgCtx, kbID); err != nil {
 		return nil, err
 	}
+	if strings.TrimSpace(text) == "" {
+		return nil, apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "text is required")
+	}
 
-	return &models.SharedKnowledgeBaseFileUploadResponse{
-		Message:         "File processed successfully",
-		KnowledgeBaseID: kbID,
-		File:            file.Filename,
-		Filename:        file.Filename,
-		Size:            file.SizeBytes,
-	}, nil
+	target := KnowledgeBaseTarget{SharedKnowledgeBaseID: &kbID}
+	return s.jobs.EnqueueText(ctx, target, text)
 }
 
-func (s *SharedKnowledgeBaseService) ProcessTextUpload(ctx context.Context, ownerID string, orgCtx *OrganizationContext, kbID uuid.UUID, text string) error {
+// ProcessWebsiteUpload queues a website crawl for background ingestion into the knowledge base.
+func (s *SharedKnowledgeBaseService) ProcessWebsiteUpload(ctx context.Context, ownerID string, orgCtx *OrganizationContext, kbID uuid.UUID, rootURL string, opts *models.CrawlOptions, auth *models.CrawlAuth) (*models.IngestionJobResponse, error) {
 	if _, err := s.ensureOwnership(ctx, ownerID, orgCtx, kbID); err != nil {
-		return err
-	}
-	if strings.TrimSpace(text) == "" {
-		return apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "text is required")
+		return nil, err
 	}
 
 	target := KnowledgeBaseTarget{SharedKnowledgeBaseID: &kbID}
-	_, err := s.ingestion.IngestText(ctx, target, text)
-	return err
+	return s.jobs.EnqueueWebsite(ctx, target, rootURL, opts, auth)
 }
 
-func (s *SharedKnowledgeBaseService) ProcessWebsiteUpload(ctx context.Context, ownerID string, orgCtx *OrganizationContext, kbID uuid.UUID, rootURL string) error {
+// GetIngestionJob returns the progress of an upload into the knowledge base.
+func (s *SharedKnowledgeBaseService) GetIngestionJob(ctx context.Context, ownerID string, orgCtx *OrganizationContext, kbID, jobID uuid.UUID) (*models.IngestionJobResponse, error) {
 	if _, err := s.ensureOwnership(ctx, ownerID, orgCtx, kbID); err != nil {
-		return err
+		return nil, err
 	}
 
 	target := KnowledgeBaseTarget{SharedKnowledgeBaseID: &kbID}
-	_, err := s.ingestion.IngestWebsite(ctx, target, rootURL)
-	return err
+	return s.jobs.Get(ctx, target, jobID)
 }
 
 func (s *SharedKnowledgeBaseService) DeleteFile(ctx context.Context, ownerID string, orgCtx *OrganizationContext, kbID uuid.UUID, filename string) error {
@@ -288,6 +291,22 @@ func (s *SharedKnowledgeBaseService) DeleteTextSource(ctx context.Context, owner
 	return nil
 }
 
+// ListWebsitePages lists the crawled pages of a website source in the knowledge base.
+func (s *SharedKnowledgeBaseService) ListWebsitePages(ctx context.Context, ownerID string, orgCtx *OrganizationContext, kbID, sourceID uuid.UUID) (*models.WebsitePagesResponse, error) {
+	if _, err := s.ensureOwnership(ctx, ownerID, orgCtx, kbID); err != nil {
+		return nil, err
+	}
+	return s.ingestion.ListWebsitePages(ctx, KnowledgeBaseTarget{SharedKnowledgeBaseID: &kbID}, sourceID)
+}
+
+// DeleteWebsitePage deletes one crawled page and its chunks from a website source in the knowledge base.
+func (s *SharedKnowledgeBaseService) DeleteWebsitePage(ctx context.Context, ownerID string, orgCtx *OrganizationContext, kbID, sourceID, pageID uuid.UUID) error {
+	if _, err := s.ensureOwnership(ctx, ownerID, orgCtx, kbID); err != nil {
+		return err
+	}
+	return s.ingestion.DeleteWebsitePage(ctx, KnowledgeBaseTarget{SharedKnowledgeBaseID: &kbID}, sourceID, pageID)
+}
+
 func (s *SharedKnowledgeBaseService) ensureOwnership(ctx context.Context, ownerID string, orgCtx *OrganizationContext, kbID uuid.UUID) (*db.SharedKnowledgeBase, error) {
 	if ownerID == "" {
 		return nil, apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "owner id is required")
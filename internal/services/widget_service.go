@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/vectorchat/internal/db"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+	"github.com/yourusername/vectorchat/pkg/constants"
+	"github.com/yourusername/vectorchat/pkg/models"
+)
+
+// WidgetService backs the public chat API used by embedded widgets: it resolves chatbots,
+// checks request origins against the chatbot's allow-list and issues anonymous session tokens.
+type WidgetService struct {
+	chatbotRepo *db.ChatbotRepository
+	secret      []byte
+	timeNowUTC  func() time.Time
+}
+
+// NewWidgetService creates the service. Session tokens are signed with secret; when it is empty a
+// random key is generated, so tokens do not survive restarts or work across replicas.
+func NewWidgetService(chatbotRepo *db.ChatbotRepository, secret string) *WidgetService {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(fmt.Sprintf("failed to generate widget session key: %v", err))
+		}
+		slog.Error("WIDGET_SESSION_SECRET is not set; widget sessions are signed with an ephemeral key and are lost on restart and rejected by other replicas")
+	}
+	return &WidgetService{
+		chatbotRepo: chatbotRepo,
+		secret:      key,
+		timeNowUTC:  func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// GetPublicChatbot loads a chatbot by ID for the public API.
+func (s *WidgetService) GetPublicChatbot(ctx context.Context, chatID string) (*db.Chatbot, error) {
+	id, err := uuid.Parse(chatID)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrChatbotNotFound, "invalid chatbot ID format")
+	}
+	return s.chatbotRepo.FindByID(ctx, id)
+}
+
+// OriginAllowed reports whether origin matches one of the chatbot's allowed origins.
+// Entries of the form https://*.example.com match any subdomain of example.com.
+func (s *WidgetService) OriginAllowed(chatbot *db.Chatbot, origin string) bool {
+	origin, err := normalizeOrigin(origin)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range chatbot.AllowedOrigins {
+		if allowed == origin {
+			return true
+		}
+		scheme, host, ok := strings.Cut(allowed, "://*.")
+		if ok && strings.HasPrefix(origin, scheme+"://") && strings.HasSuffix(origin, "."+host) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestOrigin returns the calling site's origin from the Origin header, falling back to the Referer.
+func RequestOrigin(origin, referer string) string {
+	if origin = strings.TrimSpace(origin); origin != "" && origin != "null" {
+		return origin
+	}
+	parsed, err := url.Parse(strings.TrimSpace(referer))
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return ""
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
+
+// IssueSession creates an anonymous chat session for a widget visitor.
+func (s *WidgetService) IssueSession(chatbotID uuid.UUID) *models.WidgetSessionResponse {
+	sessionID := uuid.New()
+	expiresAt := s.timeNowUTC().Add(constants.WidgetSessionTTL)
+	payload := fmt.Sprintf("%s.%s.%d", chatbotID, sessionID, expiresAt.Unix())
+	token := base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(s.sign(payload))
+	return &models.WidgetSessionResponse{
+		Token:     token,
+		SessionID: sessionID.String(),
+		ExpiresAt: expiresAt,
+	}
+}
+
+// VerifySession validates a session token for the chatbot and returns its chat session ID.
+func (s *WidgetService) VerifySession(chatbotID uuid.UUID, token string) (uuid.UUID, error) {
+	encodedPayload, encodedSig, ok := strings.Cut(strings.TrimSpace(token), ".")
+	if !ok {
+		return uuid.Nil, apperrors.Wrap(apperrors.ErrUnauthorizedChatbotAccess, "malformed session token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return uuid.Nil, apperrors.Wrap(apperrors.ErrUnauthorizedChatbotAccess, "malformed session token")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encodedSig)
+	if err != nil || !hmac.Equal(sig, s.sign(string(payload))) {
+		return uuid.Nil, apperrors.Wrap(apperrors.ErrUnauthorizedChatbotAccess, "invalid session token")
+	}
+
+	parts := strings.Split(string(payload), ".")
+	if len(parts) != 3 || parts[0] != chatbotID.String() {
+		return uuid.Nil, apperrors.Wrap(apperrors.ErrUnauthorizedChatbotAccess, "session token is for another chatbot")
+	}
+	expiresAt, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || s.timeNowUTC().Unix() > expiresAt {
+		return uuid.Nil, apperrors.Wrap(apperrors.ErrUnauthorizedChatbotAccess, "session token expired")
+	}
+	sessionID, err := uuid.Parse(parts[1])
+	if err != nil {
+		return uuid.Nil, apperrors.Wrap(apperrors.ErrUnauthorizedChatbotAccess, "invalid session token")
+	}
+	return sessionID, nil
+}
+
+func (s *WidgetService) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// normalizeOrigin reduces an origin to lowercase scheme://host[:port], allowing a leading *. wildcard label.
+func normalizeOrigin(origin string) (string, error) {
+	origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+	parsed, err := url.Parse(origin)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", fmt.Errorf("origin %q must look like https://www.example.com", origin)
+	}
+	if parsed.Path != "" || parsed.RawQuery != "" || parsed.Fragment != "" || parsed.User != nil {
+		return "", fmt.Errorf("origin %q must not contain a path, query or credentials", origin)
+	}
+	host := strings.ToLower(parsed.Host)
+	if strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+		return "", fmt.Errorf("origin %q may only use a wildcard as the first label", origin)
+	}
+	return parsed.Scheme + "://" + host, nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourusername/vectorchat/internal/db"
+	apperrors "github.com/yourusername/vectorchat/internal/errors"
+	"github.com/yourusername/vectorchat/pkg/constants"
+)
+
+func TestWidgetSessionTokens(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	svc := NewWidgetService(nil, "widget-secret")
+	svc.timeNowUTC = func() time.Time { return now }
+
+	chatbotID := uuid.New()
+	session := svc.IssueSession(chatbotID)
+
+	sessionID, err := svc.VerifySession(chatbotID, session.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sessionID.String() != session.SessionID {
+		t.Fatalf("session = %s, want %s", sessionID, session.SessionID)
+	}
+
+	payload, sig, _ := strings.Cut(session.Token, ".")
+	decoded, _ := base64.RawURLEncoding.DecodeString(payload)
+	otherSession := strings.Replace(string(decoded), session.SessionID, uuid.NewString(), 1)
+	later := string(decoded[:strings.LastIndex(string(decoded), ".")+1]) + "9999999999"
+
+	cases := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"no signature", payload},
+		{"payload with another session", base64.RawURLEncoding.EncodeToString([]byte(otherSession)) + "." + sig},
+		{"payload with a later expiry", base64.RawURLEncoding.EncodeToString([]byte(later)) + "." + sig},
+		{"truncated signature", payload + "." + sig[:len(sig)-2]},
+		{"signature that is not base64", payload + ".!!!"},
+		{"signed with another secret", NewWidgetService(nil, "other-secret").IssueSession(chatbotID).Token},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := svc.VerifySession(chatbotID, tc.token); !apperrors.Is(err, apperrors.ErrUnauthorizedChatbotAccess) {
+				t.Errorf("error = %v, want ErrUnauthorizedChatbotAccess", err)
+			}
+		})
+	}
+
+	t.Run("other chatbot", func(t *testing.T) {
+		if _, err := svc.VerifySession(uuid.New(), session.Token); !apperrors.Is(err, apperrors.ErrUnauthorizedChatbotAccess) {
+			t.Errorf("error = %v, want ErrUnauthorizedChatbotAccess", err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		now = now.Add(constants.WidgetSessionTTL - time.Second)
+		if _, err := svc.VerifySession(chatbotID, session.Token); err != nil {
+			t.Fatalf("token rejected before it expired: %v", err)
+		}
+		now = now.Add(2 * time.Second)
+		if _, err := svc.VerifySession(chatbotID, session.Token); !apperrors.Is(err, apperrors.ErrUnauthorizedChatbotAccess) {
+			t.Errorf("error = %v, want ErrUnauthorizedChatbotAccess", err)
+		}
+	})
+}
+
+func TestOriginAllowed(t *testing.T) {
+	svc := NewWidgetService(nil, "widget-secret")
+	chatbot := &db.Chatbot{AllowedOrigins: []string{
+		"https://www.example.com",
+		"https://*.example.org",
+		"http://localhost:3000",
+		"https://*.example.net:8443",
+	}}
+	cases := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://www.example.com", true},
+		{"HTTPS://WWW.EXAMPLE.COM/", true},
+		{"http://www.example.com", false},
+		{"https://example.com", false},
+		{"https://www.example.com:8443", false},
+		{"https://www.example.com.evil.com", false},
+
+		{"https://shop.example.org", true},
+		{"https://a.b.example.org", true},
+		{"https://example.org", false},
+		{"https://evil-example.org", false},
+		{"https://evilexample.org", false},
+		{"http://shop.example.org", false},
+		{"https://shop.example.org.evil.com", false},
+		{"https://shop.example.org:8443", false},
+
+		{"https://app.example.net:8443", true},
+		{"https://app.example.net", false},
+
+		{"http://localhost:3000", true},
+		{"http://localhost:3001", false},
+
+		{"https://evil.com/.example.org", false},
+		{"https://evil.com?x=.example.org", false},
+		{"https://shop.example.org@evil.com", false},
+		{"file://shop.example.org", false},
+		{"null", false},
+		{"", false},
+	}
+	for _, tc := range cases {
+		if got := svc.OriginAllowed(chatbot, tc.origin); got != tc.want {
+			t.Errorf("OriginAllowed(%q) = %v, want %v", tc.origin, got, tc.want)
+		}
+	}
+}
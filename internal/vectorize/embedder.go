@@ -0,0 +1,294 @@
+package vectorize
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/vectorchat/pkg/constants"
+)
+
+const (
+	// Inputs sent per embeddings request; self-hosted servers default to small client batches
+	remoteEmbedBatchSize = 100
+	localEmbedBatchSize  = 32
+	// maxEmbedAttempts bounds retries of a rate limited or failing embeddings request
+	maxEmbedAttempts = 5
+	// Exponential backoff between attempts when the API gives no Retry-After
+	initialEmbedBackoff = 500 * time.Millisecond
+	maxEmbedBackoff     = 30 * time.Second
+)
+
+// httpEmbedder implements the embeddings.Embedder interface for OpenAI-compatible,
+// Ollama and text-embeddings-inference servers
+type httpEmbedder struct {
+	backend   string
+	endpoint  string
+	apiKey    string
+	model     string
+	batchSize int
+	http      *http.Client
+	// backoff is the wait before the first retry when the API gives no Retry-After
+	backoff time.Duration
+}
+
+func newHTTPEmbedder(cfg Config) (*httpEmbedder, error) {
+	baseURL := strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/")
+	e := &httpEmbedder{
+		backend: cfg.Backend,
+		apiKey:  cfg.APIKey,
+		model:   cfg.Model,
+		http:    &http.Client{Timeout: 60 * time.Second},
+		backoff: initialEmbedBackoff,
+	}
+
+	switch cfg.Backend {
+	case constants.EmbeddingBackendOpenAI, "":
+		if baseURL == "" {
+			baseURL = "https://api.openai.com/v1"
+		}
+		if e.model == "" {
+			e.model = "text-embedding-3-small"
+		}
+		e.backend = constants.EmbeddingBackendOpenAI
+		e.endpoint = baseURL + "/embeddings"
+		e.batchSize = remoteEmbedBatchSize
+	case constants.EmbeddingBackendOllama:
+		if baseURL == "" {
+			baseURL = "http://localhost:11434"
+		}
+		if e.model == "" {
+			return nil, fmt.Errorf("EMBEDDING_MODEL is required for the ollama embedding backend")
+		}
+		e.endpoint = baseURL + "/api/embed"
+		e.batchSize = localEmbedBatchSize
+	case constants.EmbeddingBackendTEI:
+		if baseURL == "" {
+			return nil, fmt.Errorf("EMBEDDING_BASE_URL is required for the tei embedding backend")
+		}
+		e.endpoint = baseURL + "/embed"
+		e.batchSize = localEmbedBatchSize
+	default:
+		return nil, fmt.Errorf("unknown embedding backend %q", cfg.Backend)
+	}
+	return e, nil
+}
+
+// modelID names the model behind this embedder. TEI serves a single model and needs no
+// model name, so it is identified by its endpoint instead.
+func (e *httpEmbedder) modelID() string {
+	if e.model != "" {
+		return e.model
+	}
+	return e.backend + ":" + e.endpoint
+}
+
+// EmbedDocuments implements the embeddings.Embedder interface
+func (e *httpEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+
+	out := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += e.batchSize {
+		end := start + e.batchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+		body, err := json.Marshal(e.requestBody(texts[start:end]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal embeddings request: %w", err)
+		}
+
+		respBytes, err := e.post(ctx, body)
+		if err != nil {
+			return nil, err
+		}
+		vectors, err := e.parseResponse(respBytes)
+		if err != nil {
+			return nil, err
+		}
+		if len(vectors) != end-start {
+			return nil, fmt.Errorf("embeddings count mismatch: got %d, want %d", len(vectors), end-start)
+		}
+		for _, vec := range vectors {
+			// Convert float64 to float32
+			float32Embedding := make([]float32, len(vec))
+			for i, v := range vec {
+				float32Embedding[i] = float32(v)
+			}
+			out = append(out, float32Embedding)
+		}
+	}
+
+	return out, nil
+}
+
+// EmbedQuery implements the embeddings.Embedder interface
+func (e *httpEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := e.EmbedDocuments(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (e *httpEmbedder) requestBody(texts []string) any {
+	switch e.backend {
+	case constants.EmbeddingBackendOllama:
+		return map[string]any{"model": e.model, "input": texts}
+	case constants.EmbeddingBackendTEI:
+		return map[string]any{"inputs": texts, "truncate": true}
+	default:
+		return map[string]any{"model": e.model, "input": texts}
+	}
+}
+
+func (e *httpEmbedder) parseResponse(body []byte) ([][]float64, error) {
+	switch e.backend {
+	case constants.EmbeddingBackendOllama:
+		var parsed struct {
+			Embeddings [][]float64 `json:"embeddings"`
+			Error      string      `json:"error"`
+		}
+		if err := json.Unmarshal(body, &parsed); err != nil {
+			return nil, fmt.Errorf("failed to parse embeddings response: %w", err)
+		}
+		if parsed.Error != "" {
+			return nil, fmt.Errorf("embeddings API error: %s", parsed.Error)
+		}
+		return parsed.Embeddings, nil
+	case constants.EmbeddingBackendTEI:
+		var parsed [][]float64
+		if err := json.Unmarshal(body, &parsed); err != nil {
+			return nil, fmt.Errorf("failed to parse embeddings response: %w", err)
+		}
+		return parsed, nil
+	default:
+		var parsed struct {
+			Data []struct {
+				Index     int       `json:"index"`
+				Embedding []float64 `json:"embedding"`
+			} `json:"data"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(body, &parsed); err != nil {
+			return nil, fmt.Errorf("failed to parse embeddings response: %w", err)
+		}
+		if parsed.Error != nil {
+			return nil, fmt.Errorf("embeddings API error: %s", parsed.Error.Message)
+		}
+		out := make([][]float64, len(parsed.Data))
+		for i, d := range parsed.Data {
+			idx := d.Index
+			if idx < 0 || idx >= len(out) || out[idx] != nil {
+				idx = i
+			}
+			out[idx] = d.Embedding
+		}
+		return out, nil
+	}
+}
+
+// post sends an embeddings request, retrying rate limits (429), server errors (5xx) and
+// network failures with exponential backoff. A Retry-After header overrides the backoff.
+func (e *httpEmbedder) post(ctx context.Context, body []byte) ([]byte, error) {
+	client := e.http
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	backoff := e.backoff
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create embeddings request: %w", err)
+		}
+		if e.apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+e.apiKey)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		var (
+			respBytes  []byte
+			retryAfter time.Duration
+		)
+		resp, err := client.Do(req)
+		if err == nil {
+			respBytes, err = io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				err = fmt.Errorf("failed to read embeddings response: %w", err)
+			} else if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				return respBytes, nil
+			} else {
+				err = fmt.Errorf("embeddings API error: status %d, body: %s", resp.StatusCode, string(respBytes))
+				if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+					return nil, err
+				}
+				retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+			}
+		} else {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			err = fmt.Errorf("embeddings request error: %w", err)
+		}
+
+		if attempt >= maxEmbedAttempts {
+			return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		wait := retryWait(retryAfter, backoff)
+		backoff *= 2
+		if backoff > maxEmbedBackoff {
+			backoff = maxEmbedBackoff
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryWait is how long to wait before the next attempt: the server's Retry-After when given,
+// otherwise the jittered backoff, capped at maxEmbedBackoff either way.
+func retryWait(retryAfter, backoff time.Duration) time.Duration {
+	wait := retryAfter
+	if wait <= 0 {
+		// jitter keeps concurrent batches from retrying in lockstep
+		wait = backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+	}
+	if wait > maxEmbedBackoff {
+		wait = maxEmbedBackoff
+	}
+	return wait
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
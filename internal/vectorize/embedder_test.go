@@ -0,0 +1,120 @@
+package vectorize
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yourusername/vectorchat/pkg/constants"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		min, max time.Duration
+	}{
+		{"empty", "", 0, 0},
+		{"seconds", "7", 7 * time.Second, 7 * time.Second},
+		{"padded seconds", " 2 ", 2 * time.Second, 2 * time.Second},
+		{"zero", "0", 0, 0},
+		{"negative", "-3", 0, 0},
+		{"garbage", "soon", 0, 0},
+		{"http date", time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat), 8 * time.Second, 10 * time.Second},
+		{"past http date", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), -2 * time.Minute, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseRetryAfter(tt.value)
+			if got < tt.min || got > tt.max {
+				t.Fatalf("parseRetryAfter(%q) = %v, want between %v and %v", tt.value, got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestRetryWait(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter time.Duration
+		backoff    time.Duration
+		min, max   time.Duration
+	}{
+		{"retry-after wins", 3 * time.Second, time.Second, 3 * time.Second, 3 * time.Second},
+		{"retry-after clamped", 2 * time.Minute, time.Second, maxEmbedBackoff, maxEmbedBackoff},
+		{"jittered backoff", 0, 2 * time.Second, time.Second, 2 * time.Second},
+		{"past date falls back to backoff", -time.Minute, 2 * time.Second, time.Second, 2 * time.Second},
+		{"backoff clamped", 0, 2 * maxEmbedBackoff, maxEmbedBackoff, maxEmbedBackoff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := retryWait(tt.retryAfter, tt.backoff)
+			if got < tt.min || got > tt.max {
+				t.Fatalf("retryWait(%v, %v) = %v, want between %v and %v", tt.retryAfter, tt.backoff, got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestPostRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int // status per attempt; the last one repeats
+		wantErr  bool
+		wantHits int32
+	}{
+		{"success", []int{http.StatusOK}, false, 1},
+		{"rate limited then success", []int{http.StatusTooManyRequests, http.StatusOK}, false, 2},
+		{"server error then success", []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK}, false, 3},
+		{"client error is not retried", []int{http.StatusBadRequest}, true, 1},
+		{"gives up after max attempts", []int{http.StatusInternalServerError}, true, maxEmbedAttempts},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(hits.Add(1))
+				status := tt.statuses[min(n, len(tt.statuses))-1]
+				if status == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", "0")
+				}
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte(`{"data":[{"index":0,"embedding":[0.5]}]}`))
+			}))
+			defer srv.Close()
+
+			e := &httpEmbedder{backend: constants.EmbeddingBackendOpenAI, endpoint: srv.URL, http: srv.Client(), backoff: time.Millisecond}
+			_, err := e.post(context.Background(), []byte(`{}`))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("post error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := hits.Load(); got != tt.wantHits {
+				t.Fatalf("server hit %d times, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestPostStopsWhenContextCancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "20")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	e := &httpEmbedder{backend: constants.EmbeddingBackendOpenAI, endpoint: srv.URL, http: srv.Client(), backoff: time.Millisecond}
+	start := time.Now()
+	_, err := e.post(ctx, []byte(`{}`))
+	if err == nil || !strings.Contains(err.Error(), "deadline") {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("post kept waiting on Retry-After after the context ended")
+	}
+}
@@ -0,0 +1,67 @@
+package vectorize
+
+import "fmt"
+
+// Registry holds a vectorizer for every embedding model the server can query. Each knowledge
+// base records the model its documents were embedded with, so while one is being re-embedded
+// the old and new models are both served.
+type Registry struct {
+	primary Vectorizer
+	models  map[string]Vectorizer
+}
+
+// NewRegistry creates vectorizers for cfg.Model and each additional model, which share cfg's
+// backend, base URL, API key and dimensions.
+func NewRegistry(cfg Config, additional []string) (*Registry, error) {
+	primary, err := NewVectorizer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	r := NewSingleRegistry(primary)
+	for _, model := range additional {
+		if model == "" {
+			continue
+		}
+		extra := cfg
+		extra.Model = model
+		v, err := NewVectorizer(extra)
+		if err != nil {
+			return nil, fmt.Errorf("embedding model %s: %w", model, err)
+		}
+		r.models[v.Model()] = v
+	}
+	return r, nil
+}
+
+// NewSingleRegistry serves only v.
+func NewSingleRegistry(v Vectorizer) *Registry {
+	return &Registry{primary: v, models: map[string]Vectorizer{v.Model(): v}}
+}
+
+// Default returns the vectorizer of the configured model, used for knowledge bases that have no model yet
+func (r *Registry) Default() Vectorizer {
+	return r.primary
+}
+
+// For returns the vectorizer of model; an empty model is the default.
+func (r *Registry) For(model string) (Vectorizer, error) {
+	if model == "" {
+		return r.primary, nil
+	}
+	v, ok := r.models[model]
+	if !ok {
+		return nil, fmt.Errorf("embedding model %s is not served; add it to EMBEDDING_ADDITIONAL_MODELS", model)
+	}
+	return v, nil
+}
+
+// Models lists the served models, the default first.
+func (r *Registry) Models() []string {
+	models := []string{r.primary.Model()}
+	for model := range r.models {
+		if model != r.primary.Model() {
+			models = append(models, model)
+		}
+	}
+	return models
+}
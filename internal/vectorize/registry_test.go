@@ -0,0 +1,33 @@
+package vectorize
+
+import (
+	"testing"
+
+	"github.com/yourusername/vectorchat/pkg/constants"
+)
+
+func TestRegistryServesAdditionalModels(t *testing.T) {
+	r, err := NewRegistry(Config{Backend: constants.EmbeddingBackendOpenAI, Model: "new-model", Dimensions: 8}, []string{"old-model", ""})
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+
+	if got := r.Default().Model(); got != "new-model" {
+		t.Fatalf("Default().Model() = %q, want new-model", got)
+	}
+	for model, want := range map[string]string{"": "new-model", "new-model": "new-model", "old-model": "old-model"} {
+		v, err := r.For(model)
+		if err != nil {
+			t.Fatalf("For(%q): %v", model, err)
+		}
+		if v.Model() != want {
+			t.Fatalf("For(%q).Model() = %q, want %q", model, v.Model(), want)
+		}
+	}
+	if _, err := r.For("retired-model"); err == nil {
+		t.Fatal("For returned a vectorizer for a model that is not served")
+	}
+	if models := r.Models(); len(models) != 2 || models[0] != "new-model" {
+		t.Fatalf("Models() = %v, want new-model first of 2", models)
+	}
+}
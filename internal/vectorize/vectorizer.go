@@ -1,156 +1,151 @@
 package vectorize
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/ledongthuc/pdf"
 	"github.com/tmc/langchaingo/embeddings"
+	"golang.org/x/sync/errgroup"
 )
 
+// maxConcurrentBatches bounds how many embeddings requests VectorizeBatch runs at once
+const maxConcurrentBatches = 4
+
 // Vectorizer is an interface for creating vector embeddings from text
 type Vectorizer interface {
 	VectorizeText(ctx context.Context, text string) ([]float32, error)
+	// VectorizeBatch embeds texts in batches, returning embeddings in input order.
+	// progress, when set, receives the number of texts embedded so far after each batch.
+	VectorizeBatch(ctx context.Context, texts []string, progress func(embedded int)) ([][]float32, error)
 	VectorizeFile(ctx context.Context, filePath string) ([]float32, error)
+	// Model identifies the embedding model, recorded per document so vectors from different models are never mixed
+	Model() string
 }
 
-// OpenAIVectorizer implements Vectorizer using OpenAI's embeddings
-type OpenAIVectorizer struct {
-	client embeddings.Embedder
+// Config selects the embedding backend.
+type Config struct {
+	// Backend is one of constants.EmbeddingBackendOpenAI, EmbeddingBackendOllama or EmbeddingBackendTEI
+	Backend string
+	// BaseURL of the API, e.g. https://api.openai.com/v1, http://litellm:4000/v1 or http://ollama:11434
+	BaseURL string
+	APIKey  string
+	Model   string
+	// Dimensions every embedding must have; 0 skips the check
+	Dimensions int
 }
 
-// NewOpenAIVectorizer creates a new OpenAI vectorizer
-func NewOpenAIVectorizer(apiKey string) *OpenAIVectorizer {
-	// Create a custom OpenAI embedder since the function isn't available
-	client := &openAIEmbedder{
-		apiKey: apiKey,
-		model:  "text-embedding-3-small",
-		http:   &http.Client{Timeout: 60 * time.Second},
-	}
+// EmbeddingVectorizer implements Vectorizer on top of an embeddings.Embedder
+type EmbeddingVectorizer struct {
+	client     embeddings.Embedder
+	model      string
+	batchSize  int
+	dimensions int
+}
 
-	return &OpenAIVectorizer{
-		client: client,
+// NewVectorizer creates a vectorizer for the configured backend
+func NewVectorizer(cfg Config) (*EmbeddingVectorizer, error) {
+	client, err := newHTTPEmbedder(cfg)
+	if err != nil {
+		return nil, err
 	}
+	return &EmbeddingVectorizer{
+		client:     client,
+		model:      client.modelID(),
+		batchSize:  client.batchSize,
+		dimensions: cfg.Dimensions,
+	}, nil
 }
 
-// openAIEmbedder implements the embeddings.Embedder interface
-type openAIEmbedder struct {
-	apiKey string
-	model  string
-	http   *http.Client
+// Model returns the configured embedding model
+func (v *EmbeddingVectorizer) Model() string {
+	return v.model
 }
 
-// EmbedDocuments implements the embeddings.Embedder interface
-func (e *openAIEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
-	if len(texts) == 0 {
-		return [][]float32{}, nil
+// Dimensions returns the configured embedding dimension, or 0 when unchecked
+func (v *EmbeddingVectorizer) Dimensions() int {
+	return v.dimensions
+}
+
+// VectorizeText creates a vector embedding from text
+func (v *EmbeddingVectorizer) VectorizeText(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := v.client.EmbedDocuments(ctx, []string{text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding: %v", err)
 	}
 
-	type embedReq struct {
-		Model string   `json:"model"`
-		Input []string `json:"input"`
+	if len(embeddings) == 0 {
+		return nil, fmt.Errorf("no embeddings returned")
 	}
-	type embedRes struct {
-		Data []struct {
-			Embedding []float64 `json:"embedding"`
-		} `json:"data"`
-		Error *struct {
-			Message string `json:"message"`
-		} `json:"error"`
+	if err := v.checkDimensions(embeddings[0]); err != nil {
+		return nil, err
 	}
 
-	const endpoint = "https://api.openai.com/v1/embeddings"
-	const batchSize = 100
-	out := make([][]float32, 0, len(texts))
+	// embeddings are already float32 now
+	return embeddings[0], nil
+}
 
-	for start := 0; start < len(texts); start += batchSize {
-		end := start + batchSize
+// VectorizeBatch creates embeddings for many texts, sending up to maxConcurrentBatches
+// requests at a time.
+func (v *EmbeddingVectorizer) VectorizeBatch(ctx context.Context, texts []string, progress func(embedded int)) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	if len(texts) == 0 {
+		return out, nil
+	}
+
+	var (
+		mu       sync.Mutex
+		embedded int
+	)
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxConcurrentBatches)
+	for start := 0; start < len(texts); start += v.batchSize {
+		end := start + v.batchSize
 		if end > len(texts) {
 			end = len(texts)
 		}
-		payload := embedReq{Model: e.model, Input: texts[start:end]}
-		body, err := json.Marshal(payload)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal embeddings request: %w", err)
-		}
-
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
-		if err != nil {
-			return nil, fmt.Errorf("failed to create embeddings request: %w", err)
-		}
-		req.Header.Set("Authorization", "Bearer "+e.apiKey)
-		req.Header.Set("Content-Type", "application/json")
+		g.Go(func() error {
+			batch, err := v.client.EmbedDocuments(gctx, texts[start:end])
+			if err != nil {
+				return fmt.Errorf("failed to embed texts %d-%d: %w", start, end-1, err)
+			}
+			if len(batch) != end-start {
+				return fmt.Errorf("embeddings count mismatch: got %d, want %d", len(batch), end-start)
+			}
+			for _, emb := range batch {
+				if err := v.checkDimensions(emb); err != nil {
+					return err
+				}
+			}
+			copy(out[start:end], batch)
 
-		client := e.http
-		if client == nil {
-			client = http.DefaultClient
-		}
-		resp, err := client.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("embeddings request error: %w", err)
-		}
-		respBytes, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			return nil, fmt.Errorf("failed to read embeddings response: %w", err)
-		}
-		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			return nil, fmt.Errorf("embeddings API error: status %d, body: %s", resp.StatusCode, string(respBytes))
-		}
-		var parsed embedRes
-		if err := json.Unmarshal(respBytes, &parsed); err != nil {
-			return nil, fmt.Errorf("failed to parse embeddings response: %w", err)
-		}
-		if parsed.Error != nil {
-			return nil, fmt.Errorf("embeddings API error: %s", parsed.Error.Message)
-		}
-		if len(parsed.Data) != end-start {
-			return nil, fmt.Errorf("embeddings count mismatch: got %d, want %d", len(parsed.Data), end-start)
-		}
-		for _, d := range parsed.Data {
-			// Convert float64 to float32
-			float32Embedding := make([]float32, len(d.Embedding))
-			for i, v := range d.Embedding {
-				float32Embedding[i] = float32(v)
+			mu.Lock()
+			defer mu.Unlock()
+			embedded += end - start
+			if progress != nil {
+				progress(embedded)
 			}
-			out = append(out, float32Embedding)
-		}
+			return nil
+		})
 	}
-
-	return out, nil
-}
-
-// EmbedQuery implements the embeddings.Embedder interface
-func (e *openAIEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
-	embeddings, err := e.EmbedDocuments(ctx, []string{text})
-	if err != nil {
+	if err := g.Wait(); err != nil {
 		return nil, err
 	}
-	return embeddings[0], nil
+	return out, nil
 }
 
-// VectorizeText creates a vector embedding from text
-func (v *OpenAIVectorizer) VectorizeText(ctx context.Context, text string) ([]float32, error) {
-	embeddings, err := v.client.EmbedDocuments(ctx, []string{text})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create embedding: %v", err)
-	}
-
-	if len(embeddings) == 0 {
-		return nil, fmt.Errorf("no embeddings returned")
+// checkDimensions rejects embeddings that would not fit the configured vector columns
+func (v *EmbeddingVectorizer) checkDimensions(embedding []float32) error {
+	if v.dimensions > 0 && len(embedding) != v.dimensions {
+		return fmt.Errorf("embedding has %d dimensions, expected %d; check EMBEDDING_MODEL and EMBEDDING_DIMENSIONS", len(embedding), v.dimensions)
 	}
-
-	// embeddings are already float32 now
-	return embeddings[0], nil
+	return nil
 }
 
 // ExtractTextFromPDF extracts all text from a PDF file
@@ -186,7 +181,7 @@ func fileStatSize(f *os.File) int64 {
 }
 
 // VectorizeFile reads a file and creates a vector embedding from its content
-func (v *OpenAIVectorizer) VectorizeFile(ctx context.Context, filePath string) ([]float32, error) {
+func (v *EmbeddingVectorizer) VectorizeFile(ctx context.Context, filePath string) ([]float32, error) {
 	content, err := ioutil.ReadFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %v", err)
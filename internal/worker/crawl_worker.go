@@ -0,0 +1,354 @@
+// Package worker runs queue consumers that can be embedded in the API process or deployed on their own.
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/yourusername/vectorchat/internal/crawler"
+	"github.com/yourusername/vectorchat/internal/db"
+	"github.com/yourusername/vectorchat/internal/queue"
+	"github.com/yourusername/vectorchat/internal/services"
+	"github.com/yourusername/vectorchat/pkg/jobs"
+)
+
+// Worker health states
+const (
+	HealthOK           = "ok"
+	HealthDraining     = "draining"
+	HealthDisconnected = "disconnected"
+)
+
+// CrawlWorkerOptions tunes a CrawlWorker.
+type CrawlWorkerOptions struct {
+	// Concurrency is the number of crawl jobs run at once; defaults to 1
+	Concurrency int
+	// DrainTimeout bounds how long shutdown waits for running crawls before handing them back to the queue
+	DrainTimeout time.Duration
+	// ID names the worker in the crawl run history; defaults to the hostname and process ID
+	ID string
+}
+
+// CrawlWorker consumes crawl jobs with a pool of fetchers, each running one crawl at a time.
+// Failed jobs follow the retry policy of queue.CrawlRetries.
+type CrawlWorker struct {
+	nc          *nats.Conn
+	js          nats.JetStreamContext
+	crawls      *queue.CrawlQueue
+	retries     *queue.CrawlRetries
+	kb          *services.KnowledgeBaseService
+	credentials *services.CrawlCredentialService
+	schedules   *db.CrawlScheduleRepository
+	runs        *db.CrawlRunRepository
+	logger      *slog.Logger
+	opts        CrawlWorkerOptions
+
+	active    atomic.Int64
+	completed atomic.Int64
+	failed    atomic.Int64
+	draining  atomic.Bool
+	lastFetch atomic.Int64
+}
+
+// CrawlWorkerHealth is a snapshot of a worker, served by its health endpoint.
+type CrawlWorkerHealth struct {
+	Status      string     `json:"status"`
+	Concurrency int        `json:"concurrency"`
+	Active      int64      `json:"active"`
+	Completed   int64      `json:"completed"`
+	Failed      int64      `json:"failed"`
+	LastFetchAt *time.Time `json:"last_fetch_at,omitempty"`
+}
+
+// NewCrawlWorker binds to the crawl retry policy and in-flight claims declared by queue.EnsureStreams.
+func NewCrawlWorker(nc *nats.Conn, js nats.JetStreamContext, kb *services.KnowledgeBaseService, credentials *services.CrawlCredentialService, schedules *db.CrawlScheduleRepository, runs *db.CrawlRunRepository, logger *slog.Logger, opts CrawlWorkerOptions) (*CrawlWorker, error) {
+	crawls, err := queue.NewCrawlQueue(js)
+	if err != nil {
+		return nil, err
+	}
+	retries, err := queue.NewCrawlRetries(js, crawls)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.DrainTimeout <= 0 {
+		opts.DrainTimeout = 5 * time.Minute
+	}
+	if opts.ID == "" {
+		host, _ := os.Hostname()
+		opts.ID = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	return &CrawlWorker{
+		nc:          nc,
+		js:          js,
+		crawls:      crawls,
+		retries:     retries,
+		kb:          kb,
+		credentials: credentials,
+		schedules:   schedules,
+		runs:        runs,
+		logger:      logger,
+		opts:        opts,
+	}, nil
+}
+
+// Run consumes crawl jobs until ctx is done, then stops fetching and waits for running crawls.
+// Crawls still running after the drain timeout are cancelled and handed back to the queue.
+func (w *CrawlWorker) Run(ctx context.Context) error {
+	if advisories, err := w.retries.WatchExhausted(w.nc); err != nil {
+		w.logger.Warn("crawl worker: failed to watch exhausted jobs", "error", err)
+	} else {
+		defer advisories.Unsubscribe()
+	}
+
+	// The consumer and its retry policy are declared by queue.EnsureStreams
+	sub, err := w.js.PullSubscribe(
+		jobs.CrawlSubject,
+		jobs.CrawlConsumer,
+		nats.Bind(jobs.CrawlStream, jobs.CrawlConsumer),
+		nats.ManualAck(),
+	)
+	if err != nil {
+		return err
+	}
+	w.logger.Info("crawl worker started", "concurrency", w.opts.Concurrency)
+
+	w.consume(ctx, func(ctx, jobCtx context.Context) {
+		w.fetchLoop(ctx, jobCtx, sub)
+	})
+	w.logger.Info("crawl worker stopped")
+	return nil
+}
+
+// consume runs Concurrency copies of loop until ctx is done, then waits for them to return.
+// Jobs run under jobCtx, which outlives ctx so a shutdown lets them finish and is cancelled
+// once the drain timeout passes.
+func (w *CrawlWorker) consume(ctx context.Context, loop func(ctx, jobCtx context.Context)) {
+	jobCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJobs()
+
+	var wg sync.WaitGroup
+	for range w.opts.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			loop(ctx, jobCtx)
+		}()
+	}
+
+	<-ctx.Done()
+	w.draining.Store(true)
+	w.logger.Info("crawl worker draining", "active", w.active.Load())
+
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(w.opts.DrainTimeout):
+		w.logger.Warn("crawl worker: drain timed out; returning running crawls to the queue", "active", w.active.Load())
+		cancelJobs()
+		<-drained
+	}
+}
+
+// Health reports whether the worker is consuming jobs.
+func (w *CrawlWorker) Health() CrawlWorkerHealth {
+	h := CrawlWorkerHealth{
+		Status:      HealthOK,
+		Concurrency: w.opts.Concurrency,
+		Active:      w.active.Load(),
+		Completed:   w.completed.Load(),
+		Failed:      w.failed.Load(),
+	}
+	if last := w.lastFetch.Load(); last > 0 {
+		t := time.Unix(0, last).UTC()
+		h.LastFetchAt = &t
+	}
+	switch {
+	case w.draining.Load():
+		h.Status = HealthDraining
+	case w.nc == nil || !w.nc.IsConnected():
+		h.Status = HealthDisconnected
+	}
+	return h
+}
+
+func (w *CrawlWorker) fetchLoop(ctx, jobCtx context.Context, sub *nats.Subscription) {
+	for ctx.Err() == nil {
+		msgs, err := sub.Fetch(1, nats.MaxWait(2*time.Second))
+		w.lastFetch.Store(time.Now().UnixNano())
+		if err != nil {
+			if errors.Is(err, nats.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+				continue
+			}
+			w.logger.Warn("crawl worker: fetch error", "error", err)
+			// Avoid spinning while the server is unreachable
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		for _, msg := range msgs {
+			w.handle(jobCtx, msg)
+		}
+	}
+}
+
+func (w *CrawlWorker) handle(ctx context.Context, msg *nats.Msg) {
+	var payload jobs.CrawlJobPayload
+	if err := json.Unmarshal(msg.Data, &payload); err != nil {
+		w.logger.Warn("crawl worker: invalid payload", "error", err)
+		_ = msg.Term()
+		return
+	}
+
+	w.active.Add(1)
+	defer w.active.Add(-1)
+
+	target := services.KnowledgeBaseTarget{
+		ChatbotID:             payload.ChatbotID,
+		SharedKnowledgeBaseID: payload.SharedKnowledgeBaseID,
+	}
+
+	start := time.Now().UTC()
+	run := w.startRun(msg, payload, start)
+
+	// Crawls routinely outlast the ack wait; keep the message, the website claim and the run alive
+	// while we work. Jobs replayed from the DLQ claim their website again.
+	w.crawls.Touch(payload)
+	stop := queue.KeepInProgress(msg, jobs.CrawlHeartbeat, func() {
+		w.crawls.Touch(payload)
+		w.heartbeatRun(run)
+	})
+	defer stop()
+	var auth *crawler.Auth
+	var err error
+	if payload.CredentialsID != nil {
+		auth, err = w.credentials.Resolve(ctx, target, *payload.CredentialsID)
+	}
+	var result *services.WebsiteIngestResult
+	if err == nil {
+		result, err = w.kb.IngestWebsite(ctx, target, payload.RootURL, payload.Options, auth, nil)
+	}
+	if err != nil {
+		if w.requeueIfInterrupted(ctx, msg, run, err) {
+			return
+		}
+		w.failed.Add(1)
+		permanent := services.IsPermanentCrawlError(err)
+		deadLettered, dlqErr := w.retries.Fail(msg, payload, err, permanent)
+		if dlqErr != nil {
+			w.logger.Warn("crawl worker: failed to record crawl failure", "schedule_id", payload.ScheduleID, "error", dlqErr)
+		}
+		status := jobs.CrawlRunRetrying
+		if deadLettered {
+			status = jobs.CrawlRunFailed
+		}
+		w.finishRun(run, status, nil, err)
+		if payload.ScheduleID != uuid.Nil {
+			errMsg := err.Error()
+			_ = w.schedules.UpdateRunInfo(context.Background(), payload.ScheduleID, &start, nil, &status, &errMsg, nil)
+		}
+		w.logger.Error("crawl worker: crawl failed", "schedule_id", payload.ScheduleID, "error", err,
+			"permanent", permanent, "dead_lettered", deadLettered)
+		return
+	}
+
+	pages := db.CrawlPageCounts{Added: result.Added, Changed: result.Changed, Removed: result.Removed, Unchanged: result.Unchanged}
+	w.finishRun(run, jobs.CrawlRunCompleted, &db.CrawlRunOutput{
+		Pages:         pages,
+		PagesFetched:  result.Fetched,
+		ChunksWritten: result.Chunks,
+		BytesFetched:  result.Bytes,
+	}, nil)
+	if payload.ScheduleID != uuid.Nil {
+		status := jobs.CrawlRunCompleted
+		_ = w.schedules.UpdateRunInfo(context.Background(), payload.ScheduleID, &start, nil, &status, nil, &pages)
+	}
+	w.retries.Succeeded(msg, payload)
+	_ = msg.Ack()
+	w.completed.Add(1)
+	w.logger.Info("crawl worker: crawl completed", "schedule_id", payload.ScheduleID, "url", payload.RootURL,
+		"added", result.Added, "changed", result.Changed, "removed", result.Removed, "unchanged", result.Unchanged)
+}
+
+// nakker is the part of a JetStream message used to hand a job back to the queue.
+type nakker interface {
+	Nak(opts ...nats.AckOpt) error
+}
+
+// requeueIfInterrupted hands a crawl that failed because shutdown cancelled ctx back to the queue
+// for another worker, without counting it as a failure. It reports whether it did.
+func (w *CrawlWorker) requeueIfInterrupted(ctx context.Context, msg nakker, run *db.CrawlRun, err error) bool {
+	if ctx.Err() == nil {
+		return false
+	}
+	w.finishRun(run, jobs.CrawlRunInterrupted, nil, err)
+	_ = msg.Nak()
+	return true
+}
+
+// startRun records the attempt in the run history. Failing to do so never fails the crawl.
+func (w *CrawlWorker) startRun(msg *nats.Msg, payload jobs.CrawlJobPayload, start time.Time) *db.CrawlRun {
+	run := &db.CrawlRun{
+		JobID:                 payload.JobID,
+		Attempt:               1,
+		ChatbotID:             payload.ChatbotID,
+		SharedKnowledgeBaseID: payload.SharedKnowledgeBaseID,
+		RootURL:               payload.RootURL,
+		Trigger:               payload.RunTrigger(),
+		Status:                jobs.CrawlRunRunning,
+		WorkerID:              w.opts.ID,
+		StartedAt:             start,
+	}
+	if payload.ScheduleID != uuid.Nil {
+		run.ScheduleID = &payload.ScheduleID
+	}
+	if meta, err := msg.Metadata(); err == nil {
+		run.Attempt = int(meta.NumDelivered)
+	}
+	if err := w.runs.Start(context.Background(), run); err != nil {
+		w.logger.Warn("crawl worker: failed to record crawl run", "job_id", payload.JobID, "error", err)
+		return nil
+	}
+	return run
+}
+
+// heartbeatRun keeps a running attempt from being closed as abandoned.
+func (w *CrawlWorker) heartbeatRun(run *db.CrawlRun) {
+	if run == nil {
+		return
+	}
+	if err := w.runs.Heartbeat(context.Background(), run.ID); err != nil {
+		w.logger.Warn("crawl worker: failed to record crawl run heartbeat", "job_id", run.JobID, "error", err)
+	}
+}
+
+func (w *CrawlWorker) finishRun(run *db.CrawlRun, status string, output *db.CrawlRunOutput, cause error) {
+	if run == nil {
+		return
+	}
+	var errMsg *string
+	if cause != nil {
+		msg := cause.Error()
+		errMsg = &msg
+	}
+	if err := w.runs.Finish(context.Background(), run.ID, status, output, errMsg); err != nil {
+		w.logger.Warn("crawl worker: failed to finish crawl run", "job_id", run.JobID, "error", err)
+	}
+}
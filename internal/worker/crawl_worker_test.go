@@ -0,0 +1,137 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+type fakeMsg struct {
+	naks atomic.Int32
+}
+
+func (m *fakeMsg) Nak(...nats.AckOpt) error {
+	m.naks.Add(1)
+	return nil
+}
+
+func newTestWorker(concurrency int, drainTimeout time.Duration) *CrawlWorker {
+	return &CrawlWorker{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		opts:   CrawlWorkerOptions{Concurrency: concurrency, DrainTimeout: drainTimeout},
+	}
+}
+
+func TestConsumeHandsBackCrawlsAfterDrainTimeout(t *testing.T) {
+	w := newTestWorker(2, 50*time.Millisecond)
+	msgs := []*fakeMsg{{}, {}}
+	var (
+		next    atomic.Int32
+		started sync.WaitGroup
+	)
+	started.Add(len(msgs))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.consume(ctx, func(ctx, jobCtx context.Context) {
+			msg := msgs[next.Add(1)-1]
+			started.Done()
+			// a crawl that outlives the drain timeout
+			<-jobCtx.Done()
+			if !w.requeueIfInterrupted(jobCtx, msg, nil, jobCtx.Err()) {
+				t.Error("expected the cancelled crawl to be handed back")
+			}
+		})
+	}()
+	started.Wait()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("consume did not return after the drain timeout")
+	}
+	for i, msg := range msgs {
+		if got := msg.naks.Load(); got != 1 {
+			t.Errorf("message %d nakked %d times, want 1", i, got)
+		}
+	}
+	if h := w.Health(); h.Status != HealthDraining || h.Failed != 0 {
+		t.Errorf("health = %+v, want draining with no failures", h)
+	}
+}
+
+func TestConsumeLetsCrawlsFinishWithinDrainTimeout(t *testing.T) {
+	w := newTestWorker(1, 5*time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	var cancelled atomic.Bool
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.consume(ctx, func(ctx, jobCtx context.Context) {
+			<-ctx.Done()
+			// the crawl keeps running after shutdown starts
+			time.Sleep(20 * time.Millisecond)
+			cancelled.Store(jobCtx.Err() != nil)
+		})
+	}()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("consume waited for the drain timeout although the crawl finished")
+	}
+	if cancelled.Load() {
+		t.Fatal("crawl was cancelled while draining")
+	}
+}
+
+func TestRequeueIfInterruptedIgnoresFailures(t *testing.T) {
+	w := newTestWorker(1, time.Second)
+	msg := &fakeMsg{}
+	if w.requeueIfInterrupted(context.Background(), msg, nil, errors.New("crawl failed")) {
+		t.Fatal("a failure while running must not be handed back as interrupted")
+	}
+	if msg.naks.Load() != 0 {
+		t.Fatal("failed crawl was nakked")
+	}
+}
+
+func TestHealth(t *testing.T) {
+	w := newTestWorker(3, time.Second)
+	w.active.Store(2)
+	w.completed.Store(5)
+	w.failed.Store(1)
+
+	h := w.Health()
+	if h.Status != HealthDisconnected {
+		t.Errorf("status = %q without a NATS connection, want %q", h.Status, HealthDisconnected)
+	}
+	if h.Concurrency != 3 || h.Active != 2 || h.Completed != 5 || h.Failed != 1 {
+		t.Errorf("counters = %+v", h)
+	}
+	if h.LastFetchAt != nil {
+		t.Errorf("last fetch = %v before any fetch, want none", h.LastFetchAt)
+	}
+
+	fetched := time.Now()
+	w.lastFetch.Store(fetched.UnixNano())
+	w.draining.Store(true)
+	h = w.Health()
+	if h.Status != HealthDraining {
+		t.Errorf("status = %q while draining, want %q", h.Status, HealthDraining)
+	}
+	if h.LastFetchAt == nil || !h.LastFetchAt.Equal(fetched) {
+		t.Errorf("last fetch = %v, want %v", h.LastFetchAt, fetched)
+	}
+}
@@ -1,27 +1,69 @@
 package config
 
+import "time"
+
 // AppConfig holds the application configuration.
 type AppConfig struct {
-	PGConnection       string `env:"PG_CONNECTION_STRING" envRequired:"true"`
-	OpenAIKey          string `env:"OPENAI_API_KEY" envRequired:"true"`
-	LLMAPIKey          string `env:"LLM_API_KEY" envDefault:""`
-	LLMBaseURL         string `env:"LLM_BASE_URL" envDefault:"https://api.openai.com/v1"`
-	LLMModelChat       string `env:"LLM_MODEL_CHAT" envDefault:"gpt-4o-mini"`
-	LLMModelPromptGen  string `env:"LLM_MODEL_PROMPT_GEN" envDefault:"gpt-4o-mini"`
-	BaseURL            string `env:"BASE_URL" envRequired:"true"`
-	IsSSL              bool   `env:"IS_SSL" envDefault:"false"`
-	MigrationsPath     string `env:"MIGRATIONS_PATH" envRequired:"true"`
-	FrontendURL        string `env:"FRONTEND_URL" envRequired:"true"`
-	LightFrontendURL   string `env:"LIGHT_FRONTEND_URL" envDefault:"localhost:3100"`
-	KratosPublicURL    string `env:"KRATOS_PUBLIC_URL" envDefault:"http://kratos:4433"`
-	KratosAdminURL     string `env:"KRATOS_ADMIN_URL" envDefault:"http://kratos:4434"`
-	SessionCookieName  string `env:"SESSION_COOKIE_NAME" envDefault:"vectorauth_session"`
-	CrawlerAPIURL      string `env:"CRAWLER_API_URL" envDefault:"http://localhost:11235"`
-	MarkitdownURL      string `env:"MARKITDOWN_API_URL" envDefault:"http://localhost:8000"`
-	HydraAdminURL      string `env:"HYDRA_ADMIN_URL" envDefault:"http://hydra:4445"`
-	HydraPublicURL     string `env:"HYDRA_PUBLIC_URL" envDefault:"http://hydra:4444"`
-	NATSURL            string `env:"NATS_URL" envDefault:"nats://nats:4222"`
-	NATSUsername       string `env:"NATS_USERNAME" envDefault:""`
-	NATSPassword       string `env:"NATS_PASSWORD" envDefault:""`
-	CrawlWorkerEnabled bool   `env:"CRAWL_WORKER_ENABLED" envDefault:"true"`
+	PGConnection      string        `env:"PG_CONNECTION_STRING" envRequired:"true"`
+	OpenAIKey         string        `env:"OPENAI_API_KEY" envDefault:""`
+	LLMAPIKey         string        `env:"LLM_API_KEY" envDefault:""`
+	LLMBaseURL        string        `env:"LLM_BASE_URL" envDefault:"https://api.openai.com/v1"`
+	LLMModelChat      string        `env:"LLM_MODEL_CHAT" envDefault:"gpt-4o-mini"`
+	LLMModelPromptGen string        `env:"LLM_MODEL_PROMPT_GEN" envDefault:"gpt-4o-mini"`
+	EmbeddingBackend  string        `env:"EMBEDDING_BACKEND" envDefault:"openai"`
+	EmbeddingBaseURL  string        `env:"EMBEDDING_BASE_URL" envDefault:"https://api.openai.com/v1"`
+	EmbeddingAPIKey   string        `env:"EMBEDDING_API_KEY" envDefault:""`
+	EmbeddingModel    string        `env:"EMBEDDING_MODEL" envDefault:"text-embedding-3-small"`
+	EmbeddingDims     int           `env:"EMBEDDING_DIMENSIONS" envDefault:"1536"`
+	BaseURL           string        `env:"BASE_URL" envRequired:"true"`
+	IsSSL             bool          `env:"IS_SSL" envDefault:"false"`
+	MigrationsPath    string        `env:"MIGRATIONS_PATH" envRequired:"true"`
+	FrontendURL       string        `env:"FRONTEND_URL" envRequired:"true"`
+	LightFrontendURL  string        `env:"LIGHT_FRONTEND_URL" envDefault:"localhost:3100"`
+	KratosPublicURL   string        `env:"KRATOS_PUBLIC_URL" envDefault:"http://kratos:4433"`
+	KratosAdminURL    string        `env:"KRATOS_ADMIN_URL" envDefault:"http://kratos:4434"`
+	SessionCookieName string        `env:"SESSION_COOKIE_NAME" envDefault:"vectorauth_session"`
+	CrawlerAPIURL     string        `env:"CRAWLER_API_URL" envDefault:"http://localhost:11235"`
+	CrawlerUserAgent  string        `env:"CRAWLER_USER_AGENT" envDefault:"VectorChatBot/1.0 (+https://vectorchat.local)"`
+	CrawlerDelay      time.Duration `env:"CRAWLER_POLITENESS_DELAY" envDefault:"1s"`
+	// CrawlerBrowserPath enables rendering JavaScript pages with a local headless Chrome when crawl4ai is down
+	CrawlerBrowserPath string `env:"CRAWLER_BROWSER_PATH"`
+	// CrawlerCredentialsKey encrypts stored crawl credentials; without it authenticated crawling is disabled
+	CrawlerCredentialsKey string `env:"CRAWLER_CREDENTIALS_KEY" envDefault:""`
+	// CrawlerProbeInterval is how often an unreachable crawl4ai is checked again
+	CrawlerProbeInterval time.Duration `env:"CRAWLER_API_PROBE_INTERVAL" envDefault:"1m"`
+	MarkitdownURL        string        `env:"MARKITDOWN_API_URL" envDefault:"http://localhost:8000"`
+	HydraAdminURL        string        `env:"HYDRA_ADMIN_URL" envDefault:"http://hydra:4445"`
+	HydraPublicURL       string        `env:"HYDRA_PUBLIC_URL" envDefault:"http://hydra:4444"`
+	NATSURL              string        `env:"NATS_URL" envDefault:"nats://nats:4222"`
+	NATSUsername         string        `env:"NATS_USERNAME" envDefault:""`
+	NATSPassword         string        `env:"NATS_PASSWORD" envDefault:""`
+	CrawlWorkerEnabled   bool          `env:"CRAWL_WORKER_ENABLED" envDefault:"true"`
+	LLMPriceTable        string        `env:"LLM_PRICE_TABLE" envDefault:""`
+	RerankerBackend      string        `env:"RERANKER_BACKEND" envDefault:""`
+	RerankerModel        string        `env:"RERANKER_MODEL" envDefault:"gpt-4o-mini"`
+	RerankerURL          string        `env:"RERANKER_URL" envDefault:""`
+	WidgetSecret         string        `env:"WIDGET_SESSION_SECRET" envDefault:""`
+	WidgetIPLimit        int           `env:"WIDGET_IP_RATE_LIMIT" envDefault:"30"`
+	WidgetSessionLimit   int           `env:"WIDGET_SESSION_RATE_LIMIT" envDefault:"10"`
+	// QueueAdminEmails may inspect, replay and purge dead-lettered crawl jobs
+	QueueAdminEmails []string `env:"QUEUE_ADMIN_EMAILS"`
+	// CrawlWorkerConcurrency is how many crawl jobs a worker runs at once
+	CrawlWorkerConcurrency int `env:"CRAWL_WORKER_CONCURRENCY" envDefault:"2"`
+	// CrawlWorkerDrainTimeout is how long a stopping worker lets running crawls finish
+	CrawlWorkerDrainTimeout time.Duration `env:"CRAWL_WORKER_DRAIN_TIMEOUT" envDefault:"5m"`
+	// CrawlWorkerHealthAddr is where cmd/crawl-worker serves its health endpoint
+	CrawlWorkerHealthAddr string `env:"CRAWL_WORKER_HEALTH_ADDR" envDefault:":8081"`
+	// CrawlRunRetention is how long the crawl run history is kept; 0 keeps it forever
+	CrawlRunRetention time.Duration `env:"CRAWL_RUN_RETENTION" envDefault:"720h"`
+	// ActionSecretsKey encrypts the headers of chatbot actions; without it actions cannot send headers
+	ActionSecretsKey string `env:"ACTION_SECRETS_KEY" envDefault:""`
+	// TrustedProxies are the reverse proxy IPs or CIDRs whose ProxyHeader carries the client IP;
+	// when empty the connection address is used, so clients cannot spoof it
+	TrustedProxies []string `env:"TRUSTED_PROXIES"`
+	// ProxyHeader holds the client IP set by a trusted proxy; it must not be a header clients can append to
+	ProxyHeader string `env:"PROXY_HEADER" envDefault:"X-Real-IP"`
+	// EmbeddingAdditionalModels are served next to EMBEDDING_MODEL on the same backend so knowledge
+	// bases keep working while they are re-embedded from or to another model
+	EmbeddingAdditionalModels []string `env:"EMBEDDING_ADDITIONAL_MODELS"`
 }
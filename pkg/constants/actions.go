@@ -0,0 +1,25 @@
+package constants
+
+import "time"
+
+// Chatbot action (tool calling) limits
+const (
+	// MaxToolIterations bounds how many rounds of tool calls a single reply may make
+	MaxToolIterations = 3
+
+	// MaxChatbotActions is the number of actions a chatbot may register
+	MaxChatbotActions = 20
+
+	// ActionTimeout bounds each action HTTP call
+	ActionTimeout = 15 * time.Second
+
+	// MaxActionResponseBytes caps how much of an action response is passed back to the model
+	MaxActionResponseBytes = 16 * 1024
+)
+
+// Tool call statuses streamed to clients
+const (
+	ToolCallRunning   = "running"
+	ToolCallCompleted = "completed"
+	ToolCallFailed    = "failed"
+)
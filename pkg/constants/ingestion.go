@@ -0,0 +1,32 @@
+package constants
+
+import "time"
+
+// Ingestion job kinds
+const (
+	IngestionKindFile    = "file"
+	IngestionKindText    = "text"
+	IngestionKindWebsite = "website"
+)
+
+// Ingestion job states, in the order a job moves through them
+const (
+	IngestionQueued     = "queued"
+	IngestionConverting = "converting"
+	IngestionChunking   = "chunking"
+	IngestionEmbedding  = "embedding"
+	IngestionDone       = "done"
+	IngestionFailed     = "failed"
+)
+
+const (
+	// IngestionHeartbeat is how often a worker extends the ack deadline of a running job
+	IngestionHeartbeat = 15 * time.Second
+
+	// IngestionStaleAfter is how long a running job may go without a heartbeat before another
+	// worker or the startup sweep takes it over
+	IngestionStaleAfter = 2 * time.Minute
+
+	// IngestionPollInterval is how often the progress stream re-reads a job
+	IngestionPollInterval = time.Second
+)
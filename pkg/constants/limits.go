@@ -31,6 +31,12 @@ const (
 
 	// LimitAdvancedModels defines access to advanced AI models
 	LimitAdvancedModels = "access_to_advanced_models"
+
+	// LimitCrawlPages defines the maximum pages a single website crawl may fetch
+	LimitCrawlPages = "crawl_pages_per_website"
+
+	// LimitCrawlDepth defines the maximum link depth of a website crawl
+	LimitCrawlDepth = "crawl_depth"
 )
 
 // Default limit values for free tier
@@ -40,4 +46,14 @@ const (
 	DefaultTrainingData   = "400 KB"
 	DefaultMessageCredits = 100
 	DefaultInactivityDays = 14
+	DefaultCrawlPages     = 25
+	DefaultCrawlDepth     = 2
+)
+
+// Hard ceilings for crawl options regardless of plan
+const (
+	MaxCrawlPages       = 1000
+	MaxCrawlDepth       = 10
+	MaxCrawlURLPatterns = 20
+	MaxCrawlAuthEntries = 20 // headers or cookies per crawl source
 )
@@ -0,0 +1,48 @@
+package constants
+
+// Retrieval modes used when building the RAG context for a chatbot
+const (
+	// RetrievalModeVector ranks chunks by embedding distance only
+	RetrievalModeVector = "vector"
+
+	// RetrievalModeHybrid fuses full-text and vector rankings with reciprocal rank fusion
+	RetrievalModeHybrid = "hybrid"
+)
+
+// Retrieval configuration defaults, matching the values chatbots were built with before they became tunable
+const (
+	DefaultRetrievalTopK           = 5
+	DefaultRetrievalMinSimilarity  = 0.0
+	DefaultRevisionMatchThreshold  = 0.95
+	DefaultRevisionInjectThreshold = 0.85
+	DefaultHistoryLimit            = 20
+	DefaultContextTokenBudget      = 0 // 0 disables the budget
+	MaxRetrievalTopK               = 50
+	MaxHistoryLimit                = 200
+)
+
+// RerankCandidateLimit is how many candidates are fetched per source before reranking down to top-k
+const RerankCandidateLimit = 30
+
+// Reranker backends selectable via RERANKER_BACKEND
+const (
+	RerankerBackendLLM  = "llm"
+	RerankerBackendHTTP = "http"
+)
+
+// Embedding backends selectable via EMBEDDING_BACKEND
+const (
+	// EmbeddingBackendOpenAI speaks the OpenAI /embeddings API, also served by LiteLLM, vLLM and others
+	EmbeddingBackendOpenAI = "openai"
+	// EmbeddingBackendOllama uses Ollama's /api/embed
+	EmbeddingBackendOllama = "ollama"
+	// EmbeddingBackendTEI uses Hugging Face text-embeddings-inference's /embed
+	EmbeddingBackendTEI = "tei"
+)
+
+// Embedding migration states
+const (
+	EmbeddingMigrationEmbedding = "embedding"
+	EmbeddingMigrationDone      = "done"
+	EmbeddingMigrationFailed    = "failed"
+)
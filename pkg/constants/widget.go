@@ -0,0 +1,18 @@
+package constants
+
+import "time"
+
+// Public widget chat API settings
+const (
+	// WidgetSessionTTL is how long an anonymous widget session token stays valid
+	WidgetSessionTTL = 24 * time.Hour
+
+	// WidgetSessionHeader carries the session token on public chat requests
+	WidgetSessionHeader = "X-Widget-Session"
+
+	// DefaultEmbedOrigins is how many allowed origins a chatbot may have without embed_on_unlimited_websites
+	DefaultEmbedOrigins = 1
+
+	// MaxAllowedOrigins caps the allow-list size even on unlimited plans
+	MaxAllowedOrigins = 50
+)
@@ -3,6 +3,7 @@ package docprocessor
 import (
 	"fmt"
 	"math"
+	"strconv"
 	"strings"
 	"time"
 
@@ -69,14 +70,56 @@ func (p *Processor) ChunkMarkdownWithOptions(markdown string, opts ChunkOptions)
 
 // WrapMarkdownWithMetadata wraps markdown chunks with metadata for vector storage
 func (p *Processor) WrapMarkdownWithMetadata(markdown string, docID string, source string, fileID uuid.UUID, createdAt time.Time) []string {
+	return p.wrapWithHeader(markdown, chunkHeaderFields{
+		docID:   docID,
+		fileID:  fileID.String(),
+		source:  sanitizeMetadataValue(source),
+		created: createdAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// WrapPageWithMetadata wraps a crawled web page like WrapMarkdownWithMetadata.
+// source names the website source the page belongs to; the page URL and title are added to every header.
+func (p *Processor) WrapPageWithMetadata(markdown string, docID string, source string, pageURL string, title string, fileID uuid.UUID, fetchedAt time.Time) []string {
+	return p.wrapWithHeader(markdown, chunkHeaderFields{
+		docID:   docID,
+		fileID:  fileID.String(),
+		source:  sanitizeMetadataValue(source),
+		url:     sanitizeMetadataValue(pageURL),
+		title:   sanitizeMetadataValue(title),
+		created: fetchedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// chunkHeaderFields holds the sanitized front matter values shared by every chunk of a document
+type chunkHeaderFields struct {
+	docID   string
+	fileID  string
+	source  string
+	url     string
+	title   string
+	created string
+}
+
+func (h chunkHeaderFields) frontMatter(section string, chunkIndex int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "---\ndoc_id: %s\nfile_id: %s\nsource: \"%s\"\n", h.docID, h.fileID, h.source)
+	if h.url != "" {
+		fmt.Fprintf(&b, "url: \"%s\"\n", h.url)
+	}
+	if h.title != "" {
+		fmt.Fprintf(&b, "title: \"%s\"\n", h.title)
+	}
+	fmt.Fprintf(&b, "section: \"%s\"\nchunk_index: %d\ncreated_at: %s\n---\n\n", section, chunkIndex, h.created)
+	return b.String()
+}
+
+func (p *Processor) wrapWithHeader(markdown string, header chunkHeaderFields) []string {
 	rawChunks := p.chunkMarkdownInternal(markdown, DefaultChunkOptions())
 	if len(rawChunks) == 0 {
 		return nil
 	}
 
-	sanitizedSource := sanitizeMetadataValue(source)
-	fileIdentifier := fileID.String()
-	created := createdAt.UTC().Format(time.RFC3339)
 	wrapped := make([]string, 0, len(rawChunks))
 
 	chunkCounter := 0
@@ -87,7 +130,7 @@ func (p *Processor) WrapMarkdownWithMetadata(markdown string, docID string, sour
 			section = "Document"
 		}
 
-		metadataEstimate := estimateMetadataTokens(docID, fileIdentifier, sanitizedSource, section, created)
+		metadataEstimate := estimateMetadataTokens(header, section)
 		queue := []string{chunk.Text}
 
 		for len(queue) > 0 {
@@ -107,18 +150,66 @@ func (p *Processor) WrapMarkdownWithMetadata(markdown string, docID string, sour
 				continue
 			}
 
-			frontMatter := fmt.Sprintf("---\ndoc_id: %s\nfile_id: %s\nsource: \"%s\"\nsection: \"%s\"\nchunk_index: %d\ncreated_at: %s\n---\n\n",
-				docID, fileIdentifier, sanitizedSource, section, chunkCounter, created)
-			wrapped = append(wrapped, frontMatter+part)
+			wrapped = append(wrapped, header.frontMatter(section, chunkCounter)+part)
 			chunkCounter++
 		}
 	}
 	return wrapped
 }
 
-func estimateMetadataTokens(docID, fileID, source, section, created string) int {
-	frontMatter := fmt.Sprintf("---\ndoc_id: %s\nfile_id: %s\nsource: \"%s\"\nsection: \"%s\"\nchunk_index: 0\ncreated_at: %s\n---\n\n",
-		docID, fileID, source, section, created)
+// ChunkHeader is the front matter written by WrapMarkdownWithMetadata and WrapPageWithMetadata
+type ChunkHeader struct {
+	DocID      string
+	FileID     string
+	Source     string
+	URL        string
+	Title      string
+	Section    string
+	ChunkIndex int
+}
+
+// ParseChunkHeader reads the front matter header of a wrapped chunk.
+// It returns false when the chunk does not start with a metadata header.
+func ParseChunkHeader(chunk string) (ChunkHeader, bool) {
+	var meta ChunkHeader
+	if !strings.HasPrefix(chunk, "---\n") {
+		return meta, false
+	}
+	end := strings.Index(chunk[4:], "\n---\n")
+	if end < 0 {
+		return meta, false
+	}
+
+	for _, line := range strings.Split(chunk[4:4+end], "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), "\"")
+		switch strings.TrimSpace(key) {
+		case "doc_id":
+			meta.DocID = value
+		case "file_id":
+			meta.FileID = value
+		case "source":
+			meta.Source = value
+		case "url":
+			meta.URL = value
+		case "title":
+			meta.Title = value
+		case "section":
+			meta.Section = value
+		case "chunk_index":
+			if idx, err := strconv.Atoi(value); err == nil {
+				meta.ChunkIndex = idx
+			}
+		}
+	}
+	return meta, true
+}
+
+func estimateMetadataTokens(header chunkHeaderFields, section string) int {
+	frontMatter := header.frontMatter(section, 0)
 	return int(math.Ceil(float64(len(frontMatter)) / float64(defaultCharsPerTokenEst)))
 }
 
@@ -62,32 +62,16 @@ func NewProcessor(markitdown *MarkitdownClient) *Processor {
 	}
 }
 
+// MaxFileBytes is the largest file accepted for processing
+const MaxFileBytes = 10 * 1024 * 1024 // 10 MB
+
 // ProcessFile processes an uploaded file by converting to markdown and chunking
 func (p *Processor) ProcessFile(ctx context.Context, fileHeader *multipart.FileHeader) (*ProcessedFile, error) {
 	// Validate file size
-	const maxFileBytes = 10 * 1024 * 1024 // 10 MB
-	if fileHeader.Size > maxFileBytes {
+	if fileHeader.Size > MaxFileBytes {
 		return nil, apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "file exceeds maximum size (10MB)")
 	}
 
-	filename := filepath.Base(fileHeader.Filename)
-	if filename == "" {
-		return nil, apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "file name is required")
-	}
-
-	ext := strings.ToLower(filepath.Ext(filename))
-	if ext == "" {
-		return nil, apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "file extension is required")
-	}
-
-	// Check if extension is supported
-	if err := p.ensureSupportedExtensions(ctx); err != nil {
-		return nil, apperrors.Wrap(err, "failed to load supported file types")
-	}
-	if !p.isExtensionSupported(ext) {
-		return nil, apperrors.Wrapf(apperrors.ErrInvalidChatbotParameters, "unsupported file type: %s", ext)
-	}
-
 	// Read file content
 	src, err := fileHeader.Open()
 	if err != nil {
@@ -96,13 +80,28 @@ func (p *Processor) ProcessFile(ctx context.Context, fileHeader *multipart.FileH
 	defer src.Close()
 
 	var buf bytes.Buffer
-	hasher := sha256.New()
-	if _, err := io.Copy(io.MultiWriter(&buf, hasher), src); err != nil {
+	if _, err := io.Copy(&buf, src); err != nil {
 		return nil, apperrors.Wrap(err, "failed to read file")
 	}
 
+	return p.ProcessFileData(ctx, fileHeader.Filename, buf.Bytes())
+}
+
+// ProcessFileData converts already-read file content to markdown and chunks it
+func (p *Processor) ProcessFileData(ctx context.Context, name string, data []byte) (*ProcessedFile, error) {
+	if len(data) > MaxFileBytes {
+		return nil, apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "file exceeds maximum size (10MB)")
+	}
+	if err := p.ValidateFilename(ctx, name); err != nil {
+		return nil, err
+	}
+	filename := filepath.Base(name)
+
+	hasher := sha256.New()
+	hasher.Write(data)
+
 	// Convert to markdown
-	markdown, err := p.convertFileToMarkdown(ctx, filename, buf.Bytes())
+	markdown, err := p.convertFileToMarkdown(ctx, filename, data)
 	if err != nil {
 		return nil, apperrors.Wrap(err, "failed to convert file to markdown")
 	}
@@ -116,7 +115,7 @@ func (p *Processor) ProcessFile(ctx context.Context, fileHeader *multipart.FileH
 	return &ProcessedFile{
 		ID:           uuid.New(),
 		Filename:     filename,
-		OriginalSize: fileHeader.Size,
+		OriginalSize: int64(len(data)),
 		Hash:         hex.EncodeToString(hasher.Sum(nil)),
 		Markdown:     markdown,
 		Chunks:       chunks,
@@ -124,6 +123,28 @@ func (p *Processor) ProcessFile(ctx context.Context, fileHeader *multipart.FileH
 	}, nil
 }
 
+// ValidateFilename checks that a file name has an extension the converter supports
+func (p *Processor) ValidateFilename(ctx context.Context, name string) error {
+	filename := filepath.Base(name)
+	if filename == "" || filename == "." {
+		return apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "file name is required")
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext == "" {
+		return apperrors.Wrap(apperrors.ErrInvalidChatbotParameters, "file extension is required")
+	}
+
+	// Check if extension is supported
+	if err := p.ensureSupportedExtensions(ctx); err != nil {
+		return apperrors.Wrap(err, "failed to load supported file types")
+	}
+	if !p.isExtensionSupported(ext) {
+		return apperrors.Wrapf(apperrors.ErrInvalidChatbotParameters, "unsupported file type: %s", ext)
+	}
+	return nil
+}
+
 // ProcessText processes plain text by chunking it
 func (p *Processor) ProcessText(text string) (*ProcessedFile, error) {
 	if strings.TrimSpace(text) == "" {
@@ -111,6 +111,71 @@ func TestWrapMarkdownWithMetadata(t *testing.T) {
 	}
 }
 
+func TestParseChunkHeader(t *testing.T) {
+	processor := &Processor{}
+
+	fileID := uuid.New()
+	source := "https://example.com/docs/install"
+	wrapped := processor.WrapMarkdownWithMetadata("# Install\nRun the installer.", "doc-1", source, fileID, time.Now())
+	if len(wrapped) == 0 {
+		t.Fatal("Expected wrapped chunks to be created, got none")
+	}
+
+	meta, ok := ParseChunkHeader(wrapped[0])
+	if !ok {
+		t.Fatal("Expected metadata header to be parsed")
+	}
+	if meta.DocID != "doc-1" {
+		t.Errorf("Expected doc_id doc-1, got %q", meta.DocID)
+	}
+	if meta.FileID != fileID.String() {
+		t.Errorf("Expected file_id %s, got %q", fileID, meta.FileID)
+	}
+	if meta.Source != source {
+		t.Errorf("Expected source %s, got %q", source, meta.Source)
+	}
+	if meta.ChunkIndex != 0 {
+		t.Errorf("Expected chunk_index 0, got %d", meta.ChunkIndex)
+	}
+
+	if _, ok := ParseChunkHeader("plain chunk without header"); ok {
+		t.Error("Expected plain chunk to report no metadata")
+	}
+}
+
+func TestWrapPageWithMetadata(t *testing.T) {
+	processor := &Processor{}
+
+	fileID := uuid.New()
+	pageURL := "https://example.com/docs/install"
+	wrapped := processor.WrapPageWithMetadata("## Steps\nRun the installer.", "page-1", "website-example.com-20240101-120000", pageURL, "Install \"Guide\"", fileID, time.Now())
+	if len(wrapped) == 0 {
+		t.Fatal("Expected wrapped chunks to be created, got none")
+	}
+
+	meta, ok := ParseChunkHeader(wrapped[0])
+	if !ok {
+		t.Fatal("Expected metadata header to be parsed")
+	}
+	if meta.URL != pageURL {
+		t.Errorf("Expected url %s, got %q", pageURL, meta.URL)
+	}
+	if meta.Title != "Install 'Guide'" {
+		t.Errorf("Expected sanitized title, got %q", meta.Title)
+	}
+	if meta.Source != "website-example.com-20240101-120000" {
+		t.Errorf("Expected website source, got %q", meta.Source)
+	}
+	if meta.Section != "Steps" {
+		t.Errorf("Expected section Steps, got %q", meta.Section)
+	}
+
+	plain := processor.WrapMarkdownWithMetadata("Some text.", "doc-1", "notes.md", fileID, time.Now())
+	if strings.Contains(plain[0], "url:") || strings.Contains(plain[0], "title:") {
+		t.Error("File chunks should not carry page metadata")
+	}
+}
+
 func TestWrapMarkdownWithMetadataSplitsLargeBlocks(t *testing.T) {
 	processor := &Processor{}
 
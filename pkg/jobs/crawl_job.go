@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/yourusername/vectorchat/pkg/models"
 )
 
 const (
@@ -13,8 +14,44 @@ const (
 	CrawlDLQSubject = "crawl.schedule.dlq"
 	// CrawlStream is the JetStream stream name backing crawl jobs.
 	CrawlStream = "CrawlJobs"
+	// CrawlDLQStream is the JetStream stream name holding dead-lettered crawl jobs.
+	CrawlDLQStream = "CrawlJobsDLQ"
+	// CrawlConsumer is the durable consumer shared by crawl workers.
+	CrawlConsumer = "crawler-workers"
+	// CrawlAttemptsBucket is the key-value bucket recording failed attempts of queued crawl jobs.
+	CrawlAttemptsBucket = "crawl_attempts"
+	// CrawlInFlightBucket is the key-value bucket claiming each website with a queued or running crawl job.
+	CrawlInFlightBucket = "crawl_in_flight"
+	// CrawlInFlightTTL bounds how long a claim outlives a worker that died without releasing it.
+	CrawlInFlightTTL = 2 * time.Hour
+	// CrawlDedupeWindow groups requests into the windows that crawl message IDs are keyed on;
+	// the crawl stream drops a message whose ID it has seen within twice this window.
+	CrawlDedupeWindow = time.Minute
+	// CrawlMaxDeliver caps how often a crawl job is attempted before it is dead-lettered.
+	CrawlMaxDeliver = 5
+	// CrawlHeartbeat is how often a worker extends the ack deadline and website claim of a running crawl;
+	// it must stay below the shortest CrawlBackOff entry, which JetStream uses as the ack wait.
+	CrawlHeartbeat = 15 * time.Second
+	// CrawlRunStaleAfter is how long a running crawl may go without a heartbeat before its run is
+	// closed as abandoned; it spans many heartbeats so a missed write does not close a live run.
+	CrawlRunStaleAfter = 5 * time.Minute
 )
 
+// CrawlBackOff is the delay before each redelivery of a failed crawl job; the last entry repeats.
+// JetStream requires it to be shorter than CrawlMaxDeliver.
+var CrawlBackOff = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute, 30 * time.Minute}
+
+// CrawlRetryDelay returns the delay before the redelivery following the given attempt (1-based).
+func CrawlRetryDelay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	if attempt > len(CrawlBackOff) {
+		attempt = len(CrawlBackOff)
+	}
+	return CrawlBackOff[attempt-1]
+}
+
 // CrawlJobPayload defines the message enqueued by the scheduler and consumed by workers.
 type CrawlJobPayload struct {
 	JobID                  uuid.UUID  `json:"job_id"`
@@ -24,4 +61,67 @@ type CrawlJobPayload struct {
 	TraceID                string     `json:"trace_id,omitempty"`
 	ChatbotID              *uuid.UUID `json:"chatbot_id,omitempty"`
 	SharedKnowledgeBaseID  *uuid.UUID `json:"shared_knowledge_base_id,omitempty"`
+	// Options are the crawl options of the schedule or request; nil uses the defaults
+	Options *models.CrawlOptions `json:"options,omitempty"`
+	// CredentialsID references the sealed crawl credentials of the source; workers decrypt them
+	CredentialsID *uuid.UUID `json:"credentials_id,omitempty"`
+	// Trigger records what enqueued the job, CrawlTriggerCron or CrawlTriggerManual
+	Trigger string `json:"trigger,omitempty"`
+}
+
+// Crawl job triggers
+const (
+	CrawlTriggerCron   = "cron"
+	CrawlTriggerManual = "manual"
+)
+
+// RunTrigger returns the trigger of the job. Jobs enqueued before triggers were recorded count as
+// cron runs when they belong to a schedule.
+func (p CrawlJobPayload) RunTrigger() string {
+	if p.Trigger != "" {
+		return p.Trigger
+	}
+	if p.ScheduleID != uuid.Nil {
+		return CrawlTriggerCron
+	}
+	return CrawlTriggerManual
+}
+
+// Crawl run states, as recorded in the run history
+const (
+	CrawlRunRunning   = "running"
+	CrawlRunCompleted = "completed"
+	// CrawlRunRetrying marks a failed attempt that will be redelivered
+	CrawlRunRetrying = "retrying"
+	CrawlRunFailed   = "failed"
+	// CrawlRunInterrupted marks an attempt handed back to the queue by a worker shutting down
+	CrawlRunInterrupted = "interrupted"
+	// CrawlRunAbandoned marks an attempt whose worker stopped reporting, e.g. because it crashed
+	CrawlRunAbandoned = "abandoned"
+)
+
+// CrawlAttempt records one failed delivery of a crawl job.
+type CrawlAttempt struct {
+	Attempt  int       `json:"attempt"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// CrawlDeadLetter is published to CrawlDLQSubject when a crawl job fails permanently or runs
+// out of attempts. Replaying it re-enqueues Job unchanged.
+type CrawlDeadLetter struct {
+	// Sequence is the message's position in the DLQ stream; it is set when dead letters are read
+	Sequence       uint64          `json:"sequence,omitempty"`
+	Job            CrawlJobPayload `json:"job"`
+	Error          string          `json:"error"`
+	Permanent      bool            `json:"permanent"`
+	Attempts       []CrawlAttempt  `json:"attempts"`
+	DeadLetteredAt time.Time       `json:"dead_lettered_at"`
+}
+
+// CrawlDeadLetterList is a page of the DLQ, oldest first. NextAfter, when set, continues the listing.
+type CrawlDeadLetterList struct {
+	Total     uint64            `json:"total"`
+	Items     []CrawlDeadLetter `json:"items"`
+	NextAfter uint64            `json:"next_after,omitempty"`
 }
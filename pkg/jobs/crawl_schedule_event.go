@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// CrawlScheduleEventSubject is the JetStream subject announcing changed crawl schedules.
+	CrawlScheduleEventSubject = "crawl.schedule.changed"
+	// CrawlScheduleEventStream is the JetStream stream name backing schedule change events.
+	CrawlScheduleEventStream = "CrawlScheduleEvents"
+)
+
+// Schedule change actions
+const (
+	CrawlScheduleUpserted = "upserted"
+	CrawlScheduleDeleted  = "deleted"
+)
+
+// CrawlScheduleEvent tells schedulers to reload one schedule instead of waiting for their next resync.
+// It only names the schedule; schedulers read the current row from the database.
+type CrawlScheduleEvent struct {
+	ScheduleID uuid.UUID `json:"schedule_id"`
+	Action     string    `json:"action"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
@@ -0,0 +1,20 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// IngestionSubject is the JetStream subject used for knowledge base ingestion jobs.
+	IngestionSubject = "ingestion.requested"
+	// IngestionStream is the JetStream stream name backing ingestion jobs.
+	IngestionStream = "IngestionJobs"
+)
+
+// IngestionJobPayload references an ingestion_jobs row; the uploaded content stays in the database.
+type IngestionJobPayload struct {
+	JobID       uuid.UUID `json:"job_id"`
+	RequestedAt time.Time `json:"requested_at"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChatbotActionRequest defines the payload for creating or updating a chatbot action.
+// Header values are write-only; omit Headers on update to keep the stored ones.
+type ChatbotActionRequest struct {
+	Name        string            `json:"name" example:"lookup_order"`
+	Description string            `json:"description" example:"Look up an order by its number"`
+	Parameters  json.RawMessage   `json:"parameters" swaggertype:"object"`
+	Method      string            `json:"method" example:"GET"`
+	URL         string            `json:"url" example:"https://api.example.com/orders/{order_id}"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Enabled     *bool             `json:"enabled,omitempty" example:"true"`
+}
+
+// ChatbotActionResponse represents a saved action. Header values are never returned.
+type ChatbotActionResponse struct {
+	ID          uuid.UUID       `json:"id"`
+	ChatbotID   uuid.UUID       `json:"chatbot_id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters" swaggertype:"object"`
+	Method      string          `json:"method"`
+	URL         string          `json:"url"`
+	HeaderNames []string        `json:"header_names"`
+	Enabled     bool            `json:"enabled"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// ChatbotActionListResponse wraps multiple actions.
+type ChatbotActionListResponse struct {
+	Actions []ChatbotActionResponse `json:"actions"`
+}
+
+// ToolCallStatus reports progress of an action the model called while answering
+type ToolCallStatus struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status" example:"running"`
+	Error  string `json:"error,omitempty"`
+}
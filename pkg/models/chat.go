@@ -7,47 +7,77 @@ import (
 )
 
 type ChatbotCreateRequest struct {
-	Name                   string      `json:"name" binding:"required" example:"Customer Support Bot"`
-	Description            string      `json:"description" binding:"required" example:"AI assistant for customer support"`
-	SystemInstructions     string      `json:"system_instructions" binding:"required" example:"You are a helpful customer support assistant"`
-	ModelName              string      `json:"model_name" binding:"required" example:"gpt-3.5-turbo"`
-	TemperatureParam       float64     `json:"temperature_param" binding:"required,min=0,max=2" example:"0.7"`
-	MaxTokens              int         `json:"max_tokens" binding:"required,min=1" example:"1000"`
-	SaveMessages           *bool       `json:"save_messages,omitempty" example:"true"`
-	UseMaxTokens           *bool       `json:"use_max_tokens,omitempty" example:"true"`
-	IsEnabled              *bool       `json:"is_enabled,omitempty" example:"false"`
-	SharedKnowledgeBaseIDs []uuid.UUID `json:"shared_knowledge_base_ids,omitempty"`
+	Name                   string                  `json:"name" binding:"required" example:"Customer Support Bot"`
+	Description            string                  `json:"description" binding:"required" example:"AI assistant for customer support"`
+	SystemInstructions     string                  `json:"system_instructions" binding:"required" example:"You are a helpful customer support assistant"`
+	ModelName              string                  `json:"model_name" binding:"required" example:"gpt-3.5-turbo"`
+	TemperatureParam       float64                 `json:"temperature_param" binding:"required,min=0,max=2" example:"0.7"`
+	MaxTokens              int                     `json:"max_tokens" binding:"required,min=1" example:"1000"`
+	SaveMessages           *bool                   `json:"save_messages,omitempty" example:"true"`
+	UseMaxTokens           *bool                   `json:"use_max_tokens,omitempty" example:"true"`
+	IsEnabled              *bool                   `json:"is_enabled,omitempty" example:"false"`
+	RetrievalMode          *string                 `json:"retrieval_mode,omitempty" example:"hybrid"`
+	RetrievalConfig        *RetrievalConfigRequest `json:"retrieval_config,omitempty"`
+	SharedKnowledgeBaseIDs []uuid.UUID             `json:"shared_knowledge_base_ids,omitempty"`
 }
 
 type ChatbotUpdateRequest struct {
-	Name                   *string     `json:"name,omitempty" example:"Updated Bot Name"`
-	Description            *string     `json:"description,omitempty" example:"Updated description"`
-	SystemInstructions     *string     `json:"system_instructions,omitempty" example:"Updated system instructions"`
-	ModelName              *string     `json:"model_name,omitempty" example:"gpt-4"`
-	TemperatureParam       *float64    `json:"temperature_param,omitempty" example:"0.8"`
-	MaxTokens              *int        `json:"max_tokens,omitempty" example:"1500"`
-	SaveMessages           *bool       `json:"save_messages,omitempty" example:"true"`
-	UseMaxTokens           *bool       `json:"use_max_tokens,omitempty" example:"true"`
-	SharedKnowledgeBaseIDs []uuid.UUID `json:"shared_knowledge_base_ids,omitempty"`
+	Name                   *string                 `json:"name,omitempty" example:"Updated Bot Name"`
+	Description            *string                 `json:"description,omitempty" example:"Updated description"`
+	SystemInstructions     *string                 `json:"system_instructions,omitempty" example:"Updated system instructions"`
+	ModelName              *string                 `json:"model_name,omitempty" example:"gpt-4"`
+	TemperatureParam       *float64                `json:"temperature_param,omitempty" example:"0.8"`
+	MaxTokens              *int                    `json:"max_tokens,omitempty" example:"1500"`
+	SaveMessages           *bool                   `json:"save_messages,omitempty" example:"true"`
+	UseMaxTokens           *bool                   `json:"use_max_tokens,omitempty" example:"true"`
+	RetrievalMode          *string                 `json:"retrieval_mode,omitempty" example:"hybrid"`
+	RetrievalConfig        *RetrievalConfigRequest `json:"retrieval_config,omitempty"`
+	SharedKnowledgeBaseIDs []uuid.UUID             `json:"shared_knowledge_base_ids,omitempty"`
 }
 
 type ChatbotResponse struct {
-	ID                     uuid.UUID   `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	UserID                 string      `json:"user_id" example:"user_123"`
-	OrganizationID         *uuid.UUID  `json:"organization_id,omitempty" example:"3f5f5f4e-1234-5678-a9ab-0123456789ab"`
-	Name                   string      `json:"name" example:"Customer Support Bot"`
-	Description            string      `json:"description" example:"AI assistant for customer support"`
-	SystemInstructions     string      `json:"system_instructions" example:"You are a helpful customer support assistant"`
-	ModelName              string      `json:"model_name" example:"gpt-3.5-turbo"`
-	TemperatureParam       float64     `json:"temperature_param" example:"0.7"`
-	MaxTokens              int         `json:"max_tokens" example:"1000"`
-	SaveMessages           bool        `json:"save_messages" example:"true"`
-	UseMaxTokens           bool        `json:"use_max_tokens" example:"true"`
-	IsEnabled              bool        `json:"is_enabled" example:"true"`
-	CreatedAt              time.Time   `json:"created_at" example:"2023-01-01T00:00:00Z"`
-	UpdatedAt              time.Time   `json:"updated_at" example:"2023-01-01T00:00:00Z"`
-	AIMessagesAmount       int64       `json:"ai_messages_amount" example:"42"`
-	SharedKnowledgeBaseIDs []uuid.UUID `json:"shared_knowledge_base_ids"`
+	ID                     uuid.UUID               `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	UserID                 string                  `json:"user_id" example:"user_123"`
+	OrganizationID         *uuid.UUID              `json:"organization_id,omitempty" example:"3f5f5f4e-1234-5678-a9ab-0123456789ab"`
+	Name                   string                  `json:"name" example:"Customer Support Bot"`
+	Description            string                  `json:"description" example:"AI assistant for customer support"`
+	SystemInstructions     string                  `json:"system_instructions" example:"You are a helpful customer support assistant"`
+	ModelName              string                  `json:"model_name" example:"gpt-3.5-turbo"`
+	TemperatureParam       float64                 `json:"temperature_param" example:"0.7"`
+	MaxTokens              int                     `json:"max_tokens" example:"1000"`
+	SaveMessages           bool                    `json:"save_messages" example:"true"`
+	UseMaxTokens           bool                    `json:"use_max_tokens" example:"true"`
+	IsEnabled              bool                    `json:"is_enabled" example:"true"`
+	RetrievalMode          string                  `json:"retrieval_mode" example:"vector"`
+	RetrievalConfig        RetrievalConfigResponse `json:"retrieval_config"`
+	AllowedOrigins         []string                `json:"allowed_origins" example:"https://www.example.com"`
+	CreatedAt              time.Time               `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	UpdatedAt              time.Time               `json:"updated_at" example:"2023-01-01T00:00:00Z"`
+	AIMessagesAmount       int64                   `json:"ai_messages_amount" example:"42"`
+	SharedKnowledgeBaseIDs []uuid.UUID             `json:"shared_knowledge_base_ids"`
+}
+
+// RetrievalConfigRequest tunes how much context a chatbot retrieves; omitted fields keep their current value
+type RetrievalConfigRequest struct {
+	TopK                    *int     `json:"top_k,omitempty" example:"8"`
+	MinSimilarity           *float64 `json:"min_similarity,omitempty" example:"0.3"`
+	RevisionMatchThreshold  *float64 `json:"revision_match_threshold,omitempty" example:"0.95"`
+	RevisionInjectThreshold *float64 `json:"revision_inject_threshold,omitempty" example:"0.85"`
+	HistoryLimit            *int     `json:"history_limit,omitempty" example:"20"`
+	ContextTokenBudget      *int     `json:"context_token_budget,omitempty" example:"4000"`
+	RerankEnabled           *bool    `json:"rerank_enabled,omitempty" example:"true"`
+	CondenseQuery           *bool    `json:"condense_query,omitempty" example:"true"`
+}
+
+type RetrievalConfigResponse struct {
+	TopK                    int     `json:"top_k" example:"5"`
+	MinSimilarity           float64 `json:"min_similarity" example:"0"`
+	RevisionMatchThreshold  float64 `json:"revision_match_threshold" example:"0.95"`
+	RevisionInjectThreshold float64 `json:"revision_inject_threshold" example:"0.85"`
+	HistoryLimit            int     `json:"history_limit" example:"20"`
+	ContextTokenBudget      int     `json:"context_token_budget" example:"0"`
+	RerankEnabled           bool    `json:"rerank_enabled" example:"false"`
+	CondenseQuery           bool    `json:"condense_query" example:"false"`
 }
 
 type ChatbotsListResponse struct {
@@ -143,11 +173,22 @@ type ConversationsResponse struct {
 
 // MessageDetails represents individual message details in a conversation
 type MessageDetails struct {
-	ID        uuid.UUID `json:"id" example:"990e8400-e29b-41d4-a716-446655440004"`
-	ChatbotID uuid.UUID `json:"chatbot_id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	Role      string    `json:"role" example:"user"`
-	Content   string    `json:"content" example:"Hello, how can you help me?"`
-	CreatedAt time.Time `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	ID        uuid.UUID        `json:"id" example:"990e8400-e29b-41d4-a716-446655440004"`
+	ChatbotID uuid.UUID        `json:"chatbot_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Role      string           `json:"role" example:"user"`
+	Content   string           `json:"content" example:"Hello, how can you help me?"`
+	Sources   []SourceCitation `json:"sources,omitempty"`
+	CreatedAt time.Time        `json:"created_at" example:"2023-01-01T00:00:00Z"`
+}
+
+// SourceCitation identifies a knowledge chunk that was used to answer a message
+type SourceCitation struct {
+	DocumentID string     `json:"document_id" example:"chatbot-550e8400-web-0-1"`
+	FileID     *uuid.UUID `json:"file_id,omitempty" example:"770e8400-e29b-41d4-a716-446655440002"`
+	FileName   string     `json:"file_name,omitempty" example:"handbook.pdf"`
+	SourceURL  string     `json:"source_url,omitempty" example:"https://example.com/docs/install"`
+	ChunkIndex *int       `json:"chunk_index,omitempty" example:"3"`
+	Similarity float64    `json:"similarity" example:"0.82"`
 }
 
 // ConversationsListResponse represents a list of conversations
@@ -169,6 +210,35 @@ type ChatResponse struct {
 	Context string `json:"context,omitempty" example:"Previous conversation context"`
 }
 
+// ChatMessageResponse is returned by the non-streaming chat endpoint
+type ChatMessageResponse struct {
+	Response  string           `json:"response" example:"You can reset your password from the account page."`
+	SessionID string           `json:"session_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Sources   []SourceCitation `json:"sources"`
+}
+
+// MessageCreditUsageResponse reports message credit consumption for the current billing period
+type MessageCreditUsageResponse struct {
+	Scope       string    `json:"scope" example:"personal"`
+	Limit       int64     `json:"limit" example:"2000"`
+	Used        int64     `json:"used" example:"312"`
+	Remaining   int64     `json:"remaining" example:"1688"`
+	PeriodStart time.Time `json:"period_start" example:"2025-01-01T00:00:00Z"`
+	PeriodEnd   time.Time `json:"period_end" example:"2025-02-01T00:00:00Z"`
+}
+
+// AllowedOriginsRequest sets the websites allowed to use a chatbot's public widget API
+type AllowedOriginsRequest struct {
+	AllowedOrigins []string `json:"allowed_origins" example:"https://www.example.com,https://*.example.org"`
+}
+
+// WidgetSessionResponse carries an anonymous session token for the public widget API
+type WidgetSessionResponse struct {
+	Token     string    `json:"token"`
+	SessionID string    `json:"session_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ExpiresAt time.Time `json:"expires_at" example:"2025-01-02T00:00:00Z"`
+}
+
 // TextUploadRequest represents a plain text payload to index for a chatbot
 type TextUploadRequest struct {
 	Text string `json:"text" binding:"required" example:"Paste your knowledge base text here."`
@@ -176,7 +246,9 @@ type TextUploadRequest struct {
 
 // WebsiteUploadRequest represents a request to index a website starting at a URL
 type WebsiteUploadRequest struct {
-	URL string `json:"url" binding:"required" example:"https://docs.example.com"`
+	URL     string        `json:"url" binding:"required" example:"https://docs.example.com"`
+	Options *CrawlOptions `json:"options,omitempty"`
+	Auth    *CrawlAuth    `json:"auth,omitempty"`
 }
 
 type FileInfo struct {
@@ -186,15 +258,6 @@ type FileInfo struct {
 	UploadedAt time.Time `json:"uploaded_at" example:"2023-01-01T00:00:00Z"`
 }
 
-type FileUploadResponse struct {
-	Message   string    `json:"message" example:"File uploaded successfully"`
-	ChatID    uuid.UUID `json:"chat_id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	ChatbotID uuid.UUID `json:"chatbot_id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	File      string    `json:"file" example:"document.pdf"`
-	Filename  string    `json:"filename,omitempty" example:"document.pdf"`
-	Size      int64     `json:"size,omitempty" example:"1024"`
-}
-
 type ChatFilesResponse struct {
 	ChatID uuid.UUID  `json:"chat_id" example:"550e8400-e29b-41d4-a716-446655440000"`
 	Files  []FileInfo `json:"files"`
@@ -219,3 +282,18 @@ type TextSourcesResponse struct {
 	ChatID  uuid.UUID        `json:"chat_id" example:"550e8400-e29b-41d4-a716-446655440000"`
 	Sources []TextSourceInfo `json:"sources"`
 }
+
+// WebsitePageInfo is one crawled page of a website source
+type WebsitePageInfo struct {
+	ID        uuid.UUID `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	URL       string    `json:"url" example:"https://example.com/docs/install"`
+	Title     string    `json:"title" example:"Installation"`
+	FetchedAt time.Time `json:"fetched_at" example:"2023-01-01T00:00:00Z"`
+	Chunks    int       `json:"chunks" example:"4"`
+}
+
+type WebsitePagesResponse struct {
+	SourceID uuid.UUID         `json:"source_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Source   string            `json:"source" example:"website-example.com-20240101-120000"`
+	Pages    []WebsitePageInfo `json:"pages"`
+}
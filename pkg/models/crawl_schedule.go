@@ -1,6 +1,7 @@
 package models
 
 import (
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,6 +13,54 @@ type CrawlScheduleRequest struct {
 	CronExpr string `json:"cron_expr" example:"0 3 * * *"`          // standard 5-field cron
 	Timezone string `json:"timezone" example:"America/New_York"`    // IANA timezone
 	Enabled  bool   `json:"enabled" example:"true"`                 // whether the schedule is active
+	Options  *CrawlOptions `json:"options,omitempty"`
+	Auth     *CrawlAuth    `json:"auth,omitempty"`
+}
+
+// CrawlOptions controls which pages a crawl visits. Omitted fields use the defaults
+// (25 pages, depth 2); max pages and depth are capped by the subscription plan.
+type CrawlOptions struct {
+	MaxPages    int      `json:"max_pages,omitempty" example:"50"`
+	MaxDepth    *int     `json:"max_depth,omitempty" example:"2"`              // link depth from the root URL; 0 crawls only the root
+	Include     []string `json:"include,omitempty" example:"/docs/**"`         // URL path globs a page must match
+	Exclude     []string `json:"exclude,omitempty" example:"/docs/archive/**"` // URL path globs that are never crawled
+	PathPrefix  string   `json:"path_prefix,omitempty" example:"/docs/"`       // only follow URLs whose path starts with this prefix
+	SitemapOnly bool     `json:"sitemap_only,omitempty" example:"false"`       // crawl the URLs listed in the sitemap instead of following links
+}
+
+// CrawlAuth holds credentials sent with every request to the crawled site, for portals behind
+// SSO or basic auth. They are stored encrypted and never returned; omit the field to keep the
+// stored credentials and send an empty object to remove them.
+type CrawlAuth struct {
+	Headers  map[string]string `json:"headers,omitempty"`
+	Cookies  map[string]string `json:"cookies,omitempty"`
+	Username string            `json:"username,omitempty" example:"docs-reader"`
+	Password string            `json:"password,omitempty"`
+}
+
+// IsEmpty reports whether no credential is set.
+func (a *CrawlAuth) IsEmpty() bool {
+	return a == nil || (len(a.Headers) == 0 && len(a.Cookies) == 0 && a.Username == "" && a.Password == "")
+}
+
+// String keeps credentials out of formatted output.
+func (a *CrawlAuth) String() string {
+	if a.IsEmpty() {
+		return "none"
+	}
+	return "[REDACTED]"
+}
+
+// LogValue keeps credentials out of structured logs.
+func (a *CrawlAuth) LogValue() slog.Value {
+	return slog.StringValue(a.String())
+}
+
+// CrawlAuthSummary describes stored crawl credentials without their values.
+type CrawlAuthSummary struct {
+	Headers   []string `json:"headers,omitempty" example:"X-Api-Key"` // header names
+	Cookies   []string `json:"cookies,omitempty" example:"session"`   // cookie names
+	BasicAuth bool     `json:"basic_auth" example:"false"`
 }
 
 // CrawlScheduleResponse represents a saved schedule.
@@ -25,13 +74,62 @@ type CrawlScheduleResponse struct {
 	NextRunAt              *time.Time `json:"next_run_at,omitempty"`
 	LastStatus             *string    `json:"last_status,omitempty"`
 	LastError              *string    `json:"last_error,omitempty"`
+	LastRunPages           *CrawlPageCounts `json:"last_run_pages,omitempty"`
+	Options                CrawlOptions     `json:"options"`
+	Auth                   *CrawlAuthSummary `json:"auth,omitempty"`
 	ChatbotID              *uuid.UUID `json:"chatbot_id,omitempty"`
 	SharedKnowledgeBaseID  *uuid.UUID `json:"shared_knowledge_base_id,omitempty"`
 	CreatedAt              time.Time  `json:"created_at"`
 	UpdatedAt              time.Time  `json:"updated_at"`
 }
 
+// CrawlPageCounts reports how the last completed crawl changed the indexed pages.
+type CrawlPageCounts struct {
+	Added     int `json:"added"`
+	Changed   int `json:"changed"`
+	Removed   int `json:"removed"`
+	Unchanged int `json:"unchanged"`
+}
+
 // CrawlScheduleListResponse wraps multiple schedules.
 type CrawlScheduleListResponse struct {
 	Schedules []CrawlScheduleResponse `json:"schedules"`
 }
+
+// Crawl-now outcomes
+const (
+	CrawlEnqueued       = "enqueued"
+	CrawlAlreadyRunning = "already_running"
+)
+
+// CrawlNowResponse reports whether a one-off crawl was queued. While a crawl of the same URL is
+// queued or running no job is added and JobID names the existing one, when known.
+type CrawlNowResponse struct {
+	Status  string     `json:"status" example:"enqueued"` // enqueued or already_running
+	Message string     `json:"message" example:"Crawl enqueued"`
+	JobID   *uuid.UUID `json:"job_id,omitempty"`
+}
+
+// CrawlRunResponse describes one attempt of a crawl of the schedule's URL, scheduled or manual.
+type CrawlRunResponse struct {
+	ID            uuid.UUID       `json:"id"`
+	JobID         uuid.UUID       `json:"job_id"`
+	Attempt       int             `json:"attempt" example:"1"`
+	Trigger       string          `json:"trigger" example:"cron"`     // cron or manual
+	Status        string          `json:"status" example:"completed"` // running, completed, retrying, failed, interrupted or abandoned
+	WorkerID      string          `json:"worker_id" example:"crawl-worker-7d9f-1"`
+	StartedAt     time.Time       `json:"started_at"`
+	FinishedAt    *time.Time      `json:"finished_at,omitempty"`
+	PagesFetched  int             `json:"pages_fetched" example:"42"`
+	Pages         CrawlPageCounts `json:"pages"`
+	ChunksWritten int             `json:"chunks_written" example:"118"`
+	BytesFetched  int64           `json:"bytes_fetched" example:"524288"`
+	Error         *string         `json:"error,omitempty"`
+}
+
+// CrawlRunListResponse is a page of crawl runs, newest first. Pass NextBefore as ?before= to
+// read older runs.
+type CrawlRunListResponse struct {
+	Runs       []CrawlRunResponse `json:"runs"`
+	NextBefore *time.Time         `json:"next_before,omitempty"`
+}
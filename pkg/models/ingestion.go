@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IngestionJobResponse reports the progress of a background upload.
+// TotalChunks is known once the content has been chunked; ProcessedChunks counts embedded chunks.
+type IngestionJobResponse struct {
+	ID                    uuid.UUID  `json:"id"`
+	ChatbotID             *uuid.UUID `json:"chatbot_id,omitempty"`
+	SharedKnowledgeBaseID *uuid.UUID `json:"shared_knowledge_base_id,omitempty"`
+	Kind                  string     `json:"kind" example:"file"`
+	Source                string     `json:"source" example:"document.pdf"`
+	Status                string     `json:"status" example:"embedding"`
+	TotalChunks           int        `json:"total_chunks" example:"120"`
+	ProcessedChunks       int        `json:"processed_chunks" example:"48"`
+	FileID                *uuid.UUID `json:"file_id,omitempty"`
+	Error                 string     `json:"error,omitempty"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+	FinishedAt            *time.Time `json:"finished_at,omitempty"`
+}
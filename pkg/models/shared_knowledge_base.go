@@ -34,14 +34,6 @@ type ChatbotKnowledgeBaseLinkRequest struct {
 	SharedKnowledgeBaseIDs []uuid.UUID `json:"shared_knowledge_base_ids"`
 }
 
-type SharedKnowledgeBaseFileUploadResponse struct {
-	Message         string    `json:"message" example:"File processed successfully"`
-	KnowledgeBaseID uuid.UUID `json:"knowledge_base_id"`
-	File            string    `json:"file" example:"document.pdf"`
-	Filename        string    `json:"filename,omitempty" example:"document.pdf"`
-	Size            int64     `json:"size,omitempty" example:"1024"`
-}
-
 type SharedKnowledgeBaseFilesResponse struct {
 	KnowledgeBaseID uuid.UUID  `json:"knowledge_base_id"`
 	Files           []FileInfo `json:"files"`